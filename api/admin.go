@@ -0,0 +1,228 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/stream/consumers"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/web"
+)
+
+var ErrConfiguredChain = errors.New("chain is configured")
+
+// AdminServer is an HTTP server for the operation of magellan, it is meant to
+// listen on an address which is not exposed publicly.
+type AdminServer struct {
+	sc     *servicesctrl.Control
+	conns  *utils.Connections
+	server *http.Server
+}
+
+// NewAdminServer creates a new *AdminServer listening on the admin address of
+// the config.
+func NewAdminServer(sc *servicesctrl.Control, conf cfg.Config) (*AdminServer, error) {
+	conns, err := sc.Database()
+	if err != nil {
+		return nil, err
+	}
+
+	router := web.New(AdminContext{}).
+		Middleware(func(c *AdminContext, w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+			c.sc = sc
+			c.conns = conns
+			w.Header().Add("Content-Type", "application/json")
+			next(w, r)
+		}).
+		NotFound((*AdminContext).notFoundHandler).
+		Get("/chains", (*AdminContext).ListChains).
+		Post("/chains", (*AdminContext).CreateChain).
+		Delete("/chains/:id", (*AdminContext).DeleteChain).
+		Get("/txpool/quarantined", (*AdminContext).ListQuarantined).
+		Get("/txpool/:id", (*AdminContext).GetTxPoolMessage).
+		Post("/txpool/:id/retry", (*AdminContext).RetryTxPoolMessage).
+		Post("/txpool/:id/skip", (*AdminContext).SkipTxPoolMessage)
+
+	return &AdminServer{
+		sc:    sc,
+		conns: conns,
+		server: &http.Server{
+			Addr:         conf.AdminListenAddr,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: cfg.HTTPWriteTimeout,
+			IdleTimeout:  15 * time.Second,
+			Handler:      router,
+		},
+	}, nil
+}
+
+// Listen begins listening for new socket connections and blocks until closed
+func (s *AdminServer) Listen() error {
+	s.sc.Log.Info("Admin server listening on %s", s.server.Addr)
+	return s.server.ListenAndServe()
+}
+
+// Close shuts the server down
+func (s *AdminServer) Close() error {
+	s.sc.Log.Info("Admin server shutting down")
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	err := s.server.Shutdown(ctx)
+	_ = s.conns.Close()
+	return err
+}
+
+// AdminContext is the context of the admin APIs.
+// The chains registered here are picked up by the chains watchers of the
+// producers, the indexer and the api, without a restart.
+type AdminContext struct {
+	sc    *servicesctrl.Control
+	conns *utils.Connections
+}
+
+func (c *AdminContext) session(name string) *dbr.Session {
+	return c.conns.DB().NewSessionForEventReceiver(c.conns.Stream().NewJob(name))
+}
+
+func (c *AdminContext) writeErr(w web.ResponseWriter, code int, err error) {
+	errBytes, err := json.Marshal(&ErrorResponse{
+		Code:    code,
+		Message: err.Error(),
+	})
+	if err != nil {
+		w.WriteHeader(500)
+		c.sc.Log.Warn("marshal %v", err)
+		return
+	}
+	w.WriteHeader(code)
+	fmt.Fprint(w, string(errBytes))
+}
+
+func (c *AdminContext) writeObject(w web.ResponseWriter, obj interface{}) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		c.sc.Log.Warn("marshal %v", err)
+		c.writeErr(w, 500, err)
+		return
+	}
+	WriteJSON(w, b)
+}
+
+func (*AdminContext) notFoundHandler(w web.ResponseWriter, r *web.Request) {
+	WriteErr(w, 404, "Not Found")
+}
+
+func toIndexedChain(chain *db.Chains) *models.IndexedChain {
+	return &models.IndexedChain{
+		ID:        models.StringID(chain.ID),
+		VMType:    chain.VMType,
+		CreatedAt: chain.CreatedAt,
+	}
+}
+
+func (c *AdminContext) ListChains(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	chains, err := c.sc.Persist.QueryAllChains(ctx, c.session("list_chains"))
+	if err != nil {
+		c.sc.Log.Warn("chains %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp := &models.IndexedChainList{Chains: make([]*models.IndexedChain, 0, len(chains))}
+	for _, chain := range chains {
+		resp.Chains = append(resp.Chains, toIndexedChain(chain))
+	}
+	c.writeObject(w, resp)
+}
+
+func (c *AdminContext) CreateChain(w web.ResponseWriter, r *web.Request) {
+	req := &models.IndexedChain{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, cfg.RequestGetMaxSize)).Decode(req); err != nil {
+		c.writeErr(w, 400, err)
+		return
+	}
+	chainID, err := ids.FromString(string(req.ID))
+	if err != nil {
+		c.writeErr(w, 400, err)
+		return
+	}
+	switch req.VMType {
+	case consumers.IndexerAVMName, consumers.IndexerPVMName:
+	default:
+		c.writeErr(w, 400, fmt.Errorf("invalid vmType %s", req.VMType))
+		return
+	}
+	if c.sc.IsConfiguredChain(chainID.String()) {
+		c.writeErr(w, 409, ErrConfiguredChain)
+		return
+	}
+
+	now := time.Now().UTC()
+	chain := &db.Chains{
+		ID:        chainID.String(),
+		VMType:    req.VMType,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.session("create_chain")
+	if err := c.sc.Persist.InsertChains(ctx, sess, chain, false); err != nil {
+		c.sc.Log.Warn("chain %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	// the chain may have been registered before
+	chain, err = c.sc.Persist.QueryChains(ctx, sess, chain)
+	if err != nil {
+		c.sc.Log.Warn("chain %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, toIndexedChain(chain))
+}
+
+func (c *AdminContext) DeleteChain(w web.ResponseWriter, r *web.Request) {
+	if c.sc.IsConfiguredChain(r.PathParams["id"]) {
+		c.writeErr(w, 409, ErrConfiguredChain)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.session("delete_chain")
+	chain, err := c.sc.Persist.QueryChains(ctx, sess, &db.Chains{ID: r.PathParams["id"]})
+	switch err {
+	case nil:
+	case dbr.ErrNotFound:
+		WriteErr(w, 404, "Not Found")
+		return
+	default:
+		c.sc.Log.Warn("chain %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	if err := c.sc.Persist.DeleteChains(ctx, sess, chain); err != nil {
+		c.sc.Log.Warn("chain %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, toIndexedChain(chain))
+}
@@ -0,0 +1,209 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/cvm"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/stream"
+	"github.com/axiacoin/axia-network-v2-magellan/stream/consumers"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/web"
+)
+
+// txPoolQuarantinedLimit is the default count of quarantined messages listed.
+const txPoolQuarantinedLimit = 100
+
+var (
+	ErrTxPoolNotQuarantined = errors.New("message is not quarantined")
+	ErrTxPoolPayloadNotJSON = errors.New("payload is not JSON")
+)
+
+var txPoolStatuses = map[int]string{
+	db.TxPoolUnprocessed: "unprocessed",
+	db.TxPoolProcessed:   "processed",
+	db.TxPoolQuarantined: "quarantined",
+	db.TxPoolSkipped:     "skipped",
+}
+
+func toTxPoolMessage(txPool *db.TxPool) *models.TxPoolMessage {
+	return &models.TxPoolMessage{
+		ID:        models.StringID(txPool.ID),
+		Topic:     txPool.Topic,
+		ChainID:   models.StringID(txPool.ChainID),
+		MsgKey:    txPool.MsgKey,
+		Status:    txPoolStatuses[txPool.Processed],
+		Attempts:  txPool.Attempts,
+		LastError: txPool.LastError,
+		CreatedAt: txPool.CreatedAt,
+	}
+}
+
+// ListQuarantined lists the quarantined messages of the tx pool, of the topic
+// if one is given, oldest first.
+func (c *AdminContext) ListQuarantined(w web.ResponseWriter, r *web.Request) {
+	q := r.URL.Query()
+	limit, err := params.GetQueryInt(q, params.KeyLimit, txPoolQuarantinedLimit)
+	if err != nil || limit < 1 {
+		c.writeErr(w, 400, fmt.Errorf("invalid %s", params.KeyLimit))
+		return
+	}
+	if limit > params.PaginationMaxLimit {
+		limit = params.PaginationMaxLimit
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	txPools, err := c.sc.Persist.QueryTxPoolsQuarantined(ctx, c.session("list_quarantined"), q.Get("topic"), uint64(limit))
+	if err != nil {
+		c.sc.Log.Warn("quarantined %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp := &models.TxPoolMessageList{Messages: make([]*models.TxPoolMessage, 0, len(txPools))}
+	for _, txPool := range txPools {
+		resp.Messages = append(resp.Messages, toTxPoolMessage(txPool))
+	}
+	c.writeObject(w, resp)
+}
+
+// GetTxPoolMessage returns a message of the tx pool with its payload, decoded
+// by the writer of its topic.
+func (c *AdminContext) GetTxPoolMessage(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.session("get_tx_pool_message")
+	txPool, ok := c.queryTxPool(ctx, w, sess, r.PathParams["id"])
+	if !ok {
+		return
+	}
+
+	message := toTxPoolMessage(txPool)
+	payload, err := c.parseTxPool(ctx, sess, txPool)
+	if err != nil {
+		c.writeErr(w, 422, fmt.Errorf("parse payload: %w", err))
+		return
+	}
+	message.Payload = payload
+	c.writeObject(w, message)
+}
+
+// RetryTxPoolMessage processes a quarantined or skipped message again, with its
+// failures reset.
+func (c *AdminContext) RetryTxPoolMessage(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.session("retry_tx_pool_message")
+	txPool, ok := c.queryTxPool(ctx, w, sess, r.PathParams["id"])
+	if !ok {
+		return
+	}
+	if txPool.Processed != db.TxPoolQuarantined && txPool.Processed != db.TxPoolSkipped {
+		c.writeErr(w, 409, ErrTxPoolNotQuarantined)
+		return
+	}
+
+	if err := c.sc.Persist.ResetTxPool(ctx, sess, txPool); err != nil {
+		c.sc.Log.Warn("tx pool %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	txPool.Processed = db.TxPoolUnprocessed
+	txPool.Attempts = 0
+	txPool.LastError = ""
+	c.writeObject(w, toTxPoolMessage(txPool))
+}
+
+// SkipTxPoolMessage marks a quarantined message skipped, it is not processed.
+func (c *AdminContext) SkipTxPoolMessage(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.session("skip_tx_pool_message")
+	txPool, ok := c.queryTxPool(ctx, w, sess, r.PathParams["id"])
+	if !ok {
+		return
+	}
+	if txPool.Processed != db.TxPoolQuarantined {
+		c.writeErr(w, 409, ErrTxPoolNotQuarantined)
+		return
+	}
+
+	txPool.Processed = db.TxPoolSkipped
+	if err := c.sc.Persist.UpdateTxPoolStatus(ctx, sess, txPool); err != nil {
+		c.sc.Log.Warn("tx pool %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, toTxPoolMessage(txPool))
+}
+
+func (c *AdminContext) queryTxPool(ctx context.Context, w web.ResponseWriter, sess *dbr.Session, id string) (*db.TxPool, bool) {
+	txPool, err := c.sc.Persist.QueryTxPool(ctx, sess, &db.TxPool{ID: id})
+	switch err {
+	case nil:
+		return txPool, true
+	case dbr.ErrNotFound:
+		WriteErr(w, 404, "Not Found")
+	default:
+		c.sc.Log.Warn("tx pool %v", err)
+		c.writeErr(w, 500, ErrCacheableFnFailed)
+	}
+	return nil, false
+}
+
+// parseTxPool decodes the payload of a message with the writer of its topic.
+// The traces and logs of the EVM chains are stored as JSON.
+func (c *AdminContext) parseTxPool(ctx context.Context, sess *dbr.Session, txPool *db.TxPool) (json.RawMessage, error) {
+	networkID := c.sc.ServicesCfg.NetworkID
+
+	var payload []byte
+	switch txPool.Topic {
+	case stream.GetTopicName(networkID, txPool.ChainID, stream.EventTypeDecisions),
+		stream.GetTopicName(networkID, txPool.ChainID, stream.EventTypeConsensus):
+		chain, ok := c.sc.Chains[txPool.ChainID]
+		if !ok {
+			registered, err := c.sc.Persist.QueryChains(ctx, sess, &db.Chains{ID: txPool.ChainID})
+			if err != nil {
+				return nil, err
+			}
+			chain = cfg.Chain{ID: registered.ID, VMType: registered.VMType}
+		}
+		writer, err := consumers.IndexerConsumer(networkID, chain.VMType, chain.ID)
+		if err != nil {
+			return nil, err
+		}
+		payload, err = writer.ParseJSON(txPool.Serialization)
+		if err != nil {
+			return nil, err
+		}
+	case fmt.Sprintf("%d-%s-axchain", networkID, txPool.ChainID):
+		writer, err := cvm.NewWriter(networkID, txPool.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		payload, err = writer.ParseJSON(txPool.Serialization)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		payload = txPool.Serialization
+	}
+
+	if len(payload) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(payload) {
+		return nil, ErrTxPoolPayloadNotJSON
+	}
+	return payload, nil
+}
@@ -36,6 +36,12 @@ type Context struct {
 	delayCache  *utils.DelayCache
 	axcReader  *axc.Reader
 	connections *utils.Connections
+
+	// connectionsRW is the primary database, for the apis which write
+	connectionsRW *utils.Connections
+
+	// txFeed streams the indexed transactions to the subscriptions
+	txFeed *txFeed
 }
 
 // NetworkID returns the networkID this request is for
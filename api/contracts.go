@@ -0,0 +1,227 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/web"
+)
+
+// Contracts are not cached, and are read from and written to the primary
+// database. They are written with one of the apiKeys of the config, as a
+// bearer token.
+
+var (
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrInvalidCAddress  = errors.New("invalid contract address")
+	ErrUnknownEVMChain  = errors.New("unknown EVM chain")
+	ErrContractABIEmpty = errors.New("abi is required")
+	ErrContractName     = errors.New("name is longer than 256 characters")
+)
+
+// authorized is whether the request carries one of the api keys.
+func (c *V2Context) authorized(r *web.Request) bool {
+	key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if key == "" {
+		return false
+	}
+	for _, apiKey := range c.sc.ServicesCfg.APIKeys {
+		if apiKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// cContractKey is the contract of the address path param on the chain of the
+// chainID param, the AX chain by default.
+func (c *V2Context) cContractKey(r *web.Request) (*db.CvmContracts, error) {
+	if !common.IsHexAddress(r.PathParams["address"]) {
+		return nil, ErrInvalidCAddress
+	}
+	address := common.HexToAddress(r.PathParams["address"])
+
+	chainID := params.GetQueryString(r.URL.Query(), params.KeyChainID, c.sc.ServicesCfg.AXchainID)
+	if _, ok := c.sc.ServicesCfg.AllEVMChains()[chainID]; !ok {
+		return nil, ErrUnknownEVMChain
+	}
+	return &db.CvmContracts{ChainID: chainID, Address: utils.CommonAddressHexRepair(&address)}, nil
+}
+
+// loadCContract loads the contract of the request, or writes the error response.
+func (c *V2Context) loadCContract(ctx context.Context, w web.ResponseWriter, r *web.Request, sess dbr.SessionRunner) *db.CvmContracts {
+	q, err := c.cContractKey(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return nil
+	}
+	contract, err := c.sc.Persist.QueryCvmContracts(ctx, sess, q)
+	switch err {
+	case nil:
+		return contract
+	case dbr.ErrNotFound:
+		WriteErr(w, 404, "Not Found")
+	default:
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+	}
+	return nil
+}
+
+func toCContract(contract *db.CvmContracts) *models.CContract {
+	return &models.CContract{
+		ChainID:   contract.ChainID,
+		Address:   contract.Address,
+		Name:      contract.Name,
+		ABI:       json.RawMessage(contract.ABI),
+		CreatedAt: &contract.CreatedAt,
+		UpdatedAt: &contract.UpdatedAt,
+	}
+}
+
+// GetCContract is the deployment and the uploaded ABI of a contract, it is found
+// when it has either.
+func (c *V2Context) GetCContract(w web.ResponseWriter, r *web.Request) {
+	q, err := c.cContractKey(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("get_ccontract")
+
+	result := &models.CContract{ChainID: q.ChainID, Address: q.Address}
+	contract, err := c.sc.Persist.QueryCvmContracts(ctx, sess, q)
+	switch err {
+	case nil:
+		result = toCContract(contract)
+	case dbr.ErrNotFound:
+	default:
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	deployment, err := c.sc.Persist.QueryCvmContractDeployments(ctx, sess, &db.CvmContractDeployments{ChainID: q.ChainID, Address: q.Address})
+	switch err {
+	case nil:
+		result.Deployment = &models.CContractDeployment{
+			Creator:   deployment.Creator,
+			TxHash:    deployment.TxHash,
+			Block:     deployment.Block,
+			Type:      deployment.Type,
+			CodeHash:  deployment.CodeHash,
+			CreatedAt: deployment.CreatedAt,
+		}
+	case dbr.ErrNotFound:
+	default:
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	if result.ABI == nil && result.Deployment == nil {
+		WriteErr(w, 404, "Not Found")
+		return
+	}
+	c.writeObject(w, result)
+}
+
+// PutCContract uploads the ABI of a contract, replacing the ABI uploaded before.
+func (c *V2Context) PutCContract(w web.ResponseWriter, r *web.Request) {
+	if !c.authorized(r) {
+		c.WriteErr(w, 401, ErrUnauthorized)
+		return
+	}
+	q, err := c.cContractKey(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	req := &models.CContract{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, cfg.RequestGetMaxSize)).Decode(req); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	if len(req.ABI) == 0 {
+		c.WriteErr(w, 400, ErrContractABIEmpty)
+		return
+	}
+	if len(req.Name) > 256 {
+		c.WriteErr(w, 400, ErrContractName)
+		return
+	}
+	if _, err := modelsc.ParseABI(string(req.ABI)); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	abiJSON := &bytes.Buffer{}
+	if err := json.Compact(abiJSON, req.ABI); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	q.Name = req.Name
+	q.ABI = abiJSON.String()
+	q.CreatedAt = now
+	q.UpdatedAt = now
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("put_ccontract")
+	if err := c.sc.Persist.InsertCvmContracts(ctx, sess, q, true); err != nil {
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	// the contract may have been uploaded before
+	contract, err := c.sc.Persist.QueryCvmContracts(ctx, sess, q)
+	if err != nil {
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, toCContract(contract))
+}
+
+func (c *V2Context) DeleteCContract(w web.ResponseWriter, r *web.Request) {
+	if !c.authorized(r) {
+		c.WriteErr(w, 401, ErrUnauthorized)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("delete_ccontract")
+	contract := c.loadCContract(ctx, w, r, sess)
+	if contract == nil {
+		return
+	}
+	if err := c.sc.Persist.DeleteCvmContracts(ctx, sess, contract); err != nil {
+		c.sc.Log.Warn("contract %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, toCContract(contract))
+}
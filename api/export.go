@@ -0,0 +1,342 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/web"
+)
+
+const MetricExportCount = "api_export_count"
+const MetricExportMillis = "api_export_millis"
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	// exportWriteTimeout bounds the write of each batch of an export, which
+	// replaces the server write timeout for the whole response.
+	exportWriteTimeout = cfg.HTTPWriteTimeout
+
+	exportBufferSize = 32 * 1024
+)
+
+var (
+	exportTransactionsHeader = []string{
+		"id", "chainID", "type", "timestamp", "txFee", "genesis", "memo", "inputTotals", "outputTotals",
+	}
+	exportOutputsHeader = []string{
+		"id", "transactionID", "outputIndex", "chainID", "assetID", "outputType", "amount",
+		"locktime", "threshold", "groupID", "addresses", "redeemingTransactionID", "timestamp",
+	}
+)
+
+func (c *V2Context) ExportTransactions(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricExportMillis),
+		utils.NewCounterIncCollect(MetricExportCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListTransactionsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+	// an export is every matching transaction
+	p.ListParams.Limit = 0
+
+	format, err := exportFormat(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.export(w, r, format, exportTransactionsHeader, func(ctx context.Context, e *exportWriter) error {
+		return c.axcReader.ExportTransactions(ctx, p, c.axcAssetID, func(txs []*models.Transaction) error {
+			for _, tx := range txs {
+				if err := e.write(tx, exportTransactionRecord(tx)); err != nil {
+					return err
+				}
+			}
+			return e.flush()
+		})
+	})
+}
+
+func (c *V2Context) ExportOutputs(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricExportMillis),
+		utils.NewCounterIncCollect(MetricExportCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListOutputsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+	// an export is every matching output
+	p.ListParams.Limit = 0
+
+	format, err := exportFormat(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.export(w, r, format, exportOutputsHeader, func(ctx context.Context, e *exportWriter) error {
+		return c.axcReader.ExportOutputs(ctx, p, func(outputs []*models.Output) error {
+			for _, output := range outputs {
+				record, err := exportOutputRecord(output)
+				if err != nil {
+					return err
+				}
+				if err := e.write(output, record); err != nil {
+					return err
+				}
+			}
+			return e.flush()
+		})
+	})
+}
+
+// exportFormat is the format param, or csv if the request accepts it, or ndjson.
+func exportFormat(r *web.Request) (string, error) {
+	format := params.GetQueryString(r.URL.Query(), params.KeyFormat, "")
+	if format == "" {
+		format = exportFormatNDJSON
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			format = exportFormatCSV
+		}
+	}
+	switch format {
+	case exportFormatCSV, exportFormatNDJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid format %s", format)
+}
+
+// export runs the export, which writes its rows to the exportWriter.
+// The response starts with the first write, an export which fails before it
+// responds with the error. Once the response has started, a failed export
+// ends the connection without the end of the body, so the client can tell the
+// body is truncated.
+func (c *V2Context) export(w web.ResponseWriter, r *web.Request, format string, csvHeader []string, run func(context.Context, *exportWriter) error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.ExportTimeout)
+	defer cancelFn()
+
+	e := &exportWriter{w: w, r: r, format: format, csvHeader: csvHeader}
+	err := run(ctx, e)
+	if err == nil {
+		err = e.start()
+	}
+	if e.stream == nil {
+		c.WriteErr(w, 500, err)
+		return
+	}
+	if err != nil {
+		c.sc.Log.Warn("export %v", err)
+	}
+	e.stream.close(err == nil)
+}
+
+type exportWriter struct {
+	w         web.ResponseWriter
+	r         *web.Request
+	format    string
+	csvHeader []string
+
+	stream *exportStream
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func (e *exportWriter) start() error {
+	if e.stream != nil {
+		return nil
+	}
+
+	contentType := "application/x-ndjson"
+	if e.format == exportFormatCSV {
+		contentType = "text/csv"
+	}
+	stream, err := startExportStream(e.w, e.r, contentType)
+	if err != nil {
+		return err
+	}
+	e.stream = stream
+
+	if e.format == exportFormatCSV {
+		e.csv = csv.NewWriter(stream.Writer)
+		return e.csv.Write(e.csvHeader)
+	}
+	e.json = json.NewEncoder(stream.Writer)
+	return nil
+}
+
+// write writes the row as the csv record or as the json of v.
+func (e *exportWriter) write(v interface{}, record []string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if e.csv != nil {
+		return e.csv.Write(record)
+	}
+	return e.json.Encode(v)
+}
+
+func (e *exportWriter) flush() error {
+	if e.stream == nil {
+		return nil
+	}
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return e.stream.flush()
+}
+
+// exportStream writes the response body through the ResponseWriter, which
+// the server sends chunked. Each flush extends the write deadline of the
+// connection, so an export is not cut off by the server write timeout.
+type exportStream struct {
+	*bufio.Writer
+
+	w web.ResponseWriter
+	// conn is the connection of the request, see withConn, it is nil when the
+	// server does not keep it and the server write timeout applies.
+	conn net.Conn
+}
+
+func startExportStream(w web.ResponseWriter, r *web.Request, contentType string) (*exportStream, error) {
+	s := &exportStream{w: w, conn: connOf(r.Request)}
+	if err := s.extendDeadline(); err != nil {
+		return nil, err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(200)
+	s.Writer = bufio.NewWriterSize(w, exportBufferSize)
+	return s, nil
+}
+
+func (s *exportStream) extendDeadline() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+}
+
+func (s *exportStream) flush() error {
+	if err := s.extendDeadline(); err != nil {
+		return err
+	}
+	if err := s.Writer.Flush(); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+// close flushes the body if the export completed, the server ends it. An
+// export which failed closes the connection, so the body has no end.
+func (s *exportStream) close(completed bool) {
+	if completed && s.flush() == nil {
+		return
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+type connContextKey struct{}
+
+// withConn keeps the connection of a request in its context, for the server
+// ConnContext.
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+func connOf(r *http.Request) net.Conn {
+	conn, _ := r.Context().Value(connContextKey{}).(net.Conn)
+	return conn
+}
+
+func exportTransactionRecord(tx *models.Transaction) []string {
+	return []string{
+		string(tx.ID),
+		string(tx.ChainID),
+		tx.Type,
+		tx.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatUint(tx.Txfee, 10),
+		strconv.FormatBool(tx.Genesis),
+		base64.StdEncoding.EncodeToString(tx.Memo),
+		exportAssetTokenCounts(tx.InputTotals),
+		exportAssetTokenCounts(tx.OutputTotals),
+	}
+}
+
+func exportOutputRecord(output *models.Output) ([]string, error) {
+	addresses := make([]string, 0, len(output.Addresses))
+	for _, addr := range output.Addresses {
+		bech32Addr, err := addr.MarshalString()
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, string(bech32Addr))
+	}
+	return []string{
+		string(output.ID),
+		string(output.TransactionID),
+		strconv.FormatUint(output.OutputIndex, 10),
+		string(output.ChainID),
+		string(output.AssetID),
+		output.OutputType.String(),
+		string(output.Amount),
+		strconv.FormatUint(output.Locktime, 10),
+		strconv.FormatUint(output.Threshold, 10),
+		strconv.FormatUint(output.GroupID, 10),
+		strings.Join(addresses, ";"),
+		string(output.RedeemingTransactionID),
+		output.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// exportAssetTokenCounts formats the amounts as assetID=amount, separated by semicolons.
+func exportAssetTokenCounts(counts models.AssetTokenCounts) string {
+	amounts := make([]string, 0, len(counts))
+	for assetID, amount := range counts {
+		amounts = append(amounts, string(assetID)+"="+string(amount))
+	}
+	sort.Strings(amounts)
+	return strings.Join(amounts, ";")
+}
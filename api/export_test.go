@@ -0,0 +1,166 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2/utils/logging"
+	"github.com/gocraft/web"
+)
+
+func newExportServer(t *testing.T, writeTimeout time.Duration, run func(context.Context, *exportWriter) error) *httptest.Server {
+	sc := &servicesctrl.Control{Log: logging.NoLog{}}
+	router := web.New(V2Context{}).
+		Middleware(func(c *V2Context, w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
+			c.Context = &Context{sc: sc}
+			next(w, r)
+		}).
+		Get("/export", func(c *V2Context, w web.ResponseWriter, r *web.Request) {
+			c.export(w, r, exportFormatNDJSON, nil, run)
+		})
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = writeTimeout
+	srv.Config.ConnContext = withConn
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExportStreamsPastWriteTimeout(t *testing.T) {
+	writeTimeout := 200 * time.Millisecond
+	srv := newExportServer(t, writeTimeout, func(ctx context.Context, e *exportWriter) error {
+		for i := 0; i < 3; i++ {
+			if err := e.write(&models.Transaction{ID: models.StringID(string(rune('a' + i)))}, nil); err != nil {
+				return err
+			}
+			if err := e.flush(); err != nil {
+				return err
+			}
+			time.Sleep(2 * writeTimeout)
+		}
+		return nil
+	})
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Fatal("response", resp.StatusCode, resp.Header)
+	}
+
+	// the rows arrive as they are flushed
+	reader := bufio.NewReader(resp.Body)
+	start := time.Now()
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > writeTimeout {
+		t.Fatal("first row not streamed")
+	}
+
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal("export cut off", err)
+	}
+	if n := strings.Count(string(rest), "\n"); n != 2 {
+		t.Fatal("rows", n)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	// an export which fails before it writes responds with the error
+	srv := newExportServer(t, time.Second, func(ctx context.Context, e *exportWriter) error {
+		return errors.New("failed")
+	})
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != 500 {
+		t.Fatal("status", resp.StatusCode)
+	}
+
+	// and after it started, the body is cut off
+	srv = newExportServer(t, time.Second, func(ctx context.Context, e *exportWriter) error {
+		if err := e.write(&models.Transaction{ID: "a"}, nil); err != nil {
+			return err
+		}
+		if err := e.flush(); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	resp, err = http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatal("status", resp.StatusCode)
+	}
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatal("truncated body not reported", err)
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		query  string
+		accept string
+		format string
+		err    bool
+	}{
+		{format: exportFormatNDJSON},
+		{accept: "text/csv", format: exportFormatCSV},
+		{query: "format=ndjson", accept: "text/csv", format: exportFormatNDJSON},
+		{query: "format=csv", format: exportFormatCSV},
+		{query: "format=xml", err: true},
+	}
+	for _, test := range tests {
+		r := &web.Request{Request: &http.Request{URL: &url.URL{RawQuery: test.query}, Header: http.Header{}}}
+		r.Header.Set("Accept", test.accept)
+		format, err := exportFormat(r)
+		if (err != nil) != test.err || format != test.format {
+			t.Error(test.query, test.accept, format, err)
+		}
+	}
+}
+
+func TestExportTransactionRecord(t *testing.T) {
+	tx := &models.Transaction{
+		ID:          "id",
+		ChainID:     "chain",
+		Type:        "base",
+		CreatedAt:   time.Unix(1, 0),
+		Txfee:       5,
+		Memo:        []byte("memo"),
+		InputTotals: models.AssetTokenCounts{"b": "2", "a": "1"},
+	}
+	record := exportTransactionRecord(tx)
+	if len(record) != len(exportTransactionsHeader) {
+		t.Fatal("record", record)
+	}
+	if record[3] != "1970-01-01T00:00:01Z" || record[4] != "5" || record[6] != "bWVtbw==" {
+		t.Fatal("record", record)
+	}
+	if record[7] != "a=1;b=2" || record[8] != "" {
+		t.Fatal("totals", record[7], record[8])
+	}
+}
@@ -0,0 +1,128 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/web"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+const MetricGraphQLCount = "api_graphql_count"
+const MetricGraphQLMillis = "api_graphql_millis"
+
+type graphqlParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// AddGraphQLRoutes mounts the GraphQL endpoint at the given path.
+// Queries are read from the GET params or a POST json body.
+func AddGraphQLRoutes(router *web.Router, path string) error {
+	utils.Prometheus.CounterInit(MetricGraphQLCount, MetricGraphQLCount)
+	utils.Prometheus.CounterInit(MetricGraphQLMillis, MetricGraphQLMillis)
+
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		return err
+	}
+
+	handler := func(c *Context, w web.ResponseWriter, r *web.Request) {
+		c.GraphQL(&schema, w, r)
+	}
+	router.Get(path, handler).Post(path, handler)
+	return nil
+}
+
+func (c *Context) GraphQL(schema *graphql.Schema, w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricGraphQLMillis),
+		utils.NewCounterIncCollect(MetricGraphQLCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p, err := readGraphQLParams(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	ctx = withGraphQLRequest(ctx, newGraphQLRequest(ctx, c.axcReader, c.axcAssetID))
+
+	result := executeGraphQL(ctx, schema, p)
+	b, err := json.Marshal(result)
+	if err != nil {
+		c.sc.Log.Warn("marshal %v", err)
+		c.WriteErr(w, 500, err)
+		return
+	}
+	// a query which failed before it executed has no data
+	if result.Data == nil {
+		w.WriteHeader(400)
+		_, _ = w.Write(b)
+		return
+	}
+	WriteJSON(w, b)
+}
+
+func readGraphQLParams(r *web.Request) (*graphqlParams, error) {
+	p := &graphqlParams{}
+	if r.Method != http.MethodPost {
+		q := r.URL.Query()
+		p.Query = q.Get("query")
+		p.OperationName = q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &p.Variables); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	}
+
+	err := json.NewDecoder(io.LimitReader(r.Body, cfg.RequestGetMaxSize)).Decode(p)
+	return p, err
+}
+
+// executeGraphQL validates the query and checks its limits before it executes it.
+func executeGraphQL(ctx context.Context, schema *graphql.Schema, p *graphqlParams) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(p.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := checkQueryLimits(schema, doc, p.OperationName, p.Variables); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        *schema,
+		AST:           doc,
+		OperationName: p.OperationName,
+		Args:          p.Variables,
+		Context:       ctx,
+	})
+}
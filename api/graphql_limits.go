@@ -0,0 +1,196 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// GraphQLMaxDepth is the deepest field nesting a query may select.
+	GraphQLMaxDepth = 8
+
+	// GraphQLMaxComplexity is the most fields a query may resolve, counting
+	// every field once for each element of the lists it is nested in.
+	GraphQLMaxComplexity = 10000
+
+	graphqlDefaultLimit = 25
+	graphqlMaxLimit     = 500
+
+	// graphqlListSize is the assumed length of the lists outside of a page,
+	// such as the inputs and outputs of a transaction.
+	graphqlListSize = 10
+)
+
+// checkQueryLimits computes the depth and complexity of the operation
+// statically, so an expensive query is rejected before any of it runs.
+// The document must have passed validation.
+func checkQueryLimits(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) error {
+	var operation *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+	if operation == nil {
+		return fmt.Errorf("unknown operation %s", operationName)
+	}
+
+	l := &queryLimits{schema: schema, fragments: fragments, variables: variables}
+	_, err := l.cost(operation.SelectionSet, schema.QueryType(), false, 1)
+	return err
+}
+
+type queryLimits struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// cost returns the complexity of the selections of the parent type at depth.
+// The parent is nil below the fields it can't type, their selections count once each.
+// The parent is a page if it is the result of a list query, its lists are the size of the page.
+func (l *queryLimits) cost(selectionSet *ast.SelectionSet, parent *graphql.Object, page bool, depth int) (int, error) {
+	if selectionSet == nil {
+		return 0, nil
+	}
+
+	complexity := 0
+	for _, selection := range selectionSet.Selections {
+		var selectionCost int
+		var err error
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionCost, err = l.fieldCost(selection, parent, page, depth)
+		case *ast.InlineFragment:
+			selectionCost, err = l.cost(selection.SelectionSet, l.conditionType(selection.TypeCondition, parent), page, depth)
+		case *ast.FragmentSpread:
+			fragment, ok := l.fragments[selection.Name.Value]
+			if !ok {
+				return 0, fmt.Errorf("unknown fragment %s", selection.Name.Value)
+			}
+			selectionCost, err = l.cost(fragment.SelectionSet, l.conditionType(fragment.TypeCondition, parent), page, depth)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		complexity += selectionCost
+		if complexity > GraphQLMaxComplexity {
+			return 0, fmt.Errorf("query complexity exceeds %d", GraphQLMaxComplexity)
+		}
+	}
+	return complexity, nil
+}
+
+func (l *queryLimits) fieldCost(field *ast.Field, parent *graphql.Object, page bool, depth int) (int, error) {
+	if depth > GraphQLMaxDepth {
+		return 0, fmt.Errorf("query depth exceeds %d", GraphQLMaxDepth)
+	}
+
+	size := 1
+	isPage := false
+	var fieldType *graphql.Object
+	if parent != nil {
+		if def, ok := parent.Fields()[field.Name.Value]; ok {
+			t := unwrapNonNull(def.Type)
+			if list, ok := t.(*graphql.List); ok {
+				t = unwrapNonNull(list.OfType)
+				if !page {
+					size = graphqlListSize
+				}
+			}
+			if hasLimitArg(def) {
+				size = l.limit(field)
+				isPage = true
+			}
+			fieldType, _ = t.(*graphql.Object)
+		}
+	}
+
+	childCost, err := l.cost(field.SelectionSet, fieldType, isPage, depth+1)
+	if err != nil {
+		return 0, err
+	}
+	return 1 + size*childCost, nil
+}
+
+// limit is the page size the limit argument of the field selects.
+func (l *queryLimits) limit(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == params.KeyLimit {
+			return graphqlLimit(l.argumentValue(arg.Value))
+		}
+	}
+	return graphqlDefaultLimit
+}
+
+func hasLimitArg(def *graphql.FieldDefinition) bool {
+	for _, arg := range def.Args {
+		if arg.Name() == params.KeyLimit {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *queryLimits) argumentValue(value ast.Value) interface{} {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return nil
+		}
+		return n
+	case *ast.Variable:
+		return l.variables[value.Name.Value]
+	}
+	return nil
+}
+
+func (l *queryLimits) conditionType(condition *ast.Named, parent *graphql.Object) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	t, _ := l.schema.Type(condition.Name.Value).(*graphql.Object)
+	return t
+}
+
+// graphqlLimit converts the limit argument to the page size of a list query.
+func graphqlLimit(v interface{}) int {
+	var limit int
+	switch v := v.(type) {
+	case int:
+		limit = v
+	case float64:
+		limit = int(v)
+	default:
+		return graphqlDefaultLimit
+	}
+	switch {
+	case limit <= 0:
+		return graphqlDefaultLimit
+	case limit > graphqlMaxLimit:
+		return graphqlMaxLimit
+	}
+	return limit
+}
+
+func unwrapNonNull(t graphql.Type) graphql.Type {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		return nonNull.OfType
+	}
+	return t
+}
@@ -0,0 +1,182 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/axc"
+)
+
+// graphqlBatchSize bounds the ids loaded by one query of a batch.
+const graphqlBatchSize = 500
+
+type batchFetchFn func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+// batchLoader batches the loads of the objects nested in a GraphQL result.
+// The executor resolves a whole level of the result before running the thunks
+// returned for it, so the keys of every sibling are pending when the first
+// thunk runs and loads them all.
+// Resolvers run on the request goroutine, the loader is not locked.
+type batchLoader struct {
+	ctx     context.Context
+	fetch   batchFetchFn
+	pending []string
+	results map[string]interface{}
+	errs    map[string]error
+}
+
+func newBatchLoader(ctx context.Context, fetch batchFetchFn) *batchLoader {
+	return &batchLoader{
+		ctx:     ctx,
+		fetch:   fetch,
+		results: make(map[string]interface{}),
+		errs:    make(map[string]error),
+	}
+}
+
+// load queues the key, and returns the thunk of its object, which is nil if it was not found.
+func (l *batchLoader) load(key string) func() (interface{}, error) {
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = nil
+		l.pending = append(l.pending, key)
+	}
+
+	return func() (interface{}, error) {
+		if len(l.pending) != 0 {
+			l.fetchPending()
+		}
+		return l.results[key], l.errs[key]
+	}
+}
+
+func (l *batchLoader) fetchPending() {
+	pending := l.pending
+	l.pending = nil
+
+	for len(pending) != 0 {
+		keys := pending
+		if len(keys) > graphqlBatchSize {
+			keys = keys[:graphqlBatchSize]
+		}
+		pending = pending[len(keys):]
+
+		results, err := l.fetch(l.ctx, keys)
+		for _, key := range keys {
+			if err != nil {
+				l.errs[key] = err
+				continue
+			}
+			if result, ok := results[key]; ok {
+				l.results[key] = result
+			}
+		}
+	}
+}
+
+// graphqlRequest holds the reader and the batch loaders of a GraphQL request.
+type graphqlRequest struct {
+	reader     *axc.Reader
+	axcAssetID ids.ID
+
+	transactions *batchLoader
+	assets       *batchLoader
+	blocks       *batchLoader
+	addresses    *batchLoader
+}
+
+func newGraphQLRequest(ctx context.Context, reader *axc.Reader, axcAssetID ids.ID) *graphqlRequest {
+	return &graphqlRequest{
+		reader:     reader,
+		axcAssetID: axcAssetID,
+
+		transactions: newBatchLoader(ctx, func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+			txs, err := reader.GetTransactions(ctx, keys, axcAssetID)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]interface{}, len(txs))
+			for _, tx := range txs {
+				results[string(tx.ID)] = tx
+			}
+			return results, nil
+		}),
+		assets: newBatchLoader(ctx, func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+			assets, err := reader.GetAssets(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]interface{}, len(assets))
+			for _, asset := range assets {
+				results[string(asset.ID)] = asset
+			}
+			return results, nil
+		}),
+		blocks: newBatchLoader(ctx, func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+			blocks, err := reader.GetBlocks(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]interface{}, len(blocks))
+			for _, block := range blocks {
+				results[string(block.ID)] = block
+			}
+			return results, nil
+		}),
+		addresses: newBatchLoader(ctx, func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+			addrs := make([]ids.ShortID, 0, len(keys))
+			for _, key := range keys {
+				addr, err := ids.ShortFromString(key)
+				if err != nil {
+					continue
+				}
+				addrs = append(addrs, addr)
+			}
+			addresses, err := reader.GetAddresses(ctx, addrs, nil)
+			if err != nil {
+				return nil, err
+			}
+			results := make(map[string]interface{}, len(addresses))
+			for _, address := range addresses {
+				results[string(address.Address)] = address
+			}
+			return results, nil
+		}),
+	}
+}
+
+type graphqlRequestKey struct{}
+
+func withGraphQLRequest(ctx context.Context, req *graphqlRequest) context.Context {
+	return context.WithValue(ctx, graphqlRequestKey{}, req)
+}
+
+func graphqlRequestFrom(ctx context.Context) *graphqlRequest {
+	req, _ := ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+	return req
+}
+
+// loadTransaction returns the thunk of the transaction, or nil for an empty id.
+func (req *graphqlRequest) loadTransaction(id models.StringID) interface{} {
+	if id == "" {
+		return nil
+	}
+	return req.transactions.load(string(id))
+}
+
+func (req *graphqlRequest) loadAsset(id models.StringID) interface{} {
+	if id == "" {
+		return nil
+	}
+	return req.assets.load(string(id))
+}
+
+func (req *graphqlRequest) loadBlock(id models.StringID) interface{} {
+	if id == "" {
+		return nil
+	}
+	return req.blocks.load(string(id))
+}
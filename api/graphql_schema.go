@@ -0,0 +1,643 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/graphql-go/graphql"
+)
+
+// The GraphQL types follow the field names of the REST models. Ids, amounts and
+// 64 bit integers are strings, and byte fields are base64 as in the REST json.
+
+// graphqlAddress is an address, with its balances once they are loaded.
+type graphqlAddress struct {
+	address models.Address
+	info    *models.AddressInfo
+}
+
+type graphqlAssetAmount struct {
+	AssetID models.StringID    `json:"assetID"`
+	Amount  models.TokenAmount `json:"amount"`
+}
+
+// graphqlPage is a page of a list query, the next page starts at the cursor next.
+type graphqlPage struct {
+	items interface{}
+	next  *string
+}
+
+type graphqlAddressChains struct {
+	Address  string            `json:"address"`
+	ChainIDs []models.StringID `json:"chainIDs"`
+}
+
+func newGraphQLSchema() (graphql.Schema, error) {
+	var transactionType, outputType, addressType, assetType, blockType *graphql.Object
+
+	assetType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Asset",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"chainID":       &graphql.Field{Type: graphql.String},
+			"name":          &graphql.Field{Type: graphql.String},
+			"symbol":        &graphql.Field{Type: graphql.String},
+			"alias":         &graphql.Field{Type: graphql.String},
+			"currentSupply": &graphql.Field{Type: graphql.String},
+			"timestamp":     &graphql.Field{Type: graphql.DateTime},
+			"denomination":  &graphql.Field{Type: graphql.Int},
+			"variableCap":   &graphql.Field{Type: graphql.Int},
+			"nft":           &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	blockType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Block",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"parentID": &graphql.Field{Type: graphql.String},
+				"parent": &graphql.Field{
+					Type: blockType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlRequestFrom(p.Context).loadBlock(p.Source.(*models.Block).ParentID), nil
+					},
+				},
+				"chainID": &graphql.Field{Type: graphql.String},
+				"type": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(p.Source.(*models.Block).Type), nil
+					},
+				},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+			}
+		}),
+	})
+
+	assetAmountType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AssetAmount",
+		Fields: graphql.Fields{
+			"assetID": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"asset": &graphql.Field{
+				Type: assetType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlRequestFrom(p.Context).loadAsset(p.Source.(*graphqlAssetAmount).AssetID), nil
+				},
+			},
+			"amount": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	assetBalanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AssetBalance",
+		Fields: graphql.Fields{
+			"assetID": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*models.AssetInfo).AssetID, nil
+				},
+			},
+			"asset": &graphql.Field{
+				Type: assetType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlRequestFrom(p.Context).loadAsset(p.Source.(*models.AssetInfo).AssetID), nil
+				},
+			},
+			"transactionCount": &graphql.Field{Type: graphql.String},
+			"utxoCount":        &graphql.Field{Type: graphql.String},
+			"balance":          &graphql.Field{Type: graphql.String},
+			"totalReceived":    &graphql.Field{Type: graphql.String},
+			"totalSent":        &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	addressType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Address",
+		Fields: graphql.Fields{
+			"address": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlBech32(p.Source.(*graphqlAddress).address)
+				},
+			},
+			"chainID": graphqlAddressInfoField(graphql.String, func(info *models.AddressInfo) interface{} {
+				return info.ChainID
+			}),
+			"publicKey": graphqlAddressInfoField(graphql.String, func(info *models.AddressInfo) interface{} {
+				return graphqlBytes(info.PublicKey)
+			}),
+			"assets": graphqlAddressInfoField(graphql.NewList(assetBalanceType), func(info *models.AddressInfo) interface{} {
+				assets := make([]*models.AssetInfo, 0, len(info.Assets))
+				for assetID, asset := range info.Assets {
+					asset := asset
+					asset.AssetID = assetID
+					assets = append(assets, &asset)
+				}
+				sort.Slice(assets, func(i, j int) bool { return assets[i].AssetID < assets[j].AssetID })
+				return assets
+			}),
+		},
+	})
+
+	credentialType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Credential",
+		Fields: graphql.Fields{
+			"address": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlBech32(p.Source.(models.InputCredentials).Address)
+				},
+			},
+			"publicKey": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlBytes(p.Source.(models.InputCredentials).PublicKey), nil
+				},
+			},
+			"signature": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlBytes(p.Source.(models.InputCredentials).Signature), nil
+				},
+			},
+		},
+	})
+
+	outputType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Output",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"transactionID": &graphql.Field{Type: graphql.String},
+				"transaction": &graphql.Field{
+					Type: transactionType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlRequestFrom(p.Context).loadTransaction(p.Source.(*models.Output).TransactionID), nil
+					},
+				},
+				"outputIndex": &graphql.Field{Type: graphql.Int},
+				"assetID":     &graphql.Field{Type: graphql.String},
+				"asset": &graphql.Field{
+					Type: assetType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlRequestFrom(p.Context).loadAsset(p.Source.(*models.Output).AssetID), nil
+					},
+				},
+				"outputType": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(p.Source.(*models.Output).OutputType), nil
+					},
+				},
+				"amount":        &graphql.Field{Type: graphql.String},
+				"locktime":      &graphql.Field{Type: graphql.String},
+				"stakeLocktime": &graphql.Field{Type: graphql.String},
+				"threshold":     &graphql.Field{Type: graphql.Int},
+				"addresses": &graphql.Field{
+					Type: graphql.NewList(addressType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						output := p.Source.(*models.Output)
+						addresses := make([]*graphqlAddress, 0, len(output.Addresses))
+						for _, addr := range output.Addresses {
+							addresses = append(addresses, &graphqlAddress{address: addr})
+						}
+						return addresses, nil
+					},
+				},
+				"caddresses":             &graphql.Field{Type: graphql.NewList(graphql.String)},
+				"timestamp":              &graphql.Field{Type: graphql.DateTime},
+				"redeemingTransactionID": &graphql.Field{Type: graphql.String},
+				"redeemingTransaction": &graphql.Field{
+					Type: transactionType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlRequestFrom(p.Context).loadTransaction(p.Source.(*models.Output).RedeemingTransactionID), nil
+					},
+				},
+				"chainID":      &graphql.Field{Type: graphql.String},
+				"inChainID":    &graphql.Field{Type: graphql.String},
+				"outChainID":   &graphql.Field{Type: graphql.String},
+				"groupID":      &graphql.Field{Type: graphql.String},
+				"stake":        &graphql.Field{Type: graphql.Boolean},
+				"frozen":       &graphql.Field{Type: graphql.Boolean},
+				"stakeableout": &graphql.Field{Type: graphql.Boolean},
+				"genesisutxo":  &graphql.Field{Type: graphql.Boolean},
+				"rewardUtxo":   &graphql.Field{Type: graphql.Boolean},
+				"payload": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlBytes(p.Source.(*models.Output).Payload), nil
+					},
+				},
+			}
+		}),
+	})
+
+	inputType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Input",
+		Fields: graphql.Fields{
+			"output":      &graphql.Field{Type: outputType},
+			"credentials": &graphql.Field{Type: graphql.NewList(credentialType)},
+		},
+	})
+
+	transactionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"chainID": &graphql.Field{Type: graphql.String},
+				"type":    &graphql.Field{Type: graphql.String},
+				"inputs":  &graphql.Field{Type: graphql.NewList(inputType)},
+				"outputs": &graphql.Field{Type: graphql.NewList(outputType)},
+				"memo": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlBytes(p.Source.(*models.Transaction).Memo), nil
+					},
+				},
+				"inputTotals": &graphql.Field{
+					Type: graphql.NewList(assetAmountType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlAssetAmounts(p.Source.(*models.Transaction).InputTotals), nil
+					},
+				},
+				"outputTotals": &graphql.Field{
+					Type: graphql.NewList(assetAmountType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlAssetAmounts(p.Source.(*models.Transaction).OutputTotals), nil
+					},
+				},
+				"timestamp":       &graphql.Field{Type: graphql.DateTime},
+				"txFee":           &graphql.Field{Type: graphql.String},
+				"genesis":         &graphql.Field{Type: graphql.Boolean},
+				"rewarded":        &graphql.Field{Type: graphql.Boolean},
+				"rewardedTime":    &graphql.Field{Type: graphql.DateTime},
+				"epoch":           &graphql.Field{Type: graphql.String},
+				"vertexId":        &graphql.Field{Type: graphql.String},
+				"validatorNodeID": &graphql.Field{Type: graphql.String},
+				"validatorStart":  &graphql.Field{Type: graphql.String},
+				"validatorEnd":    &graphql.Field{Type: graphql.String},
+				"txBlockId":       &graphql.Field{Type: graphql.String},
+				"block": &graphql.Field{
+					Type: blockType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return graphqlRequestFrom(p.Context).loadBlock(p.Source.(*models.Transaction).TxBlockID), nil
+					},
+				},
+			}
+		}),
+	})
+
+	cTransactionFields := graphql.Fields{
+		"type":      &graphql.Field{Type: graphql.Int},
+		"block":     &graphql.Field{Type: graphql.String},
+		"hash":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.DateTime},
+	}
+	for _, name := range []string{
+		"nonce", "gasPrice", "maxFeePerGas", "maxPriorityFeePerGas", "gasLimit",
+		"blockGasUsed", "blockGasLimit", "blockNonce", "blockHash",
+		"recipient", "value", "input", "toAddr", "fromAddr", "v", "r", "s",
+	} {
+		cTransactionFields[name] = &graphql.Field{Type: graphql.String}
+	}
+	cTransactionType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "CTransactionData",
+		Fields: cTransactionFields,
+	})
+
+	addressChainsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AddressChains",
+		Fields: graphql.Fields{
+			"address":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"chainIDs": &graphql.Field{Type: graphql.NewList(graphql.String)},
+		},
+	})
+
+	transactionListType := newGraphQLPageType("TransactionList", "transactions", transactionType)
+	outputListType := newGraphQLPageType("OutputList", "outputs", outputType)
+	assetListType := newGraphQLPageType("AssetList", "assets", assetType)
+	blockListType := newGraphQLPageType("BlockList", "blocks", blockType)
+	cTransactionListType := newGraphQLPageType("CTransactionList", "transactions", cTransactionType)
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"transaction": &graphql.Field{
+				Type: transactionType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyID: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlRequestFrom(p.Context).loadTransaction(models.StringID(p.Args[params.KeyID].(string))), nil
+				},
+			},
+			"transactions": &graphql.Field{
+				Type: transactionListType,
+				Args: graphqlListArgs(graphql.FieldConfigArgument{
+					params.KeyChainID: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyAddress: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyAssetID: &graphql.ArgumentConfig{Type: graphql.String},
+					params.KeySortBy:  &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := graphqlRequestFrom(p.Context)
+					lp := &params.ListTransactionsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					list, err := req.reader.ListTransactions(p.Context, lp, req.axcAssetID)
+					if err != nil {
+						return nil, err
+					}
+					return &graphqlPage{items: list.Transactions, next: list.Next}, nil
+				},
+			},
+			"output": &graphql.Field{
+				Type: outputType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyID: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := ids.FromString(p.Args[params.KeyID].(string))
+					if err != nil {
+						return nil, err
+					}
+					return graphqlRequestFrom(p.Context).reader.GetOutput(p.Context, id)
+				},
+			},
+			"outputs": &graphql.Field{
+				Type: outputListType,
+				Args: graphqlListArgs(graphql.FieldConfigArgument{
+					params.KeyChainID: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyAddress: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeySpent:   &graphql.ArgumentConfig{Type: graphql.Boolean},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListOutputsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					list, err := graphqlRequestFrom(p.Context).reader.ListOutputs(p.Context, lp)
+					if err != nil {
+						return nil, err
+					}
+					return &graphqlPage{items: list.Outputs, next: list.Next}, nil
+				},
+			},
+			"address": &graphql.Field{
+				Type: addressType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyAddress: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					params.KeyChainID: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListAddressesParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					lp.ListParams.DisableCounting = true
+					info, err := graphqlRequestFrom(p.Context).reader.GetAddress(p.Context, lp)
+					if err != nil {
+						return nil, err
+					}
+					if info == nil {
+						info = &models.AddressInfo{
+							Address: models.ToAddress(*lp.Address),
+							Assets:  make(map[models.StringID]models.AssetInfo),
+						}
+					}
+					return &graphqlAddress{address: info.Address, info: info}, nil
+				},
+			},
+			"asset": &graphql.Field{
+				Type: assetType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyID: &graphql.ArgumentConfig{
+						Type:        graphql.NewNonNull(graphql.String),
+						Description: "The asset id or alias",
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := graphqlRequestFrom(p.Context)
+					idStrOrAlias := p.Args[params.KeyID].(string)
+					if _, err := ids.FromString(idStrOrAlias); err == nil {
+						return req.loadAsset(models.StringID(idStrOrAlias)), nil
+					}
+					return req.reader.GetAsset(p.Context, &params.ListAssetsParams{}, idStrOrAlias)
+				},
+			},
+			"assets": &graphql.Field{
+				Type: assetListType,
+				Args: graphqlListArgs(graphql.FieldConfigArgument{
+					params.KeyAlias: &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListAssetsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					list, err := graphqlRequestFrom(p.Context).reader.ListAssets(p.Context, lp, nil)
+					if err != nil {
+						return nil, err
+					}
+					return &graphqlPage{items: list.Assets, next: list.Next}, nil
+				},
+			},
+			"block": &graphql.Field{
+				Type: blockType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyID: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return graphqlRequestFrom(p.Context).loadBlock(models.StringID(p.Args[params.KeyID].(string))), nil
+				},
+			},
+			"blocks": &graphql.Field{
+				Type: blockListType,
+				Args: graphqlListArgs(graphql.FieldConfigArgument{}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListBlocksParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					list, err := graphqlRequestFrom(p.Context).reader.ListBlocks(p.Context, lp)
+					if err != nil {
+						return nil, err
+					}
+					return &graphqlPage{items: list.Blocks, next: list.Next}, nil
+				},
+			},
+			"ctransaction": &graphql.Field{
+				Type: cTransactionType,
+				Args: graphql.FieldConfigArgument{
+					params.KeyHash: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListCTransactionsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					lp.ListParams.Limit = 1
+					list, err := graphqlRequestFrom(p.Context).reader.ListCTransactions(p.Context, lp)
+					if err != nil || len(list.Transactions) == 0 {
+						return nil, err
+					}
+					return list.Transactions[0], nil
+				},
+			},
+			"ctransactions": &graphql.Field{
+				Type: cTransactionListType,
+				Args: graphqlListArgs(graphql.FieldConfigArgument{
+					params.KeyChainID:     &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyAddress:     &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyToAddress:   &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyFromAddress: &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					params.KeyBlockStart:  &graphql.ArgumentConfig{Type: graphql.String},
+					params.KeyBlockEnd:    &graphql.ArgumentConfig{Type: graphql.String},
+					params.KeySortBy:      &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.ListCTransactionsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					list, err := graphqlRequestFrom(p.Context).reader.ListCTransactions(p.Context, lp)
+					if err != nil {
+						return nil, err
+					}
+					return &graphqlPage{items: list.Transactions, next: list.Next}, nil
+				},
+			},
+			"addressChains": &graphql.Field{
+				Type: graphql.NewList(addressChainsType),
+				Args: graphql.FieldConfigArgument{
+					params.KeyAddress: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.String))},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lp := &params.AddressChainsParams{}
+					if err := lp.ForValues(2, graphqlValues(p.Args)); err != nil {
+						return nil, err
+					}
+					addressChains, err := graphqlRequestFrom(p.Context).reader.AddressChains(p.Context, lp)
+					if err != nil {
+						return nil, err
+					}
+					results := make([]*graphqlAddressChains, 0, len(addressChains.AddressChains))
+					for address, chainIDs := range addressChains.AddressChains {
+						results = append(results, &graphqlAddressChains{Address: address, ChainIDs: chainIDs})
+					}
+					sort.Slice(results, func(i, j int) bool { return results[i].Address < results[j].Address })
+					return results, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func newGraphQLPageType(name string, itemsName string, itemType graphql.Type) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			itemsName: &graphql.Field{
+				Type: graphql.NewList(itemType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*graphqlPage).items, nil
+				},
+			},
+			"next": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*graphqlPage).next, nil
+				},
+			},
+		},
+	})
+}
+
+// graphqlListArgs adds the paging arguments to the arguments of a list query.
+func graphqlListArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args[params.KeyLimit] = &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: graphqlDefaultLimit}
+	args[params.KeyCursor] = &graphql.ArgumentConfig{Type: graphql.String}
+	args[params.KeyStartTime] = &graphql.ArgumentConfig{Type: graphql.String}
+	args[params.KeyEndTime] = &graphql.ArgumentConfig{Type: graphql.String}
+	return args
+}
+
+// graphqlValues converts the arguments to the query values the params parse,
+// the arguments are named after the query keys.
+func graphqlValues(args map[string]interface{}) url.Values {
+	q := url.Values{}
+	for key, arg := range args {
+		switch arg := arg.(type) {
+		case []interface{}:
+			for _, v := range arg {
+				q.Add(key, fmt.Sprint(v))
+			}
+		case nil:
+		default:
+			q.Set(key, fmt.Sprint(arg))
+		}
+	}
+	if _, ok := args[params.KeyLimit]; ok {
+		q.Set(params.KeyLimit, strconv.Itoa(graphqlLimit(args[params.KeyLimit])))
+	}
+	return q
+}
+
+// graphqlAddressInfoField resolves a field of the address balances, loading them if needed.
+func graphqlAddressInfoField(t graphql.Output, fn func(info *models.AddressInfo) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			addr := p.Source.(*graphqlAddress)
+			if addr.info != nil {
+				return fn(addr.info), nil
+			}
+			thunk := graphqlRequestFrom(p.Context).addresses.load(string(addr.address))
+			return func() (interface{}, error) {
+				info, err := thunk()
+				if err != nil || info == nil {
+					return nil, err
+				}
+				return fn(info.(*models.AddressInfo)), nil
+			}, nil
+		},
+	}
+}
+
+func graphqlAssetAmounts(counts models.AssetTokenCounts) []*graphqlAssetAmount {
+	amounts := make([]*graphqlAssetAmount, 0, len(counts))
+	for assetID, amount := range counts {
+		amounts = append(amounts, &graphqlAssetAmount{AssetID: assetID, Amount: amount})
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].AssetID < amounts[j].AssetID })
+	return amounts
+}
+
+func graphqlBech32(addr models.Address) (interface{}, error) {
+	bech32Addr, err := addr.MarshalString()
+	if err != nil {
+		return nil, err
+	}
+	return string(bech32Addr), nil
+}
+
+func graphqlBytes(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,141 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+func TestGraphQLLimit(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected int
+	}{
+		{nil, graphqlDefaultLimit},
+		{"10", graphqlDefaultLimit},
+		{0, graphqlDefaultLimit},
+		{-1, graphqlDefaultLimit},
+		{10, 10},
+		{float64(10), 10},
+		{graphqlMaxLimit, graphqlMaxLimit},
+		{graphqlMaxLimit + 1, graphqlMaxLimit},
+		{float64(1e9), graphqlMaxLimit},
+	}
+	for _, test := range tests {
+		if limit := graphqlLimit(test.v); limit != test.expected {
+			t.Fatal("limit of", test.v, "is", limit)
+		}
+	}
+}
+
+func TestCheckQueryLimits(t *testing.T) {
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parents := func(n int) string {
+		return strings.Repeat("parent { ", n) + "id" + strings.Repeat(" }", n)
+	}
+	// each transaction costs 133, its inputs and their addresses are lists of 10
+	const transactions = `query Transactions($limit: Int) {
+		transactions(limit: $limit) { transactions { id inputs { output { id addresses { address } } } } }
+	}`
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		err       string
+	}{
+		{"deepest", `{ block(id: "b") { ` + parents(GraphQLMaxDepth-2) + ` } }`, nil, ""},
+		{"too deep", `{ block(id: "b") { ` + parents(GraphQLMaxDepth-1) + ` } }`, nil, "query depth exceeds"},
+		{"too deep in a fragment", `{ block(id: "b") { ...parents } } fragment parents on Block { ` + parents(GraphQLMaxDepth-1) + ` }`, nil, "query depth exceeds"},
+		{"default page", transactions, nil, ""},
+		{"page", transactions, map[string]interface{}{"limit": float64(75)}, ""},
+		{"too complex", transactions, map[string]interface{}{"limit": float64(76)}, "query complexity exceeds"},
+		{"pages past the max limit", transactions, map[string]interface{}{"limit": float64(1e9)}, "query complexity exceeds"},
+		{"literal limit", "{ blocks(limit: 500) { blocks { id parent { id } } } }", nil, ""},
+	}
+	for _, test := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(test.query)})})
+		if err != nil {
+			t.Fatal(test.name, err)
+		}
+		if validation := graphql.ValidateDocument(&schema, doc, nil); !validation.IsValid {
+			t.Fatal(test.name, validation.Errors)
+		}
+		err = checkQueryLimits(&schema, doc, "", test.variables)
+		if test.err == "" && err != nil {
+			t.Fatal(test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Fatal(test.name, "expected", test.err, "got", err)
+		}
+	}
+}
+
+func TestGraphQLBlockParent(t *testing.T) {
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := map[string]*models.Block{
+		"b0": {ID: "b0"},
+		"b1": {ID: "b1", ParentID: "b0"},
+		"b2": {ID: "b2", ParentID: "b1"},
+	}
+	var fetches [][]string
+	ctx := context.Background()
+	req := &graphqlRequest{
+		blocks: newBatchLoader(ctx, func(_ context.Context, keys []string) (map[string]interface{}, error) {
+			// the executor resolves the fields of a level in no order
+			fetch := append([]string(nil), keys...)
+			sort.Strings(fetch)
+			fetches = append(fetches, fetch)
+			results := make(map[string]interface{})
+			for _, key := range keys {
+				if block, ok := blocks[key]; ok {
+					results[key] = block
+				}
+			}
+			return results, nil
+		}),
+	}
+
+	result := executeGraphQL(withGraphQLRequest(ctx, req), &schema, &graphqlParams{
+		Query: `{
+			a: block(id: "b1") { id parent { id parent { id } } }
+			b: block(id: "b2") { id parent { id } }
+			c: block(id: "b9") { id }
+		}`,
+	})
+	if len(result.Errors) != 0 {
+		t.Fatal(result.Errors)
+	}
+	b, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":{"id":"b1","parent":{"id":"b0","parent":null}},"b":{"id":"b2","parent":{"id":"b1"}},"c":null}`
+	if string(b) != expected {
+		t.Fatal("result", string(b))
+	}
+
+	// the blocks of a level load in one batch, and each block once
+	if !reflect.DeepEqual(fetches, [][]string{{"b1", "b2", "b9"}, {"b0"}}) {
+		t.Fatal("fetches", fetches)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/axiacoin/axia-network-v2-magellan/models"
 	"github.com/axiacoin/axia-network-v2-magellan/services"
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/axc"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
 	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
 	"github.com/axiacoin/axia-network-v2-magellan/stream/consumers"
 	"github.com/axiacoin/axia-network-v2-magellan/utils"
@@ -20,13 +21,14 @@ import (
 
 // Server is an HTTP server configured with various magellan APIs
 type Server struct {
-	sc     *servicesctrl.Control
-	server *http.Server
+	sc            *servicesctrl.Control
+	server        *http.Server
+	chainsWatcher *servicesctrl.ChainsWatcher
 }
 
 // NewServer creates a new *Server based on the given config
 func NewServer(sc *servicesctrl.Control, conf cfg.Config) (*Server, error) {
-	router, err := newRouter(sc, conf)
+	router, chainsWatcher, err := newRouter(sc, conf)
 	if err != nil {
 		return nil, err
 	}
@@ -34,14 +36,19 @@ func NewServer(sc *servicesctrl.Control, conf cfg.Config) (*Server, error) {
 	// Set address prefix to use the configured network
 	models.SetBech32HRP(conf.NetworkID)
 
+	// Sign list cursors with the shared secret so any instance can resume them
+	params.SetCursorSecret([]byte(conf.CursorSecret))
+
 	return &Server{
-		sc: sc,
+		sc:            sc,
+		chainsWatcher: chainsWatcher,
 		server: &http.Server{
 			Addr:         conf.ListenAddr,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: cfg.HTTPWriteTimeout,
 			IdleTimeout:  15 * time.Second,
 			Handler:      router,
+			ConnContext:  withConn,
 		},
 	}, err
 }
@@ -57,26 +64,53 @@ func (s *Server) Close() error {
 	s.sc.Log.Info("Server shutting down")
 	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFn()
+	s.chainsWatcher.Close()
 	return s.server.Shutdown(ctx)
 }
 
-func newRouter(sc *servicesctrl.Control, conf cfg.Config) (*web.Router, error) {
+// readerChains attaches the consumers of the chains registered at runtime to
+// the reader, which uses them to parse the transactions of the chains.
+type readerChains struct {
+	networkID uint32
+	axcReader *axc.Reader
+}
+
+func (rc *readerChains) AddChain(chain cfg.Chain) error {
+	consumer, err := consumers.IndexerConsumer(rc.networkID, chain.VMType, chain.ID)
+	if err != nil {
+		return err
+	}
+	rc.axcReader.AddChainConsumer(chain.ID, consumer)
+	return nil
+}
+
+func (rc *readerChains) RemoveChain(chain cfg.Chain) error {
+	rc.axcReader.RemoveChainConsumer(chain.ID)
+	return nil
+}
+
+func newRouter(sc *servicesctrl.Control, conf cfg.Config) (*web.Router, *servicesctrl.ChainsWatcher, error) {
 	sc.Log.Info("Router chainID %s", sc.GenesisContainer.SwapChainID.String())
 
 	indexBytes, err := newIndexResponse(conf.NetworkID, sc.GenesisContainer.SwapChainID, sc.GenesisContainer.AxcAssetID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	legacyIndexResponse, err := newLegacyIndexResponse(conf.NetworkID, sc.GenesisContainer.SwapChainID, sc.GenesisContainer.AxcAssetID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Create connections and readers
 	connections, err := sc.DatabaseRO()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	connectionsRW, err := sc.Database()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	cache := utils.NewCache()
@@ -86,19 +120,25 @@ func newRouter(sc *servicesctrl.Control, conf cfg.Config) (*web.Router, error) {
 	for chid, chain := range conf.Chains {
 		consumer, err := consumers.IndexerConsumer(conf.NetworkID, chain.VMType, chid)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		consumersmap[chid] = consumer
 	}
 	consumeraxchain, err := consumers.IndexerConsumerAXChain(conf.NetworkID, conf.AXchainID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	axcReader, err := axc.NewReader(conf.NetworkID, connections, consumersmap, consumeraxchain, sc)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	chainsWatcher, err := sc.WatchChains(&readerChains{networkID: conf.NetworkID, axcReader: axcReader})
+	if err != nil {
+		return nil, nil, err
 	}
 
+	feed := newTxFeed(sc, connectionsRW, conf.NetworkID, axcReader, sc.GenesisContainer.AxcAssetID)
+
 	ctx := Context{sc: sc}
 
 	// Build router
@@ -113,16 +153,22 @@ func newRouter(sc *servicesctrl.Control, conf cfg.Config) (*web.Router, error) {
 		NotFound((*Context).notFoundHandler).
 		Middleware(func(c *Context, w web.ResponseWriter, r *web.Request, next web.NextMiddlewareFunc) {
 			c.axcReader = axcReader
+			c.connectionsRW = connectionsRW
+			c.txFeed = feed
 			c.axcAssetID = sc.GenesisContainer.AxcAssetID
 
 			next(w, r)
 		})
 
 	AddV2Routes(&ctx, router, "/v2", indexBytes, nil)
+	if err := AddGraphQLRoutes(router, "/v2/graphql"); err != nil {
+		chainsWatcher.Close()
+		return nil, nil, err
+	}
 
 	// Legacy routes.
 	AddV2Routes(&ctx, router, "/x", legacyIndexResponse, &sc.GenesisContainer.SwapChainID)
 	AddV2Routes(&ctx, router, "/X", legacyIndexResponse, &sc.GenesisContainer.SwapChainID)
 
-	return router, nil
+	return router, chainsWatcher, nil
 }
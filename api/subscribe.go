@@ -0,0 +1,368 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/axc"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/stream"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/web"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	subscribeBufferSize = 1024
+
+	// the server write timeout ends an event stream, clients reconnect after sseRetry
+	sseDuration = cfg.HTTPWriteTimeout - 5*time.Second
+	sseRetry    = time.Second
+
+	// an idle event stream sends a comment every sseHeartbeatInterval, so proxies
+	// keep it open and clients can tell a quiet stream from a dead one
+	sseHeartbeatInterval = 15 * time.Second
+
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = wsPingInterval + wsWriteTimeout
+
+	// the feed reads the txs processed by the indexer from the tx pool every
+	// txPoolPollInterval. The txs processed in the last txPoolPollDelay are left
+	// to the next read, so a tx committed just after a later one is not skipped.
+	txPoolPollInterval = time.Second
+	txPoolPollDelay    = time.Second
+	txPoolPollLimit    = 1000
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// the api is served to any origin
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Subscribe streams the transactions matching the filters as they are indexed,
+// over a websocket if the client asks for an upgrade and as server-sent events otherwise.
+func (c *V2Context) Subscribe(w web.ResponseWriter, r *web.Request) {
+	p := &params.SubscribeParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	if c.chainID != nil && len(p.ChainIDs) == 0 {
+		p.ChainIDs = []string{c.chainID.String()}
+	}
+	if c.txFeed == nil {
+		c.WriteErr(w, 503, fmt.Errorf("subscriptions unavailable"))
+		return
+	}
+
+	indexedTxs, unsubscribe := c.txFeed.Subscribe(subscribeBufferSize)
+	defer unsubscribe()
+
+	if websocket.IsWebSocketUpgrade(r.Request) {
+		c.subscribeWebsocket(w, r, p, indexedTxs)
+		return
+	}
+	c.subscribeSSE(w, r, p, indexedTxs)
+}
+
+func (c *V2Context) subscribeSSE(w web.ResponseWriter, r *web.Request, p *params.SubscribeParams, indexedTxs <-chan *indexedTransactions) {
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+		return
+	}
+	w.Flush()
+
+	timer := time.NewTimer(sseDuration)
+	defer timer.Stop()
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := r.Context()
+	for {
+		select {
+		case indexedTx := <-indexedTxs:
+			for _, tx := range matchingTransactions(p, indexedTx) {
+				txBytes, err := json.Marshal(tx)
+				if err != nil {
+					c.sc.Log.Warn("subscribe marshal %v", err)
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", tx.ID, txBytes); err != nil {
+					return
+				}
+			}
+			w.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *V2Context) subscribeWebsocket(w web.ResponseWriter, r *web.Request, p *params.SubscribeParams, indexedTxs <-chan *indexedTransactions) {
+	conn, err := wsUpgrader.Upgrade(w, r.Request, nil)
+	if err != nil {
+		// the upgrader has written the error response
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// the hijacked connection keeps the server deadlines, replace them with our own
+	_ = conn.UnderlyingConn().SetDeadline(time.Time{})
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	// the client sends nothing but control frames, read to process them and detect the close
+	closedCh := make(chan struct{})
+	go func() {
+		defer close(closedCh)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case indexedTx := <-indexedTxs:
+			for _, tx := range matchingTransactions(p, indexedTx) {
+				_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := conn.WriteJSON(tx); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-closedCh:
+			return
+		}
+	}
+}
+
+// matchingTransactions returns the indexed transactions passing the filters.
+func matchingTransactions(p *params.SubscribeParams, indexedTx *indexedTransactions) []*models.Transaction {
+	if !p.MatchChainID(indexedTx.chainID) {
+		return nil
+	}
+	var matched []*models.Transaction
+	for _, tx := range indexedTx.txs {
+		if p.Match(tx) {
+			matched = append(matched, tx)
+		}
+	}
+	return matched
+}
+
+// indexedTransactions are the transactions loaded for a tx the indexer committed.
+type indexedTransactions struct {
+	chainID string
+	txs     []*models.Transaction
+}
+
+// txSource streams the txs the indexer commits.
+type txSource interface {
+	Subscribe(size int) (<-chan *utils.IndexedTx, func())
+}
+
+// txFeed loads each tx the indexer commits once, and fans the loaded
+// transactions out to the subscribers.
+// It listens to the indexer only while there are subscribers.
+type txFeed struct {
+	indexedTxs txSource
+	load       func(*utils.IndexedTx) []*models.Transaction
+
+	lock   sync.Mutex
+	subs   map[chan *indexedTransactions]struct{}
+	doneCh chan struct{}
+}
+
+func newTxFeed(sc *servicesctrl.Control, conns *utils.Connections, networkID uint32, axcReader *axc.Reader, axcAssetID ids.ID) *txFeed {
+	return &txFeed{
+		indexedTxs: &txPoolTxs{
+			sc:        sc,
+			networkID: networkID,
+			session: func(name string) dbr.SessionRunner {
+				return conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob(name))
+			},
+		},
+		load: func(indexedTx *utils.IndexedTx) []*models.Transaction {
+			id, err := ids.FromString(indexedTx.TxID)
+			if err != nil {
+				return nil
+			}
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+			defer cancelFn()
+
+			txs, err := axcReader.GetIndexedTransactions(ctx, id, axcAssetID)
+			if err != nil {
+				sc.Log.Warn("subscribe load %s %v", indexedTx.TxID, err)
+				return nil
+			}
+			return txs
+		},
+		subs: make(map[chan *indexedTransactions]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the loaded transactions, and a func to unsubscribe.
+func (f *txFeed) Subscribe(size int) (<-chan *indexedTransactions, func()) {
+	sub := make(chan *indexedTransactions, size)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subs[sub] = struct{}{}
+	if f.doneCh == nil {
+		f.doneCh = make(chan struct{})
+		indexedTxs, unsubscribe := f.indexedTxs.Subscribe(subscribeBufferSize)
+		go f.run(indexedTxs, unsubscribe, f.doneCh)
+	}
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			f.lock.Lock()
+			defer f.lock.Unlock()
+			delete(f.subs, sub)
+			if len(f.subs) == 0 {
+				close(f.doneCh)
+				f.doneCh = nil
+			}
+		})
+	}
+}
+
+func (f *txFeed) run(indexedTxs <-chan *utils.IndexedTx, unsubscribe func(), doneCh chan struct{}) {
+	defer unsubscribe()
+	for {
+		select {
+		case indexedTx := <-indexedTxs:
+			txs := f.load(indexedTx)
+			if len(txs) == 0 {
+				continue
+			}
+			f.publish(&indexedTransactions{chainID: indexedTx.ChainID, txs: txs})
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// publish delivers the transactions to every subscriber.
+// A subscriber which is not keeping up misses them rather than blocking the others.
+func (f *txFeed) publish(indexedTx *indexedTransactions) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for sub := range f.subs {
+		select {
+		case sub <- indexedTx:
+		default:
+		}
+	}
+}
+
+// txPoolTxs reads the txs the indexer commits from the tx pool, so they are
+// streamed whether or not the indexer runs in the process of the api.
+type txPoolTxs struct {
+	sc        *servicesctrl.Control
+	networkID uint32
+	session   func(name string) dbr.SessionRunner
+}
+
+// Subscribe returns a channel receiving the txs processed from now on, and a
+// func to unsubscribe.
+func (t *txPoolTxs) Subscribe(size int) (<-chan *utils.IndexedTx, func()) {
+	sub := make(chan *utils.IndexedTx, size)
+	doneCh := make(chan struct{})
+	go t.run(sub, doneCh)
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			close(doneCh)
+		})
+	}
+}
+
+func (t *txPoolTxs) run(sub chan<- *utils.IndexedTx, doneCh chan struct{}) {
+	ticker := time.NewTicker(txPoolPollInterval)
+	defer ticker.Stop()
+
+	after := &db.TxPool{ProcessedAt: time.Now().UTC().Add(-txPoolPollDelay)}
+	for {
+		select {
+		case <-ticker.C:
+		case <-doneCh:
+			return
+		}
+		var err error
+		if after, err = t.read(after, time.Now().UTC().Add(-txPoolPollDelay), sub, doneCh); err != nil {
+			t.sc.Log.Warn("subscribe read %v", err)
+		}
+	}
+}
+
+// read sends the txs processed after the row after and before before, and
+// returns the last row read.
+func (t *txPoolTxs) read(after *db.TxPool, before time.Time, sub chan<- *utils.IndexedTx, doneCh chan struct{}) (*db.TxPool, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := t.session("subscribe_tx_pool")
+
+	for {
+		txPools, err := t.sc.Persist.QueryTxPoolsProcessedAfter(ctx, sess, after, before, txPoolPollLimit)
+		if err != nil {
+			return after, err
+		}
+		for _, txPool := range txPools {
+			after = txPool
+			// the other messages of the tx pool are not txs
+			if txPool.Topic != stream.GetTopicName(t.networkID, txPool.ChainID, stream.EventTypeDecisions) {
+				continue
+			}
+			select {
+			case sub <- &utils.IndexedTx{ChainID: txPool.ChainID, TxID: txPool.MsgKey}:
+			case <-doneCh:
+				return after, nil
+			}
+		}
+		if len(txPools) < txPoolPollLimit {
+			return after, nil
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/stream"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/axiacoin/axia-network-v2/utils/logging"
+	"github.com/gocraft/dbr/v2"
+)
+
+// testTxSource hands the test the channel of each subscription.
+type testTxSource struct {
+	subs chan chan *utils.IndexedTx
+}
+
+func (s *testTxSource) Subscribe(size int) (<-chan *utils.IndexedTx, func()) {
+	sub := make(chan *utils.IndexedTx, size)
+	s.subs <- sub
+	return sub, func() {}
+}
+
+func TestTxFeed(t *testing.T) {
+	var loads int32
+	source := &testTxSource{subs: make(chan chan *utils.IndexedTx, 2)}
+	feed := &txFeed{
+		indexedTxs: source,
+		load: func(indexedTx *utils.IndexedTx) []*models.Transaction {
+			atomic.AddInt32(&loads, 1)
+			return []*models.Transaction{{ID: models.StringID(indexedTx.TxID), ChainID: models.StringID(indexedTx.ChainID)}}
+		},
+		subs: make(map[chan *indexedTransactions]struct{}),
+	}
+
+	sub1, unsub1 := feed.Subscribe(1)
+	sub2, unsub2 := feed.Subscribe(1)
+	if len(source.subs) != 1 {
+		t.Fatal("feed subscribed", len(source.subs), "times")
+	}
+
+	(<-source.subs) <- &utils.IndexedTx{ChainID: "c1", TxID: "1"}
+	for _, sub := range []<-chan *indexedTransactions{sub1, sub2} {
+		select {
+		case indexedTx := <-sub:
+			if len(indexedTx.txs) != 1 || indexedTx.txs[0].ID != "1" {
+				t.Fatal("wrong txs", indexedTx.txs)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("subscriber missed tx")
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatal("tx loaded", n, "times")
+	}
+
+	// the feed stops listening with the last subscriber
+	unsub1()
+	unsub2()
+	unsub2()
+	feed.lock.Lock()
+	if feed.doneCh != nil {
+		t.Fatal("feed still running")
+	}
+	feed.lock.Unlock()
+
+	sub3, unsub3 := feed.Subscribe(1)
+	defer unsub3()
+	(<-source.subs) <- &utils.IndexedTx{ChainID: "c1", TxID: "2"}
+	select {
+	case indexedTx := <-sub3:
+		if indexedTx.txs[0].ID != "2" {
+			t.Fatal("wrong txs", indexedTx.txs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber missed tx")
+	}
+}
+
+func TestTxPoolTxs(t *testing.T) {
+	ctx := context.Background()
+	persist := db.NewPersistMock()
+	txs := &txPoolTxs{
+		sc:        &servicesctrl.Control{Log: logging.NoLog{}, Persist: persist},
+		networkID: 1,
+		session:   func(string) dbr.SessionRunner { return nil },
+	}
+	process := func(chainID string, msgKey string, eventType stream.EventType) {
+		txPool := &db.TxPool{ChainID: chainID, MsgKey: msgKey, Topic: stream.GetTopicName(1, chainID, eventType)}
+		if err := txPool.ComputeID(); err != nil {
+			t.Fatal(err)
+		}
+		if err := persist.InsertTxPool(ctx, nil, txPool); err != nil {
+			t.Fatal(err)
+		}
+		txPool.Processed = db.TxPoolProcessed
+		if err := persist.UpdateTxPoolStatus(ctx, nil, txPool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(after *db.TxPool) (*db.TxPool, []string) {
+		sub := make(chan *utils.IndexedTx, 10)
+		after, err := txs.read(after, time.Now().UTC().Add(time.Second), sub, make(chan struct{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		close(sub)
+		var txIDs []string
+		for indexedTx := range sub {
+			txIDs = append(txIDs, indexedTx.ChainID+"/"+indexedTx.TxID)
+		}
+		return after, txIDs
+	}
+
+	// the txs processed before the subscription are not read
+	process("c1", "tx0", stream.EventTypeDecisions)
+	time.Sleep(time.Millisecond)
+	after := &db.TxPool{ProcessedAt: time.Now().UTC()}
+
+	// only the decisions are txs, unprocessed decisions are not read yet
+	process("c1", "tx1", stream.EventTypeDecisions)
+	process("c2", "b1", stream.EventTypeConsensus)
+	if err := persist.InsertTxPool(ctx, nil, &db.TxPool{ID: "unprocessed", ChainID: "c1", MsgKey: "tx2", Topic: stream.GetTopicName(1, "c1", stream.EventTypeDecisions)}); err != nil {
+		t.Fatal(err)
+	}
+	after, txIDs := read(after)
+	if !reflect.DeepEqual(txIDs, []string{"c1/tx1"}) {
+		t.Fatal("txs", txIDs)
+	}
+
+	// the next read continues after the rows read
+	if _, txIDs = read(after); len(txIDs) != 0 {
+		t.Fatal("txs read again", txIDs)
+	}
+	process("c2", "tx3", stream.EventTypeDecisions)
+	if _, txIDs = read(after); !reflect.DeepEqual(txIDs, []string{"c2/tx3"}) {
+		t.Fatal("txs", txIDs)
+	}
+}
+
+func TestMatchingTransactions(t *testing.T) {
+	indexedTx := &indexedTransactions{
+		chainID: "c1",
+		txs: []*models.Transaction{
+			{ID: "1", ChainID: "c1", Type: "base"},
+			{ID: "2", ChainID: "c1", Type: "export"},
+		},
+	}
+
+	p := &params.SubscribeParams{ChainIDs: []string{"c2"}}
+	if txs := matchingTransactions(p, indexedTx); len(txs) != 0 {
+		t.Fatal("matched other chain", txs)
+	}
+
+	p = &params.SubscribeParams{Types: []string{"export"}}
+	txs := matchingTransactions(p, indexedTx)
+	if len(txs) != 1 || txs[0].ID != "2" {
+		t.Fatal("wrong match", txs)
+	}
+	if len(indexedTx.txs) != 2 || indexedTx.txs[0].ID != "1" {
+		t.Fatal("shared txs modified")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/axiacoin/axia-network-v2/ids"
@@ -71,8 +72,11 @@ func AddV2Routes(ctx *Context, router *web.Router, path string, indexBytes []byt
 	utils.Prometheus.CounterInit(MetricSearchCount, MetricSearchCount)
 	utils.Prometheus.CounterInit(MetricSearchMillis, MetricSearchMillis)
 
+	utils.Prometheus.CounterInit(MetricExportCount, MetricExportCount)
+	utils.Prometheus.CounterInit(MetricExportMillis, MetricExportMillis)
+
 	v2ctx := V2Context{Context: ctx}
-	router.Subrouter(v2ctx, path).
+	v2 := router.Subrouter(v2ctx, path).
 		Get("/", func(c *V2Context, resp web.ResponseWriter, _ *web.Request) {
 			if _, err := resp.Write(indexBytes); err != nil {
 				ctx.sc.Log.Info("resp write %v", err)
@@ -116,6 +120,42 @@ func AddV2Routes(ctx *Context, router *web.Router, path string, indexBytes []byt
 		Get("/cacheassets", (*V2Context).CacheAssets).
 		Get("/cacheassetaggregates", (*V2Context).CacheAssetAggregates).
 		Get("/cacheaggregates/:id", (*V2Context).CacheAggregates)
+
+	// The routes added since v1 are not served on the legacy paths
+	if chainID != nil {
+		return
+	}
+	v2.
+		Get("/addresses/:id/balances", (*V2Context).GetAddressBalances).
+		Get("/addresses/:id/history", (*V2Context).GetAddressHistory).
+		Get("/addresses/:id/nfts", (*V2Context).ListAddressNFTs).
+		Get("/validators", (*V2Context).ListValidators).
+		Get("/delegators", (*V2Context).ListDelegators).
+		Get("/subnets", (*V2Context).ListSubnets).
+		Get("/subnets/:id", (*V2Context).GetSubnet).
+		Get("/blockchains", (*V2Context).ListBlockchains).
+		Get("/blockchains/:id", (*V2Context).GetBlockchain).
+		Get("/atomictransfers", (*V2Context).ListAtomicTransfers).
+		Get("/export/transactions", (*V2Context).ExportTransactions).
+		Get("/export/outputs", (*V2Context).ExportOutputs).
+		Get("/assets/:id/holders", (*V2Context).ListAssetHolders).
+		Get("/nfts/:assetID", (*V2Context).ListNFTs).
+		Get("/nfts/:assetID/:groupID/transfers", (*V2Context).ListNFTTransfers).
+		Get("/ctokens", (*V2Context).ListCTokens).
+		Get("/ctokens/:address/transfers", (*V2Context).ListCTokenTransfers).
+		Get("/caddresses/:address/tokens", (*V2Context).ListCTokenBalances).
+		Get("/caddresses/:address/internal", (*V2Context).ListCInternalTransfers).
+		Get("/clogs", (*V2Context).ListCLogs).
+		Get("/ccontracts/:address", (*V2Context).GetCContract).
+		Put("/ccontracts/:address", (*V2Context).PutCContract).
+		Delete("/ccontracts/:address", (*V2Context).DeleteCContract).
+		Get("/subscribe", (*V2Context).Subscribe).
+		Post("/webhooks", (*V2Context).CreateWebhook).
+		Get("/webhooks/:id", (*V2Context).GetWebhook).
+		Put("/webhooks/:id", (*V2Context).UpdateWebhook).
+		Delete("/webhooks/:id", (*V2Context).DeleteWebhook).
+		Get("/webhooks/:id/deliveries", (*V2Context).ListWebhookDeliveries).
+		Post("/webhooks/:id/deliveries/:deliveryID/redeliver", (*V2Context).RedeliverWebhookDelivery)
 }
 
 //
@@ -327,6 +367,153 @@ func (c *V2Context) ListCTransactions(w web.ResponseWriter, r *web.Request) {
 	})
 }
 
+func (c *V2Context) ListCTokens(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListCTokensParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_ctokens", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListCTokens(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListCTokenTransfers(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListCTokenTransfersParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Token = params.CAddress(r.PathParams["address"])
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_ctoken_transfers", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListCTokenTransfers(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListCLogs(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListCLogsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_clogs", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListCLogs(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListCTokenBalances(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.CTokenBalancesParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = params.CAddress(r.PathParams["address"])
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_ctoken_balances", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListCTokenBalances(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListAtomicTransfers(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListAtomicTransfersParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_atomic_transfers", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListAtomicTransfers(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListCInternalTransfers(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListCInternalTransfersParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = params.CAddress(r.PathParams["address"])
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_cinternal_transfers", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListCInternalTransfers(ctx, p)
+		},
+	})
+}
+
 func (c *V2Context) ListAddresses(w web.ResponseWriter, r *web.Request) {
 	collectors := utils.NewCollectors(
 		utils.NewCounterObserveMillisCollect(MetricMillis),
@@ -391,6 +578,107 @@ func (c *V2Context) GetAddress(w web.ResponseWriter, r *web.Request) {
 	})
 }
 
+func (c *V2Context) GetAddressBalances(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricAddressesMillis),
+		utils.NewCounterIncCollect(MetricAddressesCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.AddressBalancesParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	id, err := params.AddressFromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = id
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 1 * time.Second,
+		Key: c.cacheKeyForParams("get_address_balances", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.GetAddressBalances(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListAddressNFTs(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricAddressesMillis),
+		utils.NewCounterIncCollect(MetricAddressesCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListAddressNFTsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	id, err := params.AddressFromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = id
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_address_nfts", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListAddressNFTs(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) GetAddressHistory(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+		utils.NewCounterObserveMillisCollect(MetricAddressesMillis),
+		utils.NewCounterIncCollect(MetricAddressesCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.AddressHistoryParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	id, err := params.AddressFromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.Address = id
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, utils.Cacheable{
+		Key: c.cacheKeyForParams("get_address_history", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.GetAddressHistory(ctx, p, c.axcAssetID)
+		},
+	})
+}
+
 func (c *V2Context) AddressChains(w web.ResponseWriter, r *web.Request) {
 	collectors := utils.NewCollectors(
 		utils.NewCounterObserveMillisCollect(MetricMillis),
@@ -474,6 +762,148 @@ func (c *V2Context) ListOutputs(w web.ResponseWriter, r *web.Request) {
 	})
 }
 
+func (c *V2Context) ListValidators(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListValidatorsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_validators", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListValidators(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListDelegators(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListValidatorsParams{Delegators: true}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_delegators", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListDelegators(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListSubnets(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListSubnetsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_subnets", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListSubnets(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) GetSubnet(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	id, err := ids.FromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		Key: c.cacheKeyForID("get_subnet", r.PathParams["id"]),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.GetSubnet(ctx, id)
+		},
+	})
+}
+
+func (c *V2Context) ListBlockchains(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListBlockchainsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_blockchains", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListBlockchains(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) GetBlockchain(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	id, err := ids.FromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	c.WriteCacheable(w, utils.Cacheable{
+		Key: c.cacheKeyForID("get_blockchain", r.PathParams["id"]),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.GetBlockchain(ctx, id)
+		},
+	})
+}
+
 func (c *V2Context) GetOutput(w web.ResponseWriter, r *web.Request) {
 	collectors := utils.NewCollectors(
 		utils.NewCounterObserveMillisCollect(MetricMillis),
@@ -552,6 +982,107 @@ func (c *V2Context) GetAsset(w web.ResponseWriter, r *web.Request) {
 	})
 }
 
+func (c *V2Context) ListAssetHolders(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	if !c.sc.IsAccumulateBalanceReader {
+		c.WriteErr(w, 503, fmt.Errorf("asset holders unavailable"))
+		return
+	}
+
+	p := &params.ListAssetHoldersParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	assetID, err := ids.FromString(r.PathParams["id"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.AssetID = assetID.String()
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_asset_holders", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListAssetHolders(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListNFTs(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListNFTsParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	assetID, err := ids.FromString(r.PathParams["assetID"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.AssetID = assetID.String()
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_nfts", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListNFTs(ctx, p)
+		},
+	})
+}
+
+func (c *V2Context) ListNFTTransfers(w web.ResponseWriter, r *web.Request) {
+	collectors := utils.NewCollectors(
+		utils.NewCounterObserveMillisCollect(MetricMillis),
+		utils.NewCounterIncCollect(MetricCount),
+	)
+	defer func() {
+		_ = collectors.Collect()
+	}()
+
+	p := &params.ListNFTTransfersParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	assetID, err := ids.FromString(r.PathParams["assetID"])
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.AssetID = assetID.String()
+	if p.GroupID, err = strconv.ParseUint(r.PathParams["groupID"], 10, 32); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	p.ChainIDs = params.ForValueChainID(c.chainID, p.ChainIDs)
+
+	c.WriteCacheable(w, utils.Cacheable{
+		TTL: 5 * time.Second,
+		Key: c.cacheKeyForParams("list_nft_transfers", p),
+		CacheableFn: func(ctx context.Context) (interface{}, error) {
+			return c.axcReader.ListNFTTransfers(ctx, p)
+		},
+	})
+}
+
 //
 // PVM
 //
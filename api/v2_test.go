@@ -0,0 +1,52 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/gocraft/web"
+)
+
+func TestV2RoutesLegacyPaths(t *testing.T) {
+	ctx := Context{}
+	router := web.New(ctx)
+	AddV2Routes(&ctx, router, "/v2", nil, nil)
+	AddV2Routes(&ctx, router, "/x", nil, &ids.Empty)
+	AddV2Routes(&ctx, router, "/X", nil, &ids.Empty)
+
+	tests := []struct {
+		method string
+		path   string
+		routed bool
+	}{
+		{"GET", "/v2/transactions", true},
+		{"GET", "/x/transactions", true},
+		{"GET", "/X/assets/a", true},
+		{"POST", "/v2/webhooks", true},
+		{"PUT", "/v2/ccontracts/0xab", true},
+		{"GET", "/v2/subscribe", true},
+		{"GET", "/v2/export/outputs", true},
+		{"POST", "/x/webhooks", false},
+		{"DELETE", "/X/webhooks/w", false},
+		{"PUT", "/x/ccontracts/0xab", false},
+		{"DELETE", "/x/ccontracts/0xab", false},
+		{"GET", "/x/subscribe", false},
+		{"GET", "/x/export/transactions", false},
+		{"GET", "/X/validators", false},
+	}
+	for _, test := range tests {
+		// the methods of a path are listed without running its handlers
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, test.path, nil))
+		methods := w.Header().Get("Access-Control-Allow-Methods")
+		if routed := strings.Contains(methods, test.method); routed != test.routed {
+			t.Fatal(test.method, test.path, "routed", routed)
+		}
+	}
+}
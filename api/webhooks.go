@@ -0,0 +1,236 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/services/webhooks"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/web"
+)
+
+// Webhooks are not cached, and are read from and written to the primary database.
+// The webhook id is only known to its creator and acts as its credential.
+
+// primarySession is a session of the primary database, for the apis which write.
+func (c *V2Context) primarySession(name string) *dbr.Session {
+	return c.connectionsRW.DB().NewSessionForEventReceiver(c.connectionsRW.Stream().NewJob(name))
+}
+
+func (c *V2Context) writeObject(w web.ResponseWriter, obj interface{}) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		c.sc.Log.Warn("marshal %v", err)
+		c.WriteErr(w, 500, err)
+		return
+	}
+	WriteJSON(w, b)
+}
+
+func readWebhookRequest(r *web.Request) (*models.Webhook, error) {
+	req := &models.Webhook{}
+	err := json.NewDecoder(io.LimitReader(r.Body, cfg.RequestGetMaxSize)).Decode(req)
+	return req, err
+}
+
+// loadWebhook loads the webhook of the id path param, or writes the error response.
+func (c *V2Context) loadWebhook(ctx context.Context, w web.ResponseWriter, r *web.Request, sess dbr.SessionRunner) *db.Webhooks {
+	webhook, err := c.sc.Persist.QueryWebhooks(ctx, sess, &db.Webhooks{ID: r.PathParams["id"]})
+	switch err {
+	case nil:
+		return webhook
+	case dbr.ErrNotFound:
+		WriteErr(w, 404, "Not Found")
+	default:
+		c.sc.Log.Warn("webhook %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+	}
+	return nil
+}
+
+func (c *V2Context) CreateWebhook(w web.ResponseWriter, r *web.Request) {
+	if !c.authorized(r) {
+		c.WriteErr(w, 401, ErrUnauthorized)
+		return
+	}
+	req, err := readWebhookRequest(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	webhook, err := webhooks.NewWebhook(req.URL, req.Filter)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	if err := c.sc.Persist.InsertWebhooks(ctx, c.primarySession("create_webhook"), webhook, false); err != nil {
+		c.sc.Log.Warn("webhook %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp, err := webhooks.ToWebhook(webhook)
+	if err != nil {
+		c.WriteErr(w, 500, err)
+		return
+	}
+	resp.Secret = webhook.Secret
+	c.writeObject(w, resp)
+}
+
+func (c *V2Context) GetWebhook(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	webhook := c.loadWebhook(ctx, w, r, c.primarySession("get_webhook"))
+	if webhook == nil {
+		return
+	}
+
+	resp, err := webhooks.ToWebhook(webhook)
+	if err != nil {
+		c.WriteErr(w, 500, err)
+		return
+	}
+	c.writeObject(w, resp)
+}
+
+func (c *V2Context) UpdateWebhook(w web.ResponseWriter, r *web.Request) {
+	req, err := readWebhookRequest(r)
+	if err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("update_webhook")
+	webhook := c.loadWebhook(ctx, w, r, sess)
+	if webhook == nil {
+		return
+	}
+	if err := webhooks.UpdateWebhook(webhook, req.URL, req.Filter); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	if err := c.sc.Persist.InsertWebhooks(ctx, sess, webhook, true); err != nil {
+		c.sc.Log.Warn("webhook %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp, err := webhooks.ToWebhook(webhook)
+	if err != nil {
+		c.WriteErr(w, 500, err)
+		return
+	}
+	c.writeObject(w, resp)
+}
+
+func (c *V2Context) DeleteWebhook(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("delete_webhook")
+	webhook := c.loadWebhook(ctx, w, r, sess)
+	if webhook == nil {
+		return
+	}
+	if err := c.sc.Persist.DeleteWebhooks(ctx, sess, webhook); err != nil {
+		c.sc.Log.Warn("webhook %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp, err := webhooks.ToWebhook(webhook)
+	if err != nil {
+		c.WriteErr(w, 500, err)
+		return
+	}
+	c.writeObject(w, resp)
+}
+
+func (c *V2Context) ListWebhookDeliveries(w web.ResponseWriter, r *web.Request) {
+	p := &params.ListWebhookDeliveriesParams{}
+	if err := p.ForValues(c.version, r.URL.Query()); err != nil {
+		c.WriteErr(w, 400, err)
+		return
+	}
+	filter := &db.WebhookDeliveriesFilter{
+		WebhookID: r.PathParams["id"],
+		Limit:     p.ListParams.Limit,
+	}
+	for _, statusStr := range p.Statuses {
+		status, err := webhooks.ParseDeliveryStatus(statusStr)
+		if err != nil {
+			c.WriteErr(w, 400, err)
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if cursor := p.ListParams.Cursor; cursor != nil && cursor.Time != nil {
+		filter.After = &db.WebhookDeliveries{CreatedAt: *cursor.Time, ID: cursor.ID}
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("list_webhook_deliveries")
+	if webhook := c.loadWebhook(ctx, w, r, sess); webhook == nil {
+		return
+	}
+	deliveries, err := c.sc.Persist.QueryWebhookDeliveriesList(ctx, sess, filter)
+	if err != nil {
+		c.sc.Log.Warn("webhook deliveries %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	resp := &models.WebhookDeliveryList{Deliveries: make([]*models.WebhookDelivery, 0, len(deliveries))}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, webhooks.ToDelivery(delivery))
+	}
+	if len(deliveries) > 0 {
+		last := deliveries[len(deliveries)-1]
+		resp.Next = p.ListParams.NewTimeCursor(last.CreatedAt, last.ID).Next(len(deliveries), p.ListParams.Limit)
+	}
+	c.writeObject(w, resp)
+}
+
+// RedeliverWebhookDelivery queues a delivery again, typically one which is dead.
+func (c *V2Context) RedeliverWebhookDelivery(w web.ResponseWriter, r *web.Request) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := c.primarySession("redeliver_webhook_delivery")
+	webhook := c.loadWebhook(ctx, w, r, sess)
+	if webhook == nil {
+		return
+	}
+
+	delivery, err := c.sc.Persist.QueryWebhookDeliveries(ctx, sess, &db.WebhookDeliveries{ID: r.PathParams["deliveryID"]})
+	switch {
+	case err == dbr.ErrNotFound || (err == nil && delivery.WebhookID != webhook.ID):
+		WriteErr(w, 404, "Not Found")
+		return
+	case err != nil:
+		c.sc.Log.Warn("webhook delivery %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+
+	webhooks.Redeliver(delivery)
+	if err := c.sc.Persist.UpdateWebhookDeliveriesStatus(ctx, sess, delivery); err != nil {
+		c.sc.Log.Warn("webhook delivery %v", err)
+		c.WriteErr(w, 500, ErrCacheableFnFailed)
+		return
+	}
+	c.writeObject(w, webhooks.ToDelivery(delivery))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/axiacoin/axia-network-v2-magellan/db"
@@ -48,6 +49,12 @@ type Manager struct {
 
 	managerChannelsList []*managerChannels
 
+	// the time of the latest snapshot taken, or being taken, and the earliest
+	// time of a message indexed after it, which invalidates it
+	snapshotLock sync.Mutex
+	snapshotAt   time.Time
+	invalidAt    time.Time
+
 	doneCh  chan struct{}
 	enabled bool
 }
@@ -510,6 +517,18 @@ func (a *Handler) processOutputsBase(
 		if err != nil {
 			return err
 		}
+
+		// the balance is kept on the received row, for the holders of an asset sorted by balance.
+		// either total may be accumulated first.
+		_, err = dbTx.UpdateBySql("update "+db.TableAccumulateBalancesReceived+" "+
+			"set "+
+			"balance = total_amount - coalesce((select total_amount from "+db.TableAccumulateBalancesSent+" where id=?), 0) "+
+			"where id=? "+
+			"", b.ID, b.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
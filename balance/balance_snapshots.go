@@ -0,0 +1,330 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package balance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/gocraft/dbr/v2"
+)
+
+// SnapshotInterval is the time between the address balance snapshots.
+var SnapshotInterval = 24 * time.Hour
+
+// SnapshotLag delays a snapshot past its time, so the producers have read the
+// containers before it when it is taken.
+var SnapshotLag = 1 * time.Hour
+
+var snapshotTicker = 5 * time.Minute
+
+var snapshotTimeout = 10 * time.Minute
+
+// snapshotAddressBatch bounds the addresses of one query of the previous
+// balances, and of one page of the first snapshot.
+var snapshotAddressBatch = 500
+
+var errSnapshotsClosed = errors.New("snapshots closed")
+
+// StartSnapshots maintains the daily address balance snapshots, which the
+// point in time balance queries start from.
+// A snapshot is taken once every message before it is indexed. A message
+// indexed later, a container the producers read late, invalidates the
+// snapshots after it, see InvalidateSnapshots, and they are taken again.
+func (a *Manager) StartSnapshots() error {
+	conns, err := a.sc.Database()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelCTX := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelCTX()
+	last, err := a.persist.QueryLatestBalanceSnapshots(ctx, conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("balance-snapshot")), time.Now().UTC())
+	if err != nil {
+		_ = conns.Close()
+		return err
+	}
+	if last != nil {
+		a.snapshotAt = last.SnapshotAt
+	}
+
+	a.sc.Logger().Info("start snapshots")
+	go func() {
+		ticker := time.NewTicker(snapshotTicker)
+		defer func() {
+			ticker.Stop()
+			err := conns.Close()
+			if err != nil {
+				a.sc.Logger().Warn("connection close %v", err)
+			}
+			a.sc.Logger().Info("stop snapshots")
+		}()
+
+		runEvent := func() {
+			for {
+				select {
+				case <-a.doneCh:
+					return
+				default:
+				}
+				session := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("balance-snapshot"))
+				taken, err := a.processSnapshot(session, time.Now().UTC())
+				if err == errSnapshotsClosed {
+					return
+				}
+				if err != nil {
+					a.sc.Logger().Error("balance snapshot error %v", err)
+					return
+				}
+				if !taken {
+					return
+				}
+			}
+		}
+
+		runEvent()
+		for {
+			select {
+			case <-ticker.C:
+				runEvent()
+			case <-a.doneCh:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// InvalidateSnapshots takes the snapshots after the time again, the indexer
+// calls it with the time of each message before it marks it processed.
+func (a *Manager) InvalidateSnapshots(session dbr.SessionRunner, at time.Time) error {
+	a.snapshotLock.Lock()
+	defer a.snapshotLock.Unlock()
+	if !at.Before(a.snapshotAt) {
+		return nil
+	}
+	if a.invalidAt.IsZero() || at.Before(a.invalidAt) {
+		a.invalidAt = at
+	}
+
+	ctx, cancelCTX := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
+	defer cancelCTX()
+	return a.persist.DeleteBalanceSnapshotsAfter(ctx, session, at)
+}
+
+// processSnapshot takes the snapshot following the latest one, once its time
+// has passed by the lag and the messages before it are indexed. It returns
+// whether it took the snapshot, or should be called again.
+func (a *Manager) processSnapshot(session dbr.SessionRunner, now time.Time) (bool, error) {
+	start, snapshotAt, err := a.nextSnapshot(session, now)
+	if err != nil || snapshotAt.IsZero() {
+		return false, err
+	}
+
+	if start.IsZero() {
+		err = a.processFirstSnapshotBalances(session, snapshotAt)
+	} else {
+		err = a.processSnapshotBalances(session, start, snapshotAt)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	a.snapshotLock.Lock()
+	defer a.snapshotLock.Unlock()
+	if !a.invalidAt.IsZero() && a.invalidAt.Before(snapshotAt) {
+		// a message before it was indexed while it was taken
+		return true, nil
+	}
+	ctx, cancelCTX := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelCTX()
+	err = a.persist.InsertBalanceSnapshots(ctx, session, &db.BalanceSnapshots{SnapshotAt: snapshotAt, CreatedAt: time.Now().UTC()}, true)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// nextSnapshot returns the time of the snapshot to take, and of the latest one
+// it starts from, which is zero for the first. The time is zero when no
+// snapshot is due.
+func (a *Manager) nextSnapshot(session dbr.SessionRunner, now time.Time) (time.Time, time.Time, error) {
+	ctx, cancelCTX := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelCTX()
+
+	// the invalidations are read back with the latest snapshot
+	a.snapshotLock.Lock()
+	defer a.snapshotLock.Unlock()
+
+	last, err := a.persist.QueryLatestBalanceSnapshots(ctx, session, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	var start time.Time
+	var snapshotAt time.Time
+	if last != nil {
+		start = last.SnapshotAt
+		snapshotAt = last.SnapshotAt.Add(SnapshotInterval)
+	} else {
+		// the first snapshot follows the first output, it holds every balance
+		first, err := a.persist.QueryOutputsFirstCreatedAt(ctx, session)
+		if err != nil || first.IsZero() {
+			return time.Time{}, time.Time{}, err
+		}
+		snapshotAt = first.UTC().Truncate(SnapshotInterval).Add(SnapshotInterval)
+	}
+	a.snapshotAt = start
+	a.invalidAt = time.Time{}
+
+	if snapshotAt.Add(SnapshotLag).After(now) {
+		return time.Time{}, time.Time{}, nil
+	}
+	pending, err := a.persist.QueryTxPoolUnprocessedBefore(ctx, session, snapshotAt)
+	if err != nil || pending {
+		return time.Time{}, time.Time{}, err
+	}
+
+	a.snapshotAt = snapshotAt
+	return start, snapshotAt, nil
+}
+
+// processSnapshotBalances writes the balances as of snapshotAt of the
+// addresses which changed since start.
+// The rows are overwritten, so a snapshot which failed part way is retried.
+func (a *Manager) processSnapshotBalances(
+	session dbr.SessionRunner,
+	start time.Time,
+	snapshotAt time.Time,
+) error {
+	ctx, cancelCTX := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancelCTX()
+
+	changes, err := a.persist.QueryAddressBalanceChanges(ctx, session, &db.AddressBalanceChangesFilter{
+		Start: start,
+		End:   snapshotAt,
+	})
+	if err != nil {
+		return err
+	}
+	addresses, changesByAddress := changesOfAddresses(changes)
+
+	for len(addresses) != 0 {
+		batch := addresses
+		if len(batch) > snapshotAddressBatch {
+			batch = batch[:snapshotAddressBatch]
+		}
+		addresses = addresses[len(batch):]
+
+		previous, err := a.persist.QueryAddressBalanceSnapshots(ctx, session, &db.AddressBalanceSnapshotsFilter{
+			Addresses: batch,
+			Before:    start,
+		})
+		if err != nil {
+			return err
+		}
+		balances := make(map[string]*db.AddressBalanceSnapshots)
+		for _, b := range previous {
+			balances[db.AddressBalanceKey(b.ChainID, b.Address, b.AssetID)] = b
+		}
+
+		err = a.insertSnapshotBalances(ctx, session, batch, changesByAddress, balances, snapshotAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processFirstSnapshotBalances writes every balance as of snapshotAt, reading
+// the outputs of a page of the addresses at a time.
+func (a *Manager) processFirstSnapshotBalances(session dbr.SessionRunner, snapshotAt time.Time) error {
+	var after string
+	for {
+		select {
+		case <-a.doneCh:
+			return errSnapshotsClosed
+		default:
+		}
+
+		ctx, cancelCTX := context.WithTimeout(context.Background(), snapshotTimeout)
+		addresses, err := a.persist.QueryOutputAddressesAfter(ctx, session, after, snapshotAddressBatch)
+		if err != nil || len(addresses) == 0 {
+			cancelCTX()
+			return err
+		}
+		changes, err := a.persist.QueryAddressBalanceChanges(ctx, session, &db.AddressBalanceChangesFilter{
+			Addresses: addresses,
+			End:       snapshotAt,
+		})
+		if err == nil {
+			_, changesByAddress := changesOfAddresses(changes)
+			err = a.insertSnapshotBalances(ctx, session, addresses, changesByAddress, make(map[string]*db.AddressBalanceSnapshots), snapshotAt)
+		}
+		cancelCTX()
+		if err != nil {
+			return err
+		}
+		if len(addresses) < snapshotAddressBatch {
+			return nil
+		}
+		after = addresses[len(addresses)-1]
+	}
+}
+
+// changesOfAddresses groups the changes by address, the addresses are in the
+// order of their first change.
+func changesOfAddresses(changes []*db.AddressBalanceChanges) ([]string, map[string][]*db.AddressBalanceChanges) {
+	var addresses []string
+	changesByAddress := make(map[string][]*db.AddressBalanceChanges)
+	for _, c := range changes {
+		if _, ok := changesByAddress[c.Address]; !ok {
+			addresses = append(addresses, c.Address)
+		}
+		changesByAddress[c.Address] = append(changesByAddress[c.Address], c)
+	}
+	return addresses, changesByAddress
+}
+
+// insertSnapshotBalances applies the changes of the addresses to their
+// previous balances, and writes them as of snapshotAt.
+func (a *Manager) insertSnapshotBalances(
+	ctx context.Context,
+	session dbr.SessionRunner,
+	addresses []string,
+	changesByAddress map[string][]*db.AddressBalanceChanges,
+	balances map[string]*db.AddressBalanceSnapshots,
+	snapshotAt time.Time,
+) error {
+	for _, address := range addresses {
+		for _, c := range changesByAddress[address] {
+			b, ok := balances[db.AddressBalanceKey(c.ChainID, c.Address, c.AssetID)]
+			if !ok {
+				b = &db.AddressBalanceSnapshots{
+					ChainID: c.ChainID,
+					Address: c.Address,
+					AssetID: c.AssetID,
+					Balance: "0",
+				}
+			}
+			if err := b.Apply(c); err != nil {
+				return err
+			}
+			b.SnapshotAt = snapshotAt
+			b.CreatedAt = time.Now().UTC()
+			if err := b.ComputeID(); err != nil {
+				return err
+			}
+			err := a.persist.InsertAddressBalanceSnapshots(ctx, session, b, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package balance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2/utils/logging"
+	"github.com/gocraft/dbr/v2"
+)
+
+func TestProcessSnapshot(t *testing.T) {
+	sc := &servicesctrl.Control{Log: logging.NoLog{}}
+	// the mock does not use the session
+	var sess *dbr.Session
+
+	defer func(batch int) {
+		snapshotAddressBatch = batch
+	}(snapshotAddressBatch)
+	snapshotAddressBatch = 2
+
+	persist := db.NewPersistMock()
+	day0 := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	day1 := day0.Add(SnapshotInterval)
+	day2 := day1.Add(SnapshotInterval)
+	addOutput := func(id string, address string, amount uint64, createdAt time.Time) {
+		persist.Outputs[id] = &db.Outputs{ID: id, ChainID: "c", AssetID: "a", Amount: amount, CreatedAt: createdAt}
+		persist.OutputAddresses[id+address] = &db.OutputAddresses{OutputID: id, Address: address}
+	}
+	for i, address := range []string{"a1", "a2", "a3"} {
+		addOutput("o"+address, address, uint64(i+1), day0.Add(time.Hour))
+	}
+	balanceOf := func(address string, snapshotAt time.Time) string {
+		b := &db.AddressBalanceSnapshots{ChainID: "c", Address: address, AssetID: "a", SnapshotAt: snapshotAt}
+		if err := b.ComputeID(); err != nil {
+			t.Fatal(err)
+		}
+		v, ok := persist.AddressBalanceSnapshots[b.ID]
+		if !ok {
+			return ""
+		}
+		return v.Balance
+	}
+
+	a := NewManager(persist, sc)
+
+	// the first snapshot is taken a page of addresses at a time
+	taken, err := a.processSnapshot(sess, day1.Add(SnapshotLag))
+	if err != nil || !taken {
+		t.Fatal("first snapshot not taken", err)
+	}
+	if _, ok := persist.BalanceSnapshots[day1.UnixNano()]; !ok {
+		t.Fatal("first snapshot not marked")
+	}
+	for i, address := range []string{"a1", "a2", "a3"} {
+		if balance := balanceOf(address, day1); balance != []string{"1", "2", "3"}[i] {
+			t.Fatal("balance", address, balance)
+		}
+	}
+
+	// the next waits for the messages before it
+	addOutput("o4", "a1", 10, day1.Add(time.Hour))
+	persist.TxPool["m"] = &db.TxPool{ID: "m", CreatedAt: day1.Add(time.Hour), Processed: db.TxPoolUnprocessed}
+	taken, err = a.processSnapshot(sess, day2.Add(SnapshotLag))
+	if err != nil || taken {
+		t.Fatal("snapshot taken with pending messages", err)
+	}
+	persist.TxPool["m"].Processed = 1
+	taken, err = a.processSnapshot(sess, day2.Add(SnapshotLag))
+	if err != nil || !taken {
+		t.Fatal("snapshot not taken", err)
+	}
+	if balance := balanceOf("a1", day2); balance != "11" {
+		t.Fatal("balance", balance)
+	}
+
+	// a message indexed late takes the snapshots after it again
+	addOutput("o5", "a2", 100, day1.Add(2*time.Hour))
+	if err := a.InvalidateSnapshots(sess, day1.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := persist.BalanceSnapshots[day2.UnixNano()]; ok {
+		t.Fatal("invalid snapshot kept")
+	}
+	if _, ok := persist.BalanceSnapshots[day1.UnixNano()]; !ok {
+		t.Fatal("valid snapshot removed")
+	}
+	taken, err = a.processSnapshot(sess, day2.Add(SnapshotLag))
+	if err != nil || !taken {
+		t.Fatal("snapshot not taken again", err)
+	}
+	if balance := balanceOf("a2", day2); balance != "102" {
+		t.Fatal("balance", balance)
+	}
+
+	// messages after the latest snapshot do not invalidate it
+	if err := a.InvalidateSnapshots(sess, day2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := persist.BalanceSnapshots[day2.UnixNano()]; !ok {
+		t.Fatal("snapshot removed")
+	}
+}
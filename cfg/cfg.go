@@ -20,6 +20,12 @@ var (
 	ErrChainsConfigIDNotString     = errors.New("Chain config ID is not a string")
 	ErrChainsConfigAliasNotString  = errors.New("Chain config alias is not a string")
 	ErrChainsConfigVMNotString     = errors.New("Chain config vm type is not a string")
+
+	ErrEVMChainsConfigMustBeStringMap = errors.New("EVM chain config must a string map")
+	ErrEVMChainsConfigIDEmpty         = errors.New("EVM chain config ID is empty")
+	ErrEVMChainsConfigIDNotString     = errors.New("EVM chain config ID is not a string")
+	ErrEVMChainsConfigRPCNotString    = errors.New("EVM chain config rpc is not a string")
+	ErrEVMChainsConfigRPCsNotStrings  = errors.New("EVM chain config rpcs is not a list of strings")
 )
 
 type Config struct {
@@ -29,9 +35,12 @@ type Config struct {
 	MetricsListenAddr string `json:"metricsListenAddr"`
 	AdminListenAddr   string `json:"adminListenAddr"`
 	Features          map[string]struct{}
-	AXchainID          string `json:"axchainId"`
-	Axia       string `json:"axia"`
-	NodeInstance      string `json:"nodeInstance"`
+	AXchainID         string `json:"axchainId"`
+	EVMChains         `json:"evmChains"`
+	Axia              string   `json:"axia"`
+	AxiaNodes         []string `json:"axiaNodes"`
+	NodeInstance      string   `json:"nodeInstance"`
+	CatchupDistance   uint64   `json:"catchupDistance"`
 	AP5Activation     uint64
 }
 
@@ -42,6 +51,72 @@ type Chain struct {
 
 type Chains map[string]Chain
 
+// EVMChain is an EVM chain, like the EVM chains of the subnets, indexed in
+// addition to the AX chain. Its RPC endpoints are the ones of the nodes for the
+// chain if RPC is not set. The producer fails over from RPC to the other RPCs.
+type EVMChain struct {
+	ID   string   `json:"id"`
+	RPC  string   `json:"rpc"`
+	RPCs []string `json:"rpcs"`
+}
+
+type EVMChains map[string]EVMChain
+
+// AxiaEndpoints returns the endpoints of the nodes to index from, axia first
+// then axiaNodes.
+func (c *Config) AxiaEndpoints() []string {
+	return appendEndpoints(nil, append([]string{c.Axia}, c.AxiaNodes...)...)
+}
+
+// AllEVMChains returns the EVM chains to index, the AX chain and the EVM chains
+// of the config, with their RPC endpoints.
+func (c *Config) AllEVMChains() EVMChains {
+	nodeRPCs := func(path string) []string {
+		rpcs := []string{c.Axia + path}
+		for _, endpoint := range c.AxiaNodes {
+			rpcs = appendEndpoints(rpcs, endpoint+path)
+		}
+		return rpcs
+	}
+
+	evmChains := make(EVMChains, len(c.EVMChains)+1)
+	if c.AXchainID != "" {
+		rpcs := nodeRPCs("/ext/bc/C/rpc")
+		evmChains[c.AXchainID] = EVMChain{ID: c.AXchainID, RPC: rpcs[0], RPCs: rpcs}
+	}
+	for id, evmChain := range c.EVMChains {
+		if evmChain.RPC == "" {
+			evmChain.RPCs = appendEndpoints(nodeRPCs("/ext/bc/"+id+"/rpc"), evmChain.RPCs...)
+		} else {
+			evmChain.RPCs = appendEndpoints([]string{evmChain.RPC}, evmChain.RPCs...)
+		}
+		evmChain.RPC = evmChain.RPCs[0]
+		evmChains[id] = evmChain
+	}
+	return evmChains
+}
+
+// appendEndpoints appends the endpoints which are not empty and not in
+// endpoints yet.
+func appendEndpoints(endpoints []string, more ...string) []string {
+	for _, endpoint := range more {
+		if endpoint == "" {
+			continue
+		}
+		found := false
+		for _, e := range endpoints {
+			if e == endpoint {
+				found = true
+				break
+			}
+		}
+		if !found {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
 type Services struct {
 	Logging logging.Config `json:"logging"`
 	API     `json:"api"`
@@ -49,7 +124,9 @@ type Services struct {
 }
 
 type API struct {
-	ListenAddr string `json:"listenAddr"`
+	ListenAddr   string   `json:"listenAddr"`
+	CursorSecret string   `json:"cursorSecret"`
+	APIKeys      []string `json:"apiKeys"`
 }
 
 type DB struct {
@@ -80,6 +157,10 @@ func NewFromFile(filePath string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	evmChains, err := newEVMChainsConfig(v)
+	if err != nil {
+		return nil, err
+	}
 
 	// Build logging config
 	loggingConf := logging.DefaultConfig
@@ -114,7 +195,9 @@ func NewFromFile(filePath string) (*Config, error) {
 		Services: Services{
 			Logging: loggingConf,
 			API: API{
-				ListenAddr: v.GetString(keysServicesAPIListenAddr),
+				ListenAddr:   v.GetString(keysServicesAPIListenAddr),
+				CursorSecret: v.GetString(keysServicesAPICursorSecret),
+				APIKeys:      v.GetStringSlice(keysServicesAPIKeys),
 			},
 			DB: &DB{
 				Driver: servicesDBViper.GetString(keysServicesDBDriver),
@@ -122,9 +205,12 @@ func NewFromFile(filePath string) (*Config, error) {
 				RODSN:  dbrodsn,
 			},
 		},
-		AXchainID:      v.GetString(keysStreamProducerAXchainID),
-		Axia:   v.GetString(keysStreamProducerAxia),
-		NodeInstance:  v.GetString(keysStreamProducerNodeInstance),
-		AP5Activation: uint64(ap5Activation),
+		AXchainID:       v.GetString(keysStreamProducerAXchainID),
+		EVMChains:       evmChains,
+		Axia:            v.GetString(keysStreamProducerAxia),
+		AxiaNodes:       v.GetStringSlice(keysStreamProducerAxiaNodes),
+		NodeInstance:    v.GetString(keysStreamProducerNodeInstance),
+		CatchupDistance: v.GetUint64(keysStreamProducerCatchupDistance),
+		AP5Activation:   uint64(ap5Activation),
 	}, nil
 }
@@ -11,12 +11,25 @@ const (
 	HTTPWriteTimeout = 30 * time.Second
 	CacheTimeout     = 3 * time.Second
 
+	// ExportTimeout is the maximum duration to allow an export to stream.
+	// An export is read in pages and written as it is read, so it is not held
+	// to RequestTimeout; it runs at the pace of the database and the client.
+	// An hour allows the export of the full history of a chain, while it
+	// bounds the database session held by an export whose filters match too
+	// much to complete. A client which stops reading is cut off sooner, by the
+	// write timeout of each page.
+	ExportTimeout = 1 * time.Hour
+
 	DefaultConsumeProcessWriteTimeout = 5 * time.Minute
 
 	RequestGetMaxSize = int64(10 * 1024 * 1024)
 
 	MaxSizedList  = 20000
 	MaxTxPoolSize = 10000
+
+	// TxPoolMaxAttempts is how many times a tx pool message fails to process
+	// before it is quarantined.
+	TxPoolMaxAttempts = 10
 )
 
 // PerformUpdates controls for performing sql update operations.  Disabled by normal operation.
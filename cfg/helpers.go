@@ -84,3 +84,48 @@ func newChainsConfig(v *viper.Viper) (Chains, error) {
 	}
 	return chains, nil
 }
+
+func newEVMChainsConfig(v *viper.Viper) (EVMChains, error) {
+	evmChainsConf := v.GetStringMap(keysEVMChains)
+	evmChains := make(EVMChains, len(evmChainsConf))
+	for _, evmChainConf := range evmChainsConf {
+		confMap, ok := evmChainConf.(map[string]interface{})
+		if !ok {
+			return nil, ErrEVMChainsConfigMustBeStringMap
+		}
+
+		if confMap[keysChainsID] == nil {
+			return nil, ErrEVMChainsConfigIDEmpty
+		}
+		id, ok := confMap[keysChainsID].(string)
+		if !ok {
+			return nil, ErrEVMChainsConfigIDNotString
+		}
+
+		var rpc string
+		if confMap[keysEVMChainsRPC] != nil {
+			rpc, ok = confMap[keysEVMChainsRPC].(string)
+			if !ok {
+				return nil, ErrEVMChainsConfigRPCNotString
+			}
+		}
+
+		var rpcs []string
+		if confMap[keysEVMChainsRPCs] != nil {
+			rpcsConf, ok := confMap[keysEVMChainsRPCs].([]interface{})
+			if !ok {
+				return nil, ErrEVMChainsConfigRPCsNotStrings
+			}
+			for _, rpcConf := range rpcsConf {
+				rpc, ok := rpcConf.(string)
+				if !ok {
+					return nil, ErrEVMChainsConfigRPCsNotStrings
+				}
+				rpcs = append(rpcs, rpc)
+			}
+		}
+
+		evmChains[id] = EVMChain{ID: id, RPC: rpc, RPCs: rpcs}
+	}
+	return evmChains, nil
+}
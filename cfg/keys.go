@@ -12,9 +12,15 @@ const (
 	keysChainsID     = "id"
 	keysChainsVMType = "vmtype"
 
+	keysEVMChains     = "evmChains"
+	keysEVMChainsRPC  = "rpc"
+	keysEVMChainsRPCs = "rpcs"
+
 	keysServices = "services"
 
 	keysServicesAPIListenAddr     = "listenAddr"
+	keysServicesAPICursorSecret   = "cursorSecret"
+	keysServicesAPIKeys           = "apiKeys"
 	keysServicesAdminListenAddr   = "adminListenAddr"
 	keysServicesMetricsListenAddr = "metricsListenAddr"
 
@@ -23,8 +29,11 @@ const (
 	keysServicesDBDSN    = "dsn"
 	keysServicesDBRODSN  = "ro_dsn"
 
-	keysStreamProducerAxia  = "axia"
+	keysStreamProducerAxia         = "axia"
+	keysStreamProducerAxiaNodes    = "axiaNodes"
 	keysStreamProducerNodeInstance = "nodeInstance"
 
+	keysStreamProducerCatchupDistance = "catchupDistance"
+
 	keysStreamProducerAXchainID = "axchainID"
 )
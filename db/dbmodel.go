@@ -6,6 +6,8 @@ package db
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/axiacoin/axia-network-v2/ids"
@@ -48,6 +50,20 @@ const (
 	TableNodeIndex                        = "node_index"
 	TableCvmLogs                          = "cvm_logs"
 	TablePvmProposer                      = "pvm_proposer"
+	TableWebhooks                         = "webhooks"
+	TableWebhookDeliveries                = "webhook_deliveries"
+	TableAddressBalanceSnapshots          = "address_balance_snapshots"
+	TableBalanceSnapshots                 = "balance_snapshots"
+	TableSubnets                          = "subnets"
+	TableSubnetControlKeys                = "subnet_control_keys"
+	TableBlockchains                      = "blockchains"
+	TableChains                           = "chains"
+	TableCvmTokens                        = "cvm_tokens"
+	TableCvmTokenTransfers                = "cvm_token_transfers"
+	TableCvmContracts                     = "cvm_contracts"
+	TableCvmContractDeployments           = "cvm_contract_deployments"
+	TableCvmInternalTransfers             = "cvm_internal_transfers"
+	TableAtomicTransfers                  = "atomic_transfers"
 )
 
 type Persist interface {
@@ -163,6 +179,12 @@ type Persist interface {
 		dbr.SessionRunner,
 		*CvmBlocks,
 	) error
+	DeleteCvmBlocksAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
 
 	QueryCvmAddresses(
 		context.Context,
@@ -175,6 +197,12 @@ type Persist interface {
 		*CvmAddresses,
 		bool,
 	) error
+	DeleteCvmAddressesOfTransactions(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		[]string,
+	) error
 
 	QueryCvmTransactions(
 		context.Context,
@@ -187,6 +215,12 @@ type Persist interface {
 		*CvmTransactions,
 		bool,
 	) error
+	DeleteCvmTransactionsAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
 
 	QueryCvmTransactionsTxdata(
 		context.Context,
@@ -199,6 +233,12 @@ type Persist interface {
 		*CvmTransactionsTxdata,
 		bool,
 	) error
+	DeleteCvmTransactionsTxdataAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
 
 	QueryPvmBlocks(
 		context.Context,
@@ -385,11 +425,54 @@ type Persist interface {
 		dbr.SessionRunner,
 		*TxPool,
 	) error
+	InsertTxPools(
+		context.Context,
+		dbr.SessionRunner,
+		[]*TxPool,
+	) error
 	UpdateTxPoolStatus(
 		context.Context,
 		dbr.SessionRunner,
 		*TxPool,
 	) error
+	UpdateTxPoolFailure(
+		context.Context,
+		dbr.SessionRunner,
+		*TxPool,
+		int,
+	) error
+	ResetTxPool(
+		context.Context,
+		dbr.SessionRunner,
+		*TxPool,
+	) error
+	QueryTxPoolsQuarantined(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		uint64,
+	) ([]*TxPool, error)
+	QueryTxPoolQuarantinedCounts(
+		context.Context,
+		dbr.SessionRunner,
+	) ([]*TxPoolCount, error)
+	QueryTxPoolUnprocessedBefore(
+		context.Context,
+		dbr.SessionRunner,
+		time.Time,
+	) (bool, error)
+	QueryTxPoolsProcessedAfter(
+		context.Context,
+		dbr.SessionRunner,
+		*TxPool,
+		time.Time,
+		uint64,
+	) ([]*TxPool, error)
+	DeleteTxPool(
+		context.Context,
+		dbr.SessionRunner,
+		*TxPool,
+	) error
 
 	QueryKeyValueStore(
 		context.Context,
@@ -413,6 +496,12 @@ type Persist interface {
 		*CvmTransactionsTxdataTrace,
 		bool,
 	) error
+	DeleteCvmTransactionsTxdataTraceAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
 
 	QueryNodeIndex(
 		context.Context,
@@ -442,6 +531,12 @@ type Persist interface {
 		*CvmLogs,
 		bool,
 	) error
+	DeleteCvmLogsAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
 
 	QueryPvmProposer(
 		context.Context,
@@ -454,6 +549,260 @@ type Persist interface {
 		*PvmProposer,
 		bool,
 	) error
+
+	QueryWebhooks(
+		context.Context,
+		dbr.SessionRunner,
+		*Webhooks,
+	) (*Webhooks, error)
+	QueryAllWebhooks(
+		context.Context,
+		dbr.SessionRunner,
+	) ([]*Webhooks, error)
+	InsertWebhooks(
+		context.Context,
+		dbr.SessionRunner,
+		*Webhooks,
+		bool,
+	) error
+	DeleteWebhooks(
+		context.Context,
+		dbr.SessionRunner,
+		*Webhooks,
+	) error
+
+	QueryWebhookDeliveries(
+		context.Context,
+		dbr.SessionRunner,
+		*WebhookDeliveries,
+	) (*WebhookDeliveries, error)
+	QueryWebhookDeliveriesList(
+		context.Context,
+		dbr.SessionRunner,
+		*WebhookDeliveriesFilter,
+	) ([]*WebhookDeliveries, error)
+	InsertWebhookDeliveries(
+		context.Context,
+		dbr.SessionRunner,
+		*WebhookDeliveries,
+	) error
+	ClaimWebhookDeliveries(
+		context.Context,
+		dbr.SessionRunner,
+		*WebhookDeliveries,
+		time.Time,
+	) (bool, error)
+	UpdateWebhookDeliveriesStatus(
+		context.Context,
+		dbr.SessionRunner,
+		*WebhookDeliveries,
+	) error
+
+	QueryAddressBalanceChanges(
+		context.Context,
+		dbr.SessionRunner,
+		*AddressBalanceChangesFilter,
+	) ([]*AddressBalanceChanges, error)
+	QueryOutputsFirstCreatedAt(
+		context.Context,
+		dbr.SessionRunner,
+	) (time.Time, error)
+	QueryOutputAddressesAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		int,
+	) ([]string, error)
+
+	QueryAddressBalanceSnapshots(
+		context.Context,
+		dbr.SessionRunner,
+		*AddressBalanceSnapshotsFilter,
+	) ([]*AddressBalanceSnapshots, error)
+	InsertAddressBalanceSnapshots(
+		context.Context,
+		dbr.SessionRunner,
+		*AddressBalanceSnapshots,
+		bool,
+	) error
+
+	QueryLatestBalanceSnapshots(
+		context.Context,
+		dbr.SessionRunner,
+		time.Time,
+	) (*BalanceSnapshots, error)
+	InsertBalanceSnapshots(
+		context.Context,
+		dbr.SessionRunner,
+		*BalanceSnapshots,
+		bool,
+	) error
+	DeleteBalanceSnapshotsAfter(
+		context.Context,
+		dbr.SessionRunner,
+		time.Time,
+	) error
+
+	QuerySubnets(
+		context.Context,
+		dbr.SessionRunner,
+		*Subnets,
+	) (*Subnets, error)
+	InsertSubnets(
+		context.Context,
+		dbr.SessionRunner,
+		*Subnets,
+		bool,
+	) error
+
+	QuerySubnetControlKeys(
+		context.Context,
+		dbr.SessionRunner,
+		*SubnetControlKeys,
+	) (*SubnetControlKeys, error)
+	InsertSubnetControlKeys(
+		context.Context,
+		dbr.SessionRunner,
+		*SubnetControlKeys,
+		bool,
+	) error
+
+	QueryBlockchains(
+		context.Context,
+		dbr.SessionRunner,
+		*Blockchains,
+	) (*Blockchains, error)
+	InsertBlockchains(
+		context.Context,
+		dbr.SessionRunner,
+		*Blockchains,
+		bool,
+	) error
+
+	QueryChains(
+		context.Context,
+		dbr.SessionRunner,
+		*Chains,
+	) (*Chains, error)
+	QueryAllChains(
+		context.Context,
+		dbr.SessionRunner,
+	) ([]*Chains, error)
+	InsertChains(
+		context.Context,
+		dbr.SessionRunner,
+		*Chains,
+		bool,
+	) error
+	DeleteChains(
+		context.Context,
+		dbr.SessionRunner,
+		*Chains,
+	) error
+
+	QueryCvmTokens(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmTokens,
+	) (*CvmTokens, error)
+	InsertCvmTokens(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmTokens,
+		bool,
+	) error
+
+	QueryCvmTokenTransfers(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmTokenTransfers,
+	) (*CvmTokenTransfers, error)
+	InsertCvmTokenTransfers(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmTokenTransfers,
+		bool,
+	) error
+	DeleteCvmTokenTransfersAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
+
+	QueryCvmContracts(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmContracts,
+	) (*CvmContracts, error)
+	InsertCvmContracts(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmContracts,
+		bool,
+	) error
+	DeleteCvmContracts(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmContracts,
+	) error
+
+	QueryCvmContractDeployments(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmContractDeployments,
+	) (*CvmContractDeployments, error)
+	InsertCvmContractDeployments(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmContractDeployments,
+		bool,
+	) error
+	DeleteCvmContractDeploymentsAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
+
+	QueryCvmInternalTransfers(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmInternalTransfers,
+	) (*CvmInternalTransfers, error)
+	InsertCvmInternalTransfers(
+		context.Context,
+		dbr.SessionRunner,
+		*CvmInternalTransfers,
+		bool,
+	) error
+	DeleteCvmInternalTransfersAfter(
+		context.Context,
+		dbr.SessionRunner,
+		string,
+		string,
+	) error
+
+	QueryAtomicTransfers(
+		context.Context,
+		dbr.SessionRunner,
+		*AtomicTransfers,
+	) (*AtomicTransfers, error)
+	InsertAtomicTransfersExport(
+		context.Context,
+		dbr.SessionRunner,
+		*AtomicTransfers,
+	) error
+	InsertAtomicTransfersImport(
+		context.Context,
+		dbr.SessionRunner,
+		*AtomicTransfers,
+	) error
+	DeleteAtomicTransactions(
+		context.Context,
+		dbr.SessionRunner,
+		[]string,
+	) error
 }
 
 type persist struct {
@@ -512,7 +861,7 @@ func (p *persist) InsertTransactions(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactions).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
@@ -522,8 +871,7 @@ func (p *persist) InsertTransactions(
 		Pair("canonical_serialization", v.CanonicalSerialization).
 		Pair("txfee", v.Txfee).
 		Pair("genesis", v.Genesis).
-		Pair("network_id", v.NetworkID).
-		ExecContext(ctx)
+		Pair("network_id", v.NetworkID))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactions, false, err)
 	}
@@ -588,7 +936,7 @@ func (p *persist) InsertOutputsRedeeming(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableOutputsRedeeming).
 		Pair("id", v.ID).
 		Pair("redeemed_at", v.RedeemedAt).
@@ -598,8 +946,7 @@ func (p *persist) InsertOutputsRedeeming(
 		Pair("intx", v.Intx).
 		Pair("asset_id", v.AssetID).
 		Pair("created_at", v.CreatedAt).
-		Pair("chain_id", v.ChainID).
-		ExecContext(ctx)
+		Pair("chain_id", v.ChainID))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputsRedeeming, false, err)
 	}
@@ -679,7 +1026,7 @@ func (p *persist) InsertOutputs(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableOutputs).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
@@ -697,8 +1044,7 @@ func (p *persist) InsertOutputs(
 		Pair("frozen", v.Frozen).
 		Pair("stakeableout", v.Stakeableout).
 		Pair("genesisutxo", v.Genesisutxo).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputs, false, err)
 	}
@@ -769,7 +1115,7 @@ func (p *persist) InsertAssets(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAssets).
 		Pair("id", v.ID).
 		Pair("chain_Id", v.ChainID).
@@ -778,8 +1124,7 @@ func (p *persist) InsertAssets(
 		Pair("denomination", v.Denomination).
 		Pair("alias", v.Alias).
 		Pair("current_supply", v.CurrentSupply).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAssets, false, err)
 	}
@@ -833,13 +1178,12 @@ func (p *persist) InsertAddresses(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAddresses).
 		Pair("address", v.Address).
 		Pair("public_key", v.PublicKey).
 		Pair("created_at", v.CreatedAt).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAddresses, false, err)
 	}
@@ -890,13 +1234,12 @@ func (p *persist) InsertAddressChain(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAddressChain).
 		Pair("address", v.Address).
 		Pair("chain_id", v.ChainID).
 		Pair("created_at", v.CreatedAt).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAddressChain, false, err)
 	}
@@ -956,7 +1299,7 @@ func (p *persist) InsertOutputAddresses(
 	if v.RedeemingSignature != nil {
 		stmt = stmt.Pair("redeeming_signature", v.RedeemingSignature)
 	}
-	_, err = stmt.ExecContext(ctx)
+	err = insertIgnore(ctx, sess, stmt)
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputAddresses, false, err)
 	}
@@ -1027,13 +1370,12 @@ func (p *persist) InsertTransactionsEpoch(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsEpochs).
 		Pair("id", v.ID).
 		Pair("epoch", v.Epoch).
 		Pair("vertex_id", v.VertexID).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsEpochs, false, err)
 	}
@@ -1054,8 +1396,11 @@ func (p *persist) InsertTransactionsEpoch(
 }
 
 type CvmBlocks struct {
-	Block     string
-	CreatedAt time.Time
+	ChainID    string
+	Block      string
+	Hash       string
+	ParentHash string
+	CreatedAt  time.Time
 }
 
 func (p *persist) QueryCvmBlocks(
@@ -1065,10 +1410,13 @@ func (p *persist) QueryCvmBlocks(
 ) (*CvmBlocks, error) {
 	v := &CvmBlocks{}
 	err := sess.Select(
+		"chain_id",
 		"block",
+		"hash",
+		"parent_hash",
 		"created_at",
 	).From(TableCvmBlocks).
-		Where("block="+q.Block).
+		Where("chain_id=? and block="+q.Block, q.ChainID).
 		LoadOneContext(ctx, v)
 	return v, err
 }
@@ -1079,18 +1427,35 @@ func (p *persist) InsertCvmBlocks(
 	v *CvmBlocks,
 ) error {
 	var err error
-	_, err = sess.
-		InsertBySql("insert into "+TableCvmBlocks+" (block,created_at) values("+v.Block+",?)",
-			v.CreatedAt).
-		ExecContext(ctx)
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmBlocks+" (chain_id,block,hash,parent_hash,created_at) values(?,"+v.Block+",?,?,?)",
+			v.ChainID, v.Hash, v.ParentHash, v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmBlocks, false, err)
 	}
 	return nil
 }
 
+// DeleteCvmBlocksAfter removes every block of the chain above the given block number.
+func (p *persist) DeleteCvmBlocksAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmBlocks).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmBlocks, false, err)
+	}
+	return nil
+}
+
 type CvmAddresses struct {
 	ID            string
+	ChainID       string
 	Type          models.AXChainType
 	Idx           uint64
 	TransactionID string
@@ -1109,6 +1474,7 @@ func (p *persist) QueryCvmAddresses(
 	v := &CvmAddresses{}
 	err := sess.Select(
 		"id",
+		"chain_id",
 		"type",
 		"idx",
 		"transaction_id",
@@ -1130,9 +1496,10 @@ func (p *persist) InsertCvmAddresses(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableCvmAddresses).
 		Pair("id", v.ID).
+		Pair("chain_id", v.ChainID).
 		Pair("type", v.Type).
 		Pair("idx", v.Idx).
 		Pair("transaction_id", v.TransactionID).
@@ -1140,14 +1507,14 @@ func (p *persist) InsertCvmAddresses(
 		Pair("asset_id", v.AssetID).
 		Pair("amount", v.Amount).
 		Pair("nonce", v.Nonce).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmAddresses, false, err)
 	}
 	if upd {
 		_, err = sess.
 			Update(TableCvmAddresses).
+			Set("chain_id", v.ChainID).
 			Set("type", v.Type).
 			Set("idx", v.Idx).
 			Set("transaction_id", v.TransactionID).
@@ -1165,7 +1532,29 @@ func (p *persist) InsertCvmAddresses(
 	return nil
 }
 
+// DeleteCvmAddressesOfTransactions removes the addresses of the atomic
+// transactions of the chain.
+func (p *persist) DeleteCvmAddressesOfTransactions(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	transactionIDs []string,
+) error {
+	if len(transactionIDs) == 0 {
+		return nil
+	}
+	_, err := sess.
+		DeleteFrom(TableCvmAddresses).
+		Where("chain_id=? and transaction_id in ?", chainID, transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmAddresses, false, err)
+	}
+	return nil
+}
+
 type CvmTransactions struct {
+	ChainID       string
 	ID            string
 	TransactionID string
 	Type          models.AXChainType
@@ -1186,11 +1575,12 @@ func (p *persist) QueryCvmTransactions(
 ) (*CvmTransactions, error) {
 	v := &CvmTransactions{}
 	err := sess.Select(
+		"chain_id",
 		"id",
 		"transaction_id",
 		"type",
 		"blockchain_id",
-		"cast(block as char) as block",
+		CastString(sess, "block"),
 		"created_at",
 		"serialization",
 		"tx_time",
@@ -1198,7 +1588,7 @@ func (p *persist) QueryCvmTransactions(
 		"hash",
 		"parent_hash",
 	).From(TableCvmTransactions).
-		Where("id=?", q.ID).
+		Where("chain_id=? and id=?", q.ChainID, q.ID).
 		LoadOneContext(ctx, v)
 	return v, err
 }
@@ -1210,17 +1600,16 @@ func (p *persist) InsertCvmTransactions(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
-		InsertBySql("insert into "+TableCvmTransactions+" (id,transaction_id,type,blockchain_id,created_at,block,serialization,tx_time,nonce,hash,parent_hash) values(?,?,?,?,?,"+v.Block+",?,?,?,?,?)",
-			v.ID, v.TransactionID, v.Type, v.BlockchainID, v.CreatedAt, v.Serialization, v.TxTime, v.Nonce, v.Hash, v.ParentHash).
-		ExecContext(ctx)
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmTransactions+" (chain_id,id,transaction_id,type,blockchain_id,created_at,block,serialization,tx_time,nonce,hash,parent_hash) values(?,?,?,?,?,?,"+v.Block+",?,?,?,?,?)",
+			v.ChainID, v.ID, v.TransactionID, v.Type, v.BlockchainID, v.CreatedAt, v.Serialization, v.TxTime, v.Nonce, v.Hash, v.ParentHash))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmTransactions, false, err)
 	}
 	if upd {
 		_, err = sess.
-			UpdateBySql("update "+TableCvmTransactions+" set transaction_id=?,type=?,blockchain_id=?,block="+v.Block+",serialization=?,tx_time=?,nonce=?,hash=?,parent_hash=?,created_at=? where id=?",
-				v.TransactionID, v.Type, v.BlockchainID, v.Serialization, v.TxTime, v.Nonce, v.Hash, v.ParentHash, v.CreatedAt, v.ID).
+			UpdateBySql("update "+TableCvmTransactions+" set transaction_id=?,type=?,blockchain_id=?,block="+v.Block+",serialization=?,tx_time=?,nonce=?,hash=?,parent_hash=?,created_at=? where chain_id=? and id=?",
+				v.TransactionID, v.Type, v.BlockchainID, v.Serialization, v.TxTime, v.Nonce, v.Hash, v.ParentHash, v.CreatedAt, v.ChainID, v.ID).
 			ExecContext(ctx)
 		if err != nil {
 			return EventErr(TableCvmTransactions, true, err)
@@ -1229,7 +1618,24 @@ func (p *persist) InsertCvmTransactions(
 	return nil
 }
 
+func (p *persist) DeleteCvmTransactionsAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmTransactions).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmTransactions, false, err)
+	}
+	return nil
+}
+
 type CvmTransactionsTxdata struct {
+	ChainID       string
 	Hash          string
 	Block         string
 	Idx           uint64
@@ -1246,6 +1652,7 @@ func (p *persist) QueryCvmTransactionsTxdata(
 ) (*CvmTransactionsTxdata, error) {
 	v := &CvmTransactionsTxdata{}
 	err := sess.Select(
+		"chain_id",
 		"hash",
 		"block",
 		"idx",
@@ -1254,7 +1661,7 @@ func (p *persist) QueryCvmTransactionsTxdata(
 		"serialization",
 		"created_at",
 	).From(TableCvmTransactionsTxdata).
-		Where("hash=?", q.Hash).
+		Where("chain_id=? and hash=?", q.ChainID, q.Hash).
 		LoadOneContext(ctx, v)
 	return v, err
 }
@@ -1266,17 +1673,16 @@ func (p *persist) InsertCvmTransactionsTxdata(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
-		InsertBySql("insert into "+TableCvmTransactionsTxdata+" (hash,block,idx,rcpt,nonce,serialization,created_at) values(?,"+v.Block+",?,?,?,?,?)",
-			v.Hash, v.Idx, v.Rcpt, v.Nonce, v.Serialization, v.CreatedAt).
-		ExecContext(ctx)
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmTransactionsTxdata+" (chain_id,hash,block,idx,rcpt,nonce,serialization,created_at) values(?,?,"+v.Block+",?,?,?,?,?)",
+			v.ChainID, v.Hash, v.Idx, v.Rcpt, v.Nonce, v.Serialization, v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmTransactionsTxdata, false, err)
 	}
 	if upd {
 		_, err = sess.
-			UpdateBySql("update "+TableCvmTransactionsTxdata+" set block="+v.Block+",idx=?,rcpt=?,nonce=?,serialization=?,created_at=? where hash=?",
-				v.Idx, v.Rcpt, v.Nonce, v.Serialization, v.CreatedAt, v.Hash).
+			UpdateBySql("update "+TableCvmTransactionsTxdata+" set block="+v.Block+",idx=?,rcpt=?,nonce=?,serialization=?,created_at=? where chain_id=? and hash=?",
+				v.Idx, v.Rcpt, v.Nonce, v.Serialization, v.CreatedAt, v.ChainID, v.Hash).
 			ExecContext(ctx)
 		if err != nil {
 			return EventErr(TableCvmTransactionsTxdata, true, err)
@@ -1285,6 +1691,22 @@ func (p *persist) InsertCvmTransactionsTxdata(
 	return nil
 }
 
+func (p *persist) DeleteCvmTransactionsTxdataAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmTransactionsTxdata).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmTransactionsTxdata, false, err)
+	}
+	return nil
+}
+
 type PvmBlocks struct {
 	ID            string
 	ChainID       string
@@ -1322,7 +1744,7 @@ func (p *persist) InsertPvmBlocks(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TablePvmBlocks).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
@@ -1330,8 +1752,7 @@ func (p *persist) InsertPvmBlocks(
 		Pair("parent_id", v.ParentID).
 		Pair("created_at", v.CreatedAt).
 		Pair("serialization", v.Serialization).
-		Pair("height", v.Height).
-		ExecContext(ctx)
+		Pair("height", v.Height))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TablePvmBlocks, false, err)
 	}
@@ -1389,15 +1810,14 @@ func (p *persist) InsertRewards(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableRewards).
 		Pair("id", v.ID).
 		Pair("block_id", v.BlockID).
 		Pair("txid", v.Txid).
 		Pair("shouldprefercommit", v.Shouldprefercommit).
 		Pair("created_at", v.CreatedAt).
-		Pair("processed", v.Processed).
-		ExecContext(ctx)
+		Pair("processed", v.Processed))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableRewards, false, err)
 	}
@@ -1438,8 +1858,10 @@ func (p *persist) UpdateRewardsProcessed(
 type TransactionsValidator struct {
 	ID        string
 	NodeID    string
+	SubnetID  string
 	Start     uint64
 	End       uint64
+	Weight    uint64
 	CreatedAt time.Time
 }
 
@@ -1452,8 +1874,10 @@ func (p *persist) QueryTransactionsValidator(
 	err := sess.Select(
 		"id",
 		"node_id",
+		"subnet_id",
 		"start",
-		"end",
+		quoteIdent(sess, "end"),
+		"weight",
 		"created_at",
 	).From(TableTransactionsValidator).
 		Where("id=?", q.ID).
@@ -1468,14 +1892,15 @@ func (p *persist) InsertTransactionsValidator(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsValidator).
 		Pair("id", v.ID).
 		Pair("node_id", v.NodeID).
+		Pair("subnet_id", v.SubnetID).
 		Pair("start", v.Start).
 		Pair("end", v.End).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("weight", v.Weight).
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsValidator, false, err)
 	}
@@ -1483,8 +1908,10 @@ func (p *persist) InsertTransactionsValidator(
 		_, err = sess.
 			Update(TableTransactionsValidator).
 			Set("node_id", v.NodeID).
+			Set("subnet_id", v.SubnetID).
 			Set("start", v.Start).
 			Set("end", v.End).
+			Set("weight", v.Weight).
 			Set("created_at", v.CreatedAt).
 			Where("id = ?", v.ID).
 			ExecContext(ctx)
@@ -1524,12 +1951,11 @@ func (p *persist) InsertTransactionsBlock(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsBlock).
 		Pair("id", v.ID).
 		Pair("tx_block_id", v.TxBlockID).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsBlock, false, err)
 	}
@@ -1576,12 +2002,11 @@ func (p *persist) InsertAddressBech32(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAddressBech32).
 		Pair("address", v.Address).
 		Pair("bech32_address", v.Bech32Address).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAddressBech32, false, err)
 	}
@@ -1647,15 +2072,14 @@ func (p *persist) InsertOutputAddressAccumulateOut(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableOutputAddressAccumulateOut).
 		Pair("id", v.ID).
 		Pair("output_id", v.OutputID).
 		Pair("address", v.Address).
 		Pair("transaction_id", v.TransactionID).
 		Pair("output_index", v.OutputIndex).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputAddressAccumulateOut, false, err)
 	}
@@ -1705,15 +2129,14 @@ func (p *persist) InsertOutputAddressAccumulateIn(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableOutputAddressAccumulateIn).
 		Pair("id", v.ID).
 		Pair("output_id", v.OutputID).
 		Pair("address", v.Address).
 		Pair("transaction_id", v.TransactionID).
 		Pair("output_index", v.OutputIndex).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputAddressAccumulateIn, false, err)
 	}
@@ -1798,15 +2221,14 @@ func (p *persist) InsertOutputTxsAccumulate(
 	v *OutputTxsAccumulate,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableOutputTxsAccumulate).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
 		Pair("asset_id", v.AssetID).
 		Pair("address", v.Address).
 		Pair("transaction_id", v.TransactionID).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableOutputTxsAccumulate, false, err)
 	}
@@ -1845,8 +2267,8 @@ func (p *persist) QueryAccumulateBalancesReceived(
 		"chain_id",
 		"asset_id",
 		"address",
-		"cast(total_amount as char) total_amount",
-		"cast(utxo_count as char) utxo_count",
+		CastString(sess, "total_amount"),
+		CastString(sess, "utxo_count"),
 		"updated_at",
 	).From(TableAccumulateBalancesReceived).
 		Where("id=?", q.ID).
@@ -1860,14 +2282,13 @@ func (p *persist) InsertAccumulateBalancesReceived(
 	v *AccumulateBalancesAmount,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAccumulateBalancesReceived).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
 		Pair("asset_id", v.AssetID).
 		Pair("address", v.Address).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAccumulateBalancesReceived, false, err)
 	}
@@ -1886,8 +2307,8 @@ func (p *persist) QueryAccumulateBalancesSent(
 		"chain_id",
 		"asset_id",
 		"address",
-		"cast(total_amount as char) total_amount",
-		"cast(utxo_count as char) utxo_count",
+		CastString(sess, "total_amount"),
+		CastString(sess, "utxo_count"),
 		"updated_at",
 	).From(TableAccumulateBalancesSent).
 		Where("id=?", q.ID).
@@ -1901,14 +2322,13 @@ func (p *persist) InsertAccumulateBalancesSent(
 	v *AccumulateBalancesAmount,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAccumulateBalancesSent).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
 		Pair("asset_id", v.AssetID).
 		Pair("address", v.Address).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAccumulateBalancesSent, false, err)
 	}
@@ -1946,7 +2366,7 @@ func (p *persist) QueryAccumulateBalancesTransactions(
 		"chain_id",
 		"asset_id",
 		"address",
-		"cast(transaction_count as char) transaction_count",
+		CastString(sess, "transaction_count"),
 		"updated_at",
 	).From(TableAccumulateBalancesTransactions).
 		Where("id=?", q.ID).
@@ -1960,14 +2380,13 @@ func (p *persist) InsertAccumulateBalancesTransactions(
 	v *AccumulateBalancesTransactions,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableAccumulateBalancesTransactions).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
 		Pair("asset_id", v.AssetID).
 		Pair("address", v.Address).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableAccumulateBalancesTransactions, false, err)
 	}
@@ -2006,13 +2425,12 @@ func (p *persist) InsertTransactionsRewardsOwnersAddress(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsRewardsOwnersAddress).
 		Pair("id", v.ID).
 		Pair("address", v.Address).
 		Pair("output_index", v.OutputIndex).
-		Pair("updated_at", v.UpdatedAt).
-		ExecContext(ctx)
+		Pair("updated_at", v.UpdatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsRewardsOwnersAddress, false, err)
 	}
@@ -2061,13 +2479,12 @@ func (p *persist) InsertTransactionsRewardsOwnersOutputs(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsRewardsOwnersOutputs).
 		Pair("id", v.ID).
 		Pair("transaction_id", v.TransactionID).
 		Pair("output_index", v.OutputIndex).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsRewardsOwnersOutputs, false, err)
 	}
@@ -2119,14 +2536,13 @@ func (p *persist) InsertTransactionsRewardsOwners(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTransactionsRewardsOwners).
 		Pair("id", v.ID).
 		Pair("chain_id", v.ChainID).
 		Pair("locktime", v.Locktime).
 		Pair("threshold", v.Threshold).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTransactionsRewardsOwners, false, err)
 	}
@@ -2146,6 +2562,27 @@ func (p *persist) InsertTransactionsRewardsOwners(
 	return nil
 }
 
+// The states of a tx pool row in processed. A row which failed to process
+// TxPoolMaxAttempts times is quarantined, it is not processed until it is
+// retried. A skipped row is not processed.
+const (
+	TxPoolUnprocessed = 0
+	TxPoolProcessed   = 1
+	TxPoolQuarantined = 2
+	TxPoolSkipped     = 3
+
+	// txPoolLastErrorSize is the size of the last_error column.
+	txPoolLastErrorSize = 1024
+
+	// txPoolsInsertMaxBytes bounds the statement of a multi-row insert, well
+	// under the 4MB max_allowed_packet of mysql 5.7.
+	txPoolsInsertMaxBytes = 2 << 20
+
+	// txPoolRowOverhead is the size of the quoting, the numbers and the time
+	// of a row in a statement.
+	txPoolRowOverhead = 128
+)
+
 type TxPool struct {
 	ID            string
 	NetworkID     uint32
@@ -2155,6 +2592,17 @@ type TxPool struct {
 	Processed     int
 	Topic         string
 	CreatedAt     time.Time
+	Attempts      int
+	LastError     string
+
+	// ProcessedAt is when the row was last processed.
+	ProcessedAt time.Time
+}
+
+// TxPoolCount is the count of the tx pool rows of a topic.
+type TxPoolCount struct {
+	Topic string
+	Count uint64
 }
 
 func (b *TxPool) ComputeID() error {
@@ -2182,6 +2630,8 @@ func (p *persist) QueryTxPool(
 		"processed",
 		"topic",
 		"created_at",
+		"attempts",
+		"last_error",
 	).From(TableTxPool).
 		Where("id=?", q.ID).
 		LoadOneContext(ctx, v)
@@ -2194,7 +2644,7 @@ func (p *persist) InsertTxPool(
 	v *TxPool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableTxPool).
 		Pair("id", v.ID).
 		Pair("network_id", v.NetworkID).
@@ -2203,8 +2653,7 @@ func (p *persist) InsertTxPool(
 		Pair("serialization", v.Serialization).
 		Pair("processed", v.Processed).
 		Pair("topic", v.Topic).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableTxPool, false, err)
 	}
@@ -2212,15 +2661,71 @@ func (p *persist) InsertTxPool(
 	return nil
 }
 
+// InsertTxPools inserts the rows in as few statements as fit in
+// txPoolsInsertMaxBytes, skipping the rows which exist.
+func (p *persist) InsertTxPools(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	vs []*TxPool,
+) error {
+	for _, chunk := range txPoolChunks(vs, txPoolsInsertMaxBytes) {
+		stmt := sess.
+			InsertInto(TableTxPool).
+			Columns("id", "network_id", "chain_id", "msg_key", "serialization", "processed", "topic", "created_at")
+		for _, v := range chunk {
+			stmt.Values(v.ID, v.NetworkID, v.ChainID, v.MsgKey, v.Serialization, v.Processed, v.Topic, v.CreatedAt)
+		}
+		// a single duplicate would fail the statement on mysql
+		if !isPostgres(sess) {
+			stmt.Ignore()
+		}
+		err := insertIgnore(ctx, sess, stmt)
+		if err != nil {
+			return EventErr(TableTxPool, false, err)
+		}
+	}
+
+	return nil
+}
+
+// txPoolChunks splits the rows into chunks of at most maxBytes of statement. A
+// row larger than maxBytes is a chunk of its own.
+func txPoolChunks(vs []*TxPool, maxBytes int) [][]*TxPool {
+	var chunks [][]*TxPool
+	start, size := 0, 0
+	for i, v := range vs {
+		rowBytes := txPoolRowBytes(v)
+		if i > start && size+rowBytes > maxBytes {
+			chunks = append(chunks, vs[start:i])
+			start, size = i, 0
+		}
+		size += rowBytes
+	}
+	if start < len(vs) {
+		chunks = append(chunks, vs[start:])
+	}
+	return chunks
+}
+
+// txPoolRowBytes is the size of the values of a row in a statement, the
+// serialization is interpolated in hex.
+func txPoolRowBytes(v *TxPool) int {
+	return 2*len(v.Serialization) + len(v.ID) + len(v.ChainID) + len(v.MsgKey) + len(v.Topic) + txPoolRowOverhead
+}
+
 func (p *persist) UpdateTxPoolStatus(
 	ctx context.Context,
 	sess dbr.SessionRunner,
 	v *TxPool,
 ) error {
 	var err error
-	_, err = sess.
+	stmt := sess.
 		Update(TableTxPool).
-		Set("processed", v.Processed).
+		Set("processed", v.Processed)
+	if v.Processed == TxPoolProcessed {
+		stmt.Set("processed_at", time.Now().UTC())
+	}
+	_, err = stmt.
 		Where("id=?", v.ID).
 		ExecContext(ctx)
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
@@ -2230,6 +2735,155 @@ func (p *persist) UpdateTxPoolStatus(
 	return nil
 }
 
+// UpdateTxPoolFailure counts a failure to process the row, and quarantines the
+// row at maxAttempts failures.
+func (p *persist) UpdateTxPoolFailure(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *TxPool,
+	maxAttempts int,
+) error {
+	lastError := v.LastError
+	if len(lastError) > txPoolLastErrorSize {
+		lastError = strings.ToValidUTF8(lastError[:txPoolLastErrorSize], "")
+	}
+	// mysql assigns left to right, processed is set before attempts changes
+	_, err := sess.
+		UpdateBySql("update "+TableTxPool+" set processed=case when attempts+1>=? then ? else processed end,attempts=attempts+1,last_error=? where id=? and processed=?",
+			maxAttempts, TxPoolQuarantined, lastError, v.ID, TxPoolUnprocessed).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableTxPool, true, err)
+	}
+	return nil
+}
+
+// ResetTxPool makes the row unprocessed, with no failures.
+func (p *persist) ResetTxPool(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *TxPool,
+) error {
+	_, err := sess.
+		Update(TableTxPool).
+		Set("processed", TxPoolUnprocessed).
+		Set("attempts", 0).
+		Set("last_error", "").
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableTxPool, true, err)
+	}
+	return nil
+}
+
+// QueryTxPoolsQuarantined returns the quarantined rows of the topic, of all
+// topics if it is empty, oldest first and without their serialization.
+func (p *persist) QueryTxPoolsQuarantined(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	topic string,
+	limit uint64,
+) ([]*TxPool, error) {
+	q := sess.Select(
+		"id",
+		"network_id",
+		"chain_id",
+		"msg_key",
+		"processed",
+		"topic",
+		"created_at",
+		"attempts",
+		"last_error",
+	).From(TableTxPool).
+		Where("processed=?", TxPoolQuarantined)
+	if topic != "" {
+		q.Where("topic=?", topic)
+	}
+	var vs []*TxPool
+	_, err := q.
+		OrderAsc("created_at").
+		Limit(limit).
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+// QueryTxPoolQuarantinedCounts returns the count of quarantined rows by topic.
+func (p *persist) QueryTxPoolQuarantinedCounts(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+) ([]*TxPoolCount, error) {
+	var vs []*TxPoolCount
+	_, err := sess.Select(
+		"topic",
+		"count(*) as count",
+	).From(TableTxPool).
+		Where("processed=?", TxPoolQuarantined).
+		GroupBy("topic").
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+// QueryTxPoolUnprocessedBefore returns whether a row created before the time
+// is still to be processed, the quarantined rows are not.
+func (p *persist) QueryTxPoolUnprocessedBefore(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	before time.Time,
+) (bool, error) {
+	var vs []*TxPool
+	_, err := sess.Select(
+		"id",
+	).From(TableTxPool).
+		Where("processed=? and created_at < ?", TxPoolUnprocessed, before).
+		Limit(1).
+		LoadContext(ctx, &vs)
+	return len(vs) != 0, err
+}
+
+// QueryTxPoolsProcessedAfter returns a page of the rows processed after the row
+// after and before before, in the order they were processed.
+func (p *persist) QueryTxPoolsProcessedAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	after *TxPool,
+	before time.Time,
+	limit uint64,
+) ([]*TxPool, error) {
+	var vs []*TxPool
+	_, err := sess.Select(
+		"id",
+		"network_id",
+		"chain_id",
+		"msg_key",
+		"topic",
+		"created_at",
+		"processed_at",
+	).From(TableTxPool).
+		Where("processed=? and processed_at<?", TxPoolProcessed, before).
+		Where("(processed_at>? or (processed_at=? and id>?))", after.ProcessedAt, after.ProcessedAt, after.ID).
+		OrderAsc("processed_at").
+		OrderAsc("id").
+		Limit(limit).
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func (p *persist) DeleteTxPool(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *TxPool,
+) error {
+	_, err := sess.
+		DeleteFrom(TableTxPool).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableTxPool, false, err)
+	}
+	return nil
+}
+
 type KeyValueStore struct {
 	K string
 	V string
@@ -2256,11 +2910,10 @@ func (p *persist) InsertKeyValueStore(
 	v *KeyValueStore,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableKeyValueStore).
 		Pair("k", v.K).
-		Pair("v", v.V).
-		ExecContext(ctx)
+		Pair("v", v.V))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableKeyValueStore, false, err)
 	}
@@ -2269,6 +2922,7 @@ func (p *persist) InsertKeyValueStore(
 }
 
 type CvmTransactionsTxdataTrace struct {
+	ChainID       string
 	Hash          string
 	Idx           uint32
 	ToAddr        string
@@ -2286,6 +2940,7 @@ func (p *persist) QueryCvmTransactionsTxdataTrace(
 ) (*CvmTransactionsTxdataTrace, error) {
 	v := &CvmTransactionsTxdataTrace{}
 	err := sess.Select(
+		"chain_id",
 		"hash",
 		"idx",
 		"to_addr",
@@ -2295,7 +2950,7 @@ func (p *persist) QueryCvmTransactionsTxdataTrace(
 		"serialization",
 		"created_at",
 	).From(TableCvmTransactionsTxdataTrace).
-		Where("hash=? and idx=?", q.Hash, q.Idx).
+		Where("chain_id=? and hash=? and idx=?", q.ChainID, q.Hash, q.Idx).
 		LoadOneContext(ctx, v)
 	return v, err
 }
@@ -2307,8 +2962,9 @@ func (p *persist) InsertCvmTransactionsTxdataTrace(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableCvmTransactionsTxdataTrace).
+		Pair("chain_id", v.ChainID).
 		Pair("hash", v.Hash).
 		Pair("idx", v.Idx).
 		Pair("to_addr", v.ToAddr).
@@ -2316,8 +2972,7 @@ func (p *persist) InsertCvmTransactionsTxdataTrace(
 		Pair("call_type", v.CallType).
 		Pair("type", v.Type).
 		Pair("serialization", v.Serialization).
-		Pair("created_at", v.CreatedAt).
-		ExecContext(ctx)
+		Pair("created_at", v.CreatedAt))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmTransactionsTxdataTrace, false, err)
 	}
@@ -2330,7 +2985,7 @@ func (p *persist) InsertCvmTransactionsTxdataTrace(
 			Set("type", v.Type).
 			Set("serialization", v.Serialization).
 			Set("created_at", v.CreatedAt).
-			Where("hash=? and idx=?", v.Hash, v.Idx).
+			Where("chain_id=? and hash=? and idx=?", v.ChainID, v.Hash, v.Idx).
 			ExecContext(ctx)
 		if err != nil {
 			return EventErr(TableCvmTransactionsTxdataTrace, true, err)
@@ -2339,10 +2994,31 @@ func (p *persist) InsertCvmTransactionsTxdataTrace(
 	return nil
 }
 
+// DeleteCvmTransactionsTxdataTraceAfter removes the traces of every transaction of the chain above the given block number.
+// The traces are matched through cvm_transactions_txdata, so it must run before DeleteCvmTransactionsTxdataAfter.
+func (p *persist) DeleteCvmTransactionsTxdataTraceAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmTransactionsTxdataTrace).
+		Where("chain_id=? and hash in (select hash from "+TableCvmTransactionsTxdata+" where chain_id=? and block > "+block+")", chainID, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmTransactionsTxdataTrace, false, err)
+	}
+	return nil
+}
+
+// NodeIndex is the position of a producer, Idx the index on the node of the
+// next container to read and ContainerID the last container read.
 type NodeIndex struct {
-	Instance string
-	Topic    string
-	Idx      uint64
+	Instance    string
+	Topic       string
+	Idx         uint64
+	ContainerID string
 }
 
 func (p *persist) QueryNodeIndex(
@@ -2355,6 +3031,7 @@ func (p *persist) QueryNodeIndex(
 		"instance",
 		"topic",
 		"idx",
+		"container_id",
 	).From(TableNodeIndex).
 		Where("instance=? and topic=?", q.Instance, q.Topic).
 		LoadOneContext(ctx, v)
@@ -2368,12 +3045,12 @@ func (p *persist) InsertNodeIndex(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TableNodeIndex).
 		Pair("instance", v.Instance).
 		Pair("topic", v.Topic).
 		Pair("idx", v.Idx).
-		ExecContext(ctx)
+		Pair("container_id", v.ContainerID))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableNodeIndex, false, err)
 	}
@@ -2381,6 +3058,7 @@ func (p *persist) InsertNodeIndex(
 		_, err = sess.
 			Update(TableNodeIndex).
 			Set("idx", v.Idx).
+			Set("container_id", v.ContainerID).
 			Where("instance=? and topic=?", v.Instance, v.Topic).
 			ExecContext(ctx)
 		if err != nil {
@@ -2399,6 +3077,7 @@ func (p *persist) UpdateNodeIndex(
 	_, err = sess.
 		Update(TableNodeIndex).
 		Set("idx", v.Idx).
+		Set("container_id", v.ContainerID).
 		Where("instance=? and topic=?", v.Instance, v.Topic).
 		ExecContext(ctx)
 	if err != nil {
@@ -2407,12 +3086,19 @@ func (p *persist) UpdateNodeIndex(
 	return nil
 }
 
+// CvmLogs is a log of a transaction. FirstTopic to Topic3 are its topics, the
+// topics it does not have are empty.
 type CvmLogs struct {
 	ID            string
+	ChainID       string
 	BlockHash     string
 	TxHash        string
 	LogIndex      uint64
+	Address       string
 	FirstTopic    string
+	Topic1        string
+	Topic2        string
+	Topic3        string
 	Block         string
 	Removed       bool
 	CreatedAt     time.Time
@@ -2437,10 +3123,15 @@ func (p *persist) QueryCvmLogs(
 	v := &CvmLogs{}
 	err := sess.Select(
 		"id",
+		"chain_id",
 		"block_hash",
 		"tx_hash",
 		"log_index",
+		"address",
 		"first_topic",
+		"topic1",
+		"topic2",
+		"topic3",
 		"block",
 		"removed",
 		"created_at",
@@ -2458,17 +3149,16 @@ func (p *persist) InsertCvmLogs(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
-		InsertBySql("insert into "+TableCvmLogs+" (id,block_hash,tx_hash,log_index,first_topic,block,removed,created_at,serialization) values(?,?,?,?,?,"+v.Block+",?,?,?)",
-			v.ID, v.BlockHash, v.TxHash, v.LogIndex, v.FirstTopic, v.Removed, v.CreatedAt, v.Serialization).
-		ExecContext(ctx)
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmLogs+" (id,chain_id,block_hash,tx_hash,log_index,address,first_topic,topic1,topic2,topic3,block,removed,created_at,serialization) values(?,?,?,?,?,?,?,?,?,?,"+v.Block+",?,?,?)",
+			v.ID, v.ChainID, v.BlockHash, v.TxHash, v.LogIndex, v.Address, v.FirstTopic, v.Topic1, v.Topic2, v.Topic3, v.Removed, v.CreatedAt, v.Serialization))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmLogs, false, err)
 	}
 	if upd {
 		_, err = sess.
-			UpdateBySql("update "+TableCvmLogs+" set block_hash=?,tx_hash=?,log_index=?,first_topic=?,block="+v.Block+",removed=?,serialization=?,created_at=? where id=?",
-				v.BlockHash, v.TxHash, v.LogIndex, v.FirstTopic, v.Removed, v.Serialization, v.CreatedAt, v.ID).
+			UpdateBySql("update "+TableCvmLogs+" set chain_id=?,block_hash=?,tx_hash=?,log_index=?,address=?,first_topic=?,topic1=?,topic2=?,topic3=?,block="+v.Block+",removed=?,serialization=?,created_at=? where id=?",
+				v.ChainID, v.BlockHash, v.TxHash, v.LogIndex, v.Address, v.FirstTopic, v.Topic1, v.Topic2, v.Topic3, v.Removed, v.Serialization, v.CreatedAt, v.ID).
 			ExecContext(ctx)
 		if err != nil {
 			return EventErr(TableCvmLogs, true, err)
@@ -2477,6 +3167,22 @@ func (p *persist) InsertCvmLogs(
 	return nil
 }
 
+func (p *persist) DeleteCvmLogsAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmLogs).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmLogs, false, err)
+	}
+	return nil
+}
+
 type PvmProposer struct {
 	ID            string
 	ParentID      string
@@ -2516,7 +3222,7 @@ func (p *persist) InsertPvmProposer(
 	upd bool,
 ) error {
 	var err error
-	_, err = sess.
+	err = insertIgnore(ctx, sess, sess.
 		InsertInto(TablePvmProposer).
 		Pair("id", v.ID).
 		Pair("parent_id", v.ParentID).
@@ -2525,8 +3231,7 @@ func (p *persist) InsertPvmProposer(
 		Pair("proposer", v.Proposer).
 		Pair("time_stamp", v.TimeStamp).
 		Pair("created_at", v.CreatedAt).
-		Pair("proposer_blk_id", v.ProposerBlkID).
-		ExecContext(ctx)
+		Pair("proposer_blk_id", v.ProposerBlkID))
 	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
 		return EventErr(TableCvmTransactionsTxdataTrace, false, err)
 	}
@@ -2548,3 +3253,1449 @@ func (p *persist) InsertPvmProposer(
 	}
 	return nil
 }
+
+type Webhooks struct {
+	ID        string
+	URL       string
+	Secret    string
+	Filter    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (p *persist) QueryWebhooks(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *Webhooks,
+) (*Webhooks, error) {
+	v := &Webhooks{}
+	err := sess.Select(
+		"id",
+		"url",
+		"secret",
+		"filter",
+		"created_at",
+		"updated_at",
+	).From(TableWebhooks).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) QueryAllWebhooks(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+) ([]*Webhooks, error) {
+	var vs []*Webhooks
+	_, err := sess.Select(
+		"id",
+		"url",
+		"secret",
+		"filter",
+		"created_at",
+		"updated_at",
+	).From(TableWebhooks).
+		OrderAsc("created_at").
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func (p *persist) InsertWebhooks(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Webhooks,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableWebhooks).
+		Pair("id", v.ID).
+		Pair("url", v.URL).
+		Pair("secret", v.Secret).
+		Pair("filter", v.Filter).
+		Pair("created_at", v.CreatedAt).
+		Pair("updated_at", v.UpdatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableWebhooks, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableWebhooks).
+			Set("url", v.URL).
+			Set("filter", v.Filter).
+			Set("updated_at", v.UpdatedAt).
+			Where("id=?", v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableWebhooks, true, err)
+		}
+	}
+	return nil
+}
+
+// DeleteWebhooks deletes the webhook along with its deliveries.
+func (p *persist) DeleteWebhooks(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Webhooks,
+) error {
+	_, err := sess.
+		DeleteFrom(TableWebhookDeliveries).
+		Where("webhook_id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableWebhookDeliveries, false, err)
+	}
+	_, err = sess.
+		DeleteFrom(TableWebhooks).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableWebhooks, false, err)
+	}
+	return nil
+}
+
+type WebhookDeliveryStatus int
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = iota
+	WebhookDeliveryDelivered
+	WebhookDeliveryDead
+)
+
+type WebhookDeliveries struct {
+	ID            string
+	WebhookID     string
+	TxID          string
+	Payload       []byte
+	Status        WebhookDeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (b *WebhookDeliveries) ComputeID() error {
+	idsv := fmt.Sprintf("%s:%s", b.WebhookID, b.TxID)
+	id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+	if err != nil {
+		return err
+	}
+	b.ID = id.String()
+	return nil
+}
+
+// WebhookDeliveriesFilter selects deliveries, ordered by created_at then id.
+type WebhookDeliveriesFilter struct {
+	WebhookID string
+	Statuses  []WebhookDeliveryStatus
+	DueBefore *time.Time
+	// After restricts to the deliveries following it in the list order.
+	After *WebhookDeliveries
+	Limit int
+}
+
+func webhookDeliveriesQuery(sess dbr.SessionRunner) *dbr.SelectStmt {
+	return sess.Select(
+		"id",
+		"webhook_id",
+		"tx_id",
+		"payload",
+		"status",
+		"attempts",
+		"next_attempt_at",
+		"last_error",
+		"created_at",
+		"updated_at",
+	).From(TableWebhookDeliveries)
+}
+
+func (p *persist) QueryWebhookDeliveries(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *WebhookDeliveries,
+) (*WebhookDeliveries, error) {
+	v := &WebhookDeliveries{}
+	err := webhookDeliveriesQuery(sess).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) QueryWebhookDeliveriesList(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *WebhookDeliveriesFilter,
+) ([]*WebhookDeliveries, error) {
+	b := webhookDeliveriesQuery(sess)
+	if q.WebhookID != "" {
+		b.Where("webhook_id=?", q.WebhookID)
+	}
+	if len(q.Statuses) != 0 {
+		b.Where("status in ?", q.Statuses)
+	}
+	if q.DueBefore != nil {
+		b.Where("next_attempt_at <= ?", *q.DueBefore)
+	}
+	if q.After != nil {
+		b.Where("(created_at > ? or (created_at = ? and id > ?))", q.After.CreatedAt, q.After.CreatedAt, q.After.ID)
+	}
+	if q.Limit > 0 {
+		b.Limit(uint64(q.Limit))
+	}
+
+	var vs []*WebhookDeliveries
+	_, err := b.
+		OrderAsc("created_at").
+		OrderAsc("id").
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func (p *persist) InsertWebhookDeliveries(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *WebhookDeliveries,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableWebhookDeliveries).
+		Pair("id", v.ID).
+		Pair("webhook_id", v.WebhookID).
+		Pair("tx_id", v.TxID).
+		Pair("payload", v.Payload).
+		Pair("status", v.Status).
+		Pair("attempts", v.Attempts).
+		Pair("next_attempt_at", v.NextAttemptAt).
+		Pair("last_error", v.LastError).
+		Pair("created_at", v.CreatedAt).
+		Pair("updated_at", v.UpdatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableWebhookDeliveries, false, err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries counts an attempt of a pending delivery and holds it
+// until the lease expires.
+// It returns false when another process claimed the delivery first.
+func (p *persist) ClaimWebhookDeliveries(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *WebhookDeliveries,
+	lease time.Time,
+) (bool, error) {
+	res, err := sess.
+		Update(TableWebhookDeliveries).
+		Set("attempts", v.Attempts+1).
+		Set("next_attempt_at", lease).
+		Where("id=? and status=? and attempts=?", v.ID, WebhookDeliveryPending, v.Attempts).
+		ExecContext(ctx)
+	if err != nil {
+		return false, EventErr(TableWebhookDeliveries, true, err)
+	}
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return false, EventErr(TableWebhookDeliveries, true, err)
+	}
+	if cnt != 1 {
+		return false, nil
+	}
+	v.Attempts++
+	v.NextAttemptAt = lease
+	return true, nil
+}
+
+func (p *persist) UpdateWebhookDeliveriesStatus(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *WebhookDeliveries,
+) error {
+	_, err := sess.
+		Update(TableWebhookDeliveries).
+		Set("status", v.Status).
+		Set("attempts", v.Attempts).
+		Set("next_attempt_at", v.NextAttemptAt).
+		Set("last_error", v.LastError).
+		Set("updated_at", v.UpdatedAt).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableWebhookDeliveries, true, err)
+	}
+	return nil
+}
+
+// AddressBalanceChanges is the change of the balance of an address over a
+// time range, from the outputs it received and the outputs it redeemed.
+type AddressBalanceChanges struct {
+	ChainID       string
+	Address       string
+	AssetID       string
+	Received      string
+	ReceivedCount uint64
+	Sent          string
+	SentCount     uint64
+}
+
+// AddressBalanceChangesFilter selects the outputs created, or redeemed, in
+// [Start, End). A zero Start is unbounded.
+type AddressBalanceChangesFilter struct {
+	Addresses []string
+	ChainIDs  []string
+	Start     time.Time
+	End       time.Time
+}
+
+type addressBalanceChange struct {
+	ChainID   string
+	Address   string
+	AssetID   string
+	Amount    string
+	UtxoCount uint64
+}
+
+func (p *persist) QueryAddressBalanceChanges(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *AddressBalanceChangesFilter,
+) ([]*AddressBalanceChanges, error) {
+	filter := func(b *dbr.SelectStmt, timeCol string) *dbr.SelectStmt {
+		if !q.Start.IsZero() {
+			b.Where(timeCol+" >= ?", q.Start)
+		}
+		if len(q.Addresses) != 0 {
+			b.Where("avm_output_addresses.address in ?", q.Addresses)
+		}
+		if len(q.ChainIDs) != 0 {
+			b.Where("avm_outputs.chain_id in ?", q.ChainIDs)
+		}
+		return b.
+			Where(timeCol+" < ?", q.End).
+			GroupBy("avm_outputs.chain_id", "avm_output_addresses.address", "avm_outputs.asset_id")
+	}
+	columns := []string{
+		"avm_outputs.chain_id",
+		"avm_output_addresses.address",
+		"avm_outputs.asset_id",
+		CastStringAs(sess, "sum(avm_outputs.amount)", "amount"),
+		"count(*) as utxo_count",
+	}
+
+	var received []*addressBalanceChange
+	_, err := filter(sess.Select(columns...).
+		From(TableOutputs).
+		Join(TableOutputAddresses, "avm_outputs.id = avm_output_addresses.output_id"),
+		"avm_outputs.created_at").
+		LoadContext(ctx, &received)
+	if err != nil {
+		return nil, err
+	}
+
+	var sent []*addressBalanceChange
+	_, err = filter(sess.Select(columns...).
+		From(TableOutputsRedeeming).
+		Join(TableOutputs, "avm_outputs.id = avm_outputs_redeeming.id").
+		Join(TableOutputAddresses, "avm_outputs.id = avm_output_addresses.output_id"),
+		"avm_outputs_redeeming.redeemed_at").
+		LoadContext(ctx, &sent)
+	if err != nil {
+		return nil, err
+	}
+
+	return collateAddressBalanceChanges(received, sent), nil
+}
+
+// QueryOutputsFirstCreatedAt returns the time of the first output, or the zero
+// time when there is none.
+func (p *persist) QueryOutputsFirstCreatedAt(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+) (time.Time, error) {
+	var vs []*Outputs
+	_, err := sess.Select(
+		"created_at",
+	).From(TableOutputs).
+		OrderAsc("created_at").
+		Limit(1).
+		LoadContext(ctx, &vs)
+	if err != nil || len(vs) == 0 {
+		return time.Time{}, err
+	}
+	return vs[0].CreatedAt, nil
+}
+
+// QueryOutputAddressesAfter returns a page of the addresses which have outputs,
+// in order, starting after the address.
+func (p *persist) QueryOutputAddressesAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	after string,
+	limit int,
+) ([]string, error) {
+	var vs []string
+	_, err := sess.Select(
+		"distinct address",
+	).From(TableOutputAddresses).
+		Where("address > ?", after).
+		OrderAsc("address").
+		Limit(uint64(limit)).
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func collateAddressBalanceChanges(received []*addressBalanceChange, sent []*addressBalanceChange) []*AddressBalanceChanges {
+	changes := make([]*AddressBalanceChanges, 0, len(received)+len(sent))
+	changesByKey := make(map[string]*AddressBalanceChanges)
+	get := func(c *addressBalanceChange) *AddressBalanceChanges {
+		key := AddressBalanceKey(c.ChainID, c.Address, c.AssetID)
+		v, ok := changesByKey[key]
+		if !ok {
+			v = &AddressBalanceChanges{
+				ChainID:  c.ChainID,
+				Address:  c.Address,
+				AssetID:  c.AssetID,
+				Received: "0",
+				Sent:     "0",
+			}
+			changesByKey[key] = v
+			changes = append(changes, v)
+		}
+		return v
+	}
+	for _, c := range received {
+		v := get(c)
+		v.Received = c.Amount
+		v.ReceivedCount = c.UtxoCount
+	}
+	for _, c := range sent {
+		v := get(c)
+		v.Sent = c.Amount
+		v.SentCount = c.UtxoCount
+	}
+	return changes
+}
+
+// AddressBalanceSnapshots is the balance of an address as of SnapshotAt.
+// A row is written for a snapshot only if the balance changed since the
+// previous one, the balance at a snapshot is the latest row at or before it.
+type AddressBalanceSnapshots struct {
+	ID         string
+	ChainID    string
+	Address    string
+	AssetID    string
+	Balance    string
+	UtxoCount  uint64
+	SnapshotAt time.Time
+	CreatedAt  time.Time
+}
+
+func (b *AddressBalanceSnapshots) ComputeID() error {
+	idsv := fmt.Sprintf("%s:%s:%s:%d", b.ChainID, b.Address, b.AssetID, b.SnapshotAt.UnixNano())
+	id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+	if err != nil {
+		return err
+	}
+	b.ID = id.String()
+	return nil
+}
+
+// AddressBalanceKey is the key of the balance of an address for an asset on a chain.
+func AddressBalanceKey(chainID string, address string, assetID string) string {
+	return chainID + ":" + address + ":" + assetID
+}
+
+// Apply adds the changes to the balance.
+func (b *AddressBalanceSnapshots) Apply(c *AddressBalanceChanges) error {
+	balance, ok := new(big.Int).SetString(b.Balance, 10)
+	if !ok {
+		return fmt.Errorf("invalid balance %s", b.Balance)
+	}
+	received, ok := new(big.Int).SetString(c.Received, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %s", c.Received)
+	}
+	sent, ok := new(big.Int).SetString(c.Sent, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %s", c.Sent)
+	}
+	b.Balance = balance.Add(balance, received).Sub(balance, sent).String()
+	b.UtxoCount = b.UtxoCount + c.ReceivedCount - c.SentCount
+	return nil
+}
+
+// AddressBalanceSnapshotsFilter selects the latest rows at or before Before
+// of the addresses.
+type AddressBalanceSnapshotsFilter struct {
+	Addresses []string
+	ChainIDs  []string
+	Before    time.Time
+}
+
+func (p *persist) QueryAddressBalanceSnapshots(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *AddressBalanceSnapshotsFilter,
+) ([]*AddressBalanceSnapshots, error) {
+	latest := sess.Select(
+		"chain_id",
+		"address",
+		"asset_id",
+		"max(snapshot_at) as snapshot_at",
+	).From(TableAddressBalanceSnapshots).
+		Where("address in ? and snapshot_at <= ?", q.Addresses, q.Before).
+		GroupBy("chain_id", "address", "asset_id")
+	if len(q.ChainIDs) != 0 {
+		latest.Where("chain_id in ?", q.ChainIDs)
+	}
+
+	var vs []*AddressBalanceSnapshots
+	_, err := sess.Select(
+		"address_balance_snapshots.id",
+		"address_balance_snapshots.chain_id",
+		"address_balance_snapshots.address",
+		"address_balance_snapshots.asset_id",
+		CastStringAs(sess, "address_balance_snapshots.balance", "balance"),
+		"address_balance_snapshots.utxo_count",
+		"address_balance_snapshots.snapshot_at",
+		"address_balance_snapshots.created_at",
+	).From(latest.As("latest")).
+		Join(TableAddressBalanceSnapshots, "address_balance_snapshots.chain_id = latest.chain_id and "+
+			"address_balance_snapshots.address = latest.address and "+
+			"address_balance_snapshots.asset_id = latest.asset_id and "+
+			"address_balance_snapshots.snapshot_at = latest.snapshot_at").
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func (p *persist) InsertAddressBalanceSnapshots(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *AddressBalanceSnapshots,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableAddressBalanceSnapshots).
+		Pair("id", v.ID).
+		Pair("chain_id", v.ChainID).
+		Pair("address", v.Address).
+		Pair("asset_id", v.AssetID).
+		Pair("balance", v.Balance).
+		Pair("utxo_count", v.UtxoCount).
+		Pair("snapshot_at", v.SnapshotAt).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableAddressBalanceSnapshots, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableAddressBalanceSnapshots).
+			Set("balance", v.Balance).
+			Set("utxo_count", v.UtxoCount).
+			Where("id=?", v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableAddressBalanceSnapshots, true, err)
+		}
+	}
+	return nil
+}
+
+// BalanceSnapshots marks the address balances as of SnapshotAt as complete.
+type BalanceSnapshots struct {
+	SnapshotAt time.Time
+	CreatedAt  time.Time
+}
+
+// QueryLatestBalanceSnapshots returns the latest snapshot at or before the
+// time, or nil if there is none.
+func (p *persist) QueryLatestBalanceSnapshots(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	before time.Time,
+) (*BalanceSnapshots, error) {
+	var vs []*BalanceSnapshots
+	_, err := sess.Select(
+		"snapshot_at",
+		"created_at",
+	).From(TableBalanceSnapshots).
+		Where("snapshot_at <= ?", before).
+		OrderDesc("snapshot_at").
+		Limit(1).
+		LoadContext(ctx, &vs)
+	if err != nil || len(vs) == 0 {
+		return nil, err
+	}
+	return vs[0], nil
+}
+
+func (p *persist) InsertBalanceSnapshots(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *BalanceSnapshots,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableBalanceSnapshots).
+		Pair("snapshot_at", v.SnapshotAt).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableBalanceSnapshots, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableBalanceSnapshots).
+			Set("created_at", v.CreatedAt).
+			Where("snapshot_at=?", v.SnapshotAt).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableBalanceSnapshots, true, err)
+		}
+	}
+	return nil
+}
+
+// DeleteBalanceSnapshotsAfter marks the snapshots after the time incomplete,
+// they are taken again. Their address balances are overwritten then.
+func (p *persist) DeleteBalanceSnapshotsAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	after time.Time,
+) error {
+	_, err := sess.
+		DeleteFrom(TableBalanceSnapshots).
+		Where("snapshot_at > ?", after).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableBalanceSnapshots, false, err)
+	}
+	return nil
+}
+
+// Subnets is a subnet created by a CreateSubnetTx, whose ID is the subnet ID.
+type Subnets struct {
+	ID        string
+	Threshold uint32
+	Locktime  uint64
+	CreatedAt time.Time
+}
+
+func (p *persist) QuerySubnets(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *Subnets,
+) (*Subnets, error) {
+	v := &Subnets{}
+	err := sess.Select(
+		"id",
+		"threshold",
+		"locktime",
+		"created_at",
+	).From(TableSubnets).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertSubnets(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Subnets,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableSubnets).
+		Pair("id", v.ID).
+		Pair("threshold", v.Threshold).
+		Pair("locktime", v.Locktime).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableSubnets, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableSubnets).
+			Set("threshold", v.Threshold).
+			Set("locktime", v.Locktime).
+			Set("created_at", v.CreatedAt).
+			Where("id=?", v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableSubnets, true, err)
+		}
+	}
+	return nil
+}
+
+type SubnetControlKeys struct {
+	SubnetID  string
+	Address   string
+	CreatedAt time.Time
+}
+
+func (p *persist) QuerySubnetControlKeys(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *SubnetControlKeys,
+) (*SubnetControlKeys, error) {
+	v := &SubnetControlKeys{}
+	err := sess.Select(
+		"subnet_id",
+		"address",
+		"created_at",
+	).From(TableSubnetControlKeys).
+		Where("subnet_id=? and address=?", q.SubnetID, q.Address).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertSubnetControlKeys(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *SubnetControlKeys,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableSubnetControlKeys).
+		Pair("subnet_id", v.SubnetID).
+		Pair("address", v.Address).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableSubnetControlKeys, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableSubnetControlKeys).
+			Set("created_at", v.CreatedAt).
+			Where("subnet_id=? and address=?", v.SubnetID, v.Address).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableSubnetControlKeys, true, err)
+		}
+	}
+	return nil
+}
+
+// Blockchains is a blockchain created by a CreateChainTx, whose ID is the
+// blockchain ID.
+type Blockchains struct {
+	ID          string
+	SubnetID    string
+	Name        string
+	VmID        string
+	GenesisHash string
+	CreatedAt   time.Time
+}
+
+func (p *persist) QueryBlockchains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *Blockchains,
+) (*Blockchains, error) {
+	v := &Blockchains{}
+	err := sess.Select(
+		"id",
+		"subnet_id",
+		"name",
+		"vm_id",
+		"genesis_hash",
+		"created_at",
+	).From(TableBlockchains).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertBlockchains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Blockchains,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableBlockchains).
+		Pair("id", v.ID).
+		Pair("subnet_id", v.SubnetID).
+		Pair("name", v.Name).
+		Pair("vm_id", v.VmID).
+		Pair("genesis_hash", v.GenesisHash).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableBlockchains, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableBlockchains).
+			Set("subnet_id", v.SubnetID).
+			Set("name", v.Name).
+			Set("vm_id", v.VmID).
+			Set("genesis_hash", v.GenesisHash).
+			Set("created_at", v.CreatedAt).
+			Where("id=?", v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableBlockchains, true, err)
+		}
+	}
+	return nil
+}
+
+// Chains is a chain registered to be indexed at runtime, in addition to the
+// chains of the config.
+type Chains struct {
+	ID        string
+	VMType    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (p *persist) QueryChains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *Chains,
+) (*Chains, error) {
+	v := &Chains{}
+	err := sess.Select(
+		"id",
+		"vm_type",
+		"created_at",
+		"updated_at",
+	).From(TableChains).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) QueryAllChains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+) ([]*Chains, error) {
+	var vs []*Chains
+	_, err := sess.Select(
+		"id",
+		"vm_type",
+		"created_at",
+		"updated_at",
+	).From(TableChains).
+		OrderAsc("created_at").
+		LoadContext(ctx, &vs)
+	return vs, err
+}
+
+func (p *persist) InsertChains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Chains,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableChains).
+		Pair("id", v.ID).
+		Pair("vm_type", v.VMType).
+		Pair("created_at", v.CreatedAt).
+		Pair("updated_at", v.UpdatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableChains, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableChains).
+			Set("vm_type", v.VMType).
+			Set("updated_at", v.UpdatedAt).
+			Where("id=?", v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableChains, true, err)
+		}
+	}
+	return nil
+}
+
+func (p *persist) DeleteChains(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *Chains,
+) error {
+	_, err := sess.
+		DeleteFrom(TableChains).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableChains, false, err)
+	}
+	return nil
+}
+
+type CvmTokens struct {
+	ChainID   string
+	Address   string
+	Type      string
+	Name      string
+	Symbol    string
+	Decimals  uint8
+	CreatedAt time.Time
+}
+
+func (p *persist) QueryCvmTokens(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *CvmTokens,
+) (*CvmTokens, error) {
+	v := &CvmTokens{}
+	err := sess.Select(
+		"chain_id",
+		"address",
+		"type",
+		"name",
+		"symbol",
+		"decimals",
+		"created_at",
+	).From(TableCvmTokens).
+		Where("chain_id=? and address=?", q.ChainID, q.Address).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertCvmTokens(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmTokens,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableCvmTokens).
+		Pair("chain_id", v.ChainID).
+		Pair("address", v.Address).
+		Pair("type", v.Type).
+		Pair("name", v.Name).
+		Pair("symbol", v.Symbol).
+		Pair("decimals", v.Decimals).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableCvmTokens, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableCvmTokens).
+			Set("type", v.Type).
+			Set("name", v.Name).
+			Set("symbol", v.Symbol).
+			Set("decimals", v.Decimals).
+			Where("chain_id=? and address=?", v.ChainID, v.Address).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableCvmTokens, true, err)
+		}
+	}
+	return nil
+}
+
+// CvmTokenTransfers is a Transfer or Approval event of a token, keyed by the
+// id of its log. Value is the value of the event, the token ID of an ERC-721
+// token, and Amount is the amount it moves for the token balances.
+type CvmTokenTransfers struct {
+	ID        string
+	ChainID   string
+	Address   string
+	Event     string
+	TxHash    string
+	Block     string
+	LogIndex  uint64
+	FromAddr  string
+	ToAddr    string
+	Amount    string
+	Value     string
+	CreatedAt time.Time
+}
+
+func (p *persist) QueryCvmTokenTransfers(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *CvmTokenTransfers,
+) (*CvmTokenTransfers, error) {
+	v := &CvmTokenTransfers{}
+	err := sess.Select(
+		"id",
+		"chain_id",
+		"address",
+		"event",
+		"tx_hash",
+		CastString(sess, "block"),
+		"log_index",
+		"from_addr",
+		"to_addr",
+		CastString(sess, "amount"),
+		"value",
+		"created_at",
+	).From(TableCvmTokenTransfers).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertCvmTokenTransfers(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmTokenTransfers,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmTokenTransfers+" (id,chain_id,address,event,tx_hash,block,log_index,from_addr,to_addr,amount,value,created_at) values(?,?,?,?,?,"+v.Block+",?,?,?,"+v.Amount+",?,?)",
+			v.ID, v.ChainID, v.Address, v.Event, v.TxHash, v.LogIndex, v.FromAddr, v.ToAddr, v.Value, v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableCvmTokenTransfers, false, err)
+	}
+	if upd {
+		_, err = sess.
+			UpdateBySql("update "+TableCvmTokenTransfers+" set chain_id=?,address=?,event=?,tx_hash=?,block="+v.Block+",log_index=?,from_addr=?,to_addr=?,amount="+v.Amount+",value=?,created_at=? where id=?",
+				v.ChainID, v.Address, v.Event, v.TxHash, v.LogIndex, v.FromAddr, v.ToAddr, v.Value, v.CreatedAt, v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableCvmTokenTransfers, true, err)
+		}
+	}
+	return nil
+}
+
+func (p *persist) DeleteCvmTokenTransfersAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmTokenTransfers).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmTokenTransfers, false, err)
+	}
+	return nil
+}
+
+// CvmContracts is the ABI of a contract, uploaded to decode its calls and
+// events.
+type CvmContracts struct {
+	ChainID   string
+	Address   string
+	Name      string
+	ABI       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (p *persist) QueryCvmContracts(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *CvmContracts,
+) (*CvmContracts, error) {
+	v := &CvmContracts{}
+	err := sess.Select(
+		"chain_id",
+		"address",
+		"name",
+		"abi",
+		"created_at",
+		"updated_at",
+	).From(TableCvmContracts).
+		Where("chain_id=? and address=?", q.ChainID, q.Address).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertCvmContracts(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmContracts,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableCvmContracts).
+		Pair("chain_id", v.ChainID).
+		Pair("address", v.Address).
+		Pair("name", v.Name).
+		Pair("abi", v.ABI).
+		Pair("created_at", v.CreatedAt).
+		Pair("updated_at", v.UpdatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableCvmContracts, false, err)
+	}
+	if upd {
+		_, err = sess.
+			Update(TableCvmContracts).
+			Set("name", v.Name).
+			Set("abi", v.ABI).
+			Set("updated_at", v.UpdatedAt).
+			Where("chain_id=? and address=?", v.ChainID, v.Address).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableCvmContracts, true, err)
+		}
+	}
+	return nil
+}
+
+func (p *persist) DeleteCvmContracts(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmContracts,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmContracts).
+		Where("chain_id=? and address=?", v.ChainID, v.Address).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmContracts, false, err)
+	}
+	return nil
+}
+
+// CvmContractDeployments is a contract created by a transaction or by a
+// CREATE or CREATE2 call of its trace. CodeHash is the keccak256 hash of the
+// creation bytecode.
+type CvmContractDeployments struct {
+	ChainID   string
+	Address   string
+	Creator   string
+	TxHash    string
+	Block     string
+	Type      string
+	CodeHash  string
+	CreatedAt time.Time
+}
+
+func (p *persist) QueryCvmContractDeployments(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *CvmContractDeployments,
+) (*CvmContractDeployments, error) {
+	v := &CvmContractDeployments{}
+	err := sess.Select(
+		"chain_id",
+		"address",
+		"creator",
+		"tx_hash",
+		CastString(sess, "block"),
+		"type",
+		"code_hash",
+		"created_at",
+	).From(TableCvmContractDeployments).
+		Where("chain_id=? and address=?", q.ChainID, q.Address).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertCvmContractDeployments(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmContractDeployments,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmContractDeployments+" (chain_id,address,creator,tx_hash,block,type,code_hash,created_at) values(?,?,?,?,"+v.Block+",?,?,?)",
+			v.ChainID, v.Address, v.Creator, v.TxHash, v.Type, v.CodeHash, v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableCvmContractDeployments, false, err)
+	}
+	if upd {
+		_, err = sess.
+			UpdateBySql("update "+TableCvmContractDeployments+" set creator=?,tx_hash=?,block="+v.Block+",type=?,code_hash=?,created_at=? where chain_id=? and address=?",
+				v.Creator, v.TxHash, v.Type, v.CodeHash, v.CreatedAt, v.ChainID, v.Address).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableCvmContractDeployments, true, err)
+		}
+	}
+	return nil
+}
+
+func (p *persist) DeleteCvmContractDeploymentsAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmContractDeployments).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmContractDeployments, false, err)
+	}
+	return nil
+}
+
+// CvmInternalTransfers is a call of a transaction trace which moves value.
+// TraceAddress is the position of the call in the trace, the indexes of the
+// calls down to it joined with commas. Error is the error of the call or of
+// the call which reverted it, empty when it succeeded.
+type CvmInternalTransfers struct {
+	ID           string
+	ChainID      string
+	TxHash       string
+	Block        string
+	TraceAddress string
+	Type         string
+	FromAddr     string
+	ToAddr       string
+	Value        string
+	Error        string
+	CreatedAt    time.Time
+}
+
+func (b *CvmInternalTransfers) ComputeID() error {
+	idsv := fmt.Sprintf("%s:%s:%s", b.ChainID, b.TxHash, b.TraceAddress)
+	id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+	if err != nil {
+		return err
+	}
+	b.ID = id.String()
+	return nil
+}
+
+func (p *persist) QueryCvmInternalTransfers(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *CvmInternalTransfers,
+) (*CvmInternalTransfers, error) {
+	v := &CvmInternalTransfers{}
+	err := sess.Select(
+		"id",
+		"chain_id",
+		"tx_hash",
+		CastString(sess, "block"),
+		"trace_address",
+		"type",
+		"from_addr",
+		"to_addr",
+		CastString(sess, "value"),
+		"error",
+		"created_at",
+	).From(TableCvmInternalTransfers).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+func (p *persist) InsertCvmInternalTransfers(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *CvmInternalTransfers,
+	upd bool,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertBySql("insert into "+TableCvmInternalTransfers+" (id,chain_id,tx_hash,block,trace_address,type,from_addr,to_addr,value,error,created_at) values(?,?,?,"+v.Block+",?,?,?,?,"+v.Value+",?,?)",
+			v.ID, v.ChainID, v.TxHash, v.TraceAddress, v.Type, v.FromAddr, v.ToAddr, v.Error, v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableCvmInternalTransfers, false, err)
+	}
+	if upd {
+		_, err = sess.
+			UpdateBySql("update "+TableCvmInternalTransfers+" set chain_id=?,tx_hash=?,block="+v.Block+",trace_address=?,type=?,from_addr=?,to_addr=?,value="+v.Value+",error=?,created_at=? where id=?",
+				v.ChainID, v.TxHash, v.TraceAddress, v.Type, v.FromAddr, v.ToAddr, v.Error, v.CreatedAt, v.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return EventErr(TableCvmInternalTransfers, true, err)
+		}
+	}
+	return nil
+}
+
+func (p *persist) DeleteCvmInternalTransfersAfter(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	chainID string,
+	block string,
+) error {
+	_, err := sess.
+		DeleteFrom(TableCvmInternalTransfers).
+		Where("chain_id=? and block > "+block, chainID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableCvmInternalTransfers, false, err)
+	}
+	return nil
+}
+
+// AtomicTransfers is an exported output, keyed by its id, with the import which
+// consumes it. Either leg may be indexed first, the chains are indexed
+// independently, so each leg writes the columns both know and its own.
+// ImportTxID is empty until the output is imported.
+type AtomicTransfers struct {
+	ID               string
+	ExportTxID       string
+	SourceChain      string
+	DestinationChain string
+	AssetID          string
+	Amount           uint64
+	ExportedAt       *time.Time
+	ImportTxID       string
+	ImportedAt       *time.Time
+	CreatedAt        time.Time
+}
+
+func (p *persist) QueryAtomicTransfers(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	q *AtomicTransfers,
+) (*AtomicTransfers, error) {
+	v := &AtomicTransfers{}
+	err := sess.Select(
+		"id",
+		"export_tx_id",
+		"source_chain",
+		"destination_chain",
+		"asset_id",
+		"amount",
+		"exported_at",
+		"import_tx_id",
+		"imported_at",
+		"created_at",
+	).From(TableAtomicTransfers).
+		Where("id=?", q.ID).
+		LoadOneContext(ctx, v)
+	return v, err
+}
+
+// InsertAtomicTransfersExport writes the export leg of the transfer, the
+// creation time is the one of the export.
+func (p *persist) InsertAtomicTransfersExport(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *AtomicTransfers,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableAtomicTransfers).
+		Pair("id", v.ID).
+		Pair("export_tx_id", v.ExportTxID).
+		Pair("source_chain", v.SourceChain).
+		Pair("destination_chain", v.DestinationChain).
+		Pair("asset_id", v.AssetID).
+		Pair("amount", v.Amount).
+		Pair("exported_at", v.ExportedAt).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableAtomicTransfers, false, err)
+	}
+	_, err = sess.
+		Update(TableAtomicTransfers).
+		Set("export_tx_id", v.ExportTxID).
+		Set("source_chain", v.SourceChain).
+		Set("destination_chain", v.DestinationChain).
+		Set("asset_id", v.AssetID).
+		Set("amount", v.Amount).
+		Set("exported_at", v.ExportedAt).
+		Set("created_at", v.CreatedAt).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableAtomicTransfers, true, err)
+	}
+	return nil
+}
+
+// InsertAtomicTransfersImport writes the import leg of the transfer.
+func (p *persist) InsertAtomicTransfersImport(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	v *AtomicTransfers,
+) error {
+	var err error
+	err = insertIgnore(ctx, sess, sess.
+		InsertInto(TableAtomicTransfers).
+		Pair("id", v.ID).
+		Pair("export_tx_id", v.ExportTxID).
+		Pair("source_chain", v.SourceChain).
+		Pair("destination_chain", v.DestinationChain).
+		Pair("asset_id", v.AssetID).
+		Pair("amount", v.Amount).
+		Pair("import_tx_id", v.ImportTxID).
+		Pair("imported_at", v.ImportedAt).
+		Pair("created_at", v.CreatedAt))
+	if err != nil && !utils.ErrIsDuplicateEntryError(err) {
+		return EventErr(TableAtomicTransfers, false, err)
+	}
+	_, err = sess.
+		Update(TableAtomicTransfers).
+		Set("import_tx_id", v.ImportTxID).
+		Set("imported_at", v.ImportedAt).
+		Where("id=?", v.ID).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableAtomicTransfers, true, err)
+	}
+	return nil
+}
+
+// DeleteAtomicTransactions removes the transactions, their outputs and the
+// inputs they redeemed, and their legs of the atomic transfers.
+// The export leg of a transfer which was imported is kept for the import.
+func (p *persist) DeleteAtomicTransactions(
+	ctx context.Context,
+	sess dbr.SessionRunner,
+	transactionIDs []string,
+) error {
+	if len(transactionIDs) == 0 {
+		return nil
+	}
+	_, err := sess.
+		DeleteFrom(TableOutputAddresses).
+		Where("output_id in (select id from "+TableOutputs+" where transaction_id in ?)", transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableOutputAddresses, false, err)
+	}
+	_, err = sess.
+		DeleteFrom(TableOutputs).
+		Where("transaction_id in ?", transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableOutputs, false, err)
+	}
+	_, err = sess.
+		DeleteFrom(TableOutputsRedeeming).
+		Where("redeeming_transaction_id in ?", transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableOutputsRedeeming, false, err)
+	}
+	_, err = sess.
+		DeleteFrom(TableTransactions).
+		Where("id in ?", transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableTransactions, false, err)
+	}
+	_, err = sess.
+		DeleteFrom(TableAtomicTransfers).
+		Where("export_tx_id in ? and import_tx_id=?", transactionIDs, "").
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableAtomicTransfers, false, err)
+	}
+	_, err = sess.
+		Update(TableAtomicTransfers).
+		Set("import_tx_id", "").
+		Set("imported_at", nil).
+		Where("import_tx_id in ?", transactionIDs).
+		ExecContext(ctx)
+	if err != nil {
+		return EventErr(TableAtomicTransfers, true, err)
+	}
+	return nil
+}
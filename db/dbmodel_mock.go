@@ -6,7 +6,10 @@ package db
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/gocraft/dbr/v2"
 )
@@ -45,6 +48,20 @@ type MockPersist struct {
 	NodeIndex                        map[string]*NodeIndex
 	CvmLogs                          map[string]*CvmLogs
 	PvmProposer                      map[string]*PvmProposer
+	Webhooks                         map[string]*Webhooks
+	WebhookDeliveries                map[string]*WebhookDeliveries
+	AddressBalanceSnapshots          map[string]*AddressBalanceSnapshots
+	BalanceSnapshots                 map[int64]*BalanceSnapshots
+	Subnets                          map[string]*Subnets
+	SubnetControlKeys                map[string]*SubnetControlKeys
+	Blockchains                      map[string]*Blockchains
+	Chains                           map[string]*Chains
+	CvmTokens                        map[string]*CvmTokens
+	CvmTokenTransfers                map[string]*CvmTokenTransfers
+	CvmContracts                     map[string]*CvmContracts
+	CvmContractDeployments           map[string]*CvmContractDeployments
+	CvmInternalTransfers             map[string]*CvmInternalTransfers
+	AtomicTransfers                  map[string]*AtomicTransfers
 }
 
 func NewPersistMock() *MockPersist {
@@ -81,6 +98,20 @@ func NewPersistMock() *MockPersist {
 		NodeIndex:                        make(map[string]*NodeIndex),
 		CvmLogs:                          make(map[string]*CvmLogs),
 		PvmProposer:                      make(map[string]*PvmProposer),
+		Webhooks:                         make(map[string]*Webhooks),
+		WebhookDeliveries:                make(map[string]*WebhookDeliveries),
+		AddressBalanceSnapshots:          make(map[string]*AddressBalanceSnapshots),
+		BalanceSnapshots:                 make(map[int64]*BalanceSnapshots),
+		Subnets:                          make(map[string]*Subnets),
+		SubnetControlKeys:                make(map[string]*SubnetControlKeys),
+		Blockchains:                      make(map[string]*Blockchains),
+		Chains:                           make(map[string]*Chains),
+		CvmTokens:                        make(map[string]*CvmTokens),
+		CvmTokenTransfers:                make(map[string]*CvmTokenTransfers),
+		CvmContracts:                     make(map[string]*CvmContracts),
+		CvmContractDeployments:           make(map[string]*CvmContractDeployments),
+		CvmInternalTransfers:             make(map[string]*CvmInternalTransfers),
+		AtomicTransfers:                  make(map[string]*AtomicTransfers),
 	}
 }
 
@@ -240,7 +271,7 @@ func (m *MockPersist) InsertTransactionsEpoch(ctx context.Context, runner dbr.Se
 func (m *MockPersist) QueryCvmBlocks(ctx context.Context, runner dbr.SessionRunner, v *CvmBlocks) (*CvmBlocks, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	if v, present := m.CvmBlocks[v.Block]; present {
+	if v, present := m.CvmBlocks[v.ChainID+":"+v.Block]; present {
 		return v, nil
 	}
 	return nil, nil
@@ -251,7 +282,18 @@ func (m *MockPersist) InsertCvmBlocks(ctx context.Context, runner dbr.SessionRun
 	defer m.lock.Unlock()
 	nv := &CvmBlocks{}
 	*nv = *v
-	m.CvmBlocks[v.Block] = nv
+	m.CvmBlocks[v.ChainID+":"+v.Block] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmBlocksAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmBlocks {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmBlocks, k)
+		}
+	}
 	return nil
 }
 
@@ -273,10 +315,25 @@ func (m *MockPersist) InsertCvmAddresses(ctx context.Context, runner dbr.Session
 	return nil
 }
 
+func (m *MockPersist) DeleteCvmAddressesOfTransactions(ctx context.Context, runner dbr.SessionRunner, chainID string, transactionIDs []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	txIDs := make(map[string]struct{}, len(transactionIDs))
+	for _, txID := range transactionIDs {
+		txIDs[txID] = struct{}{}
+	}
+	for k, v := range m.CvmAddresses {
+		if _, ok := txIDs[v.TransactionID]; ok && v.ChainID == chainID {
+			delete(m.CvmAddresses, k)
+		}
+	}
+	return nil
+}
+
 func (m *MockPersist) QueryCvmTransactions(ctx context.Context, runner dbr.SessionRunner, v *CvmTransactions) (*CvmTransactions, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	if v, present := m.CvmTransactions[v.ID]; present {
+	if v, present := m.CvmTransactions[v.ChainID+":"+v.ID]; present {
 		return v, nil
 	}
 	return nil, nil
@@ -287,14 +344,25 @@ func (m *MockPersist) InsertCvmTransactions(ctx context.Context, runner dbr.Sess
 	defer m.lock.Unlock()
 	nv := &CvmTransactions{}
 	*nv = *v
-	m.CvmTransactions[v.ID] = nv
+	m.CvmTransactions[v.ChainID+":"+v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmTransactionsAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmTransactions {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmTransactions, k)
+		}
+	}
 	return nil
 }
 
 func (m *MockPersist) QueryCvmTransactionsTxdata(ctx context.Context, runner dbr.SessionRunner, v *CvmTransactionsTxdata) (*CvmTransactionsTxdata, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	if v, present := m.CvmTransactionsTxdata[v.Hash]; present {
+	if v, present := m.CvmTransactionsTxdata[v.ChainID+":"+v.Hash]; present {
 		return v, nil
 	}
 	return nil, nil
@@ -305,7 +373,18 @@ func (m *MockPersist) InsertCvmTransactionsTxdata(ctx context.Context, runner db
 	defer m.lock.Unlock()
 	nv := &CvmTransactionsTxdata{}
 	*nv = *v
-	m.CvmTransactionsTxdata[v.Hash] = nv
+	m.CvmTransactionsTxdata[v.ChainID+":"+v.Hash] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmTransactionsTxdataAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmTransactionsTxdata {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmTransactionsTxdata, k)
+		}
+	}
 	return nil
 }
 
@@ -599,15 +678,131 @@ func (m *MockPersist) InsertTxPool(ctx context.Context, runner dbr.SessionRunner
 	return nil
 }
 
+func (m *MockPersist) InsertTxPools(ctx context.Context, runner dbr.SessionRunner, vs []*TxPool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, v := range vs {
+		if _, present := m.TxPool[v.ID]; present {
+			continue
+		}
+		nv := &TxPool{}
+		*nv = *v
+		m.TxPool[v.ID] = nv
+	}
+	return nil
+}
+
 func (m *MockPersist) UpdateTxPoolStatus(ctx context.Context, runner dbr.SessionRunner, v *TxPool) error {
-	m.lock.RLock()
-	defer m.lock.RUnlock()
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	if fv, present := m.TxPool[v.ID]; present {
 		fv.Processed = v.Processed
+		if v.Processed == TxPoolProcessed {
+			fv.ProcessedAt = time.Now().UTC()
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) UpdateTxPoolFailure(ctx context.Context, runner dbr.SessionRunner, v *TxPool, maxAttempts int) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.TxPool[v.ID]; present && fv.Processed == TxPoolUnprocessed {
+		fv.Attempts++
+		fv.LastError = v.LastError
+		if fv.Attempts >= maxAttempts {
+			fv.Processed = TxPoolQuarantined
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) ResetTxPool(ctx context.Context, runner dbr.SessionRunner, v *TxPool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.TxPool[v.ID]; present {
+		fv.Processed = TxPoolUnprocessed
+		fv.Attempts = 0
+		fv.LastError = ""
 	}
 	return nil
 }
 
+func (m *MockPersist) QueryTxPoolsQuarantined(ctx context.Context, runner dbr.SessionRunner, topic string, limit uint64) ([]*TxPool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var vs []*TxPool
+	for _, v := range m.TxPool {
+		if v.Processed != TxPoolQuarantined || (topic != "" && v.Topic != topic) {
+			continue
+		}
+		nv := &TxPool{}
+		*nv = *v
+		vs = append(vs, nv)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].CreatedAt.Before(vs[j].CreatedAt) })
+	if uint64(len(vs)) > limit {
+		vs = vs[:limit]
+	}
+	return vs, nil
+}
+
+func (m *MockPersist) QueryTxPoolQuarantinedCounts(ctx context.Context, runner dbr.SessionRunner) ([]*TxPoolCount, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	counts := make(map[string]uint64)
+	for _, v := range m.TxPool {
+		if v.Processed == TxPoolQuarantined {
+			counts[v.Topic]++
+		}
+	}
+	vs := make([]*TxPoolCount, 0, len(counts))
+	for topic, count := range counts {
+		vs = append(vs, &TxPoolCount{Topic: topic, Count: count})
+	}
+	return vs, nil
+}
+
+func (m *MockPersist) QueryTxPoolUnprocessedBefore(ctx context.Context, runner dbr.SessionRunner, before time.Time) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, v := range m.TxPool {
+		if v.Processed == TxPoolUnprocessed && v.CreatedAt.Before(before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockPersist) QueryTxPoolsProcessedAfter(ctx context.Context, runner dbr.SessionRunner, after *TxPool, before time.Time, limit uint64) ([]*TxPool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	processedBefore := func(v *TxPool, processedAt time.Time, id string) bool {
+		return v.ProcessedAt.Before(processedAt) || (v.ProcessedAt.Equal(processedAt) && v.ID < id)
+	}
+	var vs []*TxPool
+	for _, v := range m.TxPool {
+		if v.Processed != TxPoolProcessed || !v.ProcessedAt.Before(before) || !processedBefore(after, v.ProcessedAt, v.ID) {
+			continue
+		}
+		nv := &TxPool{}
+		*nv = *v
+		vs = append(vs, nv)
+	}
+	sort.Slice(vs, func(i, j int) bool { return processedBefore(vs[i], vs[j].ProcessedAt, vs[j].ID) })
+	if uint64(len(vs)) > limit {
+		vs = vs[:limit]
+	}
+	return vs, nil
+}
+
+func (m *MockPersist) DeleteTxPool(ctx context.Context, runner dbr.SessionRunner, v *TxPool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.TxPool, v.ID)
+	return nil
+}
+
 func (m *MockPersist) QueryKeyValueStore(ctx context.Context, runner dbr.SessionRunner, v *KeyValueStore) (*KeyValueStore, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -629,7 +824,7 @@ func (m *MockPersist) InsertKeyValueStore(ctx context.Context, runner dbr.Sessio
 func (m *MockPersist) QueryCvmTransactionsTxdataTrace(ctx context.Context, runner dbr.SessionRunner, v *CvmTransactionsTxdataTrace) (*CvmTransactionsTxdataTrace, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
-	if v, present := m.CvmTransactionsTxdataTrace[fmt.Sprintf("%s:%v", v.Hash, v.Idx)]; present {
+	if v, present := m.CvmTransactionsTxdataTrace[fmt.Sprintf("%s:%s:%v", v.ChainID, v.Hash, v.Idx)]; present {
 		return v, nil
 	}
 	return nil, nil
@@ -640,7 +835,18 @@ func (m *MockPersist) InsertCvmTransactionsTxdataTrace(ctx context.Context, runn
 	defer m.lock.Unlock()
 	nv := &CvmTransactionsTxdataTrace{}
 	*nv = *v
-	m.CvmTransactionsTxdataTrace[fmt.Sprintf("%s:%v", v.Hash, v.Idx)] = nv
+	m.CvmTransactionsTxdataTrace[fmt.Sprintf("%s:%s:%v", v.ChainID, v.Hash, v.Idx)] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmTransactionsTxdataTraceAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmTransactionsTxdataTrace {
+		if txdata, present := m.CvmTransactionsTxdata[chainID+":"+v.Hash]; present && v.ChainID == chainID && blockAfter(txdata.Block, block) {
+			delete(m.CvmTransactionsTxdataTrace, k)
+		}
+	}
 	return nil
 }
 
@@ -667,6 +873,7 @@ func (m *MockPersist) UpdateNodeIndex(ctx context.Context, runner dbr.SessionRun
 	defer m.lock.Unlock()
 	if fv, present := m.NodeIndex[v.Topic]; present {
 		fv.Idx = v.Idx
+		fv.ContainerID = v.ContainerID
 	}
 	return nil
 }
@@ -689,6 +896,17 @@ func (m *MockPersist) InsertCvmLogs(ctx context.Context, runner dbr.SessionRunne
 	return nil
 }
 
+func (m *MockPersist) DeleteCvmLogsAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmLogs {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmLogs, k)
+		}
+	}
+	return nil
+}
+
 func (m *MockPersist) QueryPvmProposer(ctx context.Context, runner dbr.SessionRunner, v *PvmProposer) (*PvmProposer, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -706,3 +924,645 @@ func (m *MockPersist) InsertPvmProposer(ctx context.Context, runner dbr.SessionR
 	m.PvmProposer[v.ID] = nv
 	return nil
 }
+
+func (m *MockPersist) QueryWebhooks(ctx context.Context, runner dbr.SessionRunner, v *Webhooks) (*Webhooks, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.Webhooks[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) QueryAllWebhooks(ctx context.Context, runner dbr.SessionRunner) ([]*Webhooks, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	vs := make([]*Webhooks, 0, len(m.Webhooks))
+	for _, v := range m.Webhooks {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		return vs[i].CreatedAt.Before(vs[j].CreatedAt)
+	})
+	return vs, nil
+}
+
+func (m *MockPersist) InsertWebhooks(ctx context.Context, runner dbr.SessionRunner, v *Webhooks, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.Webhooks[v.ID]; present {
+		if b {
+			fv.URL = v.URL
+			fv.Filter = v.Filter
+			fv.UpdatedAt = v.UpdatedAt
+		}
+		return nil
+	}
+	nv := &Webhooks{}
+	*nv = *v
+	m.Webhooks[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteWebhooks(ctx context.Context, runner dbr.SessionRunner, v *Webhooks) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, d := range m.WebhookDeliveries {
+		if d.WebhookID == v.ID {
+			delete(m.WebhookDeliveries, k)
+		}
+	}
+	delete(m.Webhooks, v.ID)
+	return nil
+}
+
+func (m *MockPersist) QueryWebhookDeliveries(ctx context.Context, runner dbr.SessionRunner, v *WebhookDeliveries) (*WebhookDeliveries, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.WebhookDeliveries[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) QueryWebhookDeliveriesList(ctx context.Context, runner dbr.SessionRunner, q *WebhookDeliveriesFilter) ([]*WebhookDeliveries, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	matchStatus := func(status WebhookDeliveryStatus) bool {
+		if len(q.Statuses) == 0 {
+			return true
+		}
+		for _, s := range q.Statuses {
+			if s == status {
+				return true
+			}
+		}
+		return false
+	}
+	before := func(a *WebhookDeliveries, b *WebhookDeliveries) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	var vs []*WebhookDeliveries
+	for _, v := range m.WebhookDeliveries {
+		if q.WebhookID != "" && v.WebhookID != q.WebhookID {
+			continue
+		}
+		if !matchStatus(v.Status) {
+			continue
+		}
+		if q.DueBefore != nil && v.NextAttemptAt.After(*q.DueBefore) {
+			continue
+		}
+		if q.After != nil && !before(q.After, v) {
+			continue
+		}
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		return before(vs[i], vs[j])
+	})
+	if q.Limit > 0 && len(vs) > q.Limit {
+		vs = vs[:q.Limit]
+	}
+	return vs, nil
+}
+
+func (m *MockPersist) InsertWebhookDeliveries(ctx context.Context, runner dbr.SessionRunner, v *WebhookDeliveries) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, present := m.WebhookDeliveries[v.ID]; present {
+		return nil
+	}
+	nv := &WebhookDeliveries{}
+	*nv = *v
+	m.WebhookDeliveries[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) ClaimWebhookDeliveries(ctx context.Context, runner dbr.SessionRunner, v *WebhookDeliveries, lease time.Time) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	fv, present := m.WebhookDeliveries[v.ID]
+	if !present || fv.Status != WebhookDeliveryPending || fv.Attempts != v.Attempts {
+		return false, nil
+	}
+	fv.Attempts++
+	fv.NextAttemptAt = lease
+	v.Attempts = fv.Attempts
+	v.NextAttemptAt = lease
+	return true, nil
+}
+
+func (m *MockPersist) UpdateWebhookDeliveriesStatus(ctx context.Context, runner dbr.SessionRunner, v *WebhookDeliveries) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.WebhookDeliveries[v.ID]; present {
+		fv.Status = v.Status
+		fv.Attempts = v.Attempts
+		fv.NextAttemptAt = v.NextAttemptAt
+		fv.LastError = v.LastError
+		fv.UpdatedAt = v.UpdatedAt
+	}
+	return nil
+}
+
+func (m *MockPersist) QueryAddressBalanceChanges(ctx context.Context, runner dbr.SessionRunner, q *AddressBalanceChangesFilter) ([]*AddressBalanceChanges, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	inRange := func(t time.Time) bool {
+		return (q.Start.IsZero() || !t.Before(q.Start)) && t.Before(q.End)
+	}
+	received := make(map[string]*addressBalanceChange)
+	sent := make(map[string]*addressBalanceChange)
+	add := func(changes map[string]*addressBalanceChange, o *Outputs, address string) {
+		key := AddressBalanceKey(o.ChainID, address, o.AssetID)
+		c, ok := changes[key]
+		if !ok {
+			c = &addressBalanceChange{ChainID: o.ChainID, Address: address, AssetID: o.AssetID, Amount: "0"}
+			changes[key] = c
+		}
+		amount, _ := big.NewInt(0).SetString(c.Amount, 10)
+		c.Amount = amount.Add(amount, big.NewInt(0).SetUint64(o.Amount)).String()
+		c.UtxoCount++
+	}
+	for _, oa := range m.OutputAddresses {
+		if len(q.Addresses) != 0 && !containsString(q.Addresses, oa.Address) {
+			continue
+		}
+		o, ok := m.Outputs[oa.OutputID]
+		if !ok || (len(q.ChainIDs) != 0 && !containsString(q.ChainIDs, o.ChainID)) {
+			continue
+		}
+		if inRange(o.CreatedAt) {
+			add(received, o, oa.Address)
+		}
+		if r, ok := m.OutputsRedeeming[o.ID]; ok && inRange(r.RedeemedAt) {
+			add(sent, o, oa.Address)
+		}
+	}
+	return collateAddressBalanceChanges(sortedBalanceChanges(received), sortedBalanceChanges(sent)), nil
+}
+
+func (m *MockPersist) QueryOutputsFirstCreatedAt(ctx context.Context, runner dbr.SessionRunner) (time.Time, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var first time.Time
+	for _, v := range m.Outputs {
+		if first.IsZero() || v.CreatedAt.Before(first) {
+			first = v.CreatedAt
+		}
+	}
+	return first, nil
+}
+
+func (m *MockPersist) QueryOutputAddressesAfter(ctx context.Context, runner dbr.SessionRunner, after string, limit int) ([]string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	seen := make(map[string]struct{})
+	var vs []string
+	for _, v := range m.OutputAddresses {
+		if _, ok := seen[v.Address]; ok || v.Address <= after {
+			continue
+		}
+		seen[v.Address] = struct{}{}
+		vs = append(vs, v.Address)
+	}
+	sort.Strings(vs)
+	if len(vs) > limit {
+		vs = vs[:limit]
+	}
+	return vs, nil
+}
+
+func sortedBalanceChanges(changes map[string]*addressBalanceChange) []*addressBalanceChange {
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	vs := make([]*addressBalanceChange, 0, len(keys))
+	for _, k := range keys {
+		vs = append(vs, changes[k])
+	}
+	return vs
+}
+
+func (m *MockPersist) QueryAddressBalanceSnapshots(ctx context.Context, runner dbr.SessionRunner, q *AddressBalanceSnapshotsFilter) ([]*AddressBalanceSnapshots, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	latest := make(map[string]*AddressBalanceSnapshots)
+	for _, v := range m.AddressBalanceSnapshots {
+		if !containsString(q.Addresses, v.Address) || v.SnapshotAt.After(q.Before) {
+			continue
+		}
+		if len(q.ChainIDs) != 0 && !containsString(q.ChainIDs, v.ChainID) {
+			continue
+		}
+		key := AddressBalanceKey(v.ChainID, v.Address, v.AssetID)
+		if l, ok := latest[key]; !ok || v.SnapshotAt.After(l.SnapshotAt) {
+			latest[key] = v
+		}
+	}
+	vs := make([]*AddressBalanceSnapshots, 0, len(latest))
+	for _, v := range latest {
+		nv := &AddressBalanceSnapshots{}
+		*nv = *v
+		vs = append(vs, nv)
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		return vs[i].ID < vs[j].ID
+	})
+	return vs, nil
+}
+
+func (m *MockPersist) InsertAddressBalanceSnapshots(ctx context.Context, runner dbr.SessionRunner, v *AddressBalanceSnapshots, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.AddressBalanceSnapshots[v.ID]; present {
+		if b {
+			fv.Balance = v.Balance
+			fv.UtxoCount = v.UtxoCount
+		}
+		return nil
+	}
+	nv := &AddressBalanceSnapshots{}
+	*nv = *v
+	m.AddressBalanceSnapshots[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) QueryLatestBalanceSnapshots(ctx context.Context, runner dbr.SessionRunner, before time.Time) (*BalanceSnapshots, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var latest *BalanceSnapshots
+	for _, v := range m.BalanceSnapshots {
+		if v.SnapshotAt.After(before) {
+			continue
+		}
+		if latest == nil || v.SnapshotAt.After(latest.SnapshotAt) {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockPersist) InsertBalanceSnapshots(ctx context.Context, runner dbr.SessionRunner, v *BalanceSnapshots, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.BalanceSnapshots[v.SnapshotAt.UnixNano()]; present {
+		if b {
+			fv.CreatedAt = v.CreatedAt
+		}
+		return nil
+	}
+	nv := &BalanceSnapshots{}
+	*nv = *v
+	m.BalanceSnapshots[v.SnapshotAt.UnixNano()] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteBalanceSnapshotsAfter(ctx context.Context, runner dbr.SessionRunner, after time.Time) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.BalanceSnapshots {
+		if v.SnapshotAt.After(after) {
+			delete(m.BalanceSnapshots, k)
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) QuerySubnets(ctx context.Context, runner dbr.SessionRunner, v *Subnets) (*Subnets, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.Subnets[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertSubnets(ctx context.Context, runner dbr.SessionRunner, v *Subnets, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &Subnets{}
+	*nv = *v
+	m.Subnets[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) QuerySubnetControlKeys(ctx context.Context, runner dbr.SessionRunner, v *SubnetControlKeys) (*SubnetControlKeys, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.SubnetControlKeys[v.SubnetID+" "+v.Address]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertSubnetControlKeys(ctx context.Context, runner dbr.SessionRunner, v *SubnetControlKeys, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &SubnetControlKeys{}
+	*nv = *v
+	m.SubnetControlKeys[v.SubnetID+" "+v.Address] = nv
+	return nil
+}
+
+func (m *MockPersist) QueryBlockchains(ctx context.Context, runner dbr.SessionRunner, v *Blockchains) (*Blockchains, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.Blockchains[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertBlockchains(ctx context.Context, runner dbr.SessionRunner, v *Blockchains, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &Blockchains{}
+	*nv = *v
+	m.Blockchains[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) QueryChains(ctx context.Context, runner dbr.SessionRunner, v *Chains) (*Chains, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.Chains[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) QueryAllChains(ctx context.Context, runner dbr.SessionRunner) ([]*Chains, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	vs := make([]*Chains, 0, len(m.Chains))
+	for _, v := range m.Chains {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool {
+		return vs[i].CreatedAt.Before(vs[j].CreatedAt)
+	})
+	return vs, nil
+}
+
+func (m *MockPersist) InsertChains(ctx context.Context, runner dbr.SessionRunner, v *Chains, b bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.Chains[v.ID]; present {
+		if b {
+			fv.VMType = v.VMType
+			fv.UpdatedAt = v.UpdatedAt
+		}
+		return nil
+	}
+	nv := &Chains{}
+	*nv = *v
+	m.Chains[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteChains(ctx context.Context, runner dbr.SessionRunner, v *Chains) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.Chains, v.ID)
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func blockAfter(block string, after string) bool {
+	b, ok := big.NewInt(0).SetString(block, 10)
+	if !ok {
+		return false
+	}
+	a, ok := big.NewInt(0).SetString(after, 10)
+	if !ok {
+		return false
+	}
+	return b.Cmp(a) > 0
+}
+
+func (m *MockPersist) QueryCvmTokens(ctx context.Context, runner dbr.SessionRunner, v *CvmTokens) (*CvmTokens, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.CvmTokens[v.ChainID+":"+v.Address]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertCvmTokens(ctx context.Context, runner dbr.SessionRunner, v *CvmTokens, _ bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &CvmTokens{}
+	*nv = *v
+	m.CvmTokens[v.ChainID+":"+v.Address] = nv
+	return nil
+}
+
+func (m *MockPersist) QueryCvmTokenTransfers(ctx context.Context, runner dbr.SessionRunner, v *CvmTokenTransfers) (*CvmTokenTransfers, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.CvmTokenTransfers[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertCvmTokenTransfers(ctx context.Context, runner dbr.SessionRunner, v *CvmTokenTransfers, _ bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &CvmTokenTransfers{}
+	*nv = *v
+	m.CvmTokenTransfers[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmTokenTransfersAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmTokenTransfers {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmTokenTransfers, k)
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) QueryCvmContracts(ctx context.Context, runner dbr.SessionRunner, v *CvmContracts) (*CvmContracts, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.CvmContracts[v.ChainID+":"+v.Address]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertCvmContracts(ctx context.Context, runner dbr.SessionRunner, v *CvmContracts, _ bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &CvmContracts{}
+	*nv = *v
+	m.CvmContracts[v.ChainID+":"+v.Address] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmContracts(ctx context.Context, runner dbr.SessionRunner, v *CvmContracts) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.CvmContracts, v.ChainID+":"+v.Address)
+	return nil
+}
+
+func (m *MockPersist) QueryCvmContractDeployments(ctx context.Context, runner dbr.SessionRunner, v *CvmContractDeployments) (*CvmContractDeployments, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.CvmContractDeployments[v.ChainID+":"+v.Address]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertCvmContractDeployments(ctx context.Context, runner dbr.SessionRunner, v *CvmContractDeployments, _ bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &CvmContractDeployments{}
+	*nv = *v
+	m.CvmContractDeployments[v.ChainID+":"+v.Address] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmContractDeploymentsAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmContractDeployments {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmContractDeployments, k)
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) QueryCvmInternalTransfers(ctx context.Context, runner dbr.SessionRunner, v *CvmInternalTransfers) (*CvmInternalTransfers, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.CvmInternalTransfers[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertCvmInternalTransfers(ctx context.Context, runner dbr.SessionRunner, v *CvmInternalTransfers, _ bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &CvmInternalTransfers{}
+	*nv = *v
+	m.CvmInternalTransfers[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteCvmInternalTransfersAfter(ctx context.Context, runner dbr.SessionRunner, chainID string, block string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k, v := range m.CvmInternalTransfers {
+		if v.ChainID == chainID && blockAfter(v.Block, block) {
+			delete(m.CvmInternalTransfers, k)
+		}
+	}
+	return nil
+}
+
+func (m *MockPersist) QueryAtomicTransfers(ctx context.Context, runner dbr.SessionRunner, v *AtomicTransfers) (*AtomicTransfers, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if v, present := m.AtomicTransfers[v.ID]; present {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (m *MockPersist) InsertAtomicTransfersExport(ctx context.Context, runner dbr.SessionRunner, v *AtomicTransfers) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	nv := &AtomicTransfers{}
+	if fv, present := m.AtomicTransfers[v.ID]; present {
+		*nv = *fv
+	}
+	nv.ID = v.ID
+	nv.ExportTxID = v.ExportTxID
+	nv.SourceChain = v.SourceChain
+	nv.DestinationChain = v.DestinationChain
+	nv.AssetID = v.AssetID
+	nv.Amount = v.Amount
+	nv.ExportedAt = v.ExportedAt
+	nv.CreatedAt = v.CreatedAt
+	m.AtomicTransfers[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) InsertAtomicTransfersImport(ctx context.Context, runner dbr.SessionRunner, v *AtomicTransfers) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if fv, present := m.AtomicTransfers[v.ID]; present {
+		fv.ImportTxID = v.ImportTxID
+		fv.ImportedAt = v.ImportedAt
+		return nil
+	}
+	nv := &AtomicTransfers{}
+	*nv = *v
+	m.AtomicTransfers[v.ID] = nv
+	return nil
+}
+
+func (m *MockPersist) DeleteAtomicTransactions(ctx context.Context, runner dbr.SessionRunner, transactionIDs []string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	txIDs := make(map[string]struct{}, len(transactionIDs))
+	for _, txID := range transactionIDs {
+		txIDs[txID] = struct{}{}
+	}
+	for k, v := range m.Outputs {
+		if _, ok := txIDs[v.TransactionID]; ok {
+			for ka, va := range m.OutputAddresses {
+				if va.OutputID == v.ID {
+					delete(m.OutputAddresses, ka)
+				}
+			}
+			delete(m.Outputs, k)
+		}
+	}
+	for k, v := range m.OutputsRedeeming {
+		if _, ok := txIDs[v.RedeemingTransactionID]; ok {
+			delete(m.OutputsRedeeming, k)
+		}
+	}
+	for k := range m.Transactions {
+		if _, ok := txIDs[k]; ok {
+			delete(m.Transactions, k)
+		}
+	}
+	for k, v := range m.AtomicTransfers {
+		if _, ok := txIDs[v.ExportTxID]; ok && v.ImportTxID == "" {
+			delete(m.AtomicTransfers, k)
+			continue
+		}
+		if _, ok := txIDs[v.ImportTxID]; ok {
+			v.ImportTxID = ""
+			v.ImportedAt = nil
+		}
+	}
+	return nil
+}
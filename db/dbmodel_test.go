@@ -5,6 +5,7 @@ package db
 
 import (
 	"context"
+	"os"
 	"reflect"
 	"testing"
 	"time"
@@ -13,8 +14,17 @@ import (
 	"github.com/gocraft/dbr/v2"
 )
 
-const TestDB = "mysql"
-const TestDSN = "root:password@tcp(127.0.0.1:3306)/magellan_test?parseTime=true"
+// The suite runs against mysql by default.
+// Set MAGELLAN_TEST_DB=pgx and MAGELLAN_TEST_DSN to run it against postgres.
+var TestDB = testEnv("MAGELLAN_TEST_DB", "mysql")
+var TestDSN = testEnv("MAGELLAN_TEST_DSN", "root:password@tcp(127.0.0.1:3306)/magellan_test?parseTime=true")
+
+func testEnv(key string, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
 
 func TestTransaction(t *testing.T) {
 	p := NewPersist()
@@ -544,7 +554,10 @@ func TestCvmBlocks(t *testing.T) {
 	tm := time.Now().UTC().Truncate(1 * time.Second)
 
 	v := &CvmBlocks{}
+	v.ChainID = "ch1"
 	v.Block = "1"
+	v.Hash = "h1"
+	v.ParentHash = "ph1"
 	v.CreatedAt = tm
 
 	stream := &dbr.NullEventReceiver{}
@@ -566,6 +579,15 @@ func TestCvmBlocks(t *testing.T) {
 	if !reflect.DeepEqual(*v, *fv) {
 		t.Fatal("compare fail")
 	}
+
+	err = p.DeleteCvmBlocksAfter(ctx, rawDBConn.NewSession(stream), v.ChainID, "0")
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmBlocks(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
 }
 
 func TestCvmAddresses(t *testing.T) {
@@ -574,6 +596,7 @@ func TestCvmAddresses(t *testing.T) {
 	tm := time.Now().UTC().Truncate(1 * time.Second)
 
 	v := &CvmAddresses{}
+	v.ChainID = "ch1"
 	v.ID = "id1"
 	v.Type = models.AXChainIn
 	v.Idx = 1
@@ -627,6 +650,15 @@ func TestCvmAddresses(t *testing.T) {
 	if !reflect.DeepEqual(*v, *fv) {
 		t.Fatal("compare fail")
 	}
+
+	err = p.DeleteCvmAddressesOfTransactions(ctx, rawDBConn.NewSession(stream), v.ChainID, []string{"tid2"})
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmAddresses(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
 }
 
 func TestCvmTransactions(t *testing.T) {
@@ -636,6 +668,7 @@ func TestCvmTransactions(t *testing.T) {
 	txtime := time.Now().UTC().Truncate(1 * time.Second).Add(-1 * time.Hour)
 
 	v := &CvmTransactions{}
+	v.ChainID = "ch1"
 	v.ID = "id1"
 	v.TransactionID = "trid1"
 	v.Type = models.AXChainIn
@@ -712,6 +745,7 @@ func TestCvmTransactionsTxdata(t *testing.T) {
 	tm := time.Now().UTC().Truncate(1 * time.Second)
 
 	v := &CvmTransactionsTxdata{}
+	v.ChainID = "ch1"
 	v.Hash = "h1"
 	v.Block = "1"
 	v.Idx = 1
@@ -880,8 +914,10 @@ func TestTransactionsValidator(t *testing.T) {
 	v := &TransactionsValidator{}
 	v.ID = "id1"
 	v.NodeID = "nid1"
+	v.SubnetID = "sid1"
 	v.Start = 1
 	v.End = 2
+	v.Weight = 3
 	v.CreatedAt = tm
 
 	stream := &dbr.NullEventReceiver{}
@@ -905,8 +941,10 @@ func TestTransactionsValidator(t *testing.T) {
 	}
 
 	v.NodeID = "nid2"
+	v.SubnetID = "sid2"
 	v.Start = 2
 	v.End = 3
+	v.Weight = 4
 	v.CreatedAt = tm
 
 	err = p.InsertTransactionsValidator(ctx, rawDBConn.NewSession(stream), v, true)
@@ -1452,6 +1490,135 @@ func TestTxPool(t *testing.T) {
 	if !reflect.DeepEqual(*v, *fv) {
 		t.Fatal("compare fail")
 	}
+
+	v2 := &TxPool{}
+	*v2 = *v
+	v2.MsgKey = "key2"
+	err = v2.ComputeID()
+	if err != nil {
+		t.Fatal("compute id failed", err)
+	}
+
+	// v exists, it is skipped
+	err = p.InsertTxPools(ctx, rawDBConn.NewSession(stream), []*TxPool{v, v2})
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryTxPool(ctx, rawDBConn.NewSession(stream), v2)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v2, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v2.LastError = "failed"
+	for i := 0; i < 2; i++ {
+		err = p.UpdateTxPoolFailure(ctx, rawDBConn.NewSession(stream), v2, 2)
+		if err != nil {
+			t.Fatal("update fail", err)
+		}
+	}
+	fv, err = p.QueryTxPool(ctx, rawDBConn.NewSession(stream), v2)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Processed != TxPoolQuarantined || fv.Attempts != 2 || fv.LastError != "failed" {
+		t.Fatal("compare fail")
+	}
+
+	quarantined, err := p.QueryTxPoolsQuarantined(ctx, rawDBConn.NewSession(stream), v2.Topic, 10)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(quarantined) != 1 || quarantined[0].ID != v2.ID {
+		t.Fatal("compare fail")
+	}
+	counts, err := p.QueryTxPoolQuarantinedCounts(ctx, rawDBConn.NewSession(stream))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(counts) != 1 || counts[0].Topic != v2.Topic || counts[0].Count != 1 {
+		t.Fatal("compare fail")
+	}
+
+	err = p.ResetTxPool(ctx, rawDBConn.NewSession(stream), v2)
+	if err != nil {
+		t.Fatal("reset fail", err)
+	}
+	fv, err = p.QueryTxPool(ctx, rawDBConn.NewSession(stream), v2)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Processed != TxPoolUnprocessed || fv.Attempts != 0 || fv.LastError != "" {
+		t.Fatal("compare fail")
+	}
+
+	pending, err := p.QueryTxPoolUnprocessedBefore(ctx, rawDBConn.NewSession(stream), v2.CreatedAt.Add(time.Second))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !pending {
+		t.Fatal("compare fail")
+	}
+	pending, err = p.QueryTxPoolUnprocessedBefore(ctx, rawDBConn.NewSession(stream), v2.CreatedAt)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if pending {
+		t.Fatal("compare fail")
+	}
+
+	after := &TxPool{ProcessedAt: time.Now().UTC().Add(-time.Second)}
+	v.Processed = TxPoolProcessed
+	err = p.UpdateTxPoolStatus(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("update fail", err)
+	}
+	processed, err := p.QueryTxPoolsProcessedAfter(ctx, rawDBConn.NewSession(stream), after, time.Now().UTC().Add(time.Second), 10)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(processed) != 1 || processed[0].ID != v.ID || !processed[0].ProcessedAt.After(after.ProcessedAt) {
+		t.Fatal("compare fail")
+	}
+	processed, err = p.QueryTxPoolsProcessedAfter(ctx, rawDBConn.NewSession(stream), processed[0], time.Now().UTC().Add(time.Second), 10)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(processed) != 0 {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestTxPoolChunks(t *testing.T) {
+	row := func(size int) *TxPool {
+		return &TxPool{Serialization: make([]byte, size)}
+	}
+	rowBytes := txPoolRowBytes(row(100))
+
+	vs := []*TxPool{row(100), row(100), row(100), row(100), row(100)}
+	chunks := txPoolChunks(vs, 2*rowBytes)
+	if len(chunks) != 3 || len(chunks[0]) != 2 || len(chunks[1]) != 2 || len(chunks[2]) != 1 {
+		t.Fatal("chunks", len(chunks))
+	}
+	if chunks[2][0] != vs[4] {
+		t.Fatal("rows out of order")
+	}
+
+	// a row over the size is a chunk of its own
+	vs = []*TxPool{row(100), row(1000), row(100)}
+	chunks = txPoolChunks(vs, 2*rowBytes)
+	if len(chunks) != 3 || chunks[1][0] != vs[1] {
+		t.Fatal("chunks", len(chunks))
+	}
+
+	if chunks := txPoolChunks(nil, rowBytes); len(chunks) != 0 {
+		t.Fatal("chunks of no rows", len(chunks))
+	}
+	if chunks := txPoolChunks(vs, txPoolsInsertMaxBytes); len(chunks) != 1 || len(chunks[0]) != 3 {
+		t.Fatal("chunks", len(chunks))
+	}
 }
 
 func TestKeyValueStore(t *testing.T) {
@@ -1489,6 +1656,7 @@ func TestCvmTransactionsTxdataTrace(t *testing.T) {
 	tm := time.Now().UTC().Truncate(1 * time.Second)
 
 	v := &CvmTransactionsTxdataTrace{}
+	v.ChainID = "ch1"
 	v.Hash = "h1"
 	v.Idx = 1
 	v.ToAddr = "to1"
@@ -1546,6 +1714,7 @@ func TestNodeIndex(t *testing.T) {
 	v.Instance = "def"
 	v.Topic = "top"
 	v.Idx = 1
+	v.ContainerID = "cid1"
 
 	stream := &dbr.NullEventReceiver{}
 
@@ -1568,6 +1737,7 @@ func TestNodeIndex(t *testing.T) {
 	}
 
 	v.Idx = 2
+	v.ContainerID = "cid2"
 
 	err = p.InsertNodeIndex(ctx, rawDBConn.NewSession(stream), v, true)
 	if err != nil {
@@ -1585,6 +1755,7 @@ func TestNodeIndex(t *testing.T) {
 	}
 
 	v.Idx = 3
+	v.ContainerID = "cid3"
 	err = p.UpdateNodeIndex(ctx, rawDBConn.NewSession(stream), v)
 	if err != nil {
 		t.Fatal("insert fail", err)
@@ -1607,10 +1778,13 @@ func TestCvmLogs(t *testing.T) {
 	tm := time.Now().UTC().Truncate(1 * time.Second)
 
 	v := &CvmLogs{}
+	v.ChainID = "ch1"
 	v.BlockHash = "bh1"
 	v.TxHash = "txh1"
 	v.LogIndex = 1
+	v.Address = "addr1"
 	v.FirstTopic = "ft1"
+	v.Topic1 = "t11"
 	v.Block = "123"
 	v.Removed = false
 	v.CreatedAt = tm
@@ -1642,6 +1816,8 @@ func TestCvmLogs(t *testing.T) {
 	}
 
 	v.FirstTopic = "ft3"
+	v.Topic1 = "t13"
+	v.Topic2 = "t23"
 	v.Block = "124"
 	v.Removed = false
 	v.Serialization = []byte("bits2")
@@ -1720,3 +1896,974 @@ func TestPvmProposer(t *testing.T) {
 		t.Fatal("compare fail")
 	}
 }
+
+func TestWebhooks(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &Webhooks{}
+	v.ID = "id"
+	v.URL = "https://example.com/hook"
+	v.Secret = "secret"
+	v.Filter = "{}"
+	v.CreatedAt = tm
+	v.UpdatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableWebhooks).Exec()
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableWebhookDeliveries).Exec()
+
+	err = p.InsertWebhooks(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryWebhooks(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.URL = "https://example.com/hook2"
+	v.Filter = "{\"types\":[\"base\"]}"
+	v.UpdatedAt = tm.Add(time.Second)
+
+	err = p.InsertWebhooks(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fvs, err := p.QueryAllWebhooks(ctx, rawDBConn.NewSession(stream))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*v, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	d := &WebhookDeliveries{WebhookID: v.ID, TxID: "tx"}
+	if err = d.ComputeID(); err != nil {
+		t.Fatal("compute id failed", err)
+	}
+	d.CreatedAt = tm
+	d.UpdatedAt = tm
+	d.NextAttemptAt = tm
+	d.Payload = []byte("{}")
+	err = p.InsertWebhookDeliveries(ctx, rawDBConn.NewSession(stream), d)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+
+	err = p.DeleteWebhooks(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryWebhooks(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryWebhookDeliveries(ctx, rawDBConn.NewSession(stream), d)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &WebhookDeliveries{}
+	v.WebhookID = "wh"
+	v.TxID = "tx"
+	v.Payload = []byte("{}")
+	v.Status = WebhookDeliveryPending
+	v.NextAttemptAt = tm
+	v.CreatedAt = tm
+	v.UpdatedAt = tm
+	err := v.ComputeID()
+	if err != nil {
+		t.Fatal("compute id failed", err)
+	}
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableWebhookDeliveries).Exec()
+
+	err = p.InsertWebhookDeliveries(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryWebhookDeliveries(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	due := tm.Add(time.Second)
+	fvs, err := p.QueryWebhookDeliveriesList(ctx, rawDBConn.NewSession(stream), &WebhookDeliveriesFilter{
+		Statuses:  []WebhookDeliveryStatus{WebhookDeliveryPending},
+		DueBefore: &due,
+	})
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*v, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	// a claim only succeeds once per attempt
+	lease := tm.Add(time.Minute)
+	claimed, err := p.ClaimWebhookDeliveries(ctx, rawDBConn.NewSession(stream), v, lease)
+	if err != nil {
+		t.Fatal("claim fail", err)
+	}
+	if !claimed || v.Attempts != 1 {
+		t.Fatal("claim fail")
+	}
+	claimed, err = p.ClaimWebhookDeliveries(ctx, rawDBConn.NewSession(stream), fvs[0], lease)
+	if err != nil {
+		t.Fatal("claim fail", err)
+	}
+	if claimed {
+		t.Fatal("claim fail")
+	}
+
+	v.Status = WebhookDeliveryDead
+	v.LastError = "failed"
+	v.UpdatedAt = tm.Add(time.Second)
+	err = p.UpdateWebhookDeliveriesStatus(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("update fail", err)
+	}
+	fv, err = p.QueryWebhookDeliveries(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestAddressBalanceChanges(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableOutputs).Exec()
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableOutputAddresses).Exec()
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableOutputsRedeeming).Exec()
+
+	for i, id := range []string{"out1", "out2"} {
+		o := &Outputs{
+			ID:            id,
+			ChainID:       "cid",
+			TransactionID: "txid",
+			OutputIndex:   uint32(i),
+			AssetID:       "aid",
+			Amount:        uint64(10 * (i + 1)),
+			CreatedAt:     tm.Add(time.Duration(i) * time.Hour),
+		}
+		if err = p.InsertOutputs(ctx, rawDBConn.NewSession(stream), o, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+		oa := &OutputAddresses{OutputID: id, Address: "addr", CreatedAt: tm, UpdatedAt: tm}
+		if err = p.InsertOutputAddresses(ctx, rawDBConn.NewSession(stream), oa, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+	}
+	r := &OutputsRedeeming{
+		ID:                     "out1",
+		RedeemedAt:             tm.Add(2 * time.Hour),
+		RedeemingTransactionID: "rtxid",
+		Amount:                 10,
+		AssetID:                "aid",
+		ChainID:                "cid",
+		CreatedAt:              tm,
+	}
+	if err = p.InsertOutputsRedeeming(ctx, rawDBConn.NewSession(stream), r, false); err != nil {
+		t.Fatal("insert fail", err)
+	}
+
+	q := &AddressBalanceChangesFilter{Addresses: []string{"addr"}, End: tm.Add(3 * time.Hour)}
+	fvs, err := p.QueryAddressBalanceChanges(ctx, rawDBConn.NewSession(stream), q)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	expected := &AddressBalanceChanges{
+		ChainID:       "cid",
+		Address:       "addr",
+		AssetID:       "aid",
+		Received:      "30",
+		ReceivedCount: 2,
+		Sent:          "10",
+		SentCount:     1,
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*expected, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	q.Start = tm.Add(time.Hour)
+	q.End = tm.Add(2 * time.Hour)
+	fvs, err = p.QueryAddressBalanceChanges(ctx, rawDBConn.NewSession(stream), q)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	expected.Received = "20"
+	expected.ReceivedCount = 1
+	expected.Sent = "0"
+	expected.SentCount = 0
+	if len(fvs) != 1 || !reflect.DeepEqual(*expected, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	first, err := p.QueryOutputsFirstCreatedAt(ctx, rawDBConn.NewSession(stream))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !first.Equal(tm) {
+		t.Fatal("compare fail")
+	}
+	addresses, err := p.QueryOutputAddressesAfter(ctx, rawDBConn.NewSession(stream), "", 10)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(addresses, []string{"addr"}) {
+		t.Fatal("compare fail")
+	}
+	addresses, err = p.QueryOutputAddressesAfter(ctx, rawDBConn.NewSession(stream), "addr", 10)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(addresses) != 0 {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestAddressBalanceSnapshots(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(24 * time.Hour)
+
+	v := &AddressBalanceSnapshots{}
+	v.ChainID = "cid"
+	v.Address = "addr"
+	v.AssetID = "aid"
+	v.Balance = "10"
+	v.UtxoCount = 1
+	v.SnapshotAt = tm
+	v.CreatedAt = tm
+	if err := v.ComputeID(); err != nil {
+		t.Fatal("compute id failed", err)
+	}
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableAddressBalanceSnapshots).Exec()
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableBalanceSnapshots).Exec()
+
+	err = p.InsertAddressBalanceSnapshots(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+
+	nv := &AddressBalanceSnapshots{}
+	*nv = *v
+	nv.Balance = "20"
+	nv.SnapshotAt = tm.Add(24 * time.Hour)
+	if err := nv.ComputeID(); err != nil {
+		t.Fatal("compute id failed", err)
+	}
+	err = p.InsertAddressBalanceSnapshots(ctx, rawDBConn.NewSession(stream), nv, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+
+	q := &AddressBalanceSnapshotsFilter{Addresses: []string{"addr"}, Before: tm.Add(time.Hour)}
+	fvs, err := p.QueryAddressBalanceSnapshots(ctx, rawDBConn.NewSession(stream), q)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*v, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	nv.Balance = "30"
+	nv.UtxoCount = 2
+	err = p.InsertAddressBalanceSnapshots(ctx, rawDBConn.NewSession(stream), nv, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	q.Before = nv.SnapshotAt
+	fvs, err = p.QueryAddressBalanceSnapshots(ctx, rawDBConn.NewSession(stream), q)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*nv, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	s := &BalanceSnapshots{SnapshotAt: tm, CreatedAt: tm}
+	err = p.InsertBalanceSnapshots(ctx, rawDBConn.NewSession(stream), s, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fs, err := p.QueryLatestBalanceSnapshots(ctx, rawDBConn.NewSession(stream), tm.Add(time.Hour))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fs == nil || !reflect.DeepEqual(*s, *fs) {
+		t.Fatal("compare fail")
+	}
+	fs, err = p.QueryLatestBalanceSnapshots(ctx, rawDBConn.NewSession(stream), tm.Add(-time.Hour))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fs != nil {
+		t.Fatal("compare fail")
+	}
+
+	s2 := &BalanceSnapshots{SnapshotAt: tm.Add(24 * time.Hour), CreatedAt: tm}
+	err = p.InsertBalanceSnapshots(ctx, rawDBConn.NewSession(stream), s2, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	err = p.DeleteBalanceSnapshotsAfter(ctx, rawDBConn.NewSession(stream), tm.Add(time.Hour))
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	fs, err = p.QueryLatestBalanceSnapshots(ctx, rawDBConn.NewSession(stream), s2.SnapshotAt)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fs == nil || !reflect.DeepEqual(*s, *fs) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestSubnets(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &Subnets{}
+	v.ID = "id1"
+	v.Threshold = 1
+	v.Locktime = 2
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableSubnets).Exec()
+
+	err = p.InsertSubnets(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QuerySubnets(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Threshold = 2
+	v.Locktime = 3
+
+	err = p.InsertSubnets(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QuerySubnets(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Threshold != 2 {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestSubnetControlKeys(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &SubnetControlKeys{}
+	v.SubnetID = "id1"
+	v.Address = "addr1"
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableSubnetControlKeys).Exec()
+
+	err = p.InsertSubnetControlKeys(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QuerySubnetControlKeys(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestBlockchains(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &Blockchains{}
+	v.ID = "id1"
+	v.SubnetID = "sid1"
+	v.Name = "name1"
+	v.VmID = "vm1"
+	v.GenesisHash = "hash1"
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableBlockchains).Exec()
+
+	err = p.InsertBlockchains(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryBlockchains(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.SubnetID = "sid2"
+	v.Name = "name2"
+	v.VmID = "vm2"
+	v.GenesisHash = "hash2"
+
+	err = p.InsertBlockchains(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryBlockchains(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Name != "name2" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestChains(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &Chains{}
+	v.ID = "id"
+	v.VMType = "avm"
+	v.CreatedAt = tm
+	v.UpdatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableChains).Exec()
+
+	err = p.InsertChains(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryChains(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.VMType = "pvm"
+	v.UpdatedAt = tm.Add(time.Second)
+
+	err = p.InsertChains(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fvs, err := p.QueryAllChains(ctx, rawDBConn.NewSession(stream))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 1 || !reflect.DeepEqual(*v, *fvs[0]) {
+		t.Fatal("compare fail")
+	}
+
+	err = p.DeleteChains(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	fvs, err = p.QueryAllChains(ctx, rawDBConn.NewSession(stream))
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if len(fvs) != 0 {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestCvmTokens(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &CvmTokens{}
+	v.ChainID = "ch1"
+	v.Address = "0x01"
+	v.Type = "erc20"
+	v.Name = "name1"
+	v.Symbol = "sym1"
+	v.Decimals = 18
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableCvmTokens).Exec()
+
+	err = p.InsertCvmTokens(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryCvmTokens(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Type = "erc721"
+	v.Name = "name2"
+	v.Symbol = "sym2"
+	v.Decimals = 0
+
+	err = p.InsertCvmTokens(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryCvmTokens(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Name != "name2" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+}
+
+func TestCvmTokenTransfers(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &CvmTokenTransfers{}
+	v.ID = "id1"
+	v.ChainID = "ch1"
+	v.Address = "0x01"
+	v.Event = "transfer"
+	v.TxHash = "txh1"
+	v.Block = "123"
+	v.LogIndex = 1
+	v.FromAddr = "0x02"
+	v.ToAddr = "0x03"
+	v.Amount = "100"
+	v.Value = "100"
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableCvmTokenTransfers).Exec()
+
+	err = p.InsertCvmTokenTransfers(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryCvmTokenTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Event = "approval"
+	v.Block = "124"
+	v.Amount = "0"
+	v.Value = "200"
+
+	err = p.InsertCvmTokenTransfers(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryCvmTokenTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Block != "124" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	err = p.DeleteCvmTokenTransfersAfter(ctx, rawDBConn.NewSession(stream), v.ChainID, "123")
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmTokenTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
+
+func TestCvmContracts(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &CvmContracts{}
+	v.ChainID = "ch1"
+	v.Address = "0x01"
+	v.Name = "name1"
+	v.ABI = "[]"
+	v.CreatedAt = tm
+	v.UpdatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableCvmContracts).Exec()
+
+	err = p.InsertCvmContracts(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryCvmContracts(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Name = "name2"
+	v.ABI = `[{"type":"fallback"}]`
+	v.UpdatedAt = tm.Add(time.Second)
+
+	err = p.InsertCvmContracts(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryCvmContracts(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Name != "name2" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	err = p.DeleteCvmContracts(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmContracts(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
+
+func TestCvmContractDeployments(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &CvmContractDeployments{}
+	v.ChainID = "ch1"
+	v.Address = "0x01"
+	v.Creator = "0x02"
+	v.TxHash = "txh1"
+	v.Block = "123"
+	v.Type = "CREATE"
+	v.CodeHash = "0x03"
+	v.CreatedAt = tm
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableCvmContractDeployments).Exec()
+
+	err = p.InsertCvmContractDeployments(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryCvmContractDeployments(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Creator = "0x04"
+	v.TxHash = "txh2"
+	v.Block = "124"
+	v.Type = "CREATE2"
+	v.CodeHash = "0x05"
+
+	err = p.InsertCvmContractDeployments(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryCvmContractDeployments(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Block != "124" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	err = p.DeleteCvmContractDeploymentsAfter(ctx, rawDBConn.NewSession(stream), v.ChainID, "123")
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmContractDeployments(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
+
+func TestCvmInternalTransfers(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+
+	v := &CvmInternalTransfers{}
+	v.ChainID = "ch1"
+	v.TxHash = "txh1"
+	v.Block = "123"
+	v.TraceAddress = "0,1"
+	v.Type = "CALL"
+	v.FromAddr = "0x01"
+	v.ToAddr = "0x02"
+	v.Value = "100"
+	v.Error = ""
+	v.CreatedAt = tm
+	if err := v.ComputeID(); err != nil {
+		t.Fatal("compute id fail", err)
+	}
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableCvmInternalTransfers).Exec()
+
+	err = p.InsertCvmInternalTransfers(ctx, rawDBConn.NewSession(stream), v, false)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryCvmInternalTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	v.Block = "124"
+	v.Type = "SELFDESTRUCT"
+	v.Value = "200"
+	v.Error = "execution reverted"
+
+	err = p.InsertCvmInternalTransfers(ctx, rawDBConn.NewSession(stream), v, true)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryCvmInternalTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.Block != "124" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	err = p.DeleteCvmInternalTransfersAfter(ctx, rawDBConn.NewSession(stream), v.ChainID, "123")
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryCvmInternalTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
+
+func TestAtomicTransfers(t *testing.T) {
+	p := NewPersist()
+	ctx := context.Background()
+	tm := time.Now().UTC().Truncate(1 * time.Second)
+	importedAt := tm.Add(time.Minute)
+
+	v := &AtomicTransfers{}
+	v.ID = "id1"
+	v.ExportTxID = "tx1"
+	v.SourceChain = "ch1"
+	v.DestinationChain = "ch2"
+	v.AssetID = "asset1"
+	v.Amount = 100
+	v.ImportTxID = "tx2"
+	v.ImportedAt = &importedAt
+	v.CreatedAt = importedAt
+
+	stream := &dbr.NullEventReceiver{}
+
+	rawDBConn, err := dbr.Open(TestDB, TestDSN, stream)
+	if err != nil {
+		t.Fatal("db fail", err)
+	}
+	_, _ = rawDBConn.NewSession(stream).DeleteFrom(TableAtomicTransfers).Exec()
+
+	// the import is indexed before the export
+	err = p.InsertAtomicTransfersImport(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err := p.QueryAtomicTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	export := &AtomicTransfers{}
+	export.ID = "id1"
+	export.ExportTxID = "tx1"
+	export.SourceChain = "ch1"
+	export.DestinationChain = "ch2"
+	export.AssetID = "asset1"
+	export.Amount = 100
+	export.ExportedAt = &tm
+	export.CreatedAt = tm
+
+	err = p.InsertAtomicTransfersExport(ctx, rawDBConn.NewSession(stream), export)
+	if err != nil {
+		t.Fatal("insert fail", err)
+	}
+	fv, err = p.QueryAtomicTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	v.ExportedAt = &tm
+	v.CreatedAt = tm
+	if fv.ImportTxID != "tx2" {
+		t.Fatal("compare fail")
+	}
+	if !reflect.DeepEqual(*v, *fv) {
+		t.Fatal("compare fail")
+	}
+
+	// the import is orphaned, the export is kept
+	err = p.DeleteAtomicTransactions(ctx, rawDBConn.NewSession(stream), []string{"tx2"})
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	fv, err = p.QueryAtomicTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != nil {
+		t.Fatal("query fail", err)
+	}
+	if fv.ImportTxID != "" || fv.ImportedAt != nil {
+		t.Fatal("compare fail")
+	}
+
+	// the export is orphaned
+	err = p.DeleteAtomicTransactions(ctx, rawDBConn.NewSession(stream), []string{"tx1"})
+	if err != nil {
+		t.Fatal("delete fail", err)
+	}
+	_, err = p.QueryAtomicTransfers(ctx, rawDBConn.NewSession(stream), v)
+	if err != dbr.ErrNotFound {
+		t.Fatal("delete fail", err)
+	}
+}
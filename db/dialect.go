@@ -0,0 +1,71 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package db
+
+import (
+	"context"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+)
+
+// dialectOf returns the dialect the session was opened with.
+func dialectOf(sess dbr.SessionRunner) dbr.Dialect {
+	switch s := sess.(type) {
+	case *dbr.Session:
+		return s.Dialect
+	case *dbr.Tx:
+		return s.Dialect
+	}
+	return dialect.MySQL
+}
+
+func isPostgres(sess dbr.SessionRunner) bool {
+	return dialectOf(sess) == dialect.PostgreSQL
+}
+
+// CastString selects a numeric column as a string, aliased to the column name.
+func CastString(sess dbr.SessionRunner, col string) string {
+	return CastStringAs(sess, col, col)
+}
+
+// CastStringAs selects a numeric expression as a string, aliased to alias.
+func CastStringAs(sess dbr.SessionRunner, expr string, alias string) string {
+	if isPostgres(sess) {
+		return "cast(" + expr + " as text) " + alias
+	}
+	return "cast(" + expr + " as char) " + alias
+}
+
+// UnixTimestamp selects a time expression as the seconds since the epoch.
+func UnixTimestamp(sess dbr.SessionRunner, expr string) string {
+	if isPostgres(sess) {
+		return "extract(epoch from " + expr + ")"
+	}
+	return "UNIX_TIMESTAMP(" + expr + ")"
+}
+
+// quoteIdent quotes a column name which is a reserved word.
+func quoteIdent(sess dbr.SessionRunner, col string) string {
+	return dialectOf(sess).QuoteIdent(col)
+}
+
+// insertIgnore executes the insert.
+// On postgres an existing row is skipped with "on conflict do nothing", as a
+// failed statement aborts the enclosing transaction.
+// On mysql the duplicate entry error is returned for the caller to ignore.
+func insertIgnore(ctx context.Context, sess dbr.SessionRunner, stmt *dbr.InsertStmt) error {
+	if stmt.Dialect != dialect.PostgreSQL {
+		_, err := stmt.ExecContext(ctx)
+		return err
+	}
+	buf := dbr.NewBuffer()
+	if err := stmt.Build(stmt.Dialect, buf); err != nil {
+		return err
+	}
+	_, err := sess.
+		InsertBySql(buf.String()+" on conflict do nothing", buf.Value()...).
+		ExecContext(ctx)
+	return err
+}
@@ -4,6 +4,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -116,6 +117,55 @@ type Asset struct {
 	Denomination uint8 `json:"denomination"`
 	VariableCap  uint8 `json:"variableCap"`
 	Nft          uint8 `json:"nft"`
+
+	Holders *AssetHolders `json:"holders,omitempty"`
+}
+
+// AssetHolders is how concentrated the balances of an asset are, the shares
+// are those of the total held by the largest holders.
+type AssetHolders struct {
+	Count       uint64      `json:"count"`
+	TotalHeld   TokenAmount `json:"totalHeld"`
+	Top10Share  float64     `json:"top10Share"`
+	Top100Share float64     `json:"top100Share"`
+}
+
+// AssetHolder is the balance of an asset held by an address.
+type AssetHolder struct {
+	ChainID StringID `json:"chainID"`
+	Address Address  `json:"address"`
+
+	Balance          TokenAmount `json:"balance"`
+	UTXOCount        uint64      `json:"utxoCount"`
+	TransactionCount uint64      `json:"transactionCount"`
+}
+
+// NFT is a token of an NFT collection, an NFT transfer output of the asset
+// which is not spent. ContentType is sniffed from the payload.
+type NFT struct {
+	AssetID       StringID  `json:"assetID"`
+	GroupID       uint64    `json:"groupID"`
+	OutputID      StringID  `json:"outputID"`
+	TransactionID StringID  `json:"transactionID"`
+	ChainID       StringID  `json:"chainID"`
+	Owners        []Address `json:"owners"`
+	Threshold     uint64    `json:"threshold"`
+	Locktime      uint64    `json:"locktime"`
+	Payload       []byte    `json:"payload"`
+	ContentType   string    `json:"contentType"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// NFTTransfer is an NFT transfer output in the history of an NFT, from the
+// owners of the outputs its transaction spent, none for a mint, to its owners.
+type NFTTransfer struct {
+	OutputID               StringID  `json:"outputID"`
+	TransactionID          StringID  `json:"transactionID"`
+	ChainID                StringID  `json:"chainID"`
+	From                   []Address `json:"from"`
+	To                     []Address `json:"to"`
+	RedeemingTransactionID StringID  `json:"redeemingTransactionID"`
+	Timestamp              time.Time `json:"timestamp"`
 }
 
 type AssetInfo struct {
@@ -138,6 +188,218 @@ type AddressInfo struct {
 	Score uint64 `json:"-"`
 }
 
+// AddressBalances is the UTXO set balance of an address as of a time.
+type AddressBalances struct {
+	Address Address   `json:"address"`
+	AsOf    time.Time `json:"asOf"`
+
+	Assets map[StringID]AddressAssetBalance `json:"assets"`
+}
+
+type AddressAssetBalance struct {
+	AssetID StringID `json:"id"`
+
+	UTXOCount uint64      `json:"utxoCount"`
+	Balance   TokenAmount `json:"balance"`
+}
+
+// Validator is a validator, or a delegator, of the P-chain.
+type Validator struct {
+	TxID     StringID `json:"txID"`
+	Type     string   `json:"type"`
+	NodeID   StringID `json:"nodeID"`
+	SubnetID StringID `json:"subnetID"`
+	Status   string   `json:"status"`
+
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+
+	// StakeAmount is the amount staked, which is the weight on the primary
+	// network. Subnet validators do not stake.
+	StakeAmount TokenAmount `json:"stakeAmount"`
+	Weight      TokenAmount `json:"weight"`
+
+	RewardOwner *ValidatorRewardOwner `json:"rewardOwner,omitempty"`
+	Reward      *ValidatorReward      `json:"reward,omitempty"`
+
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+type ValidatorRewardOwner struct {
+	Addresses []Address `json:"addresses"`
+	Threshold uint64    `json:"threshold"`
+	Locktime  uint64    `json:"locktime"`
+}
+
+// ValidatorReward is the outcome of the reward of a validator or delegator,
+// which is pending until the staking period ends and it is committed.
+type ValidatorReward struct {
+	Status    string      `json:"status"`
+	Amount    TokenAmount `json:"amount"`
+	Timestamp *time.Time  `json:"timestamp,omitempty"`
+}
+
+// Subnet is a subnet created on the P-chain. Its ID is the ID of the
+// transaction which created it.
+type Subnet struct {
+	ID          StringID  `json:"id"`
+	ControlKeys []Address `json:"controlKeys"`
+	Threshold   uint32    `json:"threshold"`
+	Locktime    uint64    `json:"locktime"`
+	CreatedAt   time.Time `json:"timestamp"`
+}
+
+// Blockchain is a blockchain created on the P-chain. Its ID is the ID of the
+// transaction which created it.
+type Blockchain struct {
+	ID          StringID  `json:"id"`
+	SubnetID    StringID  `json:"subnetID"`
+	Name        string    `json:"name"`
+	VMID        StringID  `json:"vmID"`
+	GenesisHash string    `json:"genesisHash"`
+	CreatedAt   time.Time `json:"timestamp"`
+}
+
+// IndexedChain is a chain indexed in addition to the chains of the config.
+type IndexedChain struct {
+	ID        StringID  `json:"id"`
+	VMType    string    `json:"vmType"`
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+// TxPoolMessage is a message of the tx pool, Payload is the message decoded by
+// the writer of its topic.
+type TxPoolMessage struct {
+	ID        StringID        `json:"id"`
+	Topic     string          `json:"topic"`
+	ChainID   StringID        `json:"chainID"`
+	MsgKey    string          `json:"msgKey"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"timestamp"`
+}
+
+// CToken is an ERC-20 or ERC-721 token contract of an EVM chain.
+type CToken struct {
+	ChainID   string    `json:"chainID"`
+	Address   string    `json:"address"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	Symbol    string    `json:"symbol"`
+	Decimals  uint8     `json:"decimals"`
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+// CTokenTransfer is a Transfer or Approval event of a token. Amount is the
+// value of an ERC-20 event, and TokenID the token of an ERC-721 event.
+type CTokenTransfer struct {
+	ID        StringID  `json:"id"`
+	ChainID   string    `json:"chainID"`
+	Address   string    `json:"address"`
+	Event     string    `json:"event"`
+	TxHash    string    `json:"txHash"`
+	Block     string    `json:"block"`
+	LogIndex  uint64    `json:"logIndex"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Amount    *string   `json:"amount,omitempty"`
+	TokenID   *string   `json:"tokenID,omitempty"`
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+// CTokenBalance is the balance of an address for a token, the number of tokens
+// held for an ERC-721 token.
+type CTokenBalance struct {
+	Token   *CToken `json:"token"`
+	Balance string  `json:"balance"`
+}
+
+// CLog is a log of an EVM chain, with its event when an ABI has it.
+type CLog struct {
+	ChainID   string         `json:"chainID"`
+	Address   string         `json:"address"`
+	Topics    []string       `json:"topics"`
+	Data      string         `json:"data"`
+	Block     string         `json:"blockNumber"`
+	BlockHash string         `json:"blockHash"`
+	TxHash    string         `json:"transactionHash"`
+	TxIndex   uint           `json:"transactionIndex"`
+	LogIndex  uint           `json:"logIndex"`
+	Decoded   *CDecodedEvent `json:"decoded,omitempty"`
+	CreatedAt time.Time      `json:"timestamp"`
+}
+
+// CContract is a contract, with its deployment when it is indexed and the ABI
+// uploaded to decode its calls and events, the timestamps are the ones of the
+// upload.
+type CContract struct {
+	ChainID    string               `json:"chainID"`
+	Address    string               `json:"address"`
+	Name       string               `json:"name,omitempty"`
+	ABI        json.RawMessage      `json:"abi,omitempty"`
+	Deployment *CContractDeployment `json:"deployment,omitempty"`
+	CreatedAt  *time.Time           `json:"timestamp,omitempty"`
+	UpdatedAt  *time.Time           `json:"updatedAt,omitempty"`
+}
+
+// CContractDeployment is the creation of a contract by a transaction or by a
+// CREATE or CREATE2 call of its trace. CodeHash is the keccak256 hash of the
+// runtime bytecode, the output of the creation.
+type CContractDeployment struct {
+	Creator   string    `json:"creator"`
+	TxHash    string    `json:"txHash"`
+	Block     string    `json:"block"`
+	Type      string    `json:"type"`
+	CodeHash  string    `json:"codeHash"`
+	CreatedAt time.Time `json:"timestamp"`
+}
+
+// CInternalTransfer is a call of a transaction trace which moves value.
+// TraceAddress is the position of the call in the trace, and Error the error
+// which reverted it.
+type CInternalTransfer struct {
+	ID           StringID  `json:"id"`
+	ChainID      string    `json:"chainID"`
+	TxHash       string    `json:"txHash"`
+	Block        string    `json:"block"`
+	TraceAddress []int     `json:"traceAddress"`
+	Type         string    `json:"type"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Value        string    `json:"value"`
+	Error        *string   `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"timestamp"`
+}
+
+// CDecodedCall is the method and arguments of a contract call, decoded with
+// the ABI named by ABI, the one of the contract or of a token standard.
+type CDecodedCall struct {
+	ABI       string         `json:"abi"`
+	Method    string         `json:"method"`
+	Signature string         `json:"signature"`
+	Args      []*CDecodedArg `json:"args"`
+}
+
+// CDecodedEvent is the event and fields of a log, decoded like a CDecodedCall.
+type CDecodedEvent struct {
+	ABI       string         `json:"abi"`
+	Event     string         `json:"event"`
+	Signature string         `json:"signature"`
+	Args      []*CDecodedArg `json:"args"`
+}
+
+// CDecodedArg is a decoded argument. The numbers are decimal strings and the
+// bytes hex strings, a tuple is a list of its fields, and an indexed event
+// field of a dynamic type is the hash of its value.
+type CDecodedArg struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Indexed bool        `json:"indexed,omitempty"`
+	Value   interface{} `json:"value"`
+}
+
 type AddressChainInfo struct {
 	Address   Address   `json:"address"`
 	ChainID   StringID  `json:"chainID"`
@@ -172,3 +434,20 @@ type ChainCounts struct {
 	ChainID StringID `json:"chainID"`
 	Total   string   `json:"total"`
 }
+
+// AtomicTransfer is an output exported from the source chain, keyed by its id,
+// with the import which consumes it on the destination chain. Latency is the
+// seconds from the export to the import.
+type AtomicTransfer struct {
+	ID               StringID    `json:"id"`
+	ExportTxID       StringID    `json:"exportTxID"`
+	ImportTxID       *StringID   `json:"importTxID"`
+	SourceChain      StringID    `json:"sourceChain"`
+	DestinationChain StringID    `json:"destinationChain"`
+	AssetID          StringID    `json:"assetID"`
+	Amount           TokenAmount `json:"amount"`
+	ExportedAt       *time.Time  `json:"exportedAt"`
+	ImportedAt       *time.Time  `json:"importedAt"`
+	Latency          *float64    `json:"latency,omitempty"`
+	Timestamp        time.Time   `json:"timestamp"`
+}
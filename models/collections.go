@@ -11,6 +11,9 @@ import (
 
 type ListMetadata struct {
 	Count *uint64 `json:"count,omitempty"`
+
+	// Next is the cursor to pass as the cursor param to list the next page.
+	Next *string `json:"next,omitempty"`
 }
 
 type TransactionList struct {
@@ -25,8 +28,6 @@ type TransactionList struct {
 	// EndTime is the calculated end time rounded to the nearest
 	// TransactionRoundDuration.
 	EndTime time.Time `json:"endTime"`
-
-	Next *string `json:"next,omitempty"`
 }
 
 type CvmTransactionsTxDataTrace struct {
@@ -48,9 +49,12 @@ type CvmTransactionsTxDataTrace struct {
 	RevertReason               *string `json:"revertReason,omitempty"`
 	RevertReasonUnpacked       *string `json:"revertReasonUnpacked,omitempty"`
 	TraceAddress               []int   `json:"traceAddress,omitempty"`
+
+	DecodedInput *CDecodedCall `json:"decodedInput,omitempty"`
 }
 
 type CTransactionData struct {
+	ChainID       string    `json:"chainID"`
 	Type          int       `json:"type"`
 	Block         string    `json:"block"`
 	Hash          string    `json:"hash"`
@@ -70,6 +74,8 @@ type CTransactionData struct {
 	ToAddr        string    `json:"toAddr"`
 	FromAddr      string    `json:"fromAddr"`
 
+	DecodedInput *CDecodedCall `json:"decodedInput,omitempty"`
+
 	// Signature values
 	V *string `json:"v,omitempty"`
 	R *string `json:"r,omitempty"`
@@ -81,6 +87,8 @@ type CTransactionData struct {
 }
 
 type CTransactionList struct {
+	ListMetadata
+
 	Transactions []*CTransactionData
 	// StartTime is the calculated start time rounded to the nearest
 	// TransactionRoundDuration.
@@ -96,6 +104,21 @@ type AssetList struct {
 	Assets []*Asset `json:"assets"`
 }
 
+type AssetHolderList struct {
+	ListMetadata
+	Holders []*AssetHolder `json:"holders"`
+}
+
+type NFTList struct {
+	ListMetadata
+	NFTs []*NFT `json:"nfts"`
+}
+
+type NFTTransferList struct {
+	ListMetadata
+	Transfers []*NFTTransfer `json:"transfers"`
+}
+
 type AddressList struct {
 	ListMetadata
 	Addresses []*AddressInfo `json:"addresses"`
@@ -172,6 +195,31 @@ type AggregatesHistogram struct {
 	EndTime time.Time `json:"endTime"`
 }
 
+// AddressHistory is the balance history of an address for an asset.
+type AddressHistory struct {
+	Address      Address                  `json:"address"`
+	AssetID      StringID                 `json:"assetID"`
+	IntervalSize time.Duration            `json:"intervalSize,omitempty"`
+	Intervals    []AddressHistoryInterval `json:"intervals"`
+
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+type AddressHistoryInterval struct {
+	// Idx is used internally when creating the intervals.
+	// It is exported only so it can be written to by dbr.
+	Idx int `json:"-"`
+
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+
+	// Balance is the balance at the end of the interval.
+	Balance  TokenAmount `json:"balance"`
+	Received TokenAmount `json:"received"`
+	Sent     TokenAmount `json:"sent"`
+}
+
 type Aggregates struct {
 	// Idx is used internally when creating a histogram of Aggregates.
 	// It is exported only so it can be written to by dbr.
@@ -200,3 +248,66 @@ type AssetAggregate struct {
 	Asset     ids.ID               `json:"asset"`
 	Aggregate *AggregatesHistogram `json:"aggregate"`
 }
+
+type ValidatorList struct {
+	ListMetadata
+	Validators []*Validator `json:"validators"`
+}
+
+type DelegatorList struct {
+	ListMetadata
+	Delegators []*Validator `json:"delegators"`
+}
+
+type SubnetList struct {
+	ListMetadata
+	Subnets []*Subnet `json:"subnets"`
+}
+
+type BlockchainList struct {
+	ListMetadata
+	Blockchains []*Blockchain `json:"blockchains"`
+}
+
+type CTokenList struct {
+	ListMetadata
+	Tokens []*CToken `json:"tokens"`
+}
+
+type CTokenTransferList struct {
+	ListMetadata
+	Transfers []*CTokenTransfer `json:"transfers"`
+}
+
+type CLogList struct {
+	ListMetadata
+	Logs []*CLog `json:"logs"`
+}
+
+type CInternalTransferList struct {
+	ListMetadata
+	Transfers []*CInternalTransfer `json:"transfers"`
+}
+
+type AtomicTransferList struct {
+	ListMetadata
+	Transfers []*AtomicTransfer `json:"transfers"`
+}
+
+type CTokenBalanceList struct {
+	Address  string           `json:"address"`
+	Balances []*CTokenBalance `json:"balances"`
+}
+
+type IndexedChainList struct {
+	Chains []*IndexedChain `json:"chains"`
+}
+
+type TxPoolMessageList struct {
+	Messages []*TxPoolMessage `json:"messages"`
+}
+
+type WebhookDeliveryList struct {
+	ListMetadata
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+}
@@ -45,6 +45,17 @@ var (
 	ResultTypeOutput      SearchResultType = "output"
 
 	TypeUnknown = "unknown"
+
+	CTokenTypeERC20  = "erc20"
+	CTokenTypeERC721 = "erc721"
+
+	CTokenEventTransfer = "transfer"
+	CTokenEventApproval = "approval"
+
+	CABIContract = "contract"
+	CABIERC20    = "erc20"
+	CABIERC721   = "erc721"
+	CABIERC1155  = "erc1155"
 )
 
 // BlockType represents a sub class of Block.
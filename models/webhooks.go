@@ -0,0 +1,59 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package models
+
+import (
+	"time"
+)
+
+// WebhookFilter selects the transactions delivered to a webhook.
+// Empty filters match every transaction.
+type WebhookFilter struct {
+	Addresses []string `json:"addresses,omitempty"`
+	AssetIDs  []string `json:"assetIDs,omitempty"`
+	ChainIDs  []string `json:"chainIDs,omitempty"`
+	Types     []string `json:"types,omitempty"`
+
+	// MinAmount is the amount an input or output matching the address and asset filters must reach.
+	MinAmount TokenAmount `json:"minAmount,omitempty"`
+}
+
+type Webhook struct {
+	ID     StringID      `json:"id"`
+	URL    string        `json:"url"`
+	Filter WebhookFilter `json:"filter"`
+
+	// Secret signs the payloads, it is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+
+	CreatedAt time.Time `json:"timestamp"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	ID            StringID              `json:"id"`
+	WebhookID     StringID              `json:"webhookID"`
+	TransactionID StringID              `json:"transactionID"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"nextAttemptAt"`
+	LastError     string                `json:"lastError,omitempty"`
+	CreatedAt     time.Time             `json:"timestamp"`
+	UpdatedAt     time.Time             `json:"updatedAt"`
+}
+
+// WebhookPayload is the body posted to a webhook.
+type WebhookPayload struct {
+	WebhookID   StringID     `json:"webhookID"`
+	DeliveryID  StringID     `json:"deliveryID"`
+	Transaction *Transaction `json:"transaction"`
+}
@@ -0,0 +1,208 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package modelsc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type namedABI struct {
+	name string
+	abi  *abi.ABI
+}
+
+var standardABIs = []*namedABI{
+	{name: models.CABIERC20, abi: mustParseABI(erc20ABI)},
+	{name: models.CABIERC721, abi: mustParseABI(erc721ABI)},
+	{name: models.CABIERC1155, abi: mustParseABI(erc1155ABI)},
+}
+
+// ParseABI parses the JSON ABI of a contract.
+func ParseABI(abiJSON string) (*abi.ABI, error) {
+	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	return &contractABI, nil
+}
+
+func mustParseABI(abiJSON string) *abi.ABI {
+	contractABI, err := ParseABI(abiJSON)
+	if err != nil {
+		panic(err)
+	}
+	return contractABI
+}
+
+// ABIDecoder decodes the calls and the events of contracts with their ABIs,
+// falling back to the ABIs of the token standards.
+type ABIDecoder struct {
+	contracts map[common.Address]*abi.ABI
+}
+
+func NewABIDecoder() *ABIDecoder {
+	return &ABIDecoder{contracts: make(map[common.Address]*abi.ABI)}
+}
+
+// AddContract sets the ABI of the contract at the address.
+func (d *ABIDecoder) AddContract(address common.Address, contractABI *abi.ABI) {
+	d.contracts[address] = contractABI
+}
+
+func (d *ABIDecoder) abis(address common.Address) []*namedABI {
+	contractABI, ok := d.contracts[address]
+	if !ok {
+		return standardABIs
+	}
+	return append([]*namedABI{{name: models.CABIContract, abi: contractABI}}, standardABIs...)
+}
+
+// DecodeCall decodes the input of a call to the contract at the address, it is
+// nil if no ABI has the method.
+func (d *ABIDecoder) DecodeCall(to common.Address, input []byte) *models.CDecodedCall {
+	if len(input) < 4 {
+		return nil
+	}
+	for _, na := range d.abis(to) {
+		method, err := na.abi.MethodById(input[:4])
+		if err != nil {
+			continue
+		}
+		vals, err := method.Inputs.Unpack(input[4:])
+		if err != nil {
+			continue
+		}
+		return &models.CDecodedCall{
+			ABI:       na.name,
+			Method:    method.RawName,
+			Signature: method.Sig,
+			Args:      decodeArgs(method.Inputs, vals),
+		}
+	}
+	return nil
+}
+
+// DecodeLog decodes the event of the log, it is nil if no ABI has the event.
+// The events of the standards which share a signature, like the Transfer of
+// ERC-20 and ERC-721, are told apart by their indexed fields.
+func (d *ABIDecoder) DecodeLog(txLog *types.Log) *models.CDecodedEvent {
+	if len(txLog.Topics) == 0 {
+		return nil
+	}
+	for _, na := range d.abis(txLog.Address) {
+		event, err := na.abi.EventByID(txLog.Topics[0])
+		if err != nil {
+			continue
+		}
+		args, ok := decodeEventArgs(event, txLog)
+		if !ok {
+			continue
+		}
+		return &models.CDecodedEvent{
+			ABI:       na.name,
+			Event:     event.RawName,
+			Signature: event.Sig,
+			Args:      args,
+		}
+	}
+	return nil
+}
+
+func decodeArgs(args abi.Arguments, vals []interface{}) []*models.CDecodedArg {
+	decoded := make([]*models.CDecodedArg, 0, len(args))
+	for i, arg := range args {
+		decoded = append(decoded, &models.CDecodedArg{
+			Name:  arg.Name,
+			Type:  arg.Type.String(),
+			Value: abiValue(arg.Type, reflect.ValueOf(vals[i])),
+		})
+	}
+	return decoded
+}
+
+func decodeEventArgs(event *abi.Event, txLog *types.Log) ([]*models.CDecodedArg, bool) {
+	topics := txLog.Topics[1:]
+	nonIndexed := event.Inputs.NonIndexed()
+	if len(topics) != len(event.Inputs)-len(nonIndexed) {
+		return nil, false
+	}
+	vals, err := nonIndexed.Unpack(txLog.Data)
+	if err != nil || len(vals) != len(nonIndexed) {
+		return nil, false
+	}
+
+	decoded := make([]*models.CDecodedArg, 0, len(event.Inputs))
+	for _, input := range event.Inputs {
+		arg := &models.CDecodedArg{
+			Name:    input.Name,
+			Type:    input.Type.String(),
+			Indexed: input.Indexed,
+		}
+		if input.Indexed {
+			arg.Value = topicValue(input.Type, topics[0])
+			topics = topics[1:]
+		} else {
+			arg.Value = abiValue(input.Type, reflect.ValueOf(vals[0]))
+			vals = vals[1:]
+		}
+		decoded = append(decoded, arg)
+	}
+	return decoded, true
+}
+
+// topicValue is the value of an indexed field, or the hash of the value for
+// the dynamic types.
+func topicValue(t abi.Type, topic common.Hash) interface{} {
+	switch t.T {
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy:
+		vals, err := abi.Arguments{{Type: t}}.Unpack(topic.Bytes())
+		if err == nil && len(vals) == 1 {
+			return abiValue(t, reflect.ValueOf(vals[0]))
+		}
+	}
+	return topic.Hex()
+}
+
+// abiValue converts an unpacked value for json, as described by CDecodedArg.
+func abiValue(t abi.Type, v reflect.Value) interface{} {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		return fmt.Sprint(v.Interface())
+	case abi.AddressTy:
+		address, _ := v.Interface().(common.Address)
+		return utils.CommonAddressHexRepair(&address)
+	case abi.BytesTy, abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		vals := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			vals = append(vals, abiValue(*t.Elem, v.Index(i)))
+		}
+		return vals
+	case abi.TupleTy:
+		v = reflect.Indirect(v)
+		fields := make([]*models.CDecodedArg, 0, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields = append(fields, &models.CDecodedArg{
+				Name:  t.TupleRawNames[i],
+				Type:  elem.String(),
+				Value: abiValue(*elem, v.Field(i)),
+			})
+		}
+		return fields
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,120 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package modelsc
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestABIDecoderCall(t *testing.T) {
+	token := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	to := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	input, err := standardABIs[0].abi.Pack("transfer", to, big.NewInt(1000))
+	if err != nil {
+		t.Fatal("pack failed", err)
+	}
+
+	d := NewABIDecoder()
+	call := d.DecodeCall(token, input)
+	if call == nil || call.ABI != models.CABIERC20 || call.Signature != "transfer(address,uint256)" {
+		t.Fatal("erc20 call", call)
+	}
+	if call.Args[0].Value != "0x2000000000000000000000000000000000000002" || call.Args[1].Value != "1000" {
+		t.Fatal("erc20 call args", call.Args[0].Value, call.Args[1].Value)
+	}
+
+	contractABI, err := ParseABI(`[{"type":"function","name":"set","inputs":[{"name":"p","type":"tuple","components":[{"name":"a","type":"uint8"},{"name":"b","type":"bytes2[]"}]}],"outputs":[]},
+{"type":"function","name":"move","inputs":[{"name":"dst","type":"address"},{"name":"wad","type":"uint256"}],"outputs":[]}]`)
+	if err != nil {
+		t.Fatal("parse failed", err)
+	}
+	d.AddContract(token, contractABI)
+
+	call = d.DecodeCall(token, input)
+	if call == nil || call.ABI != models.CABIERC20 {
+		t.Fatal("fallback call", call)
+	}
+
+	input, err = contractABI.Pack("set", struct {
+		A uint8
+		B [][2]byte
+	}{A: 7, B: [][2]byte{{0xab, 0xcd}}})
+	if err != nil {
+		t.Fatal("pack failed", err)
+	}
+	call = d.DecodeCall(token, input)
+	if call == nil || call.ABI != models.CABIContract || call.Method != "set" {
+		t.Fatal("contract call", call)
+	}
+	expected := []*models.CDecodedArg{
+		{Name: "a", Type: "uint8", Value: "7"},
+		{Name: "b", Type: "bytes2[]", Value: []interface{}{"0xabcd"}},
+	}
+	if !reflect.DeepEqual(call.Args[0].Value, expected) {
+		t.Fatal("contract call args", call.Args[0].Value)
+	}
+
+	if call = d.DecodeCall(to, input); call != nil {
+		t.Fatal("unknown call decoded", call)
+	}
+}
+
+func TestABIDecoderLog(t *testing.T) {
+	token := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	from := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	to := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	transfer := standardABIs[0].abi.Events["Transfer"].ID
+
+	d := NewABIDecoder()
+	event := d.DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{transfer, from.Hash(), to.Hash()},
+		Data:    common.BigToHash(big.NewInt(1000)).Bytes(),
+	})
+	if event == nil || event.ABI != models.CABIERC20 || event.Event != "Transfer" {
+		t.Fatal("erc20 event", event)
+	}
+	if event.Args[2].Name != "value" || event.Args[2].Value != "1000" || event.Args[2].Indexed {
+		t.Fatal("erc20 event args", event.Args[2])
+	}
+
+	event = d.DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{transfer, from.Hash(), to.Hash(), common.BigToHash(big.NewInt(7))},
+	})
+	if event == nil || event.ABI != models.CABIERC721 {
+		t.Fatal("erc721 event", event)
+	}
+	if event.Args[2].Name != "tokenId" || event.Args[2].Value != "7" || !event.Args[2].Indexed {
+		t.Fatal("erc721 event args", event.Args[2])
+	}
+	if event.Args[0].Value != "0x1000000000000000000000000000000000000001" {
+		t.Fatal("erc721 event from", event.Args[0].Value)
+	}
+
+	uri := standardABIs[2].abi.Events["URI"]
+	data, err := uri.Inputs.NonIndexed().Pack("ipfs://token")
+	if err != nil {
+		t.Fatal("pack failed", err)
+	}
+	event = d.DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{uri.ID, common.BigToHash(big.NewInt(9))},
+		Data:    data,
+	})
+	if event == nil || event.ABI != models.CABIERC1155 || event.Args[0].Value != "ipfs://token" || event.Args[1].Value != "9" {
+		t.Fatal("erc1155 event", event)
+	}
+
+	if event = d.DecodeLog(&types.Log{Topics: []common.Hash{transfer, from.Hash()}}); event != nil {
+		t.Fatal("invalid event decoded", event)
+	}
+}
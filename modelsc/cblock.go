@@ -36,6 +36,8 @@ type Call struct {
 	To      common.Address `json:"to"`
 	Value   *hexutil.Big   `json:"value"`
 	GasUsed *hexutil.Big   `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input,omitempty"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
 	Revert  bool           `json:"revert"`
 	Error   string         `json:"error,omitempty"`
 	Calls   []*Call        `json:"calls,omitempty"`
@@ -98,10 +100,17 @@ func Unmarshal(data []byte) (*Block, error) {
 	return &block, err
 }
 
+// TransactionTrace is the trace of the call at Idx of a transaction, with the
+// number of the block and the error of the transaction. Create is the call of a
+// contract creation transaction, without its calls, set on the first trace of
+// the transaction, which has no Trace when the creation made no call.
 type TransactionTrace struct {
-	Hash  string `json:"hash"`
-	Idx   uint32 `json:"idx"`
-	Trace []byte `json:"trace"`
+	Hash   string `json:"hash"`
+	Idx    uint32 `json:"idx"`
+	Trace  []byte `json:"trace"`
+	Block  string `json:"block,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Create *Call  `json:"create,omitempty"`
 }
 
 type Client struct {
@@ -137,6 +146,23 @@ func (c *Client) Close() {
 	c.rpcClient.Close()
 }
 
+func (c *Client) ReadHeader(blockNumber *big.Int, rpcTimeout time.Duration) (*types.Header, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ctx, cancelCTX := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancelCTX()
+	return c.ethClient.HeaderByNumber(ctx, blockNumber)
+}
+
+// CallContract runs the eth_call of the data on the contract at the latest block.
+func (c *Client) CallContract(to common.Address, data []byte, rpcTimeout time.Duration) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ctx, cancelCTX := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancelCTX()
+	return c.ethClient.CallContract(ctx, interfaces.CallMsg{To: &to, Data: data}, nil)
+}
+
 type BlockContainer struct {
 	Block  *types.Block
 	Traces []*TransactionTrace
@@ -160,6 +186,7 @@ func (c *Client) ReadBlock(blockNumber *big.Int, rpcTimeout time.Duration) (*Blo
 		tracerTimeout = "180s"
 	)
 
+	block := bl.Number().String()
 	txTraces := make([]*TransactionTrace, 0, len(bl.Transactions()))
 	for _, tx := range bl.Transactions() {
 		txh := tx.Hash().Hex()
@@ -174,6 +201,7 @@ func (c *Client) ReadBlock(blockNumber *big.Int, rpcTimeout time.Duration) (*Blo
 		if err := c.rpcClient.CallContext(ctx, &results, "debug_traceTransaction", txh, args); err != nil {
 			return nil, err
 		}
+		first := len(txTraces)
 		for ipos, result := range results.Calls {
 			traceBits, err := json.Marshal(result)
 			if err != nil {
@@ -184,9 +212,19 @@ func (c *Client) ReadBlock(blockNumber *big.Int, rpcTimeout time.Duration) (*Blo
 					Hash:  txh,
 					Idx:   uint32(ipos),
 					Trace: traceBits,
+					Block: block,
+					Error: results.Error,
 				},
 			)
 		}
+		if tx.To() == nil {
+			if len(results.Calls) == 0 {
+				txTraces = append(txTraces, &TransactionTrace{Hash: txh, Block: block, Error: results.Error})
+			}
+			create := results
+			create.Calls = nil
+			txTraces[first].Create = &create
+		}
 	}
 
 	blhash := bl.Hash()
@@ -104,10 +104,12 @@ func (replay *dbReplay) Start() error {
 		}
 	}
 
-	err = replay.handleCReader(replay.config.AXchainID, waitGroup, worker)
-	if err != nil {
-		log.Fatalln("reader failed", replay.config.AXchainID, ":", err.Error())
-		return err
+	for _, evmChain := range replay.config.AllEVMChains() {
+		err = replay.handleCReader(evmChain, waitGroup, worker)
+		if err != nil {
+			log.Fatalln("reader failed", evmChain.ID, ":", err.Error())
+			return err
+		}
 	}
 
 	timeLog := time.Now()
@@ -174,11 +176,17 @@ func (replay *dbReplay) Start() error {
 	return nil
 }
 
-func (replay *dbReplay) handleCReader(chain string, waitGroup *int64, worker utils.Worker) error {
+func (replay *dbReplay) handleCReader(evmChain cfg.EVMChain, waitGroup *int64, worker utils.Worker) error {
+	chain := evmChain.ID
 	writer, err := cvm.NewWriter(replay.config.NetworkID, chain)
 	if err != nil {
 		return err
 	}
+	client, err := modelsc.NewClient(evmChain.RPC)
+	if err != nil {
+		return err
+	}
+	writer.SetContractCaller(client)
 
 	err = replay.startAXchain(chain, waitGroup, worker, writer)
 	if err != nil {
@@ -0,0 +1,2 @@
+alter table `cvm_blocks` drop COLUMN `hash`;
+alter table `cvm_blocks` drop COLUMN `parent_hash`;
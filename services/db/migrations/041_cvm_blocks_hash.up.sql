@@ -0,0 +1,2 @@
+alter table `cvm_blocks` add COLUMN `hash` varchar(100) not null default '';
+alter table `cvm_blocks` add COLUMN `parent_hash` varchar(100) not null default '';
@@ -0,0 +1,4 @@
+drop index avm_outputs_redeeming_redeemed_at ON avm_outputs_redeeming;
+drop index avm_outputs_created_at ON avm_outputs;
+drop table `balance_snapshots`;
+drop table `address_balance_snapshots`;
@@ -0,0 +1,6 @@
+drop index transactions_rewards_owners_outputs_transaction_id ON transactions_rewards_owners_outputs;
+drop index transactions_validator_created_at ON transactions_validator;
+drop index transactions_validator_subnet_id ON transactions_validator;
+drop index transactions_validator_node_id ON transactions_validator;
+alter table `transactions_validator` DROP COLUMN `weight`;
+alter table `transactions_validator` DROP COLUMN `subnet_id`;
@@ -0,0 +1,3 @@
+drop table `blockchains`;
+drop table `subnet_control_keys`;
+drop table `subnets`;
@@ -0,0 +1,20 @@
+drop index cvm_logs_chain_id_block ON cvm_logs;
+alter table `cvm_logs` drop column `chain_id`;
+
+drop index cvm_transactions_txdata_trace_hash ON cvm_transactions_txdata_trace;
+alter table `cvm_transactions_txdata_trace` drop primary key, add primary key (hash, idx);
+alter table `cvm_transactions_txdata_trace` drop column `chain_id`;
+
+drop index cvm_transactions_txdata_chain_id_block ON cvm_transactions_txdata;
+drop index cvm_transactions_txdata_hash ON cvm_transactions_txdata;
+alter table `cvm_transactions_txdata` drop primary key, add primary key (hash);
+alter table `cvm_transactions_txdata` drop column `chain_id`;
+
+alter table `cvm_addresses` drop column `chain_id`;
+
+drop index cvm_transactions_chain_id_block ON cvm_transactions;
+alter table `cvm_transactions` drop primary key, add primary key (id);
+alter table `cvm_transactions` drop column `chain_id`;
+
+alter table `cvm_blocks` drop primary key, add primary key (block);
+alter table `cvm_blocks` drop column `chain_id`;
@@ -0,0 +1,10 @@
+create table `cvm_contracts`
+(
+    chain_id   varchar(50)  not null,
+    address    varchar(50)  not null,
+    name       varchar(256) not null,
+    abi        mediumtext   not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6),
+    primary key (chain_id, address)
+);
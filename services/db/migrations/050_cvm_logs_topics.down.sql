@@ -0,0 +1,12 @@
+drop index cvm_logs_topic3 ON cvm_logs;
+drop index cvm_logs_topic2 ON cvm_logs;
+drop index cvm_logs_topic1 ON cvm_logs;
+drop index cvm_logs_first_topic ON cvm_logs;
+drop index cvm_logs_address ON cvm_logs;
+drop index cvm_logs_chain_id_block ON cvm_logs;
+create index cvm_logs_chain_id_block on cvm_logs (chain_id, block);
+
+alter table `cvm_logs` drop column `topic3`;
+alter table `cvm_logs` drop column `topic2`;
+alter table `cvm_logs` drop column `topic1`;
+alter table `cvm_logs` drop column `address`;
@@ -0,0 +1,12 @@
+alter table `cvm_logs` add column `address` varchar(50) not null default '';
+alter table `cvm_logs` add column `topic1` varchar(100) not null default '';
+alter table `cvm_logs` add column `topic2` varchar(100) not null default '';
+alter table `cvm_logs` add column `topic3` varchar(100) not null default '';
+
+drop index cvm_logs_chain_id_block ON cvm_logs;
+create index cvm_logs_chain_id_block on cvm_logs (chain_id, block, log_index);
+create index cvm_logs_address on cvm_logs (chain_id, address, block, log_index);
+create index cvm_logs_first_topic on cvm_logs (chain_id, first_topic, block, log_index);
+create index cvm_logs_topic1 on cvm_logs (chain_id, topic1, block, log_index);
+create index cvm_logs_topic2 on cvm_logs (chain_id, topic2, block, log_index);
+create index cvm_logs_topic3 on cvm_logs (chain_id, topic3, block, log_index);
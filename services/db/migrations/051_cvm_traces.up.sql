@@ -0,0 +1,33 @@
+create table `cvm_contract_deployments`
+(
+    chain_id   varchar(50)  not null,
+    address    varchar(50)  not null,
+    creator    varchar(50)  not null,
+    tx_hash    varchar(100) not null,
+    block      decimal(65)  not null,
+    type       varchar(20)  not null,
+    code_hash  varchar(100) not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    primary key (chain_id, address)
+);
+create index cvm_contract_deployments_chain_id_block on cvm_contract_deployments (chain_id, block);
+create index cvm_contract_deployments_creator on cvm_contract_deployments (chain_id, creator, created_at);
+
+create table `cvm_internal_transfers`
+(
+    id            varchar(50)  not null primary key,
+    chain_id      varchar(50)  not null,
+    tx_hash       varchar(100) not null,
+    block         decimal(65)  not null,
+    trace_address varchar(256) not null,
+    type          varchar(20)  not null,
+    from_addr     varchar(50)  not null,
+    to_addr       varchar(50)  not null,
+    value         decimal(65)  not null,
+    error         varchar(256) not null,
+    created_at    timestamp(6) not null default current_timestamp(6)
+);
+create index cvm_internal_transfers_chain_id_block on cvm_internal_transfers (chain_id, block);
+create index cvm_internal_transfers_tx_hash on cvm_internal_transfers (tx_hash);
+create index cvm_internal_transfers_from_addr on cvm_internal_transfers (from_addr, created_at);
+create index cvm_internal_transfers_to_addr on cvm_internal_transfers (to_addr, created_at);
@@ -0,0 +1 @@
+drop table `atomic_transfers`;
@@ -0,0 +1 @@
+drop index `accumulate_balances_received_asset_id` on `accumulate_balances_received`;
@@ -0,0 +1 @@
+drop index `avm_outputs_asset_id_output_type_group_id` on `avm_outputs`;
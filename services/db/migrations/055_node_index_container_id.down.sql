@@ -0,0 +1 @@
+alter table `node_index` drop column `container_id`;
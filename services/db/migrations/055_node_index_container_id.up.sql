@@ -0,0 +1 @@
+alter table `node_index` add column `container_id` varchar(50) not null default '';
@@ -0,0 +1,2 @@
+alter table `tx_pool` add column `attempts` int unsigned not null default 0;
+alter table `tx_pool` add column `last_error` varchar(1024) not null default '';
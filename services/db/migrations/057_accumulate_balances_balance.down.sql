@@ -0,0 +1,2 @@
+drop index `accumulate_balances_received_asset_id_balance` on `accumulate_balances_received`;
+alter table `accumulate_balances_received` drop column `balance`;
@@ -0,0 +1,4 @@
+alter table `accumulate_balances_received` add column `balance` decimal(65) not null default 0;
+update `accumulate_balances_received` set balance = total_amount;
+update `accumulate_balances_received` r join `accumulate_balances_sent` s on r.id = s.id set r.balance = r.total_amount - s.total_amount;
+create index `accumulate_balances_received_asset_id_balance` on `accumulate_balances_received` (asset_id, balance, id);
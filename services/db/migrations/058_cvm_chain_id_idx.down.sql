@@ -0,0 +1,2 @@
+drop index cvm_logs_chain_id ON cvm_logs;
+drop index cvm_addresses_chain_id ON cvm_addresses;
@@ -0,0 +1,2 @@
+drop index tx_pool_processed_at ON tx_pool;
+alter table `tx_pool` drop column `processed_at`;
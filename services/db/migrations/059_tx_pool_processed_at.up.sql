@@ -0,0 +1,2 @@
+alter table `tx_pool` add column `processed_at` timestamp(6) null default null;
+create index tx_pool_processed_at on tx_pool (processed, processed_at, id);
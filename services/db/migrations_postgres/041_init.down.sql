@@ -0,0 +1,32 @@
+drop table if exists node_index;
+drop table if exists key_value_store;
+drop table if exists tx_pool;
+drop table if exists output_txs_accumulate;
+drop table if exists output_addresses_accumulate_in;
+drop table if exists output_addresses_accumulate_out;
+drop table if exists accumulate_balances_transactions;
+drop table if exists accumulate_balances_sent;
+drop table if exists accumulate_balances_received;
+drop table if exists cvm_logs;
+drop table if exists cvm_transactions_txdata_trace;
+drop table if exists cvm_transactions_txdata;
+drop table if exists cvm_addresses;
+drop table if exists cvm_transactions;
+drop table if exists cvm_blocks;
+drop table if exists transactions_rewards_owners_outputs;
+drop table if exists transactions_rewards_owners_address;
+drop table if exists transactions_rewards_owners;
+drop table if exists transactions_block;
+drop table if exists transactions_validator;
+drop table if exists transactions_epoch;
+drop table if exists rewards;
+drop table if exists pvm_proposer;
+drop table if exists pvm_blocks;
+drop table if exists addresses_bech32;
+drop table if exists address_chain;
+drop table if exists addresses;
+drop table if exists avm_transactions;
+drop table if exists avm_outputs_redeeming;
+drop table if exists avm_output_addresses;
+drop table if exists avm_outputs;
+drop table if exists avm_assets;
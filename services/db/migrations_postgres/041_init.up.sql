@@ -0,0 +1,386 @@
+-- Postgres schema, equivalent to the mysql migrations 001 through 041.
+-- Later migrations share their version number with the mysql set.
+
+create table avm_assets
+(
+    id             varchar(50)  not null primary key,
+    chain_id       varchar(50)  not null,
+    name           varchar(255) not null,
+    symbol         varchar(255) not null,
+    alias          varchar(255) not null,
+    denomination   smallint     not null,
+    current_supply numeric(40)  not null,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+
+create table avm_outputs
+(
+    id             varchar(50)  not null primary key,
+    chain_id       varchar(50)  not null,
+    transaction_id varchar(50)  not null,
+    output_index   integer      not null,
+    asset_id       varchar(50)  not null,
+    output_type    bigint       not null,
+    amount         numeric(20)  not null,
+    locktime       numeric(20)  not null,
+    threshold      bigint       not null,
+    group_id       numeric(20)  default 0,
+    payload        bytea,
+    stake_locktime numeric(20)  not null default 0,
+    stake          boolean      default false,
+    frozen         boolean      default false,
+    stakeableout   boolean      default false,
+    genesisutxo    boolean      default false,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+create unique index avm_outputs_tx_id_output_idx on avm_outputs (transaction_id, output_index);
+create index avm_outputs_chain_id_id on avm_outputs (chain_id, id);
+create index avm_outputs_asset_id_output_type on avm_outputs (asset_id, output_type);
+create index avm_outputs_chain_id_created_at on avm_outputs (chain_id, created_at desc);
+create index avm_outputs_chain_id_asset_id_created_at on avm_outputs (chain_id, asset_id, created_at desc);
+create index avm_outputs_transaction_id_asset_id_output_type on avm_outputs (transaction_id, asset_id, output_type);
+
+create table avm_output_addresses
+(
+    output_id           varchar(50)  not null,
+    address             varchar(50)  not null,
+    redeeming_signature bytea        null,
+    created_at          timestamp(6) not null default current_timestamp(6),
+    updated_at          timestamp(6) not null default current_timestamp(6)
+);
+create index avm_output_addresses_output_id on avm_output_addresses (output_id);
+create unique index avm_output_addresses_output_id_addr on avm_output_addresses (output_id, address);
+create index avm_output_addresses_address on avm_output_addresses (address);
+create index avm_output_addresses_created_at on avm_output_addresses (created_at);
+
+create table avm_outputs_redeeming
+(
+    id                       varchar(50)  not null primary key,
+    redeemed_at              timestamp(6) not null default current_timestamp(6),
+    redeeming_transaction_id varchar(50)  not null,
+    amount                   numeric(20)  not null,
+    output_index             bigint       not null,
+    intx                     varchar(50)  default null,
+    asset_id                 varchar(50)  default null,
+    chain_id                 varchar(50)  default '',
+    created_at               timestamp(6) not null default current_timestamp(6)
+);
+create index avm_outputs_redeeming_redeeming_transaction_id on avm_outputs_redeeming (redeeming_transaction_id);
+
+create table avm_transactions
+(
+    id                      varchar(50)  not null primary key,
+    chain_id                varchar(50)  not null,
+    type                    varchar(255) not null,
+    memo                    bytea,
+    canonical_serialization bytea,
+    txfee                   numeric(20)  default 0,
+    genesis                 boolean      default false,
+    network_id              bigint       default 0,
+    created_at              timestamp(6) not null default current_timestamp(6)
+);
+create index avm_transactions_chain_id_created_at on avm_transactions (chain_id, created_at desc);
+create index avm_transactions_created_at_chain_id_idx on avm_transactions (created_at, chain_id);
+
+create table addresses
+(
+    address    varchar(50)  not null primary key,
+    public_key bytea        null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6)
+);
+
+create table address_chain
+(
+    address    varchar(50)  not null,
+    chain_id   varchar(50)  not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6),
+    primary key (address, chain_id)
+);
+
+create table addresses_bech32
+(
+    address        varchar(50)  not null primary key,
+    bech32_address varchar(100),
+    updated_at     timestamp(6) not null default current_timestamp(6)
+);
+create index addresses_bech32_bech32_address on addresses_bech32 (bech32_address);
+
+create table pvm_blocks
+(
+    id            varchar(50)  not null primary key,
+    type          smallint     not null,
+    parent_id     varchar(50)  not null,
+    chain_id      varchar(50)  not null,
+    serialization bytea,
+    height        numeric(20)  not null default 0,
+    created_at    timestamp(6) not null default current_timestamp(6)
+);
+create index pvm_blocks_parent_id on pvm_blocks (parent_id);
+create index pvm_blocks_height on pvm_blocks (height);
+
+create table pvm_proposer
+(
+    id              varchar(50)  not null primary key,
+    parent_id       varchar(50),
+    blk_id          varchar(50),
+    proposer_blk_id varchar(50),
+    p_chain_height  numeric(20),
+    proposer        varchar(50),
+    time_stamp      timestamp(6) not null default current_timestamp(6),
+    created_at      timestamp(6) not null default current_timestamp(6)
+);
+create index pvm_proposer_blk_id on pvm_proposer (blk_id);
+create index pvm_proposer_proposer_blk_id on pvm_proposer (proposer_blk_id);
+
+create table rewards
+(
+    id                 varchar(50)  not null primary key,
+    block_id           varchar(50)  not null,
+    txid               varchar(50)  not null,
+    shouldprefercommit boolean      default false,
+    processed          smallint     default 0,
+    created_at         timestamp(6) not null default current_timestamp(6)
+);
+create index rewards_block_id on rewards (block_id);
+create index rewards_txid on rewards (txid);
+create index rewards_processed on rewards (processed, created_at);
+
+create table transactions_epoch
+(
+    id         varchar(50)  not null primary key,
+    epoch      bigint       not null default 0,
+    vertex_id  varchar(50)  default null,
+    created_at timestamp(6) not null default current_timestamp(6)
+);
+
+create table transactions_validator
+(
+    id         varchar(50)  not null primary key,
+    node_id    varchar(50)  default '',
+    start      numeric(20)  default 0,
+    "end"      numeric(20)  default 0,
+    created_at timestamp(6) not null default current_timestamp(6)
+);
+
+create table transactions_block
+(
+    id          varchar(50)  not null primary key,
+    tx_block_id varchar(50)  default '',
+    created_at  timestamp(6) not null default current_timestamp(6)
+);
+
+create table transactions_rewards_owners
+(
+    id         varchar(50)  not null primary key,
+    chain_id   varchar(50)  not null,
+    locktime   numeric(20)  not null,
+    threshold  bigint       not null,
+    created_at timestamp(6) not null default current_timestamp(6)
+);
+
+create table transactions_rewards_owners_address
+(
+    id           varchar(50)  not null,
+    address      varchar(50)  not null,
+    output_index integer      not null,
+    updated_at   timestamp(6) not null default current_timestamp(6),
+    primary key (id, address)
+);
+create index transactions_rewards_owners_address_address on transactions_rewards_owners_address (address);
+
+create table transactions_rewards_owners_outputs
+(
+    id             varchar(50)  not null primary key,
+    transaction_id varchar(50)  not null,
+    output_index   bigint       not null,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+
+create table cvm_blocks
+(
+    block       numeric(65)  not null primary key,
+    hash        varchar(100) not null default '',
+    parent_hash varchar(100) not null default '',
+    created_at  timestamp(6) not null default current_timestamp(6)
+);
+
+create table cvm_transactions
+(
+    id             varchar(50)  not null primary key,
+    transaction_id varchar(50)  not null default '',
+    type           smallint     not null,
+    blockchain_id  varchar(50)  not null,
+    block          numeric(65)  not null,
+    serialization  bytea,
+    tx_time        timestamp(6) not null default current_timestamp(6),
+    nonce          numeric(20)  not null default 0,
+    hash           varchar(100) not null default '',
+    parent_hash    varchar(100) not null default '',
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+create index cvm_transactions_block on cvm_transactions (block);
+create index cvm_transactions_transaction_id on cvm_transactions (transaction_id);
+create index cvm_transactions_hash on cvm_transactions (hash);
+create index cvm_transactions_parent_hash on cvm_transactions (parent_hash);
+
+create table cvm_addresses
+(
+    id             varchar(50)  not null primary key,
+    type           smallint     not null,
+    idx            bigint       not null,
+    transaction_id varchar(50)  not null,
+    address        varchar(50)  not null,
+    asset_id       varchar(50)  not null,
+    amount         numeric(20)  not null default 0,
+    nonce          numeric(20)  not null default 0,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+create index cvm_address_transaction_id on cvm_addresses (transaction_id);
+
+create table cvm_transactions_txdata
+(
+    hash          varchar(100) not null primary key,
+    block         numeric(65)  not null,
+    idx           numeric(20)  not null,
+    rcpt          varchar(50)  not null,
+    nonce         numeric(20)  not null,
+    serialization bytea,
+    created_at    timestamp(6) not null default current_timestamp(6)
+);
+create index cvm_transactions_txdata_block on cvm_transactions_txdata (block, idx);
+create index cvm_transactions_txdata_rcpt_created_at on cvm_transactions_txdata (rcpt, created_at);
+
+create table cvm_transactions_txdata_trace
+(
+    hash          varchar(100) not null,
+    idx           numeric(20)  not null,
+    to_addr       varchar(50)  not null,
+    from_addr     varchar(50)  not null,
+    call_type     varchar(255) not null,
+    type          varchar(255) not null,
+    serialization bytea,
+    created_at    timestamp(6) not null default current_timestamp(6),
+    primary key (hash, idx)
+);
+create index cvm_transactions_txdata_trace_from_addr_created_at on cvm_transactions_txdata_trace (from_addr, created_at);
+create index cvm_transactions_txdata_trace_to_addr_created_at on cvm_transactions_txdata_trace (to_addr, created_at);
+
+create table cvm_logs
+(
+    id            varchar(50)  not null primary key,
+    block_hash    varchar(100) not null,
+    tx_hash       varchar(100) not null,
+    log_index     numeric(20)  not null,
+    first_topic   varchar(256) not null,
+    block         numeric(65)  not null,
+    removed       boolean      not null,
+    serialization bytea,
+    created_at    timestamp(6) not null default current_timestamp(6)
+);
+create index cvm_logs_block on cvm_logs (block);
+create index cvm_logs_block_hash on cvm_logs (block_hash);
+
+create table accumulate_balances_received
+(
+    id           varchar(50)  not null primary key,
+    chain_id     varchar(50)  not null,
+    asset_id     varchar(50)  not null,
+    address      varchar(50)  not null,
+    total_amount numeric(65)  not null default 0,
+    utxo_count   numeric(65)  not null default 0,
+    updated_at   timestamp(6) not null default current_timestamp(6)
+);
+create index accumulate_balances_received_address_chain_id on accumulate_balances_received (address, chain_id);
+create index accumulate_balances_received_chain_id on accumulate_balances_received (chain_id);
+
+create table accumulate_balances_sent
+(
+    id           varchar(50)  not null primary key,
+    chain_id     varchar(50)  not null,
+    asset_id     varchar(50)  not null,
+    address      varchar(50)  not null,
+    total_amount numeric(65)  not null default 0,
+    utxo_count   numeric(65)  not null default 0,
+    updated_at   timestamp(6) not null default current_timestamp(6)
+);
+
+create table accumulate_balances_transactions
+(
+    id                varchar(50)  not null primary key,
+    chain_id          varchar(50)  not null,
+    asset_id          varchar(50)  not null,
+    address           varchar(50)  not null,
+    transaction_count numeric(65)  not null default 0,
+    updated_at        timestamp(6) not null default current_timestamp(6)
+);
+
+create table output_addresses_accumulate_out
+(
+    id             varchar(50)  not null primary key,
+    output_id      varchar(50)  not null,
+    address        varchar(50)  not null,
+    processed      smallint     not null default 0,
+    transaction_id varchar(50)  not null default '',
+    output_index   integer      not null default 0,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+create index output_addresses_accumulate_out_processed on output_addresses_accumulate_out (processed asc, created_at asc);
+create index output_addresses_accumulate_out_output_id on output_addresses_accumulate_out (output_id asc);
+
+create table output_addresses_accumulate_in
+(
+    id               varchar(50)  not null primary key,
+    output_id        varchar(50)  not null,
+    address          varchar(50)  not null,
+    processed        smallint     not null default 0,
+    output_processed smallint     not null default 0,
+    transaction_id   varchar(50)  not null default '',
+    output_index     integer      not null default 0,
+    created_at       timestamp(6) not null default current_timestamp(6)
+);
+create index output_addresses_accumulate_in_processed on output_addresses_accumulate_in (processed asc, created_at asc);
+create index output_addresses_accumulate_in_output_processed on output_addresses_accumulate_in (output_processed asc, processed asc, created_at asc);
+create index output_addresses_accumulate_in_output_id on output_addresses_accumulate_in (output_id asc);
+
+create table output_txs_accumulate
+(
+    id             varchar(50)  not null primary key,
+    chain_id       varchar(50)  not null,
+    asset_id       varchar(50)  not null,
+    address        varchar(50)  not null,
+    transaction_id varchar(50)  not null,
+    processed      smallint     not null default 0,
+    created_at     timestamp(6) not null default current_timestamp(6)
+);
+create index output_txs_accumulate_processed on output_txs_accumulate (processed asc, created_at asc);
+
+create table tx_pool
+(
+    id            varchar(50)  not null primary key,
+    network_id    bigint       default 0,
+    chain_id      varchar(50)  not null,
+    msg_key       varchar(50)  not null,
+    serialization bytea,
+    processed     smallint     not null default 0,
+    topic         varchar(100) not null,
+    created_at    timestamp(6) not null default current_timestamp(6)
+);
+create index tx_pool_processed on tx_pool (topic, processed, created_at asc);
+create index tx_pool_msg_key on tx_pool (msg_key);
+create index tx_pool_processed_created_at on tx_pool (processed, created_at asc);
+
+create table key_value_store
+(
+    k varchar(256)  not null primary key,
+    v varchar(1000) not null
+);
+
+create table node_index
+(
+    instance varchar(100) not null,
+    topic    varchar(100) not null,
+    idx      numeric(20)  default 0,
+    primary key (instance, topic)
+);
@@ -0,0 +1,25 @@
+create table webhooks
+(
+    id         varchar(50)   not null primary key,
+    url        varchar(2048) not null,
+    secret     varchar(128)  not null,
+    filter     text          not null,
+    created_at timestamp(6)  not null default current_timestamp(6),
+    updated_at timestamp(6)  not null default current_timestamp(6)
+);
+
+create table webhook_deliveries
+(
+    id              varchar(50)   not null primary key,
+    webhook_id      varchar(50)   not null,
+    tx_id           varchar(50)   not null,
+    payload         bytea         not null,
+    status          smallint      not null default 0,
+    attempts        integer       not null default 0,
+    next_attempt_at timestamp(6)  not null default current_timestamp(6),
+    last_error      varchar(1024) not null default '',
+    created_at      timestamp(6)  not null default current_timestamp(6),
+    updated_at      timestamp(6)  not null default current_timestamp(6)
+);
+create index webhook_deliveries_status_next_attempt_at on webhook_deliveries (status, next_attempt_at);
+create index webhook_deliveries_webhook_id_created_at on webhook_deliveries (webhook_id, created_at);
@@ -0,0 +1,4 @@
+drop index avm_outputs_redeeming_redeemed_at;
+drop index avm_outputs_created_at;
+drop table balance_snapshots;
+drop table address_balance_snapshots;
@@ -0,0 +1,21 @@
+create table address_balance_snapshots
+(
+    id          varchar(50)  not null primary key,
+    chain_id    varchar(50)  not null,
+    address     varchar(50)  not null,
+    asset_id    varchar(50)  not null,
+    balance     numeric(65)  not null default 0,
+    utxo_count  bigint       not null default 0,
+    snapshot_at timestamp(6) not null,
+    created_at  timestamp(6) not null default current_timestamp(6)
+);
+create index address_balance_snapshots_address_snapshot_at on address_balance_snapshots (address, snapshot_at);
+
+create table balance_snapshots
+(
+    snapshot_at timestamp(6) not null primary key,
+    created_at  timestamp(6) not null default current_timestamp(6)
+);
+
+create index avm_outputs_created_at on avm_outputs (created_at);
+create index avm_outputs_redeeming_redeemed_at on avm_outputs_redeeming (redeemed_at);
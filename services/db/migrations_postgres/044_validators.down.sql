@@ -0,0 +1,6 @@
+drop index transactions_rewards_owners_outputs_transaction_id;
+drop index transactions_validator_created_at;
+drop index transactions_validator_subnet_id;
+drop index transactions_validator_node_id;
+alter table transactions_validator drop column weight;
+alter table transactions_validator drop column subnet_id;
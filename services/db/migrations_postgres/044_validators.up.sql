@@ -0,0 +1,12 @@
+alter table transactions_validator add column subnet_id varchar(50) not null default '11111111111111111111111111111111LpoYY';
+alter table transactions_validator add column weight numeric(20) not null default 0;
+
+update transactions_validator set weight = (
+    select coalesce(sum(avm_outputs.amount), 0) from avm_outputs
+    where avm_outputs.transaction_id = transactions_validator.id and avm_outputs.stake = true
+);
+
+create index transactions_validator_node_id on transactions_validator (node_id);
+create index transactions_validator_subnet_id on transactions_validator (subnet_id, created_at);
+create index transactions_validator_created_at on transactions_validator (created_at);
+create index transactions_rewards_owners_outputs_transaction_id on transactions_rewards_owners_outputs (transaction_id);
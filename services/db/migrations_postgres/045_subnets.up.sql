@@ -0,0 +1,30 @@
+create table subnets
+(
+    id         varchar(50)  not null primary key,
+    threshold  bigint       not null,
+    locktime   numeric(20)  not null,
+    created_at timestamp(6) not null default current_timestamp(6)
+);
+create index subnets_created_at on subnets (created_at);
+
+create table subnet_control_keys
+(
+    subnet_id  varchar(50)  not null,
+    address    varchar(50)  not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    primary key (subnet_id, address)
+);
+create index subnet_control_keys_address on subnet_control_keys (address);
+
+create table blockchains
+(
+    id           varchar(50)  not null primary key,
+    subnet_id    varchar(50)  not null,
+    name         varchar(255) not null,
+    vm_id        varchar(50)  not null,
+    genesis_hash varchar(64)  not null,
+    created_at   timestamp(6) not null default current_timestamp(6)
+);
+create index blockchains_subnet_id on blockchains (subnet_id);
+create index blockchains_vm_id on blockchains (vm_id);
+create index blockchains_created_at on blockchains (created_at);
@@ -0,0 +1,7 @@
+create table chains
+(
+    id         varchar(50)  not null primary key,
+    vm_type    varchar(50)  not null,
+    created_at timestamp(6) not null default current_timestamp(6),
+    updated_at timestamp(6) not null default current_timestamp(6)
+);
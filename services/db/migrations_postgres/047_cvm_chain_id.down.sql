@@ -0,0 +1,20 @@
+drop index cvm_logs_chain_id_block;
+alter table cvm_logs drop column chain_id;
+
+drop index cvm_transactions_txdata_trace_hash;
+alter table cvm_transactions_txdata_trace drop constraint cvm_transactions_txdata_trace_pkey, add primary key (hash, idx);
+alter table cvm_transactions_txdata_trace drop column chain_id;
+
+drop index cvm_transactions_txdata_chain_id_block;
+drop index cvm_transactions_txdata_hash;
+alter table cvm_transactions_txdata drop constraint cvm_transactions_txdata_pkey, add primary key (hash);
+alter table cvm_transactions_txdata drop column chain_id;
+
+alter table cvm_addresses drop column chain_id;
+
+drop index cvm_transactions_chain_id_block;
+alter table cvm_transactions drop constraint cvm_transactions_pkey, add primary key (id);
+alter table cvm_transactions drop column chain_id;
+
+alter table cvm_blocks drop constraint cvm_blocks_pkey, add primary key (block);
+alter table cvm_blocks drop column chain_id;
@@ -0,0 +1,20 @@
+alter table cvm_blocks add column chain_id varchar(50) not null default '';
+alter table cvm_blocks drop constraint cvm_blocks_pkey, add primary key (chain_id, block);
+
+alter table cvm_transactions add column chain_id varchar(50) not null default '';
+alter table cvm_transactions drop constraint cvm_transactions_pkey, add primary key (chain_id, id);
+create index cvm_transactions_chain_id_block on cvm_transactions (chain_id, block);
+
+alter table cvm_addresses add column chain_id varchar(50) not null default '';
+
+alter table cvm_transactions_txdata add column chain_id varchar(50) not null default '';
+alter table cvm_transactions_txdata drop constraint cvm_transactions_txdata_pkey, add primary key (chain_id, hash);
+create index cvm_transactions_txdata_hash on cvm_transactions_txdata (hash);
+create index cvm_transactions_txdata_chain_id_block on cvm_transactions_txdata (chain_id, block, idx);
+
+alter table cvm_transactions_txdata_trace add column chain_id varchar(50) not null default '';
+alter table cvm_transactions_txdata_trace drop constraint cvm_transactions_txdata_trace_pkey, add primary key (chain_id, hash, idx);
+create index cvm_transactions_txdata_trace_hash on cvm_transactions_txdata_trace (hash, idx);
+
+alter table cvm_logs add column chain_id varchar(50) not null default '';
+create index cvm_logs_chain_id_block on cvm_logs (chain_id, block);
@@ -0,0 +1,2 @@
+drop table cvm_token_transfers;
+drop table cvm_tokens;
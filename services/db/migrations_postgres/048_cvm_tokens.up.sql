@@ -0,0 +1,32 @@
+create table cvm_tokens
+(
+    chain_id   varchar(50)       not null,
+    address    varchar(50)       not null,
+    type       varchar(10)       not null,
+    name       varchar(256)      not null,
+    symbol     varchar(256)      not null,
+    decimals   smallint          not null,
+    created_at timestamp(6)      not null default current_timestamp(6),
+    primary key (chain_id, address)
+);
+create index cvm_tokens_created_at on cvm_tokens (created_at);
+
+create table cvm_token_transfers
+(
+    id         varchar(50)     not null primary key,
+    chain_id   varchar(50)     not null,
+    address    varchar(50)     not null,
+    event      varchar(10)     not null,
+    tx_hash    varchar(100)    not null,
+    block      numeric(65)     not null,
+    log_index  bigint          not null,
+    from_addr  varchar(50)     not null,
+    to_addr    varchar(50)     not null,
+    amount     numeric(65)     not null,
+    value      varchar(80)     not null,
+    created_at timestamp(6)    not null default current_timestamp(6)
+);
+create index cvm_token_transfers_chain_id_block on cvm_token_transfers (chain_id, block);
+create index cvm_token_transfers_address_created_at on cvm_token_transfers (chain_id, address, created_at);
+create index cvm_token_transfers_from_addr on cvm_token_transfers (from_addr, created_at);
+create index cvm_token_transfers_to_addr on cvm_token_transfers (to_addr, created_at);
@@ -0,0 +1 @@
+drop table cvm_contracts;
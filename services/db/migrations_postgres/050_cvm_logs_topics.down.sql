@@ -0,0 +1,12 @@
+drop index cvm_logs_topic3;
+drop index cvm_logs_topic2;
+drop index cvm_logs_topic1;
+drop index cvm_logs_first_topic;
+drop index cvm_logs_address;
+drop index cvm_logs_chain_id_block;
+create index cvm_logs_chain_id_block on cvm_logs (chain_id, block);
+
+alter table cvm_logs drop column topic3;
+alter table cvm_logs drop column topic2;
+alter table cvm_logs drop column topic1;
+alter table cvm_logs drop column address;
@@ -0,0 +1,2 @@
+drop table cvm_internal_transfers;
+drop table cvm_contract_deployments;
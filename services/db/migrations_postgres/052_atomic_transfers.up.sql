@@ -0,0 +1,36 @@
+create table atomic_transfers
+(
+    id                varchar(50)     not null primary key,
+    export_tx_id      varchar(50)     not null,
+    source_chain      varchar(50)     not null,
+    destination_chain varchar(50)     not null,
+    asset_id          varchar(50)     not null,
+    amount            bigint          not null,
+    exported_at       timestamp(6)    null,
+    import_tx_id      varchar(50)     not null default '',
+    imported_at       timestamp(6)    null,
+    created_at        timestamp(6)    not null default current_timestamp(6)
+);
+create index atomic_transfers_created_at on atomic_transfers (created_at);
+create index atomic_transfers_export_tx_id on atomic_transfers (export_tx_id);
+create index atomic_transfers_import_tx_id on atomic_transfers (import_tx_id, created_at);
+create index atomic_transfers_source_chain on atomic_transfers (source_chain, created_at);
+create index atomic_transfers_destination_chain on atomic_transfers (destination_chain, created_at);
+
+-- the exported outputs are indexed on their destination chain
+insert into atomic_transfers (id, export_tx_id, source_chain, destination_chain, asset_id, amount, exported_at, import_tx_id, imported_at, created_at)
+select avm_outputs.id, avm_outputs.transaction_id, avm_transactions.chain_id, avm_outputs.chain_id, avm_outputs.asset_id, avm_outputs.amount, avm_outputs.created_at,
+       coalesce(avm_outputs_redeeming.redeeming_transaction_id, ''), avm_outputs_redeeming.redeemed_at, avm_outputs.created_at
+from avm_outputs
+join avm_transactions on avm_transactions.id = avm_outputs.transaction_id
+left join avm_outputs_redeeming on avm_outputs_redeeming.id = avm_outputs.id
+where avm_outputs.chain_id <> avm_transactions.chain_id;
+
+-- the imported inputs are indexed on their source chain, with the exports which are not indexed
+insert into atomic_transfers (id, export_tx_id, source_chain, destination_chain, asset_id, amount, exported_at, import_tx_id, imported_at, created_at)
+select avm_outputs_redeeming.id, avm_outputs_redeeming.intx, avm_outputs_redeeming.chain_id, avm_transactions.chain_id, avm_outputs_redeeming.asset_id, avm_outputs_redeeming.amount, cast(null as timestamp),
+       avm_outputs_redeeming.redeeming_transaction_id, avm_outputs_redeeming.redeemed_at, avm_outputs_redeeming.redeemed_at
+from avm_outputs_redeeming
+join avm_transactions on avm_transactions.id = avm_outputs_redeeming.redeeming_transaction_id
+left join avm_outputs on avm_outputs.id = avm_outputs_redeeming.id
+where avm_outputs_redeeming.chain_id <> avm_transactions.chain_id and avm_outputs.id is null;
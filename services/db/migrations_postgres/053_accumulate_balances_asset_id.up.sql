@@ -0,0 +1 @@
+create index accumulate_balances_received_asset_id on accumulate_balances_received (asset_id);
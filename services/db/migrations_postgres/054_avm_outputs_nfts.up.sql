@@ -0,0 +1 @@
+create index avm_outputs_asset_id_output_type_group_id on avm_outputs (asset_id, output_type, group_id);
@@ -0,0 +1,2 @@
+alter table tx_pool drop column last_error;
+alter table tx_pool drop column attempts;
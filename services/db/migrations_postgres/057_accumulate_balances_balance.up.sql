@@ -0,0 +1,4 @@
+alter table accumulate_balances_received add column balance numeric(65) not null default 0;
+update accumulate_balances_received set balance = total_amount;
+update accumulate_balances_received r set balance = r.total_amount - s.total_amount from accumulate_balances_sent s where r.id = s.id;
+create index accumulate_balances_received_asset_id_balance on accumulate_balances_received (asset_id, balance, id);
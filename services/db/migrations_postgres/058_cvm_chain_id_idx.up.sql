@@ -0,0 +1,2 @@
+create index cvm_addresses_chain_id on cvm_addresses (chain_id, id);
+create index cvm_logs_chain_id on cvm_logs (chain_id, id);
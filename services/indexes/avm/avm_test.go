@@ -5,7 +5,6 @@ package avm
 
 import (
 	"context"
-	"fmt"
 	"net/url"
 	"testing"
 	"time"
@@ -337,13 +336,12 @@ func TestTransactionNext(t *testing.T) {
 		t.Fatal("invalid transactions")
 	}
 
-	n, _ := url.ParseQuery(*tl.Next)
-
-	if n[params.KeyStartTime][0] != fmt.Sprintf("%d", tnow3.Unix()) {
-		t.Fatal("invalid next starttime")
+	if tl.Next == nil {
+		t.Fatal("invalid next")
 	}
-	if n[params.KeySortBy][0] != params.TransactionSortTimestampAscStr {
-		t.Fatal("invalid sort")
+	n := url.Values{
+		params.KeyCursor: []string{*tl.Next},
+		params.KeySortBy: []string{params.TransactionSortTimestampAscStr},
 	}
 
 	_ = tp.ForValues(0, n)
@@ -368,13 +366,12 @@ func TestTransactionNext(t *testing.T) {
 		t.Fatal("invalid transactions")
 	}
 
-	n, _ = url.ParseQuery(*tl.Next)
-
-	if n[params.KeyEndTime][0] != fmt.Sprintf("%d", tnow3.Unix()) {
-		t.Fatal("invalid next endtime")
+	if tl.Next == nil {
+		t.Fatal("invalid next")
 	}
-	if n[params.KeySortBy][0] != params.TransactionSortTimestampDescStr {
-		t.Fatal("invalid sort")
+	n = url.Values{
+		params.KeyCursor: []string{*tl.Next},
+		params.KeySortBy: []string{params.TransactionSortTimestampDescStr},
 	}
 
 	_ = tp.ForValues(0, n)
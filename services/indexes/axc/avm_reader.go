@@ -31,11 +31,19 @@ func (r *Reader) ListAssets(ctx context.Context, p *params.ListAssetsParams, con
 	_, err = p.Apply(dbRunner.
 		Select("id", "chain_id", "name", "symbol", "alias", "denomination", "current_supply", "created_at").
 		From("avm_assets")).
+		OrderAsc("avm_assets.created_at").
+		OrderAsc("avm_assets.id").
 		LoadContext(ctx, &assets)
 	if err != nil {
 		return nil, err
 	}
 
+	var next *string
+	if len(assets) != 0 {
+		last := assets[len(assets)-1]
+		next = p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(assets), p.ListParams.Limit)
+	}
+
 	// Add all the addition information we might want
 	if err = r.dressAssets(ctx, dbRunner, assets); err != nil {
 		return nil, err
@@ -56,7 +64,7 @@ func (r *Reader) ListAssets(ctx context.Context, p *params.ListAssetsParams, con
 		}
 	}
 
-	return &models.AssetList{ListMetadata: models.ListMetadata{Count: count}, Assets: assets}, nil
+	return &models.AssetList{ListMetadata: models.ListMetadata{Count: count, Next: next}, Assets: assets}, nil
 }
 
 func (r *Reader) GetAsset(ctx context.Context, p *params.ListAssetsParams, idStrOrAlias string) (*models.Asset, error) {
@@ -72,10 +80,21 @@ func (r *Reader) GetAsset(ctx context.Context, p *params.ListAssetsParams, idStr
 	if err != nil {
 		return nil, err
 	}
-	if len(assetList.Assets) > 0 {
-		return assetList.Assets[0], nil
+	if len(assetList.Assets) == 0 {
+		return nil, err
+	}
+
+	asset := assetList.Assets[0]
+	if r.sc.IsAccumulateBalanceReader {
+		dbRunner, err := r.conns.DB().NewSession("get_asset_holders", cfg.RequestTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if asset.Holders, err = r.assetHolders(ctx, dbRunner, asset.ID); err != nil {
+			return nil, err
+		}
 	}
-	return nil, err
+	return asset, nil
 }
 
 func (r *Reader) dressAssets(ctx context.Context, dbRunner dbr.SessionRunner, assets []*models.Asset) error {
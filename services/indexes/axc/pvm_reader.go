@@ -12,7 +12,7 @@ import (
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
 )
 
-func (r *Reader) ListBlocks(ctx context.Context, params *params.ListBlocksParams) (*models.BlockList, error) {
+func (r *Reader) ListBlocks(ctx context.Context, p *params.ListBlocksParams) (*models.BlockList, error) {
 	dbRunner, err := r.conns.DB().NewSession("list_blocks", cfg.RequestTimeout)
 	if err != nil {
 		return nil, err
@@ -20,15 +20,24 @@ func (r *Reader) ListBlocks(ctx context.Context, params *params.ListBlocksParams
 
 	blocks := []*models.Block{}
 
-	_, err = params.Apply(dbRunner.
+	_, err = p.Apply(dbRunner.
 		Select("id", "type", "parent_id", "chain_id", "created_at").
 		From("pvm_blocks")).
+		OrderAsc("pvm_blocks.created_at").
+		OrderAsc("pvm_blocks.id").
 		LoadContext(ctx, &blocks)
 
 	if err != nil {
 		return nil, err
 	}
-	return &models.BlockList{Blocks: blocks}, nil
+
+	var next *string
+	if len(blocks) != 0 {
+		last := blocks[len(blocks)-1]
+		next = p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(blocks), p.ListParams.Limit)
+	}
+
+	return &models.BlockList{ListMetadata: models.ListMetadata{Next: next}, Blocks: blocks}, nil
 }
 
 func (r *Reader) GetBlock(ctx context.Context, id ids.ID) (*models.Block, error) {
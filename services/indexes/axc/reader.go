@@ -27,6 +27,7 @@ import (
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
 	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
 	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gocraft/dbr/v2"
 )
 
@@ -189,15 +190,9 @@ func (r *Reader) TxfeeAggregate(ctx context.Context, params *params.TxfeeAggrega
 
 	// Ensure the interval count requested isn't too large
 	intervalSeconds := int64(params.IntervalSize.Seconds())
-	requestedIntervalCount := 0
-	if intervalSeconds != 0 {
-		requestedIntervalCount = int(math.Ceil(params.ListParams.EndTime.Sub(params.ListParams.StartTime).Seconds() / params.IntervalSize.Seconds()))
-		if requestedIntervalCount > MaxAggregateIntervalCount {
-			return nil, ErrAggregateIntervalCountTooLarge
-		}
-		if requestedIntervalCount < 1 {
-			requestedIntervalCount = 1
-		}
+	requestedIntervalCount, err := aggregateIntervalCount(params.ListParams.StartTime, params.ListParams.EndTime, params.IntervalSize)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build the query and load the base data
@@ -209,14 +204,11 @@ func (r *Reader) TxfeeAggregate(ctx context.Context, params *params.TxfeeAggrega
 	var builder *dbr.SelectStmt
 
 	columns := []string{
-		"CAST(COALESCE(SUM(avm_transactions.txfee), 0) AS CHAR) AS txfee",
+		db.CastStringAs(dbRunner, "COALESCE(SUM(avm_transactions.txfee), 0)", "txfee"),
 	}
 
 	if requestedIntervalCount > 0 {
-		columns = append(columns, fmt.Sprintf(
-			"FLOOR((UNIX_TIMESTAMP(avm_transactions.created_at)-%d) / %d) AS idx",
-			params.ListParams.StartTime.Unix(),
-			intervalSeconds))
+		columns = append(columns, aggregateIntervalIdx(dbRunner, "avm_transactions.created_at", params.ListParams.StartTime, intervalSeconds))
 	}
 
 	builder = dbRunner.
@@ -268,11 +260,8 @@ func (r *Reader) TxfeeAggregate(ctx context.Context, params *params.TxfeeAggrega
 	// We also add the start and end times of each interval to that interval
 	aggs := &models.TxfeeAggregatesHistogram{IntervalSize: params.IntervalSize}
 
-	var startTS int64
 	timesForInterval := func(intervalIdx int) (time.Time, time.Time) {
-		startTS = params.ListParams.StartTime.Unix() + (int64(intervalIdx) * intervalSeconds)
-		return time.Unix(startTS, 0).UTC(),
-			time.Unix(startTS+intervalSeconds-1, 0).UTC()
+		return aggregateIntervalTimes(params.ListParams.StartTime, intervalSeconds, intervalIdx)
 	}
 
 	padTo := func(slice []models.TxfeeAggregates, to int) []models.TxfeeAggregates {
@@ -339,19 +328,12 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams,
 
 	// Ensure the interval count requested isn't too large
 	intervalSeconds := int64(params.IntervalSize.Seconds())
-	requestedIntervalCount := 0
-	if intervalSeconds != 0 {
-		requestedIntervalCount = int(math.Ceil(params.ListParams.EndTime.Sub(params.ListParams.StartTime).Seconds() / params.IntervalSize.Seconds()))
-		if requestedIntervalCount > MaxAggregateIntervalCount {
-			return nil, ErrAggregateIntervalCountTooLarge
-		}
-		if requestedIntervalCount < 1 {
-			requestedIntervalCount = 1
-		}
+	requestedIntervalCount, err := aggregateIntervalCount(params.ListParams.StartTime, params.ListParams.EndTime, params.IntervalSize)
+	if err != nil {
+		return nil, err
 	}
 
 	var dbRunner *dbr.Session
-	var err error
 
 	if conns != nil {
 		dbRunner = conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("get_transaction_aggregates_histogram"))
@@ -374,10 +356,7 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams,
 	}
 
 	if requestedIntervalCount > 0 {
-		columns = append(columns, fmt.Sprintf(
-			"FLOOR((UNIX_TIMESTAMP(avm_outputs.created_at)-%d) / %d) AS idx",
-			params.ListParams.StartTime.Unix(),
-			intervalSeconds))
+		columns = append(columns, aggregateIntervalIdx(dbRunner, "avm_outputs.created_at", params.ListParams.StartTime, intervalSeconds))
 	}
 
 	builder = dbRunner.
@@ -434,11 +413,8 @@ func (r *Reader) Aggregate(ctx context.Context, params *params.AggregateParams,
 	// We also add the start and end times of each interval to that interval
 	aggs := &models.AggregatesHistogram{IntervalSize: params.IntervalSize}
 
-	var startTS int64
 	timesForInterval := func(intervalIdx int) (time.Time, time.Time) {
-		startTS = params.ListParams.StartTime.Unix() + (int64(intervalIdx) * intervalSeconds)
-		return time.Unix(startTS, 0).UTC(),
-			time.Unix(startTS+intervalSeconds-1, 0).UTC()
+		return aggregateIntervalTimes(params.ListParams.StartTime, intervalSeconds, intervalIdx)
 	}
 
 	padTo := func(slice []models.Aggregates, to int) []models.Aggregates {
@@ -536,6 +512,7 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 			OrderAsc("accumulate_balances_received.chain_id").
 			OrderAsc("accumulate_balances_received.address").
 			OrderAsc("accumulate_balances_received.asset_id"), true)
+		p.ListParams.ApplyCursor(baseq, "accumulate_balances_received.chain_id", "accumulate_balances_received.address", false)
 	} else {
 		ua = dbRunner.Select("avm_outputs.chain_id", "avm_output_addresses.address").
 			Distinct().
@@ -558,9 +535,10 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 			From("avm_outputs").
 			LeftJoin("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id").
 			LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id").
-			Where("avm_output_addresses.address in ?", dbRunner.Select(
+			Where("(avm_outputs.chain_id, avm_output_addresses.address) in ?", dbRunner.Select(
+				"avm_outputs_ua.chain_id",
 				"avm_outputs_ua.address",
-			).From(p.Apply(ua, false).As("avm_outputs_ua"))).
+			).From(p.ListParams.ApplyCursor(p.Apply(ua, false), "avm_outputs.chain_id", "avm_output_addresses.address", false).As("avm_outputs_ua"))).
 			GroupBy("avm_outputs.chain_id", "avm_output_addresses.address", "avm_outputs.asset_id").
 			OrderAsc("avm_outputs.chain_id").
 			OrderAsc("avm_output_addresses.address").
@@ -582,7 +560,10 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 		"avm_outputs_j.utxo_count",
 		"addresses.public_key",
 	).From(baseq.As("avm_outputs_j")).
-		LeftJoin("addresses", "addresses.address = avm_outputs_j.address")
+		LeftJoin("addresses", "addresses.address = avm_outputs_j.address").
+		OrderAsc("avm_outputs_j.chain_id").
+		OrderAsc("avm_outputs_j.address").
+		OrderAsc("avm_outputs_j.asset_id")
 
 	_, err = builder.
 		LoadContext(ctx, &rows)
@@ -590,6 +571,23 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 		return nil, err
 	}
 
+	// The accumulate query limits rows, not addresses, so a full page may end
+	// part way through the assets of its last address.
+	// Leave that address to the next page.
+	pageSize := len(rows)
+	if r.sc.IsAccumulateBalanceReader && p.ListParams.Limit > 0 && pageSize >= p.ListParams.Limit {
+		last := rows[pageSize-1]
+		trimmed := rows
+		for len(trimmed) != 0 &&
+			trimmed[len(trimmed)-1].ChainID == last.ChainID &&
+			trimmed[len(trimmed)-1].Address == last.Address {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if len(trimmed) != 0 {
+			rows = trimmed
+		}
+	}
+
 	addresses := make([]*models.AddressInfo, 0, len(rows))
 
 	addrsByID := make(map[string]*models.AddressInfo)
@@ -605,11 +603,18 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 				Assets:    make(map[models.StringID]models.AssetInfo),
 			}
 			addrsByID[k] = addr
+			addresses = append(addresses, addr)
 		}
 		addr.Assets[row.AssetID] = row.AssetInfo
 	}
-	for _, addr := range addrsByID {
-		addresses = append(addresses, addr)
+
+	if !r.sc.IsAccumulateBalanceReader {
+		pageSize = len(addresses)
+	}
+	var next *string
+	if len(addresses) != 0 {
+		last := addresses[len(addresses)-1]
+		next = p.ListParams.NewCursor(string(last.ChainID), string(last.Address)).Next(pageSize, p.ListParams.Limit)
 	}
 
 	var count *uint64
@@ -629,7 +634,7 @@ func (r *Reader) ListAddresses(ctx context.Context, p *params.ListAddressesParam
 		}
 	}
 
-	return &models.AddressList{ListMetadata: models.ListMetadata{Count: count}, Addresses: addresses}, nil
+	return &models.AddressList{ListMetadata: models.ListMetadata{Count: count, Next: next}, Addresses: addresses}, nil
 }
 
 func (r *Reader) ListOutputs(ctx context.Context, p *params.ListOutputsParams) (*models.OutputList, error) {
@@ -643,6 +648,8 @@ func (r *Reader) ListOutputs(ctx context.Context, p *params.ListOutputsParams) (
 		Select(outputSelectColumns...).
 		From("avm_outputs").
 		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id")).
+		OrderAsc("avm_outputs.created_at").
+		OrderAsc("avm_outputs.id").
 		LoadContext(ctx, &outputs)
 	if err != nil {
 		return nil, err
@@ -652,6 +659,9 @@ func (r *Reader) ListOutputs(ctx context.Context, p *params.ListOutputsParams) (
 		return &models.OutputList{Outputs: outputs}, nil
 	}
 
+	last := outputs[len(outputs)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(outputs), p.ListParams.Limit)
+
 	outputIDs := make([]models.StringID, len(outputs))
 	outputMap := make(map[models.StringID]*models.Output, len(outputs))
 	for i, output := range outputs {
@@ -698,7 +708,7 @@ func (r *Reader) ListOutputs(ctx context.Context, p *params.ListOutputsParams) (
 		}
 	}
 
-	return &models.OutputList{ListMetadata: models.ListMetadata{Count: count}, Outputs: outputs}, err
+	return &models.OutputList{ListMetadata: models.ListMetadata{Count: count, Next: next}, Outputs: outputs}, err
 }
 
 func (r *Reader) GetTransaction(ctx context.Context, id ids.ID, axcAssetID ids.ID) (*models.Transaction, error) {
@@ -714,6 +724,42 @@ func (r *Reader) GetTransaction(ctx context.Context, id ids.ID, axcAssetID ids.I
 	return nil, nil
 }
 
+// GetIndexedTransactions returns the transactions committed with the container
+// the indexer keyed by id, which is the tx id on the X chain and the block id on the P chain.
+func (r *Reader) GetIndexedTransactions(ctx context.Context, id ids.ID, axcAssetID ids.ID) ([]*models.Transaction, error) {
+	dbRunner, err := r.conns.DB().NewSession("get_indexed_transactions", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var txIDs []string
+	_, err = dbRunner.
+		Select("id").
+		From(db.TableTransactionsBlock).
+		Where("tx_block_id=?", id.String()).
+		LoadContext(ctx, &txIDs)
+	if err != nil {
+		return nil, err
+	}
+	txIDs = append([]string{id.String()}, txIDs...)
+
+	txs := make([]*models.Transaction, 0, len(txIDs))
+	for _, txIDStr := range txIDs {
+		txID, err := ids.FromString(txIDStr)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := r.GetTransaction(ctx, txID, axcAssetID)
+		if err != nil {
+			return nil, err
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
 func (r *Reader) GetAddress(ctx context.Context, p *params.ListAddressesParams) (*models.AddressInfo, error) {
 	addressList, err := r.ListAddresses(ctx, p)
 	if err != nil {
@@ -796,6 +842,38 @@ func (r *Reader) AddressChains(ctx context.Context, p *params.AddressChainsParam
 	return &resp, nil
 }
 
+// aggregateIntervalCount returns the count of the intervals of the size
+// between the times, or 0 for a single aggregate of the whole range.
+func aggregateIntervalCount(startTime time.Time, endTime time.Time, intervalSize time.Duration) (int, error) {
+	if int64(intervalSize.Seconds()) == 0 {
+		return 0, nil
+	}
+	intervalCount := int(math.Ceil(endTime.Sub(startTime).Seconds() / intervalSize.Seconds()))
+	if intervalCount > MaxAggregateIntervalCount {
+		return 0, ErrAggregateIntervalCountTooLarge
+	}
+	if intervalCount < 1 {
+		intervalCount = 1
+	}
+	return intervalCount, nil
+}
+
+// aggregateIntervalIdx selects the index of the interval the time column falls in as idx.
+func aggregateIntervalIdx(sess dbr.SessionRunner, col string, startTime time.Time, intervalSeconds int64) string {
+	return fmt.Sprintf(
+		"FLOOR((%s-%d) / %d) AS idx",
+		db.UnixTimestamp(sess, col),
+		startTime.Unix(),
+		intervalSeconds)
+}
+
+// aggregateIntervalTimes returns the first and last second of the interval.
+func aggregateIntervalTimes(startTime time.Time, intervalSeconds int64, intervalIdx int) (time.Time, time.Time) {
+	startTS := startTime.Unix() + (int64(intervalIdx) * intervalSeconds)
+	return time.Unix(startTS, 0).UTC(),
+		time.Unix(startTS+intervalSeconds-1, 0).UTC()
+}
+
 func (r *Reader) getFirstTransactionTime(ctx context.Context, chainIDs []string) (time.Time, error) {
 	dbRunner, err := r.conns.DB().NewSession("get_first_transaction_time", cfg.RequestTimeout)
 	if err != nil {
@@ -804,7 +882,7 @@ func (r *Reader) getFirstTransactionTime(ctx context.Context, chainIDs []string)
 
 	var ts float64
 	builder := dbRunner.
-		Select("COALESCE(UNIX_TIMESTAMP(MIN(created_at)), 0)").
+		Select("COALESCE(" + db.UnixTimestamp(dbRunner, "MIN(created_at)") + ", 0)").
 		From("avm_transactions")
 
 	if len(chainIDs) > 0 {
@@ -933,6 +1011,23 @@ func (r *Reader) chainWriter(chainID string) (services.Consumer, error) {
 	return nil, fmt.Errorf("unimplemented")
 }
 
+// AddChainConsumer attaches the consumer of a chain registered at runtime.
+func (r *Reader) AddChainConsumer(chainID string, consumer services.Consumer) {
+	r.avmLock.Lock()
+	defer r.avmLock.Unlock()
+	if r.chainConsumers == nil {
+		r.chainConsumers = make(map[string]services.Consumer)
+	}
+	r.chainConsumers[chainID] = consumer
+}
+
+// RemoveChainConsumer detaches the consumer of a chain registered at runtime.
+func (r *Reader) RemoveChainConsumer(chainID string) {
+	r.avmLock.Lock()
+	defer r.avmLock.Unlock()
+	delete(r.chainConsumers, chainID)
+}
+
 func (r *Reader) ATxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, error) {
 	dbRunner, err := r.conns.DB().NewSession("atx_data", cfg.RequestTimeout)
 	if err != nil {
@@ -1053,12 +1148,18 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 
 	rows := []Row{}
 
+	// the block numbers overlap between the EVM chains, the AX chain is the default
+	chainID := p.ChainID
+	if chainID == "" {
+		chainID = r.sc.ServicesCfg.AXchainID
+	}
+
 	idInt, ok := big.NewInt(0).SetString(p.ID, 10)
 	if idInt != nil && ok {
 		_, err = dbRunner.
 			Select("serialization").
 			From("cvm_transactions").
-			Where("block="+idInt.String()).
+			Where("chain_id=? and block="+idInt.String(), chainID).
 			Limit(1).
 			LoadContext(ctx, &rows)
 		if err != nil {
@@ -1073,12 +1174,13 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 		sq := dbRunner.
 			Select("block").
 			From("cvm_transactions_txdata").
-			Where("hash=? or rcpt=?", h, h)
+			Where("chain_id=? and (hash=? or rcpt=?)", chainID, h, h)
 
 		_, err = dbRunner.
 			Select("serialization").
 			From("cvm_transactions").
-			Where("hash=? or block in ?",
+			Where("chain_id=? and (hash=? or block in ?)",
+				chainID,
 				h,
 				dbRunner.Select("block").From(sq.As("sq")),
 			).
@@ -1109,6 +1211,10 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 		BlockExtraID   string                   `json:"blockExtraID"`
 		Txs            []corethType.Transaction `json:"transactions,omitempty"`
 		Logs           []corethType.Log         `json:"logs,omitempty"`
+
+		// the decoded inputs keyed by transaction hash, and logs by log index
+		DecodedInputs map[string]*models.CDecodedCall `json:"decodedInputs,omitempty"`
+		DecodedLogs   map[uint]*models.CDecodedEvent  `json:"decodedLogs,omitempty"`
 	}
 
 	unserializedBlock, err := modelsc.Unmarshal(row.Serialization)
@@ -1155,7 +1261,7 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 			"serialization",
 		).
 		From("cvm_transactions_txdata").
-		Where("block="+block.Header.Number.String()).
+		Where("chain_id=? and block="+block.Header.Number.String(), chainID).
 		OrderAsc("idx").
 		LoadContext(ctx, &rowsData)
 	if err != nil {
@@ -1182,7 +1288,7 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 			"serialization",
 		).
 		From(db.TableCvmLogs).
-		Where("block="+block.Header.Number.String()).
+		Where("chain_id=? and block="+block.Header.Number.String(), chainID).
 		LoadContext(ctx, &rowsLog)
 	if err != nil {
 		return nil, err
@@ -1202,6 +1308,35 @@ func (r *Reader) CTxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 		return block.Logs[i].Index < block.Logs[j].Index
 	})
 
+	addresses := make([]common.Address, 0, len(block.Txs)+len(block.Logs))
+	for _, tx := range block.Txs {
+		if tx.To() != nil && len(tx.Data()) != 0 {
+			addresses = append(addresses, *tx.To())
+		}
+	}
+	for _, clog := range block.Logs {
+		addresses = append(addresses, clog.Address)
+	}
+	decoder, err := newCABIDecoder(ctx, dbRunner, chainID, addresses)
+	if err != nil {
+		return nil, err
+	}
+	block.DecodedInputs = make(map[string]*models.CDecodedCall)
+	for _, tx := range block.Txs {
+		if tx.To() == nil {
+			continue
+		}
+		if decoded := decoder.DecodeCall(*tx.To(), tx.Data()); decoded != nil {
+			block.DecodedInputs[tx.Hash().Hex()] = decoded
+		}
+	}
+	block.DecodedLogs = make(map[uint]*models.CDecodedEvent)
+	for i := range block.Logs {
+		if decoded := decoder.DecodeLog(&block.Logs[i]); decoded != nil {
+			block.DecodedLogs[block.Logs[i].Index] = decoded
+		}
+	}
+
 	return json.Marshal(block)
 }
 
@@ -1216,12 +1351,17 @@ func (r *Reader) ETxDATA(ctx context.Context, p *params.TxDataParam) ([]byte, er
 	}
 	rows := []Row{}
 
+	chainID := p.ChainID
+	if chainID == "" {
+		chainID = r.sc.ServicesCfg.AXchainID
+	}
+
 	idInt, ok := big.NewInt(0).SetString(p.ID, 10)
 	if idInt != nil && ok {
 		_, err = dbRunner.
 			Select("serialization").
 			From(db.TableCvmTransactions).
-			Where("block="+idInt.String()).
+			Where("chain_id=? and block="+idInt.String(), chainID).
 			LoadContext(ctx, &rows)
 		if err != nil {
 			return nil, err
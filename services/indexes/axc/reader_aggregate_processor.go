@@ -306,7 +306,7 @@ func (r *Reader) addressCounts(ctx context.Context, sess *dbr.Session) {
 	var addressCountl []*models.ChainCounts
 	_, err := sess.Select(
 		"chain_id",
-		"cast(count(*) as char) as total",
+		db.CastStringAs(sess, "count(*)", "total"),
 	).
 		From(db.TableAddressChain).
 		GroupBy("chain_id").
@@ -333,7 +333,7 @@ func (r *Reader) txCounts(ctx context.Context, sess *dbr.Session) {
 	var txCountl []*models.ChainCounts
 	_, err := sess.Select(
 		"chain_id",
-		"cast(count(*) as char) as total",
+		db.CastStringAs(sess, "count(*)", "total"),
 	).
 		From(db.TableTransactions).
 		GroupBy("chain_id").
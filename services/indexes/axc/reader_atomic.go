@@ -0,0 +1,77 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+// ListAtomicTransfers lists the outputs exported between the chains, with the
+// imports which consume them.
+func (r *Reader) ListAtomicTransfers(ctx context.Context, p *params.ListAtomicTransfersParams) (*models.AtomicTransferList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_atomic_transfers", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*db.AtomicTransfers
+	_, err = p.Apply(dbRunner.
+		Select(
+			db.TableAtomicTransfers+".id",
+			db.TableAtomicTransfers+".export_tx_id",
+			db.TableAtomicTransfers+".source_chain",
+			db.TableAtomicTransfers+".destination_chain",
+			db.TableAtomicTransfers+".asset_id",
+			db.TableAtomicTransfers+".amount",
+			db.TableAtomicTransfers+".exported_at",
+			db.TableAtomicTransfers+".import_tx_id",
+			db.TableAtomicTransfers+".imported_at",
+			db.TableAtomicTransfers+".created_at",
+		).
+		From(db.TableAtomicTransfers)).
+		OrderDesc(db.TableAtomicTransfers+".created_at").
+		OrderDesc(db.TableAtomicTransfers+".id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*models.AtomicTransfer, 0, len(rows))
+	for _, row := range rows {
+		transfer := &models.AtomicTransfer{
+			ID:               models.StringID(row.ID),
+			ExportTxID:       models.StringID(row.ExportTxID),
+			SourceChain:      models.StringID(row.SourceChain),
+			DestinationChain: models.StringID(row.DestinationChain),
+			AssetID:          models.StringID(row.AssetID),
+			Amount:           models.TokenAmountForUint64(row.Amount),
+			ExportedAt:       row.ExportedAt,
+			ImportedAt:       row.ImportedAt,
+			Timestamp:        row.CreatedAt,
+		}
+		if row.ImportTxID != "" {
+			importTxID := models.StringID(row.ImportTxID)
+			transfer.ImportTxID = &importTxID
+		}
+		if row.ExportedAt != nil && row.ImportedAt != nil {
+			latency := row.ImportedAt.Sub(*row.ExportedAt).Seconds()
+			transfer.Latency = &latency
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if len(rows) < 1 {
+		return &models.AtomicTransferList{Transfers: transfers}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, last.ID).Next(len(rows), p.ListParams.Limit)
+
+	return &models.AtomicTransferList{ListMetadata: models.ListMetadata{Next: next}, Transfers: transfers}, nil
+}
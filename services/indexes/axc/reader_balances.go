@@ -0,0 +1,108 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+// GetAddressBalances returns the balances of the address as of p.AsOf, from
+// the outputs created before it which were not redeemed before it.
+// The balances start from the latest snapshot before p.AsOf, only the
+// outputs since the snapshot are read.
+func (r *Reader) GetAddressBalances(ctx context.Context, p *params.AddressBalancesParams) (*models.AddressBalances, error) {
+	dbRunner, err := r.conns.DB().NewSession("get_address_balances", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	persist := db.NewPersist()
+	address := p.Address.String()
+
+	snapshot, err := persist.QueryLatestBalanceSnapshots(ctx, dbRunner, p.AsOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var start time.Time
+	balances := make(map[string]*db.AddressBalanceSnapshots)
+	if snapshot != nil {
+		start = snapshot.SnapshotAt
+		previous, err := persist.QueryAddressBalanceSnapshots(ctx, dbRunner, &db.AddressBalanceSnapshotsFilter{
+			Addresses: []string{address},
+			ChainIDs:  p.ChainIDs,
+			Before:    start,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range previous {
+			balances[db.AddressBalanceKey(b.ChainID, b.Address, b.AssetID)] = b
+		}
+	}
+
+	changes, err := persist.QueryAddressBalanceChanges(ctx, dbRunner, &db.AddressBalanceChangesFilter{
+		Addresses: []string{address},
+		ChainIDs:  p.ChainIDs,
+		Start:     start,
+		End:       p.AsOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		key := db.AddressBalanceKey(c.ChainID, c.Address, c.AssetID)
+		b, ok := balances[key]
+		if !ok {
+			b = &db.AddressBalanceSnapshots{
+				ChainID: c.ChainID,
+				Address: c.Address,
+				AssetID: c.AssetID,
+				Balance: "0",
+			}
+			balances[key] = b
+		}
+		if err := b.Apply(c); err != nil {
+			return nil, err
+		}
+	}
+
+	// collate the balances of the asset across the chains
+	addressBalances := &models.AddressBalances{
+		Address: models.Address(address),
+		AsOf:    p.AsOf,
+		Assets:  make(map[models.StringID]models.AddressAssetBalance),
+	}
+	for _, b := range balances {
+		if b.UtxoCount == 0 {
+			continue
+		}
+		assetID := models.StringID(b.AssetID)
+		if p.AssetID != nil && b.AssetID != p.AssetID.String() {
+			continue
+		}
+		balance, ok := new(big.Int).SetString(b.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %s", b.Balance)
+		}
+		assetBalance, ok := addressBalances.Assets[assetID]
+		if ok {
+			total, _ := new(big.Int).SetString(string(assetBalance.Balance), 10)
+			balance.Add(balance, total)
+		}
+		assetBalance.AssetID = assetID
+		assetBalance.UTXOCount += b.UtxoCount
+		assetBalance.Balance = models.TokenAmount(balance.String())
+		addressBalances.Assets[assetID] = assetBalance
+	}
+	return addressBalances, nil
+}
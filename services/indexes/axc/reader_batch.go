@@ -0,0 +1,125 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+// The batch getters load a set of ids in one round of queries, for callers
+// resolving many nested objects at once. Missing ids are left out of the results.
+
+func (r *Reader) GetTransactions(ctx context.Context, txIDs []string, axcAssetID ids.ID) ([]*models.Transaction, error) {
+	if len(txIDs) == 0 {
+		return nil, nil
+	}
+
+	dbRunner, err := r.conns.DB().NewSession("get_transactions_batch", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []*models.Transaction
+	_, err = transactionQuery(dbRunner).
+		Where("avm_transactions.id IN ?", txIDs).
+		OrderAsc("avm_transactions.created_at").
+		OrderAsc("avm_transactions.id").
+		LoadContext(ctx, &txs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dressTransactions(ctx, dbRunner, txs, axcAssetID, nil, false); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (r *Reader) GetAssets(ctx context.Context, assetIDs []string) ([]*models.Asset, error) {
+	if len(assetIDs) == 0 {
+		return nil, nil
+	}
+
+	dbRunner, err := r.conns.DB().NewSession("get_assets_batch", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*models.Asset
+	_, err = dbRunner.
+		Select("id", "chain_id", "name", "symbol", "alias", "denomination", "current_supply", "created_at").
+		From("avm_assets").
+		Where("avm_assets.id IN ?", assetIDs).
+		OrderAsc("avm_assets.created_at").
+		OrderAsc("avm_assets.id").
+		LoadContext(ctx, &assets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.dressAssets(ctx, dbRunner, assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+func (r *Reader) GetBlocks(ctx context.Context, blockIDs []string) ([]*models.Block, error) {
+	if len(blockIDs) == 0 {
+		return nil, nil
+	}
+
+	dbRunner, err := r.conns.DB().NewSession("get_blocks_batch", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*models.Block
+	_, err = dbRunner.
+		Select("id", "type", "parent_id", "chain_id", "created_at").
+		From("pvm_blocks").
+		Where("pvm_blocks.id IN ?", blockIDs).
+		OrderAsc("pvm_blocks.created_at").
+		OrderAsc("pvm_blocks.id").
+		LoadContext(ctx, &blocks)
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// GetAddresses returns the addresses with their assets collated across the
+// chainIDs, or all chains, as GetAddress does for a single address.
+func (r *Reader) GetAddresses(ctx context.Context, addrs []ids.ShortID, chainIDs []string) ([]*models.AddressInfo, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	addressList, err := r.ListAddresses(ctx, &params.ListAddressesParams{
+		ListParams: params.ListParams{DisableCounting: true},
+		ChainIDs:   chainIDs,
+		Addresses:  addrs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]*models.AddressInfo, 0, len(addrs))
+	collated := make(map[models.Address]*models.AddressInfo, len(addrs))
+	for _, a := range addressList.Addresses {
+		addressInfo, ok := collated[a.Address]
+		if !ok {
+			collated[a.Address] = a
+			addresses = append(addresses, a)
+			continue
+		}
+		addressInfo.ChainID = ""
+		addressInfo.Assets = addAssetInfoMap(addressInfo.Assets, a.Assets)
+	}
+	return addresses, nil
+}
@@ -0,0 +1,76 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2/ids"
+)
+
+func TestGetBatch(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := db.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_get_batch", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("pvm_blocks").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_transactions").ExecContext(ctx)
+
+	tnow := time.Now().UTC().Truncate(1 * time.Second)
+
+	for i, id := range []string{"blk2", "blk1"} {
+		block := &db.PvmBlocks{
+			ID:            id,
+			ChainID:       "cid",
+			Type:          models.BlockTypeStandard,
+			ParentID:      "blk0",
+			Serialization: []byte(""),
+			CreatedAt:     tnow.Add(time.Duration(i) * time.Second),
+		}
+		if err := persist.InsertPvmBlocks(ctx, sess, block, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+	}
+	for i, id := range []string{"tx2", "tx1"} {
+		transaction := &db.Transactions{
+			ID:        id,
+			ChainID:   "cid",
+			Type:      "type",
+			CreatedAt: tnow.Add(time.Duration(i) * time.Second),
+		}
+		if err := persist.InsertTransactions(ctx, sess, transaction, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+	}
+
+	// the missing ids are left out, the others are in the order they were created
+	blocks, err := reader.GetBlocks(ctx, []string{"blk1", "blk9", "blk2"})
+	if err != nil {
+		t.Fatal("get blocks", err)
+	}
+	if len(blocks) != 2 || blocks[0].ID != "blk2" || blocks[1].ID != "blk1" || blocks[1].ParentID != "blk0" {
+		t.Fatal("blocks", blocks)
+	}
+
+	txs, err := reader.GetTransactions(ctx, []string{"tx1", "tx9", "tx2"}, ids.Empty)
+	if err != nil {
+		t.Fatal("get transactions", err)
+	}
+	if len(txs) != 2 || txs[0].ID != "tx2" || txs[1].ID != "tx1" {
+		t.Fatal("transactions", txs)
+	}
+
+	blocks, err = reader.GetBlocks(ctx, nil)
+	if err != nil || blocks != nil {
+		t.Fatal("blocks of no ids", blocks, err)
+	}
+}
@@ -0,0 +1,88 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gocraft/dbr/v2"
+)
+
+// newCABIDecoder loads the uploaded ABIs of the contracts at the addresses.
+func newCABIDecoder(ctx context.Context, dbRunner dbr.SessionRunner, chainID string, addresses []common.Address) (*modelsc.ABIDecoder, error) {
+	decoder := modelsc.NewABIDecoder()
+	if len(addresses) == 0 {
+		return decoder, nil
+	}
+
+	addressStrs := make([]string, 0, len(addresses))
+	for i := range addresses {
+		addressStrs = append(addressStrs, utils.CommonAddressHexRepair(&addresses[i]))
+	}
+	var contracts []*db.CvmContracts
+	_, err := dbRunner.
+		Select("address", "abi").
+		From(db.TableCvmContracts).
+		Where("chain_id=? and address in ?", chainID, addressStrs).
+		LoadContext(ctx, &contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, contract := range contracts {
+		// the abi is validated on upload
+		contractABI, err := modelsc.ParseABI(contract.ABI)
+		if err != nil {
+			continue
+		}
+		decoder.AddContract(common.HexToAddress(contract.Address), contractABI)
+	}
+	return decoder, nil
+}
+
+// decodeCTransactions decodes the inputs of the transactions and of their
+// traces, with the ABIs of the called contracts.
+func (r *Reader) decodeCTransactions(ctx context.Context, dbRunner dbr.SessionRunner, trItems []*models.CTransactionData) error {
+	addresses := make(map[string][]common.Address)
+	for _, trItem := range trItems {
+		if trItem.Recipient != nil && trItem.Payload != nil {
+			addresses[trItem.ChainID] = append(addresses[trItem.ChainID], common.HexToAddress(*trItem.Recipient))
+		}
+		for _, trace := range trItem.Traces {
+			if trace != nil && trace.Input != nil {
+				addresses[trItem.ChainID] = append(addresses[trItem.ChainID], common.HexToAddress(trace.ToAddr))
+			}
+		}
+	}
+
+	decoders := make(map[string]*modelsc.ABIDecoder, len(addresses))
+	for chainID, chainAddresses := range addresses {
+		decoder, err := newCABIDecoder(ctx, dbRunner, chainID, chainAddresses)
+		if err != nil {
+			return err
+		}
+		decoders[chainID] = decoder
+	}
+
+	for _, trItem := range trItems {
+		decoder, ok := decoders[trItem.ChainID]
+		if !ok {
+			continue
+		}
+		if trItem.Recipient != nil && trItem.Payload != nil {
+			trItem.DecodedInput = decoder.DecodeCall(common.HexToAddress(*trItem.Recipient), common.FromHex(*trItem.Payload))
+		}
+		for _, trace := range trItem.Traces {
+			if trace != nil && trace.Input != nil {
+				trace.DecodedInput = decoder.DecodeCall(common.HexToAddress(trace.ToAddr), common.FromHex(*trace.Input))
+			}
+		}
+	}
+	return nil
+}
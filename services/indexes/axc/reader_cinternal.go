@@ -0,0 +1,82 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+// ListCInternalTransfers lists the internal transfers from or to an address,
+// the calls of the transaction traces which move value.
+func (r *Reader) ListCInternalTransfers(ctx context.Context, p *params.ListCInternalTransfersParams) (*models.CInternalTransferList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_cinternal_transfers", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*db.CvmInternalTransfers
+	_, err = p.Apply(dbRunner.
+		Select(
+			db.TableCvmInternalTransfers+".id",
+			db.TableCvmInternalTransfers+".chain_id",
+			db.TableCvmInternalTransfers+".tx_hash",
+			db.CastStringAs(dbRunner, db.TableCvmInternalTransfers+".block", "block"),
+			db.TableCvmInternalTransfers+".trace_address",
+			db.TableCvmInternalTransfers+".type",
+			db.TableCvmInternalTransfers+".from_addr",
+			db.TableCvmInternalTransfers+".to_addr",
+			db.CastStringAs(dbRunner, db.TableCvmInternalTransfers+".value", "value"),
+			db.TableCvmInternalTransfers+".error",
+			db.TableCvmInternalTransfers+".created_at",
+		).
+		From(db.TableCvmInternalTransfers)).
+		OrderDesc(db.TableCvmInternalTransfers+".created_at").
+		OrderDesc(db.TableCvmInternalTransfers+".id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*models.CInternalTransfer, 0, len(rows))
+	for _, row := range rows {
+		transfer := &models.CInternalTransfer{
+			ID:           models.StringID(row.ID),
+			ChainID:      row.ChainID,
+			TxHash:       row.TxHash,
+			Block:        row.Block,
+			TraceAddress: []int{},
+			Type:         row.Type,
+			From:         row.FromAddr,
+			To:           row.ToAddr,
+			Value:        row.Value,
+			CreatedAt:    row.CreatedAt,
+		}
+		for _, i := range strings.Split(row.TraceAddress, ",") {
+			if idx, err := strconv.Atoi(i); err == nil {
+				transfer.TraceAddress = append(transfer.TraceAddress, idx)
+			}
+		}
+		if row.Error != "" {
+			transferErr := row.Error
+			transfer.Error = &transferErr
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if len(rows) < 1 {
+		return &models.CInternalTransferList{Transfers: transfers}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, last.ID).Next(len(rows), p.ListParams.Limit)
+
+	return &models.CInternalTransferList{ListMetadata: models.ListMetadata{Next: next}, Transfers: transfers}, nil
+}
@@ -0,0 +1,96 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ListCLogs lists the logs of an EVM chain in the order of the chain, by block
+// then log index.
+func (r *Reader) ListCLogs(ctx context.Context, p *params.ListCLogsParams) (*models.CLogList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_clogs", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ChainID == "" {
+		p.ChainID = r.sc.ServicesCfg.AXchainID
+	}
+
+	var rows []*db.CvmLogs
+	_, err = p.Apply(dbRunner.
+		Select(
+			db.TableCvmLogs+".chain_id",
+			db.CastStringAs(dbRunner, db.TableCvmLogs+".block", "block"),
+			db.TableCvmLogs+".log_index",
+			db.TableCvmLogs+".created_at",
+			db.TableCvmLogs+".serialization",
+		).
+		From(db.TableCvmLogs)).
+		OrderAsc(db.TableCvmLogs+".block").
+		OrderAsc(db.TableCvmLogs+".log_index").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	txLogs := make([]*types.Log, 0, len(rows))
+	addresses := make([]common.Address, 0, len(rows))
+	for _, row := range rows {
+		txLog := &types.Log{}
+		if err := json.Unmarshal(row.Serialization, txLog); err != nil {
+			return nil, err
+		}
+		txLogs = append(txLogs, txLog)
+		addresses = append(addresses, txLog.Address)
+	}
+
+	decoder, err := newCABIDecoder(ctx, dbRunner, p.ChainID, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*models.CLog, 0, len(rows))
+	for i, row := range rows {
+		txLog := txLogs[i]
+		topics := make([]string, 0, len(txLog.Topics))
+		for _, topic := range txLog.Topics {
+			topics = append(topics, topic.Hex())
+		}
+		logs = append(logs, &models.CLog{
+			ChainID:   row.ChainID,
+			Address:   utils.CommonAddressHexRepair(&txLog.Address),
+			Topics:    topics,
+			Data:      hexutil.Encode(txLog.Data),
+			Block:     row.Block,
+			BlockHash: txLog.BlockHash.Hex(),
+			TxHash:    txLog.TxHash.Hex(),
+			TxIndex:   txLog.TxIndex,
+			LogIndex:  txLog.Index,
+			Decoded:   decoder.DecodeLog(txLog),
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	if len(rows) < 1 {
+		return &models.CLogList{Logs: logs}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewCursor(last.Block, strconv.FormatUint(last.LogIndex, 10)).Next(len(rows), p.ListParams.Limit)
+
+	return &models.CLogList{ListMetadata: models.ListMetadata{Next: next}, Logs: logs}, nil
+}
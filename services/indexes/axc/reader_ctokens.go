@@ -0,0 +1,229 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+func (r *Reader) ListCTokens(ctx context.Context, p *params.ListCTokensParams) (*models.CTokenList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_ctokens", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []*models.CToken
+	_, err = p.Apply(selectCTokens(dbRunner)).
+		OrderAsc(db.TableCvmTokens+".created_at").
+		OrderAsc(db.TableCvmTokens+".address").
+		LoadContext(ctx, &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) < 1 {
+		return &models.CTokenList{Tokens: tokens}, nil
+	}
+
+	last := tokens[len(tokens)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, last.Address).Next(len(tokens), p.ListParams.Limit)
+
+	return &models.CTokenList{ListMetadata: models.ListMetadata{Next: next}, Tokens: tokens}, nil
+}
+
+func selectCTokens(dbRunner dbr.SessionRunner) *dbr.SelectStmt {
+	return dbRunner.
+		Select(
+			db.TableCvmTokens+".chain_id",
+			db.TableCvmTokens+".address",
+			db.TableCvmTokens+".type",
+			db.TableCvmTokens+".name",
+			db.TableCvmTokens+".symbol",
+			db.TableCvmTokens+".decimals",
+			db.TableCvmTokens+".created_at",
+		).
+		From(db.TableCvmTokens)
+}
+
+func (r *Reader) ListCTokenTransfers(ctx context.Context, p *params.ListCTokenTransfersParams) (*models.CTokenTransferList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_ctoken_transfers", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	type transferRow struct {
+		ID        models.StringID
+		ChainID   string
+		Address   string
+		Event     string
+		TxHash    string
+		Block     string
+		LogIndex  uint64
+		FromAddr  string
+		ToAddr    string
+		Value     string
+		Type      string
+		CreatedAt time.Time
+	}
+	var rows []*transferRow
+	_, err = p.Apply(dbRunner.
+		Select(
+			db.TableCvmTokenTransfers+".id",
+			db.TableCvmTokenTransfers+".chain_id",
+			db.TableCvmTokenTransfers+".address",
+			db.TableCvmTokenTransfers+".event",
+			db.TableCvmTokenTransfers+".tx_hash",
+			db.CastStringAs(dbRunner, db.TableCvmTokenTransfers+".block", "block"),
+			db.TableCvmTokenTransfers+".log_index",
+			db.TableCvmTokenTransfers+".from_addr",
+			db.TableCvmTokenTransfers+".to_addr",
+			db.TableCvmTokenTransfers+".value",
+			db.TableCvmTokens+".type",
+			db.TableCvmTokenTransfers+".created_at",
+		).
+		From(db.TableCvmTokenTransfers).
+		Join(db.TableCvmTokens,
+			db.TableCvmTokenTransfers+".chain_id = "+db.TableCvmTokens+".chain_id and "+
+				db.TableCvmTokenTransfers+".address = "+db.TableCvmTokens+".address")).
+		OrderDesc(db.TableCvmTokenTransfers+".created_at").
+		OrderDesc(db.TableCvmTokenTransfers+".id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*models.CTokenTransfer, 0, len(rows))
+	for _, row := range rows {
+		value := row.Value
+		transfer := &models.CTokenTransfer{
+			ID:        row.ID,
+			ChainID:   row.ChainID,
+			Address:   row.Address,
+			Event:     row.Event,
+			TxHash:    row.TxHash,
+			Block:     row.Block,
+			LogIndex:  row.LogIndex,
+			From:      row.FromAddr,
+			To:        row.ToAddr,
+			CreatedAt: row.CreatedAt,
+		}
+		if row.Type == models.CTokenTypeERC721 {
+			transfer.TokenID = &value
+		} else {
+			transfer.Amount = &value
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if len(rows) < 1 {
+		return &models.CTokenTransferList{Transfers: transfers}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(rows), p.ListParams.Limit)
+
+	return &models.CTokenTransferList{ListMetadata: models.ListMetadata{Next: next}, Transfers: transfers}, nil
+}
+
+// ListCTokenBalances sums the transfers to and from the address for each of
+// its tokens. A token is listed while the address holds a balance of it.
+func (r *Reader) ListCTokenBalances(ctx context.Context, p *params.CTokenBalancesParams) (*models.CTokenBalanceList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_ctoken_balances", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	address := p.Address
+
+	type amountRow struct {
+		ChainID string
+		Address string
+		Amount  string
+	}
+	sumAmounts := func(addrCol string) ([]*amountRow, error) {
+		sq := dbRunner.
+			Select(
+				db.TableCvmTokenTransfers+".chain_id",
+				db.TableCvmTokenTransfers+".address",
+				db.CastStringAs(dbRunner, "sum("+db.TableCvmTokenTransfers+".amount)", "amount"),
+			).
+			From(db.TableCvmTokenTransfers).
+			Where(db.TableCvmTokenTransfers+"."+addrCol+" = ?", address).
+			Where(db.TableCvmTokenTransfers+".event = ?", models.CTokenEventTransfer).
+			GroupBy(db.TableCvmTokenTransfers+".chain_id", db.TableCvmTokenTransfers+".address")
+		if len(p.ChainIDs) != 0 {
+			sq.Where(db.TableCvmTokenTransfers+".chain_id IN ?", p.ChainIDs)
+		}
+		var rows []*amountRow
+		_, err := sq.LoadContext(ctx, &rows)
+		return rows, err
+	}
+
+	received, err := sumAmounts("to_addr")
+	if err != nil {
+		return nil, err
+	}
+	sent, err := sumAmounts("from_addr")
+	if err != nil {
+		return nil, err
+	}
+
+	balanceMap := make(map[string]*big.Int, len(received))
+	for _, row := range received {
+		amount, ok := new(big.Int).SetString(row.Amount, 10)
+		if !ok {
+			continue
+		}
+		balanceMap[row.ChainID+":"+row.Address] = amount
+	}
+	for _, row := range sent {
+		amount, ok := new(big.Int).SetString(row.Amount, 10)
+		if !ok {
+			continue
+		}
+		key := row.ChainID + ":" + row.Address
+		if balance, ok := balanceMap[key]; ok {
+			balance.Sub(balance, amount)
+		} else {
+			balanceMap[key] = amount.Neg(amount)
+		}
+	}
+
+	balances := make([]*models.CTokenBalance, 0, len(balanceMap))
+	tokenAddresses := make([]string, 0, len(balanceMap))
+	for _, row := range append(received, sent...) {
+		if balance, ok := balanceMap[row.ChainID+":"+row.Address]; ok && balance.Sign() != 0 {
+			tokenAddresses = append(tokenAddresses, row.Address)
+		}
+	}
+	if len(tokenAddresses) == 0 {
+		return &models.CTokenBalanceList{Address: address, Balances: balances}, nil
+	}
+
+	var tokens []*models.CToken
+	_, err = selectCTokens(dbRunner).
+		Where(db.TableCvmTokens+".address IN ?", tokenAddresses).
+		OrderAsc(db.TableCvmTokens+".created_at").
+		OrderAsc(db.TableCvmTokens+".address").
+		LoadContext(ctx, &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		balance, ok := balanceMap[token.ChainID+":"+token.Address]
+		if !ok || balance.Sign() == 0 {
+			continue
+		}
+		balances = append(balances, &models.CTokenBalance{Token: token, Balance: balance.String()})
+	}
+	return &models.CTokenBalanceList{Address: address, Balances: balances}, nil
+}
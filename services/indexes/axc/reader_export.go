@@ -0,0 +1,180 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+// ExportBatchSize is the number of rows dressed and passed on at once by the exports.
+var ExportBatchSize = 256
+
+// The exports iterate the rows of a list query without its limit, and pass them
+// on in batches, so only one batch is held in memory however long the export is.
+
+// ExportTransactions calls fn with the transactions matching p, in the order
+// of ListTransactions.
+func (r *Reader) ExportTransactions(
+	ctx context.Context,
+	p *params.ListTransactionsParams,
+	axcAssetID ids.ID,
+	fn func([]*models.Transaction) error,
+) error {
+	dbRunner, err := r.conns.DB().NewSession("export_transactions", cfg.ExportTimeout)
+	if err != nil {
+		return err
+	}
+
+	builder := r.listTxsQuery(transactionQuery(dbRunner), p)
+	if p.Sort == params.TransactionSortTimestampDesc {
+		builder.OrderDesc("avm_transactions.created_at").OrderDesc("avm_transactions.id")
+	} else {
+		builder.OrderAsc("avm_transactions.created_at").OrderAsc("avm_transactions.id")
+	}
+
+	itr, err := builder.IterateContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = itr.Close()
+	}()
+
+	flush := func(txs []*models.Transaction) error {
+		if err := dressTransactions(ctx, dbRunner, txs, axcAssetID, nil, p.DisableGenesis); err != nil {
+			return err
+		}
+		return fn(txs)
+	}
+
+	txs := make([]*models.Transaction, 0, ExportBatchSize)
+	var lastID models.StringID
+	for itr.Next() {
+		var tx *models.Transaction
+		if err := itr.Scan(&tx); err != nil {
+			return err
+		}
+		// the asset filter joins the outputs, which repeats the transaction
+		if tx.ID == lastID {
+			continue
+		}
+		lastID = tx.ID
+
+		txs = append(txs, tx)
+		if len(txs) < ExportBatchSize {
+			continue
+		}
+		if err := flush(txs); err != nil {
+			return err
+		}
+		txs = make([]*models.Transaction, 0, ExportBatchSize)
+	}
+	if err := itr.Err(); err != nil {
+		return err
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	return flush(txs)
+}
+
+// ExportOutputs calls fn with the outputs matching p, in the order of ListOutputs.
+func (r *Reader) ExportOutputs(
+	ctx context.Context,
+	p *params.ListOutputsParams,
+	fn func([]*models.Output) error,
+) error {
+	dbRunner, err := r.conns.DB().NewSession("export_outputs", cfg.ExportTimeout)
+	if err != nil {
+		return err
+	}
+
+	itr, err := p.Apply(dbRunner.
+		Select(outputSelectColumns...).
+		From("avm_outputs").
+		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id")).
+		OrderAsc("avm_outputs.created_at").
+		OrderAsc("avm_outputs.id").
+		IterateContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = itr.Close()
+	}()
+
+	flush := func(outputs []*models.Output) error {
+		if err := dressOutputAddresses(ctx, dbRunner, outputs); err != nil {
+			return err
+		}
+		return fn(outputs)
+	}
+
+	outputs := make([]*models.Output, 0, ExportBatchSize)
+	var lastID models.StringID
+	for itr.Next() {
+		var output *models.Output
+		if err := itr.Scan(&output); err != nil {
+			return err
+		}
+		// the address filter joins the addresses, which repeats the output
+		if output.ID == lastID {
+			continue
+		}
+		lastID = output.ID
+
+		outputs = append(outputs, output)
+		if len(outputs) < ExportBatchSize {
+			continue
+		}
+		if err := flush(outputs); err != nil {
+			return err
+		}
+		outputs = make([]*models.Output, 0, ExportBatchSize)
+	}
+	if err := itr.Err(); err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+	return flush(outputs)
+}
+
+func dressOutputAddresses(ctx context.Context, dbRunner dbr.SessionRunner, outputs []*models.Output) error {
+	outputIDs := make([]models.StringID, len(outputs))
+	outputMap := make(map[models.StringID]*models.Output, len(outputs))
+	for i, output := range outputs {
+		outputIDs[i] = output.ID
+		outputMap[output.ID] = output
+	}
+
+	var addresses []*models.OutputAddress
+	_, err := dbRunner.
+		Select(
+			"avm_output_addresses.output_id",
+			"avm_output_addresses.address",
+		).
+		From("avm_output_addresses").
+		Where("avm_output_addresses.output_id IN ?", outputIDs).
+		LoadContext(ctx, &addresses)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		output := outputMap[address.OutputID]
+		if output == nil {
+			continue
+		}
+		output.Addresses = append(output.Addresses, address.Address)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+// GetAddressHistory returns the balance of the address for the asset at the
+// end of each interval, with the amounts it received and sent in it.
+// The asset is AXC if p.AssetID is not set.
+func (r *Reader) GetAddressHistory(ctx context.Context, p *params.AddressHistoryParams, axcAssetID ids.ID) (*models.AddressHistory, error) {
+	dbRunner, err := r.conns.DB().NewSession("get_address_history", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	assetID := axcAssetID
+	if p.AssetID != nil {
+		assetID = *p.AssetID
+	}
+	address := p.Address.String()
+	asset := assetID.String()
+
+	// Validate params and set defaults if necessary
+	if p.ListParams.StartTime.IsZero() {
+		p.ListParams.StartTime, err = getFirstOutputTime(ctx, dbRunner, address, p.ChainIDs)
+		if err != nil {
+			return nil, err
+		}
+		// an address without outputs has an empty history
+		if p.ListParams.StartTime.IsZero() {
+			p.ListParams.StartTime = p.ListParams.EndTime
+		}
+	}
+	startTime := p.ListParams.StartTime
+	endTime := p.ListParams.EndTime
+
+	// Ensure the interval count requested isn't too large, without an interval
+	// size the whole range is one interval
+	intervalSeconds := int64(p.IntervalSize.Seconds())
+	intervalCount, err := aggregateIntervalCount(startTime, endTime, p.IntervalSize)
+	if err != nil {
+		return nil, err
+	}
+	if intervalCount == 0 {
+		intervalCount = 1
+		intervalSeconds = int64(math.Ceil(endTime.Sub(startTime).Seconds()))
+		if intervalSeconds < 1 {
+			intervalSeconds = 1
+		}
+	}
+
+	startBalances, err := r.GetAddressBalances(ctx, &params.AddressBalancesParams{
+		ChainIDs: p.ChainIDs,
+		AssetID:  &assetID,
+		Address:  p.Address,
+		AsOf:     startTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loadAmounts := func(b *dbr.SelectStmt, timeCol string) ([]*big.Int, error) {
+		b.Where("avm_output_addresses.address = ?", address).
+			Where("avm_outputs.asset_id = ?", asset).
+			Where(timeCol+" >= ?", startTime).
+			Where(timeCol+" < ?", endTime).
+			GroupBy("idx").
+			OrderAsc("idx").
+			Limit(uint64(intervalCount))
+		if len(p.ChainIDs) != 0 {
+			b.Where("avm_outputs.chain_id IN ?", p.ChainIDs)
+		}
+		var rows []*intervalAmount
+		if _, err := b.LoadContext(ctx, &rows); err != nil {
+			return nil, err
+		}
+		return intervalAmounts(rows, intervalCount)
+	}
+
+	received, err := loadAmounts(dbRunner.
+		Select(
+			"COALESCE(SUM(avm_outputs.amount), 0) AS amount",
+			aggregateIntervalIdx(dbRunner, "avm_outputs.created_at", startTime, intervalSeconds),
+		).
+		From("avm_outputs").
+		Join("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id"),
+		"avm_outputs.created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := loadAmounts(dbRunner.
+		Select(
+			"COALESCE(SUM(avm_outputs.amount), 0) AS amount",
+			aggregateIntervalIdx(dbRunner, "avm_outputs_redeeming.redeemed_at", startTime, intervalSeconds),
+		).
+		From("avm_outputs_redeeming").
+		Join("avm_outputs", "avm_outputs.id = avm_outputs_redeeming.id").
+		Join("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id"),
+		"avm_outputs_redeeming.redeemed_at")
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := addressAssetBalance(startBalances, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AddressHistory{
+		Address:      models.Address(address),
+		AssetID:      models.StringID(asset),
+		IntervalSize: p.IntervalSize,
+		Intervals:    addressHistoryIntervals(startTime, intervalSeconds, balance, received, sent),
+		StartTime:    startTime,
+		EndTime:      endTime,
+	}, nil
+}
+
+// intervalAmount is the amount received or sent in the interval Idx.
+type intervalAmount struct {
+	Idx    int
+	Amount string
+}
+
+// intervalAmounts returns the amounts of the intervals, nil for an interval
+// without an amount.
+func intervalAmounts(rows []*intervalAmount, intervalCount int) ([]*big.Int, error) {
+	amounts := make([]*big.Int, intervalCount)
+	for _, row := range rows {
+		if row.Idx < 0 || row.Idx >= intervalCount {
+			continue
+		}
+		amount, ok := new(big.Int).SetString(row.Amount, 10)
+		if !ok {
+			return nil, ErrFailedToParseStringAsBigInt
+		}
+		amounts[row.Idx] = amount
+	}
+	return amounts, nil
+}
+
+// addressAssetBalance returns the balance of the asset, 0 if the address has none.
+func addressAssetBalance(balances *models.AddressBalances, asset string) (*big.Int, error) {
+	balance := big.NewInt(0)
+	if assetBalance, ok := balances.Assets[models.StringID(asset)]; ok {
+		if _, ok := balance.SetString(string(assetBalance.Balance), 10); !ok {
+			return nil, ErrFailedToParseStringAsBigInt
+		}
+	}
+	return balance, nil
+}
+
+// addressHistoryIntervals returns the intervals from the balance at startTime
+// and the amounts received and sent in each interval.
+func addressHistoryIntervals(startTime time.Time, intervalSeconds int64, balance *big.Int, received []*big.Int, sent []*big.Int) []models.AddressHistoryInterval {
+	balance = new(big.Int).Set(balance)
+	intervals := make([]models.AddressHistoryInterval, len(received))
+	zero := big.NewInt(0)
+	for i := range intervals {
+		interval := &intervals[i]
+		interval.Idx = i
+		interval.StartTime, interval.EndTime = aggregateIntervalTimes(startTime, intervalSeconds, i)
+
+		receivedAmount, sentAmount := zero, zero
+		if received[i] != nil {
+			receivedAmount = received[i]
+		}
+		if sent[i] != nil {
+			sentAmount = sent[i]
+		}
+		balance.Add(balance, receivedAmount).Sub(balance, sentAmount)
+
+		interval.Received = models.TokenAmount(receivedAmount.String())
+		interval.Sent = models.TokenAmount(sentAmount.String())
+		interval.Balance = models.TokenAmount(balance.String())
+	}
+	return intervals
+}
+
+func getFirstOutputTime(ctx context.Context, dbRunner *dbr.Session, address string, chainIDs []string) (time.Time, error) {
+	var ts float64
+	builder := dbRunner.
+		Select("COALESCE(" + db.UnixTimestamp(dbRunner, "MIN(avm_outputs.created_at)") + ", 0)").
+		From("avm_outputs").
+		Join("avm_output_addresses", "avm_output_addresses.output_id = avm_outputs.id").
+		Where("avm_output_addresses.address = ?", address)
+
+	if len(chainIDs) > 0 {
+		builder.Where("avm_outputs.chain_id IN ?", chainIDs)
+	}
+
+	err := builder.LoadOneContext(ctx, &ts)
+	if err != nil || ts == 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(math.Floor(ts)), 0).UTC(), nil
+}
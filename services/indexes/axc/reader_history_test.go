@@ -0,0 +1,214 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/axiacoin/axia-network-v2/ids"
+)
+
+func TestAggregateIntervals(t *testing.T) {
+	startTime := time.Unix(1600000000, 0).UTC()
+
+	count, err := aggregateIntervalCount(startTime, startTime.Add(150*time.Minute), time.Hour)
+	if err != nil || count != 3 {
+		t.Fatal("interval count", count, err)
+	}
+	if count, err = aggregateIntervalCount(startTime, startTime, time.Hour); err != nil || count != 1 {
+		t.Fatal("interval count of an empty range", count, err)
+	}
+	if count, err = aggregateIntervalCount(startTime, startTime.Add(time.Hour), 0); err != nil || count != 0 {
+		t.Fatal("interval count without an interval size", count, err)
+	}
+	if _, err = aggregateIntervalCount(startTime, startTime.Add(time.Duration(MaxAggregateIntervalCount+1)*time.Second), time.Second); err != ErrAggregateIntervalCountTooLarge {
+		t.Fatal("interval count too large", err)
+	}
+
+	idx := aggregateIntervalIdx(nil, "avm_outputs.created_at", startTime, 3600)
+	if idx != "FLOOR((UNIX_TIMESTAMP(avm_outputs.created_at)-1600000000) / 3600) AS idx" {
+		t.Fatal("interval idx", idx)
+	}
+
+	// a time falls in the interval of the index the database selects for it
+	for _, offset := range []int64{0, 1, 3599, 3600, 7199, 7200} {
+		ts := startTime.Unix() + offset
+		i := int(math.Floor(float64(ts-startTime.Unix()) / 3600))
+		start, end := aggregateIntervalTimes(startTime, 3600, i)
+		if ts < start.Unix() || ts > end.Unix() {
+			t.Fatal("time", offset, "not in interval", i, start, end)
+		}
+	}
+	start, end := aggregateIntervalTimes(startTime, 3600, 2)
+	if !start.Equal(startTime.Add(2*time.Hour)) || !end.Equal(startTime.Add(3*time.Hour-time.Second)) {
+		t.Fatal("interval times", start, end)
+	}
+}
+
+func TestAddressHistoryIntervals(t *testing.T) {
+	startTime := time.Unix(1600000000, 0).UTC()
+
+	received, err := intervalAmounts([]*intervalAmount{
+		{Idx: 0, Amount: "10"},
+		{Idx: 2, Amount: "5"},
+		{Idx: 3, Amount: "1"},
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent, err := intervalAmounts([]*intervalAmount{{Idx: 1, Amount: "30"}, {Idx: 2, Amount: "5"}}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intervalAmounts([]*intervalAmount{{Idx: 0, Amount: "x"}}, 3); err != ErrFailedToParseStringAsBigInt {
+		t.Fatal("invalid amount", err)
+	}
+
+	// the balance accumulates from the balance at the start
+	balance := big.NewInt(100)
+	intervals := addressHistoryIntervals(startTime, 60, balance, received, sent)
+	expected := []struct {
+		received string
+		sent     string
+		balance  string
+	}{
+		{"10", "0", "110"},
+		{"0", "30", "80"},
+		{"5", "5", "80"},
+	}
+	if len(intervals) != len(expected) {
+		t.Fatal("intervals", intervals)
+	}
+	for i, e := range expected {
+		interval := intervals[i]
+		if interval.Idx != i || string(interval.Received) != e.received || string(interval.Sent) != e.sent || string(interval.Balance) != e.balance {
+			t.Fatal("interval", i, interval)
+		}
+		if !interval.StartTime.Equal(startTime.Add(time.Duration(i)*time.Minute)) || !interval.EndTime.Equal(interval.StartTime.Add(59*time.Second)) {
+			t.Fatal("interval", i, "times", interval.StartTime, interval.EndTime)
+		}
+	}
+	if balance.Int64() != 100 {
+		t.Fatal("start balance modified", balance)
+	}
+}
+
+func TestAddressAssetBalance(t *testing.T) {
+	balances := &models.AddressBalances{Assets: map[models.StringID]models.AddressAssetBalance{
+		"asset1": {AssetID: "asset1", UTXOCount: 2, Balance: "1000000000000000000000"},
+	}}
+	balance, err := addressAssetBalance(balances, "asset1")
+	if err != nil || balance.String() != "1000000000000000000000" {
+		t.Fatal("balance", balance, err)
+	}
+	if balance, err = addressAssetBalance(balances, "asset2"); err != nil || balance.Sign() != 0 {
+		t.Fatal("balance of another asset", balance, err)
+	}
+}
+
+func TestGetAddressHistory(t *testing.T) {
+	reader, closeFn := newTestIndex(t)
+	defer closeFn()
+
+	ctx := newTestContext()
+
+	persist := db.NewPersist()
+
+	sess, _ := reader.conns.DB().NewSession("test_get_address_history", cfg.RequestTimeout)
+	_, _ = sess.DeleteFrom("avm_outputs").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_output_addresses").ExecContext(ctx)
+	_, _ = sess.DeleteFrom("avm_outputs_redeeming").ExecContext(ctx)
+	_, _ = sess.DeleteFrom(db.TableBalanceSnapshots).ExecContext(ctx)
+	_, _ = sess.DeleteFrom(db.TableAddressBalanceSnapshots).ExecContext(ctx)
+
+	address := ids.ShortID{1}
+	assetID := ids.ID{2}
+	tm := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+
+	// out1 is received before the history and spent in its second interval,
+	// out2 is received in its first interval
+	for _, output := range []struct {
+		id        string
+		amount    uint64
+		createdAt time.Time
+	}{
+		{"out1", 100, tm.Add(-time.Hour)},
+		{"out2", 10, tm.Add(10 * time.Minute)},
+	} {
+		if err := persist.InsertOutputs(ctx, sess, &db.Outputs{
+			ID:            output.id,
+			ChainID:       "ch1",
+			TransactionID: "tx1",
+			AssetID:       assetID.String(),
+			OutputType:    models.OutputTypesSECP2556K1Transfer,
+			Amount:        output.amount,
+			CreatedAt:     output.createdAt,
+		}, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+		if err := persist.InsertOutputAddresses(ctx, sess, &db.OutputAddresses{
+			OutputID:  output.id,
+			Address:   address.String(),
+			CreatedAt: output.createdAt,
+			UpdatedAt: time.Now().UTC(),
+		}, false); err != nil {
+			t.Fatal("insert fail", err)
+		}
+	}
+	if err := persist.InsertOutputsRedeeming(ctx, sess, &db.OutputsRedeeming{
+		ID:                     "out1",
+		RedeemedAt:             tm.Add(70 * time.Minute),
+		RedeemingTransactionID: "tx2",
+		Amount:                 100,
+		AssetID:                assetID.String(),
+		ChainID:                "ch1",
+		CreatedAt:              tm.Add(70 * time.Minute),
+	}, false); err != nil {
+		t.Fatal("insert fail", err)
+	}
+
+	p := &params.AddressHistoryParams{
+		ListParams:   params.ListParams{StartTime: tm, EndTime: tm.Add(3 * time.Hour)},
+		AssetID:      &assetID,
+		Address:      address,
+		IntervalSize: time.Hour,
+	}
+	history, err := reader.GetAddressHistory(ctx, p, ids.Empty)
+	if err != nil {
+		t.Fatal("get address history", err)
+	}
+	if len(history.Intervals) != 3 {
+		t.Fatal("intervals", history.Intervals)
+	}
+	for i, balance := range []models.TokenAmount{"110", "10", "10"} {
+		if history.Intervals[i].Balance != balance {
+			t.Fatal("interval", i, history.Intervals[i])
+		}
+	}
+	if history.Intervals[0].Received != "10" || history.Intervals[1].Sent != "100" || history.Intervals[2].Received != "0" {
+		t.Fatal("intervals", history.Intervals)
+	}
+
+	// an address without outputs has an empty history
+	p = &params.AddressHistoryParams{
+		ListParams:   params.ListParams{EndTime: tm.Add(3 * time.Hour)},
+		AssetID:      &assetID,
+		Address:      ids.ShortID{3},
+		IntervalSize: time.Hour,
+	}
+	history, err = reader.GetAddressHistory(ctx, p, ids.Empty)
+	if err != nil {
+		t.Fatal("get address history", err)
+	}
+	if len(history.Intervals) != 1 || history.Intervals[0].Balance != "0" || history.Intervals[0].Received != "0" || history.Intervals[0].Sent != "0" {
+		t.Fatal("intervals", history.Intervals)
+	}
+}
@@ -0,0 +1,132 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+// the largest holders of the concentration of an asset
+const (
+	assetHoldersTop10  = 10
+	assetHoldersTop100 = 100
+)
+
+// ListAssetHolders lists the addresses with a balance of an asset, from the
+// balances of the accumulate balance indexer.
+func (r *Reader) ListAssetHolders(ctx context.Context, p *params.ListAssetHoldersParams) (*models.AssetHolderList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_asset_holders", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*struct {
+		ID string
+		models.AssetHolder
+	}
+	_, err = p.Apply(dbRunner.
+		Select(
+			"accumulate_balances_received.id",
+			"accumulate_balances_received.chain_id",
+			"accumulate_balances_received.address",
+			params.AssetHolderBalance+" as balance",
+			"accumulate_balances_received.utxo_count - case when accumulate_balances_sent.utxo_count is null then 0 else accumulate_balances_sent.utxo_count end as utxo_count",
+			"case when accumulate_balances_transactions.transaction_count is null then 0 else accumulate_balances_transactions.transaction_count end as transaction_count",
+		).
+		From("accumulate_balances_received").
+		LeftJoin("accumulate_balances_sent", "accumulate_balances_received.id = accumulate_balances_sent.id").
+		LeftJoin("accumulate_balances_transactions", "accumulate_balances_received.id = accumulate_balances_transactions.id")).
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make([]*models.AssetHolder, 0, len(rows))
+	for _, row := range rows {
+		holder := row.AssetHolder
+		holders = append(holders, &holder)
+	}
+
+	if len(rows) < 1 {
+		return &models.AssetHolderList{Holders: holders}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewCursor(string(last.Balance), last.ID).Next(len(rows), p.ListParams.Limit)
+
+	return &models.AssetHolderList{ListMetadata: models.ListMetadata{Next: next}, Holders: holders}, nil
+}
+
+// assetHolders counts the holders of an asset, and the shares of the largest
+// of them in the total held.
+func (r *Reader) assetHolders(ctx context.Context, dbRunner dbr.SessionRunner, assetID models.StringID) (*models.AssetHolders, error) {
+	holdersQuery := func(columns ...string) *dbr.SelectStmt {
+		return dbRunner.
+			Select(columns...).
+			From("accumulate_balances_received").
+			Where("accumulate_balances_received.asset_id = ?", assetID).
+			Where(params.AssetHolderBalance + " > 0")
+	}
+
+	var totals struct {
+		HolderCount uint64
+		TotalHeld   string
+	}
+	err := holdersQuery(
+		"count(*) as holder_count",
+		db.CastStringAs(dbRunner, "coalesce(sum("+params.AssetHolderBalance+"), 0)", "total_held"),
+	).LoadOneContext(ctx, &totals)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []string
+	_, err = holdersQuery(db.CastStringAs(dbRunner, params.AssetHolderBalance, "balance")).
+		OrderDesc(params.AssetHolderBalance).
+		Limit(assetHoldersTop100).
+		LoadContext(ctx, &balances)
+	if err != nil {
+		return nil, err
+	}
+
+	totalHeld, ok := new(big.Int).SetString(totals.TotalHeld, 10)
+	if !ok {
+		return nil, ErrFailedToParseStringAsBigInt
+	}
+	holders := &models.AssetHolders{Count: totals.HolderCount, TotalHeld: models.TokenAmount(totalHeld.String())}
+	if totalHeld.Sign() == 0 {
+		return holders, nil
+	}
+
+	topHeld := new(big.Int)
+	for i, balanceStr := range balances {
+		balance, ok := new(big.Int).SetString(balanceStr, 10)
+		if !ok {
+			return nil, ErrFailedToParseStringAsBigInt
+		}
+		topHeld.Add(topHeld, balance)
+		if i+1 == assetHoldersTop10 {
+			holders.Top10Share = share(topHeld, totalHeld)
+		}
+	}
+	if len(balances) < assetHoldersTop10 {
+		holders.Top10Share = share(topHeld, totalHeld)
+	}
+	holders.Top100Share = share(topHeld, totalHeld)
+
+	return holders, nil
+}
+
+// share is the fraction of total held by part.
+func share(part *big.Int, total *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(part), new(big.Float).SetInt(total)).Float64()
+	return f
+}
@@ -76,6 +76,7 @@ func (r *Reader) ListCTransactions(ctx context.Context, p *params.ListCTransacti
 	var dataList []*db.CvmTransactionsTxdata
 
 	sq := dbRunner.Select(
+		"chain_id",
 		"hash",
 		"block",
 		"idx",
@@ -92,19 +93,27 @@ func (r *Reader) ListCTransactions(ctx context.Context, p *params.ListCTransacti
 	}
 
 	_, err = p.Apply(sq).
-		OrderDesc("created_at").
+		OrderDesc(db.TableCvmTransactionsTxdata+".created_at").
+		OrderDesc(db.TableCvmTransactionsTxdata+".hash").
 		LoadContext(ctx, &dataList)
 	if err != nil {
 		return nil, err
 	}
 
+	var next *string
+	if len(dataList) != 0 {
+		last := dataList[len(dataList)-1]
+		next = p.ListParams.NewTimeCursor(last.CreatedAt, last.Hash).Next(len(dataList), p.ListParams.Limit)
+	}
+
 	trItemsByHash := make(map[string]*models.CTransactionData)
 
 	trItems := make([]*models.CTransactionData, 0, len(dataList))
 	hashes := make([]string, 0, len(dataList))
 
+	// the block numbers are of the chain of the transaction
 	blocksMap := make(map[string]struct{})
-	blocks := make([]string, 0, len(dataList))
+	blocks := make(map[string][]string)
 
 	for _, txdata := range dataList {
 		var tr types.Transaction
@@ -113,22 +122,30 @@ func (r *Reader) ListCTransactions(ctx context.Context, p *params.ListCTransacti
 			return nil, err
 		}
 		ctr := toCTransactionData(&tr)
+		ctr.ChainID = txdata.ChainID
 		ctr.Block = txdata.Block
 		ctr.CreatedAt = txdata.CreatedAt
 		trItems = append(trItems, ctr)
 
-		trItemsByHash[ctr.Hash] = ctr
+		trItemsByHash[cTransactionKey(ctr.ChainID, ctr.Hash)] = ctr
 		hashes = append(hashes, ctr.Hash)
 
-		if _, ok := blocksMap[txdata.Block]; !ok {
-			blocksMap[txdata.Block] = struct{}{}
-			blocks = append(blocks, txdata.Block)
+		blockKey := cTransactionKey(txdata.ChainID, txdata.Block)
+		if _, ok := blocksMap[blockKey]; !ok {
+			blocksMap[blockKey] = struct{}{}
+			blocks[txdata.ChainID] = append(blocks[txdata.ChainID], txdata.Block)
 		}
 	}
 
-	cblocksMap, err := r.fetchAndDecodeCBlocks(ctx, dbRunner, blocks)
-	if err != nil {
-		return nil, err
+	cblocksMap := make(map[string]*modelsc.Block)
+	for chainID, chainBlocks := range blocks {
+		chainCBlocksMap, err := r.fetchAndDecodeCBlocks(ctx, dbRunner, chainID, chainBlocks)
+		if err != nil {
+			return nil, err
+		}
+		for block, cblock := range chainCBlocksMap {
+			cblocksMap[cTransactionKey(chainID, block)] = cblock
+		}
 	}
 
 	err = r.handleDressTraces(ctx, dbRunner, hashes, trItemsByHash)
@@ -137,7 +154,7 @@ func (r *Reader) ListCTransactions(ctx context.Context, p *params.ListCTransacti
 	}
 
 	for _, trItem := range trItemsByHash {
-		if cblockv, ok := cblocksMap[trItem.Block]; ok {
+		if cblockv, ok := cblocksMap[cTransactionKey(trItem.ChainID, trItem.Block)]; ok {
 			trItem.BlockGasUsed = cblockv.Header.GasUsed
 			trItem.BlockGasLimit = cblockv.Header.GasLimit
 			trItem.BlockNonce = cblockv.Header.Nonce.Uint64()
@@ -152,15 +169,26 @@ func (r *Reader) ListCTransactions(ctx context.Context, p *params.ListCTransacti
 		}
 	}
 
+	if err := r.decodeCTransactions(ctx, dbRunner, trItems); err != nil {
+		return nil, err
+	}
+
 	listParamsOriginal := p.ListParams
 
 	return &models.CTransactionList{
+		ListMetadata: models.ListMetadata{Next: next},
 		Transactions: trItems,
 		StartTime:    listParamsOriginal.StartTime,
 		EndTime:      listParamsOriginal.EndTime,
 	}, nil
 }
 
+// cTransactionKey keys the hashes and the blocks by chain, as the block
+// numbers of the EVM chains overlap.
+func cTransactionKey(chainID string, v string) string {
+	return chainID + ":" + v
+}
+
 func (r *Reader) listCTransFilter(p *params.ListCTransactionsParams, dbRunner *dbr.Session, sq *dbr.SelectStmt) {
 	createdatefilter := func(tbl string, b *dbr.SelectStmt) *dbr.SelectStmt {
 		if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
@@ -172,12 +200,21 @@ func (r *Reader) listCTransFilter(p *params.ListCTransactionsParams, dbRunner *d
 		return b
 	}
 
+	chainfilter := func(tbl string, b *dbr.SelectStmt) *dbr.SelectStmt {
+		if len(p.ChainIDs) != 0 {
+			b.Where(tbl+".chain_id IN ?", p.ChainIDs)
+		}
+		return b
+	}
+
 	blockfilter := func(b *dbr.SelectStmt) *dbr.SelectStmt {
+		chainfilter(db.TableCvmTransactionsTxdataTrace, b)
 		if p.BlockStart == nil && p.BlockEnd == nil {
 			return b
 		}
 		b.Join(db.TableCvmTransactionsTxdata,
-			db.TableCvmTransactionsTxdataTrace+".hash = "+db.TableCvmTransactionsTxdata+".hash")
+			db.TableCvmTransactionsTxdataTrace+".chain_id = "+db.TableCvmTransactionsTxdata+".chain_id and "+
+				db.TableCvmTransactionsTxdataTrace+".hash = "+db.TableCvmTransactionsTxdata+".hash")
 		if p.BlockStart != nil {
 			b.Where(db.TableCvmTransactionsTxdata + ".block >= " + p.BlockStart.String())
 		}
@@ -232,8 +269,9 @@ func (r *Reader) listCTransFilter(p *params.ListCTransactionsParams, dbRunner *d
 				Where(db.TableCvmTransactionsTxdataTrace+".from_addr in ?", p.CAddresses)),
 		)
 		subqrcpt := createdatefilter(db.TableCvmTransactionsTxdata,
-			blockrcptfilter(dbRunner.Select(db.TableCvmTransactionsTxdata+".hash").From(db.TableCvmTransactionsTxdata).
-				Where("rcpt in ?", p.CAddresses)),
+			chainfilter(db.TableCvmTransactionsTxdata,
+				blockrcptfilter(dbRunner.Select(db.TableCvmTransactionsTxdata+".hash").From(db.TableCvmTransactionsTxdata).
+					Where("rcpt in ?", p.CAddresses))),
 		)
 		sq.
 			Where("hash in ?",
@@ -251,6 +289,7 @@ func (r *Reader) handleDressTraces(ctx context.Context, dbRunner *dbr.Session, h
 	var err error
 	var txTransactionTraceServices []*db.CvmTransactionsTxdataTrace
 	_, err = dbRunner.Select(
+		"chain_id",
 		"hash",
 		"idx",
 		"to_addr",
@@ -267,14 +306,18 @@ func (r *Reader) handleDressTraces(ctx context.Context, dbRunner *dbr.Session, h
 	}
 
 	for _, txTransactionTraceService := range txTransactionTraceServices {
+		trItem, ok := trItemsByHash[cTransactionKey(txTransactionTraceService.ChainID, txTransactionTraceService.Hash)]
+		if !ok {
+			continue
+		}
 		txTransactionTraceModel := &models.CvmTransactionsTxDataTrace{}
 		err = json.Unmarshal(txTransactionTraceService.Serialization, txTransactionTraceModel)
 		if err != nil {
 			return err
 		}
 		if txTransactionTraceService.Idx == 0 {
-			trItemsByHash[txTransactionTraceService.Hash].ToAddr = txTransactionTraceModel.ToAddr
-			trItemsByHash[txTransactionTraceService.Hash].FromAddr = txTransactionTraceModel.FromAddr
+			trItem.ToAddr = txTransactionTraceModel.ToAddr
+			trItem.FromAddr = txTransactionTraceModel.FromAddr
 		}
 
 		toDecimal := func(v *string) {
@@ -301,13 +344,13 @@ func (r *Reader) handleDressTraces(ctx context.Context, dbRunner *dbr.Session, h
 		txTransactionTraceModel.Input = nilEmpty(txTransactionTraceModel.Input, "0x")
 		txTransactionTraceModel.Output = nilEmpty(txTransactionTraceModel.Output, "0x")
 
-		if trItemsByHash[txTransactionTraceService.Hash].TracesMap == nil {
-			trItemsByHash[txTransactionTraceService.Hash].TracesMap = make(map[uint32]*models.CvmTransactionsTxDataTrace)
+		if trItem.TracesMap == nil {
+			trItem.TracesMap = make(map[uint32]*models.CvmTransactionsTxDataTrace)
 		}
-		if txTransactionTraceService.Idx+1 > trItemsByHash[txTransactionTraceService.Hash].TracesMax {
-			trItemsByHash[txTransactionTraceService.Hash].TracesMax = txTransactionTraceService.Idx + 1
+		if txTransactionTraceService.Idx+1 > trItem.TracesMax {
+			trItem.TracesMax = txTransactionTraceService.Idx + 1
 		}
-		trItemsByHash[txTransactionTraceService.Hash].TracesMap[txTransactionTraceService.Idx] = txTransactionTraceModel
+		trItem.TracesMap[txTransactionTraceService.Idx] = txTransactionTraceModel
 
 		txTransactionTraceModel.RevertReason = nilEmpty(txTransactionTraceModel.RevertReason, "reverted 0x")
 		if txTransactionTraceModel.RevertReason != nil {
@@ -328,17 +371,17 @@ func (r *Reader) handleDressTraces(ctx context.Context, dbRunner *dbr.Session, h
 	return nil
 }
 
-func (r *Reader) fetchAndDecodeCBlocks(ctx context.Context, dbRunner *dbr.Session, blocks []string) (map[string]*modelsc.Block, error) {
+func (r *Reader) fetchAndDecodeCBlocks(ctx context.Context, dbRunner *dbr.Session, chainID string, blocks []string) (map[string]*modelsc.Block, error) {
 	var err error
 	cblocksMap := make(map[string]*modelsc.Block)
 
 	if len(blocks) > 0 {
 		var cvmTxs []*db.CvmTransactions
 		_, err = dbRunner.Select(
-			"cast(block as char) as block",
+			db.CastString(dbRunner, "block"),
 			"serialization",
 		).From(db.TableCvmTransactions).
-			Where("block in ("+strings.Join(blocks, ",")+")").
+			Where("chain_id=? and block in ("+strings.Join(blocks, ",")+")", chainID).
 			LoadContext(ctx, &cvmTxs)
 		if err != nil {
 			return nil, err
@@ -3,7 +3,6 @@ package axc
 import (
 	"context"
 	"errors"
-	"fmt"
 	"math/big"
 	"time"
 
@@ -33,6 +32,8 @@ func (r *Reader) listTxsQuery(baseStmt *dbr.SelectStmt, p *params.ListTransactio
 	if len(p.ChainIDs) > 0 {
 		builder.Where("avm_transactions.chain_id in ?", p.ChainIDs)
 	}
+	p.ListParams.ApplyCursor(builder, "avm_transactions.created_at", "avm_transactions.id",
+		p.Sort == params.TransactionSortTimestampDesc)
 
 	assetCheck := func(stmt *dbr.SelectStmt) {
 		stmt.Where("avm_outputs.asset_id = ?", p.AssetID.String())
@@ -146,11 +147,11 @@ func (r *Reader) listTxs(
 		switch sort {
 		case params.TransactionSortTimestampDesc:
 			stmt.OrderDesc("avm_transactions.created_at")
-			stmt.OrderDesc("avm_transactions.chain_id")
+			stmt.OrderDesc("avm_transactions.id")
 		default:
 			// default is ascending...
 			stmt.OrderAsc("avm_transactions.created_at")
-			stmt.OrderAsc("avm_transactions.chain_id")
+			stmt.OrderAsc("avm_transactions.id")
 		}
 		return stmt
 	}
@@ -267,61 +268,22 @@ func (r *Reader) ListTransactions(ctx context.Context, p *params.ListTransaction
 		}
 	}
 
-	next := r.transactionProcessNext(txs, listParamsOriginal, p)
+	var next *string
+	if len(txs) != 0 {
+		last := txs[len(txs)-1]
+		next = listParamsOriginal.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(txs), listParamsOriginal.Limit)
+	}
 
 	return &models.TransactionList{ListMetadata: models.ListMetadata{
 		Count: count,
+		Next:  next,
 	},
 		Transactions: txs,
 		StartTime:    listParamsOriginal.StartTime,
 		EndTime:      listParamsOriginal.EndTime,
-		Next:         next,
 	}, nil
 }
 
-func (r *Reader) transactionProcessNext(txs []*models.Transaction, listParams params.ListParams, transactionsParams *params.ListTransactionsParams) *string {
-	if len(txs) == 0 || len(txs) < listParams.Limit {
-		return nil
-	}
-
-	lasttxCreated := txs[len(txs)-1].CreatedAt
-
-	next := ""
-	switch transactionsParams.Sort {
-	case params.TransactionSortTimestampAsc:
-		next = fmt.Sprintf("%s=%d", params.KeyStartTime, lasttxCreated.Add(time.Second).Unix())
-	case params.TransactionSortTimestampDesc:
-		next = fmt.Sprintf("%s=%d", params.KeyEndTime, lasttxCreated.Unix())
-	}
-
-	for k, vs := range listParams.Values {
-		switch k {
-		case params.KeyLimit:
-			next = fmt.Sprintf("%s&%s=%d", next, params.KeyLimit, transactionsParams.ListParams.Limit)
-		case params.KeyStartTime:
-			if transactionsParams.Sort == params.TransactionSortTimestampDesc {
-				for _, v := range vs {
-					next = fmt.Sprintf("%s&%s=%s", next, params.KeyStartTime, v)
-				}
-			}
-		case params.KeyEndTime:
-			if transactionsParams.Sort == params.TransactionSortTimestampAsc {
-				for _, v := range vs {
-					next = fmt.Sprintf("%s&%s=%s", next, params.KeyEndTime, v)
-				}
-			}
-		case params.KeyOffset:
-		case params.KeySortBy:
-		default:
-			for _, v := range vs {
-				next = fmt.Sprintf("%s&%s=%s", next, k, v)
-			}
-		}
-	}
-	next = fmt.Sprintf("%s&%s=%s", next, params.KeySortBy, transactionsParams.Sort.String())
-	return &next
-}
-
 // Load output data for all inputs and outputs into a single list
 // We can't treat them separately because some my be both inputs and outputs
 // for different transactions
@@ -633,7 +595,7 @@ func collectCvmTransactions(ctx context.Context, dbRunner dbr.SessionRunner, txI
 		"cvm_addresses.type",
 		"cvm_transactions.type as transaction_type",
 		"cvm_addresses.idx",
-		"cast(cvm_addresses.amount as char) as amount",
+		db.CastStringAs(dbRunner, "cvm_addresses.amount", "amount"),
 		"cvm_addresses.nonce",
 		"cvm_addresses.id",
 		"cvm_addresses.transaction_id",
@@ -644,7 +606,7 @@ func collectCvmTransactions(ctx context.Context, dbRunner dbr.SessionRunner, txI
 		"cvm_transactions.block",
 	).
 		From("cvm_addresses").
-		Join("cvm_transactions", "cvm_addresses.transaction_id=cvm_transactions.transaction_id").
+		Join("cvm_transactions", "cvm_addresses.chain_id=cvm_transactions.chain_id and cvm_addresses.transaction_id=cvm_transactions.transaction_id").
 		Where("cvm_addresses.transaction_id IN ?", txIDs).
 		LoadContext(ctx, &cvmAddress)
 	if err != nil {
@@ -723,10 +685,10 @@ func selectOutputs(dbRunner dbr.SessionRunner, redeem bool) *dbr.SelectBuilder {
 	cols = append(cols, "case when avm_outputs.chain_id is null then '' else avm_outputs.chain_id end as out_chain_id")
 	cols = append(cols, "case when avm_outputs_redeeming.chain_id is null then '' else avm_outputs_redeeming.chain_id end as in_chain_id")
 	cols = append(cols, "case when avm_outputs.payload is null then '' else avm_outputs.payload end as payload")
-	cols = append(cols, "case when avm_outputs.stake is null then 0 else avm_outputs.stake end as stake")
-	cols = append(cols, "case when avm_outputs.stakeableout is null then 0 else avm_outputs.stakeableout end as stakeableout")
-	cols = append(cols, "case when avm_outputs.genesisutxo is null then 0 else avm_outputs.genesisutxo end as genesisutxo")
-	cols = append(cols, "case when avm_outputs.frozen is null then 0 else avm_outputs.frozen end as frozen")
+	cols = append(cols, "case when avm_outputs.stake is null then false else avm_outputs.stake end as stake")
+	cols = append(cols, "case when avm_outputs.stakeableout is null then false else avm_outputs.stakeableout end as stakeableout")
+	cols = append(cols, "case when avm_outputs.genesisutxo is null then false else avm_outputs.genesisutxo end as genesisutxo")
+	cols = append(cols, "case when avm_outputs.frozen is null then false else avm_outputs.frozen end as frozen")
 	cols = append(cols, "case when transactions_rewards_owners_outputs.id is null then false else true end as reward_utxo")
 
 	sq := dbRunner.Select(cols...).From(tbl)
@@ -0,0 +1,322 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+// the content types sniffed from NFT payloads, other payloads are sniffed by
+// http.DetectContentType
+const (
+	nftContentTypeJSON = "application/json"
+	nftContentTypeURL  = "text/uri-list"
+)
+
+// nftURLSchemes are the schemes of the URL payloads, links to the content of
+// the NFT.
+var nftURLSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"ipfs":  true,
+	"ipns":  true,
+	"ar":    true,
+}
+
+var nftSelectColumns = []string{
+	"avm_outputs.id",
+	"avm_outputs.transaction_id",
+	"avm_outputs.chain_id",
+	"avm_outputs.asset_id",
+	"avm_outputs.group_id",
+	"avm_outputs.threshold",
+	"avm_outputs.locktime",
+	"avm_outputs.payload",
+	"avm_outputs.created_at",
+}
+
+type nftRow struct {
+	ID            models.StringID
+	TransactionID models.StringID
+	ChainID       models.StringID
+	AssetID       models.StringID
+	GroupID       uint64
+	Threshold     uint64
+	Locktime      uint64
+	Payload       []byte
+	CreatedAt     time.Time
+}
+
+// ListNFTs lists the NFTs of a collection with their owners, by group.
+func (r *Reader) ListNFTs(ctx context.Context, p *params.ListNFTsParams) (*models.NFTList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_nfts", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*nftRow
+	_, err = p.Apply(dbRunner.
+		Select(nftSelectColumns...).
+		From("avm_outputs").
+		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id")).
+		OrderAsc("avm_outputs.group_id").
+		OrderAsc("avm_outputs.id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := r.dressNFTs(ctx, dbRunner, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) < 1 {
+		return &models.NFTList{NFTs: nfts}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewCursor(strconv.FormatUint(last.GroupID, 10), string(last.ID)).Next(len(rows), p.ListParams.Limit)
+
+	return &models.NFTList{ListMetadata: models.ListMetadata{Next: next}, NFTs: nfts}, nil
+}
+
+// ListAddressNFTs lists the NFTs an address holds, the newest first.
+func (r *Reader) ListAddressNFTs(ctx context.Context, p *params.ListAddressNFTsParams) (*models.NFTList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_address_nfts", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*nftRow
+	_, err = p.Apply(dbRunner.
+		Select(nftSelectColumns...).
+		From("avm_outputs").
+		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id")).
+		OrderDesc("avm_outputs.created_at").
+		OrderDesc("avm_outputs.id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	nfts, err := r.dressNFTs(ctx, dbRunner, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) < 1 {
+		return &models.NFTList{NFTs: nfts}, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(rows), p.ListParams.Limit)
+
+	return &models.NFTList{ListMetadata: models.ListMetadata{Next: next}, NFTs: nfts}, nil
+}
+
+// ListNFTTransfers lists the history of an NFT group, oldest first.
+func (r *Reader) ListNFTTransfers(ctx context.Context, p *params.ListNFTTransfersParams) (*models.NFTTransferList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_nft_transfers", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []*struct {
+		ID                     models.StringID
+		TransactionID          models.StringID
+		ChainID                models.StringID
+		RedeemingTransactionID models.StringID
+		CreatedAt              time.Time
+	}
+	_, err = p.Apply(dbRunner.
+		Select(
+			"avm_outputs.id",
+			"avm_outputs.transaction_id",
+			"avm_outputs.chain_id",
+			"case when avm_outputs_redeeming.redeeming_transaction_id IS NULL then '' else avm_outputs_redeeming.redeeming_transaction_id end as redeeming_transaction_id",
+			"avm_outputs.created_at",
+		).
+		From("avm_outputs").
+		LeftJoin("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id")).
+		OrderAsc("avm_outputs.created_at").
+		OrderAsc("avm_outputs.id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := make([]*models.NFTTransfer, 0, len(rows))
+	if len(rows) < 1 {
+		return &models.NFTTransferList{Transfers: transfers}, nil
+	}
+
+	outputIDs := make([]models.StringID, 0, len(rows))
+	txIDs := make([]models.StringID, 0, len(rows))
+	for _, row := range rows {
+		outputIDs = append(outputIDs, row.ID)
+		txIDs = append(txIDs, row.TransactionID)
+	}
+
+	owners, err := loadOutputOwners(ctx, dbRunner, outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// the previous owners are those of the outputs of the group spent by the
+	// transaction of the transfer
+	var spent []*struct {
+		RedeemingTransactionID models.StringID
+		Address                models.Address
+	}
+	_, err = dbRunner.
+		Select(
+			"avm_outputs_redeeming.redeeming_transaction_id",
+			"avm_output_addresses.address",
+		).
+		From("avm_outputs").
+		Join("avm_outputs_redeeming", "avm_outputs.id = avm_outputs_redeeming.id").
+		Join("avm_output_addresses", "avm_outputs.id = avm_output_addresses.output_id").
+		Where("avm_outputs.output_type = ?", models.OutputTypesNFTTransfer).
+		Where("avm_outputs.asset_id = ?", p.AssetID).
+		Where("avm_outputs.group_id = ?", p.GroupID).
+		Where("avm_outputs_redeeming.redeeming_transaction_id IN ?", txIDs).
+		LoadContext(ctx, &spent)
+	if err != nil {
+		return nil, err
+	}
+	previousOwners := make(map[models.StringID][]models.Address)
+	for _, s := range spent {
+		if !containsAddress(previousOwners[s.RedeemingTransactionID], s.Address) {
+			previousOwners[s.RedeemingTransactionID] = append(previousOwners[s.RedeemingTransactionID], s.Address)
+		}
+	}
+
+	for _, row := range rows {
+		transfer := &models.NFTTransfer{
+			OutputID:               row.ID,
+			TransactionID:          row.TransactionID,
+			ChainID:                row.ChainID,
+			From:                   previousOwners[row.TransactionID],
+			To:                     owners[row.ID],
+			RedeemingTransactionID: row.RedeemingTransactionID,
+			Timestamp:              row.CreatedAt,
+		}
+		if transfer.From == nil {
+			transfer.From = []models.Address{}
+		}
+		if transfer.To == nil {
+			transfer.To = []models.Address{}
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(rows), p.ListParams.Limit)
+
+	return &models.NFTTransferList{ListMetadata: models.ListMetadata{Next: next}, Transfers: transfers}, nil
+}
+
+func (r *Reader) dressNFTs(ctx context.Context, dbRunner dbr.SessionRunner, rows []*nftRow) ([]*models.NFT, error) {
+	nfts := make([]*models.NFT, 0, len(rows))
+	if len(rows) == 0 {
+		return nfts, nil
+	}
+
+	outputIDs := make([]models.StringID, 0, len(rows))
+	for _, row := range rows {
+		outputIDs = append(outputIDs, row.ID)
+	}
+	owners, err := loadOutputOwners(ctx, dbRunner, outputIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		nft := &models.NFT{
+			AssetID:       row.AssetID,
+			GroupID:       row.GroupID,
+			OutputID:      row.ID,
+			TransactionID: row.TransactionID,
+			ChainID:       row.ChainID,
+			Owners:        owners[row.ID],
+			Threshold:     row.Threshold,
+			Locktime:      row.Locktime,
+			Payload:       row.Payload,
+			ContentType:   nftContentType(row.Payload),
+			Timestamp:     row.CreatedAt,
+		}
+		if nft.Owners == nil {
+			nft.Owners = []models.Address{}
+		}
+		nfts = append(nfts, nft)
+	}
+	return nfts, nil
+}
+
+// loadOutputOwners loads the addresses of the outputs.
+func loadOutputOwners(ctx context.Context, dbRunner dbr.SessionRunner, outputIDs []models.StringID) (map[models.StringID][]models.Address, error) {
+	var addresses []*models.OutputAddress
+	_, err := dbRunner.
+		Select(
+			"avm_output_addresses.output_id",
+			"avm_output_addresses.address",
+		).
+		From("avm_output_addresses").
+		Where("avm_output_addresses.output_id IN ?", outputIDs).
+		OrderAsc("avm_output_addresses.address").
+		LoadContext(ctx, &addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[models.StringID][]models.Address, len(outputIDs))
+	for _, address := range addresses {
+		owners[address.OutputID] = append(owners[address.OutputID], address.Address)
+	}
+	return owners, nil
+}
+
+func containsAddress(addresses []models.Address, address models.Address) bool {
+	for _, a := range addresses {
+		if a.Equals(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// nftContentType sniffs the content type of an NFT payload, a JSON document,
+// a URL, or any of the types of http.DetectContentType.
+func nftContentType(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) != 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return nftContentTypeJSON
+	}
+
+	if s := string(trimmed); utf8.ValidString(s) && !strings.ContainsAny(s, " \t\r\n") {
+		if u, err := url.Parse(s); err == nil && nftURLSchemes[strings.ToLower(u.Scheme)] && u.Host != "" {
+			return nftContentTypeURL
+		}
+	}
+
+	return http.DetectContentType(payload)
+}
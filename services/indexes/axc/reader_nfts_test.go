@@ -0,0 +1,26 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"testing"
+)
+
+func TestNFTContentType(t *testing.T) {
+	for payload, contentType := range map[string]string{
+		"":                                   "",
+		`{"name": "axia", "image": "x.png"}`: nftContentTypeJSON,
+		" [1, 2]\n":                          nftContentTypeJSON,
+		"{not json":                          "text/plain; charset=utf-8",
+		"https://example.com/nft/1.json":     nftContentTypeURL,
+		"ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG": nftContentTypeURL,
+		"ftp://example.com/nft":                                 "text/plain; charset=utf-8",
+		"see https://example.com":                               "text/plain; charset=utf-8",
+		"\x89PNG\r\n\x1a\n\x00":                                 "image/png",
+	} {
+		if ct := nftContentType([]byte(payload)); ct != contentType {
+			t.Fatal("content type", payload, ct)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+func (r *Reader) ListSubnets(ctx context.Context, p *params.ListSubnetsParams) (*models.SubnetList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_subnets", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []*models.Subnet
+	_, err = p.Apply(dbRunner.
+		Select(
+			"subnets.id",
+			"subnets.threshold",
+			"subnets.locktime",
+			"subnets.created_at",
+		).
+		From("subnets")).
+		OrderAsc("subnets.created_at").
+		OrderAsc("subnets.id").
+		LoadContext(ctx, &subnets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subnets) < 1 {
+		return &models.SubnetList{Subnets: subnets}, nil
+	}
+
+	last := subnets[len(subnets)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(subnets), p.ListParams.Limit)
+
+	subnetIDs := make([]models.StringID, len(subnets))
+	subnetMap := make(map[models.StringID]*models.Subnet, len(subnets))
+	for i, subnet := range subnets {
+		subnet.ControlKeys = []models.Address{}
+		subnetIDs[i] = subnet.ID
+		subnetMap[subnet.ID] = subnet
+	}
+
+	type controlKeyRow struct {
+		SubnetID models.StringID
+		Address  models.Address
+	}
+	var controlKeys []*controlKeyRow
+	_, err = dbRunner.
+		Select(
+			"subnet_control_keys.subnet_id",
+			"subnet_control_keys.address",
+		).
+		From("subnet_control_keys").
+		Where("subnet_control_keys.subnet_id IN ?", subnetIDs).
+		LoadContext(ctx, &controlKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, controlKey := range controlKeys {
+		subnet := subnetMap[controlKey.SubnetID]
+		if subnet == nil {
+			continue
+		}
+		subnet.ControlKeys = append(subnet.ControlKeys, controlKey.Address)
+	}
+
+	return &models.SubnetList{ListMetadata: models.ListMetadata{Next: next}, Subnets: subnets}, nil
+}
+
+func (r *Reader) GetSubnet(ctx context.Context, id ids.ID) (*models.Subnet, error) {
+	subnetList, err := r.ListSubnets(ctx, &params.ListSubnetsParams{
+		ListParams: params.ListParams{ID: &id, DisableCounting: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(subnetList.Subnets) > 0 {
+		return subnetList.Subnets[0], nil
+	}
+	return nil, err
+}
+
+func (r *Reader) ListBlockchains(ctx context.Context, p *params.ListBlockchainsParams) (*models.BlockchainList, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_blockchains", cfg.RequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockchains []*models.Blockchain
+	_, err = p.Apply(dbRunner.
+		Select(
+			"blockchains.id",
+			"blockchains.subnet_id",
+			"blockchains.name",
+			"blockchains.vm_id AS vmid",
+			"blockchains.genesis_hash",
+			"blockchains.created_at",
+		).
+		From("blockchains")).
+		OrderAsc("blockchains.created_at").
+		OrderAsc("blockchains.id").
+		LoadContext(ctx, &blockchains)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blockchains) < 1 {
+		return &models.BlockchainList{Blockchains: blockchains}, nil
+	}
+
+	last := blockchains[len(blockchains)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(blockchains), p.ListParams.Limit)
+
+	return &models.BlockchainList{ListMetadata: models.ListMetadata{Next: next}, Blockchains: blockchains}, nil
+}
+
+func (r *Reader) GetBlockchain(ctx context.Context, id ids.ID) (*models.Blockchain, error) {
+	blockchainList, err := r.ListBlockchains(ctx, &params.ListBlockchainsParams{
+		ListParams: params.ListParams{ID: &id, DisableCounting: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(blockchainList.Blockchains) > 0 {
+		return blockchainList.Blockchains[0], nil
+	}
+	return nil, err
+}
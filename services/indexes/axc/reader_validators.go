@@ -0,0 +1,249 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package axc
+
+import (
+	"context"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/utils/constants"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	RewardStatusPending     = "pending"
+	RewardStatusRewarded    = "rewarded"
+	RewardStatusNotRewarded = "not_rewarded"
+)
+
+func (r *Reader) ListValidators(ctx context.Context, p *params.ListValidatorsParams) (*models.ValidatorList, error) {
+	p.Delegators = false
+	validators, next, err := r.listValidators(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ValidatorList{ListMetadata: models.ListMetadata{Next: next}, Validators: validators}, nil
+}
+
+func (r *Reader) ListDelegators(ctx context.Context, p *params.ListValidatorsParams) (*models.DelegatorList, error) {
+	p.Delegators = true
+	delegators, next, err := r.listValidators(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DelegatorList{ListMetadata: models.ListMetadata{Next: next}, Delegators: delegators}, nil
+}
+
+func (r *Reader) listValidators(ctx context.Context, p *params.ListValidatorsParams) ([]*models.Validator, *string, error) {
+	dbRunner, err := r.conns.DB().NewSession("list_validators", cfg.RequestTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type validatorRow struct {
+		ID        models.StringID
+		Type      string
+		NodeID    models.StringID
+		SubnetID  models.StringID
+		Start     uint64
+		End       uint64
+		Weight    uint64
+		CreatedAt time.Time
+	}
+	var rows []*validatorRow
+	_, err = p.Apply(dbRunner.
+		Select(
+			"transactions_validator.id",
+			"avm_transactions.type",
+			"transactions_validator.node_id",
+			"transactions_validator.subnet_id",
+			"transactions_validator.start",
+			"transactions_validator.end",
+			"transactions_validator.weight",
+			"transactions_validator.created_at",
+		).
+		From("transactions_validator").
+		Join("avm_transactions", "transactions_validator.id = avm_transactions.id")).
+		OrderDesc("transactions_validator.created_at").
+		OrderDesc("transactions_validator.id").
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	validators := make([]*models.Validator, 0, len(rows))
+	if len(rows) == 0 {
+		return validators, nil, nil
+	}
+
+	last := rows[len(rows)-1]
+	next := p.ListParams.NewTimeCursor(last.CreatedAt, string(last.ID)).Next(len(rows), p.ListParams.Limit)
+
+	now := uint64(p.Now.Unix())
+	primaryNetworkID := models.StringID(constants.PrimaryNetworkID.String())
+	txIDs := make([]models.StringID, 0, len(rows))
+	stakerIDs := make([]models.StringID, 0, len(rows))
+	validatorMap := make(map[models.StringID]*models.Validator, len(rows))
+	for _, row := range rows {
+		validator := &models.Validator{
+			TxID:        row.ID,
+			Type:        row.Type,
+			NodeID:      row.NodeID,
+			SubnetID:    row.SubnetID,
+			Start:       row.Start,
+			End:         row.End,
+			StakeAmount: models.TokenAmountForUint64(0),
+			Weight:      models.TokenAmountForUint64(row.Weight),
+			CreatedAt:   row.CreatedAt,
+		}
+		switch {
+		case now < row.Start:
+			validator.Status = string(params.ValidatorStatusPending)
+		case now < row.End:
+			validator.Status = string(params.ValidatorStatusActive)
+		default:
+			validator.Status = string(params.ValidatorStatusExpired)
+		}
+
+		// only the stakers of the primary network are rewarded
+		if row.SubnetID == primaryNetworkID {
+			validator.StakeAmount = validator.Weight
+			validator.Reward = &models.ValidatorReward{
+				Status: RewardStatusPending,
+				Amount: models.TokenAmountForUint64(0),
+			}
+			stakerIDs = append(stakerIDs, row.ID)
+		}
+
+		txIDs = append(txIDs, row.ID)
+		validatorMap[row.ID] = validator
+		validators = append(validators, validator)
+	}
+
+	if err := dressValidatorRewardOwners(ctx, dbRunner, txIDs, validatorMap); err != nil {
+		return nil, nil, err
+	}
+	if len(stakerIDs) != 0 {
+		if err := dressValidatorRewards(ctx, dbRunner, stakerIDs, validatorMap); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return validators, next, nil
+}
+
+func dressValidatorRewardOwners(ctx context.Context, dbRunner dbr.SessionRunner, txIDs []models.StringID, validatorMap map[models.StringID]*models.Validator) error {
+	type rewardOwnerRow struct {
+		ID        models.StringID
+		Threshold uint64
+		Locktime  uint64
+	}
+	var owners []*rewardOwnerRow
+	_, err := dbRunner.
+		Select(
+			"transactions_rewards_owners.id",
+			"transactions_rewards_owners.threshold",
+			"transactions_rewards_owners.locktime",
+		).
+		From("transactions_rewards_owners").
+		Where("transactions_rewards_owners.id IN ?", txIDs).
+		LoadContext(ctx, &owners)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	for _, owner := range owners {
+		validator := validatorMap[owner.ID]
+		if validator == nil {
+			continue
+		}
+		validator.RewardOwner = &models.ValidatorRewardOwner{
+			Addresses: []models.Address{},
+			Threshold: owner.Threshold,
+			Locktime:  owner.Locktime,
+		}
+	}
+
+	type rewardOwnerAddressRow struct {
+		ID      models.StringID
+		Address models.Address
+	}
+	var addresses []*rewardOwnerAddressRow
+	_, err = dbRunner.
+		Select(
+			"transactions_rewards_owners_address.id",
+			"transactions_rewards_owners_address.address",
+		).
+		From("transactions_rewards_owners_address").
+		Where("transactions_rewards_owners_address.id IN ?", txIDs).
+		OrderAsc("transactions_rewards_owners_address.output_index").
+		LoadContext(ctx, &addresses)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		validator := validatorMap[address.ID]
+		if validator == nil || validator.RewardOwner == nil {
+			continue
+		}
+		validator.RewardOwner.Addresses = append(validator.RewardOwner.Addresses, address.Address)
+	}
+	return nil
+}
+
+// dressValidatorRewards sets the outcome of the rewards, from the block which
+// accepted the reward transaction, and the amount of the reward outputs.
+func dressValidatorRewards(ctx context.Context, dbRunner dbr.SessionRunner, txIDs []models.StringID, validatorMap map[models.StringID]*models.Validator) error {
+	rewardsTypes, err := resolveRewarded(ctx, dbRunner, txIDs)
+	if err != nil {
+		return err
+	}
+	for txID, rewardsType := range rewardsTypes {
+		validator := validatorMap[txID]
+		if validator == nil {
+			continue
+		}
+		rewardedTime := rewardsType.CreatedAt
+		validator.Reward.Timestamp = &rewardedTime
+		validator.Reward.Status = RewardStatusNotRewarded
+		if rewardsType.Type == models.BlockTypeCommit {
+			validator.Reward.Status = RewardStatusRewarded
+		}
+	}
+
+	type rewardAmountRow struct {
+		TransactionID models.StringID
+		Amount        models.TokenAmount
+	}
+	var amounts []*rewardAmountRow
+	_, err = dbRunner.
+		Select(
+			"transactions_rewards_owners_outputs.transaction_id",
+			"COALESCE(SUM(avm_outputs.amount), 0) AS amount",
+		).
+		From("transactions_rewards_owners_outputs").
+		Join("avm_outputs", "transactions_rewards_owners_outputs.id = avm_outputs.id").
+		Where("transactions_rewards_owners_outputs.transaction_id IN ?", txIDs).
+		GroupBy("transactions_rewards_owners_outputs.transaction_id").
+		LoadContext(ctx, &amounts)
+	if err != nil {
+		return err
+	}
+
+	for _, amount := range amounts {
+		validator := validatorMap[amount.TransactionID]
+		if validator == nil {
+			continue
+		}
+		validator.Reward.Amount = amount.Amount
+	}
+	return nil
+}
@@ -223,6 +223,25 @@ func (w *Writer) InsertTransactionIns(
 		return 0, err
 	}
 
+	// an input of another chain imports an exported output
+	if chainID != w.chainID {
+		importedAt := ctx.Time()
+		err = ctx.Persist().InsertAtomicTransfersImport(ctx.Ctx(), ctx.DB(), &db.AtomicTransfers{
+			ID:               inputID.String(),
+			ExportTxID:       in.TxID.String(),
+			SourceChain:      chainID,
+			DestinationChain: w.chainID,
+			AssetID:          in.AssetID().String(),
+			Amount:           in.Input().Amount(),
+			ImportTxID:       txID.String(),
+			ImportedAt:       &importedAt,
+			CreatedAt:        importedAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	return totalin, ctx.Persist().InsertOutputsRedeeming(ctx.Ctx(), ctx.DB(), outputsRedeeming, cfg.PerformUpdates)
 }
 
@@ -241,6 +260,24 @@ func (w *Writer) InsertTransactionOuts(
 	if err != nil {
 		return 0, err
 	}
+
+	// an output to another chain is exported
+	if chainID != w.chainID {
+		exportedAt := ctx.Time()
+		err = ctx.Persist().InsertAtomicTransfersExport(ctx.Ctx(), ctx.DB(), &db.AtomicTransfers{
+			ID:               txID.Prefix(uint64(idx)).String(),
+			ExportTxID:       txID.String(),
+			SourceChain:      w.chainID,
+			DestinationChain: chainID,
+			AssetID:          out.AssetID().String(),
+			Amount:           out.Output().Amount(),
+			ExportedAt:       &exportedAt,
+			CreatedAt:        exportedAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
 	return totalout, nil
 }
 
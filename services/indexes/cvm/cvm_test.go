@@ -85,6 +85,9 @@ func TestInsertTxInternalExport(t *testing.T) {
 	if len(persist.Outputs) != 1 {
 		t.Fatal("insert failed")
 	}
+	if len(persist.AtomicTransfers) != 1 {
+		t.Fatal("insert failed")
+	}
 }
 
 func TestInsertTxInternalImport(t *testing.T) {
@@ -120,4 +123,7 @@ func TestInsertTxInternalImport(t *testing.T) {
 	if len(persist.OutputsRedeeming) != 1 {
 		t.Fatal("insert failed")
 	}
+	if len(persist.AtomicTransfers) != 1 {
+		t.Fatal("insert failed")
+	}
 }
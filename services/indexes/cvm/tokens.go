@@ -0,0 +1,211 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-coreth/rpc"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gocraft/dbr/v2"
+)
+
+// maxTokenAmountDigits is the precision of the token amounts of the db, the
+// amount of a larger value is not counted in the balances.
+const maxTokenAmountDigits = 65
+
+var tokenCallTimeout = 10 * time.Second
+
+var (
+	// Transfer(address,address,uint256) and Approval(address,address,uint256)
+	transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	approvalTopic = common.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+
+	nameSelector     = common.FromHex("0x06fdde03")
+	symbolSelector   = common.FromHex("0x95d89b41")
+	decimalsSelector = common.FromHex("0x313ce567")
+)
+
+// ContractCaller runs the eth_call of the token metadata.
+type ContractCaller interface {
+	CallContract(to common.Address, data []byte, rpcTimeout time.Duration) ([]byte, error)
+}
+
+// SetContractCaller sets the caller of the token metadata, without it the
+// tokens are indexed without a name, symbol and decimals.
+func (w *Writer) SetContractCaller(caller ContractCaller) {
+	w.contractCaller = caller
+}
+
+type tokenLog struct {
+	tokenType string
+	event     string
+	from      common.Address
+	to        common.Address
+	value     *big.Int
+}
+
+// decodeTokenLog decodes the Transfer and Approval events of the ERC-20 and
+// ERC-721 tokens. They share their signature, the value is indexed by ERC-721.
+func decodeTokenLog(txLogs *types.Log) (*tokenLog, bool) {
+	if len(txLogs.Topics) == 0 {
+		return nil, false
+	}
+	tl := &tokenLog{}
+	switch txLogs.Topics[0] {
+	case transferTopic:
+		tl.event = models.CTokenEventTransfer
+	case approvalTopic:
+		tl.event = models.CTokenEventApproval
+	default:
+		return nil, false
+	}
+	switch {
+	case len(txLogs.Topics) == 3 && len(txLogs.Data) == common.HashLength:
+		tl.tokenType = models.CTokenTypeERC20
+		tl.value = new(big.Int).SetBytes(txLogs.Data)
+	case len(txLogs.Topics) == 4 && len(txLogs.Data) == 0:
+		tl.tokenType = models.CTokenTypeERC721
+		tl.value = txLogs.Topics[3].Big()
+	default:
+		return nil, false
+	}
+	tl.from = common.BytesToAddress(txLogs.Topics[1].Bytes())
+	tl.to = common.BytesToAddress(txLogs.Topics[2].Bytes())
+	return tl, true
+}
+
+// amount is what the event moves for the token balances, the value of an
+// ERC-20 transfer, and one token for an ERC-721 transfer.
+func (tl *tokenLog) amount() string {
+	if tl.event != models.CTokenEventTransfer {
+		return "0"
+	}
+	if tl.tokenType == models.CTokenTypeERC721 {
+		return "1"
+	}
+	amount := tl.value.String()
+	if len(amount) > maxTokenAmountDigits {
+		return "0"
+	}
+	return amount
+}
+
+func (w *Writer) newTokenTransfer(cvmLogs *db.CvmLogs, txLogs *types.Log, tl *tokenLog) *db.CvmTokenTransfers {
+	return &db.CvmTokenTransfers{
+		ID:        cvmLogs.ID,
+		ChainID:   w.chainID,
+		Address:   utils.CommonAddressHexRepair(&txLogs.Address),
+		Event:     tl.event,
+		TxHash:    cvmLogs.TxHash,
+		Block:     cvmLogs.Block,
+		LogIndex:  cvmLogs.LogIndex,
+		FromAddr:  utils.CommonAddressHexRepair(&tl.from),
+		ToAddr:    utils.CommonAddressHexRepair(&tl.to),
+		Amount:    tl.amount(),
+		Value:     tl.value.String(),
+		CreatedAt: cvmLogs.CreatedAt,
+	}
+}
+
+// queryNewToken is the token of the contract, if it was not indexed yet.
+func (w *Writer) queryNewToken(ctx context.Context, sess dbr.SessionRunner, persist db.Persist, address common.Address, tokenType string, createdAt time.Time) (*db.CvmTokens, error) {
+	_, err := persist.QueryCvmTokens(ctx, sess, &db.CvmTokens{
+		ChainID: w.chainID,
+		Address: utils.CommonAddressHexRepair(&address),
+	})
+	switch err {
+	case nil:
+		return nil, nil
+	case dbr.ErrNotFound:
+		return w.newToken(address, tokenType, createdAt)
+	default:
+		return nil, err
+	}
+}
+
+// newToken reads the metadata of the token from its contract. A call which
+// the contract reverts leaves its field empty, the token does not implement
+// the optional metadata.
+func (w *Writer) newToken(address common.Address, tokenType string, createdAt time.Time) (*db.CvmTokens, error) {
+	token := &db.CvmTokens{
+		ChainID:   w.chainID,
+		Address:   utils.CommonAddressHexRepair(&address),
+		Type:      tokenType,
+		CreatedAt: createdAt,
+	}
+	if w.contractCaller == nil {
+		return token, nil
+	}
+
+	var err error
+	if token.Name, err = w.callTokenString(address, nameSelector); err != nil {
+		return nil, err
+	}
+	if token.Symbol, err = w.callTokenString(address, symbolSelector); err != nil {
+		return nil, err
+	}
+	if tokenType == models.CTokenTypeERC20 {
+		res, err := w.callToken(address, decimalsSelector)
+		if err != nil {
+			return nil, err
+		}
+		if len(res) == common.HashLength {
+			decimals := new(big.Int).SetBytes(res)
+			if decimals.IsUint64() && decimals.Uint64() <= 255 {
+				token.Decimals = uint8(decimals.Uint64())
+			}
+		}
+	}
+	return token, nil
+}
+
+func (w *Writer) callToken(address common.Address, selector []byte) ([]byte, error) {
+	res, err := w.contractCaller.CallContract(address, selector, tokenCallTimeout)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return nil, nil
+	}
+	return res, err
+}
+
+// callTokenString calls a metadata method returning a string, or a bytes32
+// for some of the early tokens.
+func (w *Writer) callTokenString(address common.Address, selector []byte) (string, error) {
+	res, err := w.callToken(address, selector)
+	if err != nil || len(res) == 0 {
+		return "", err
+	}
+
+	var str string
+	if len(res) == common.HashLength {
+		str = string(bytes.TrimRight(res, "\x00"))
+	} else {
+		stringType, err := abi.NewType("string", "", nil)
+		if err != nil {
+			return "", err
+		}
+		vals, err := abi.Arguments{{Type: stringType}}.Unpack(res)
+		if err != nil || len(vals) != 1 {
+			return "", nil
+		}
+		str, _ = vals[0].(string)
+	}
+
+	str = strings.ToValidUTF8(strings.ReplaceAll(str, "\x00", ""), "")
+	if len(str) > 256 {
+		str = str[:256]
+	}
+	return strings.ToValidUTF8(str, ""), nil
+}
@@ -0,0 +1,109 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cvm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testContractCaller map[string][]byte
+
+func (c testContractCaller) CallContract(to common.Address, data []byte, rpcTimeout time.Duration) ([]byte, error) {
+	return c[common.Bytes2Hex(data)], nil
+}
+
+func TestDecodeTokenLog(t *testing.T) {
+	from := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	to := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	value := common.BigToHash(big.NewInt(1000))
+
+	tl, ok := decodeTokenLog(&types.Log{
+		Topics: []common.Hash{transferTopic, from.Hash(), to.Hash()},
+		Data:   value.Bytes(),
+	})
+	if !ok {
+		t.Fatal("erc20 transfer not decoded")
+	}
+	if tl.tokenType != models.CTokenTypeERC20 || tl.event != models.CTokenEventTransfer {
+		t.Fatal("erc20 transfer type", tl.tokenType, tl.event)
+	}
+	if tl.from != from || tl.to != to || tl.value.Int64() != 1000 || tl.amount() != "1000" {
+		t.Fatal("erc20 transfer values")
+	}
+
+	tl, ok = decodeTokenLog(&types.Log{
+		Topics: []common.Hash{transferTopic, from.Hash(), to.Hash(), common.BigToHash(big.NewInt(7))},
+	})
+	if !ok {
+		t.Fatal("erc721 transfer not decoded")
+	}
+	if tl.tokenType != models.CTokenTypeERC721 || tl.value.Int64() != 7 || tl.amount() != "1" {
+		t.Fatal("erc721 transfer values")
+	}
+
+	tl, ok = decodeTokenLog(&types.Log{
+		Topics: []common.Hash{approvalTopic, from.Hash(), to.Hash()},
+		Data:   bytes.Repeat([]byte{0xff}, common.HashLength),
+	})
+	if !ok {
+		t.Fatal("erc20 approval not decoded")
+	}
+	if tl.event != models.CTokenEventApproval || tl.amount() != "0" {
+		t.Fatal("erc20 approval values")
+	}
+
+	if _, ok = decodeTokenLog(&types.Log{Topics: []common.Hash{transferTopic, from.Hash()}}); ok {
+		t.Fatal("invalid transfer decoded")
+	}
+	if _, ok = decodeTokenLog(&types.Log{Topics: []common.Hash{from.Hash()}}); ok {
+		t.Fatal("other event decoded")
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	// abi encoded "Token", and the bytes32 "TKN"
+	name := append(common.BigToHash(big.NewInt(32)).Bytes(), common.BigToHash(big.NewInt(5)).Bytes()...)
+	name = append(name, common.RightPadBytes([]byte("Token"), 32)...)
+	symbol := common.RightPadBytes([]byte("TKN"), 32)
+
+	w := &Writer{chainID: "ch1"}
+	w.SetContractCaller(testContractCaller{
+		common.Bytes2Hex(nameSelector):     name,
+		common.Bytes2Hex(symbolSelector):   symbol,
+		common.Bytes2Hex(decimalsSelector): common.BigToHash(big.NewInt(6)).Bytes(),
+	})
+
+	address := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	token, err := w.newToken(address, models.CTokenTypeERC20, time.Now())
+	if err != nil {
+		t.Fatal("new token failed", err)
+	}
+	if token.Name != "Token" || token.Symbol != "TKN" || token.Decimals != 6 {
+		t.Fatal("token metadata", token.Name, token.Symbol, token.Decimals)
+	}
+	if !bytes.Equal(common.FromHex(token.Address), address.Bytes()) || token.ChainID != "ch1" {
+		t.Fatal("token address", token.Address)
+	}
+}
+
+func TestLogAddressTopics(t *testing.T) {
+	txLogs := &types.Log{
+		Address: common.HexToAddress("0x00000000000000000000000000000000000000a1"),
+		Topics:  []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")},
+	}
+	address, topics := LogAddressTopics(txLogs)
+	if address != "0x00000000000000000000000000000000000000a1" {
+		t.Fatal("wrong address", address)
+	}
+	if topics[0] != common.HexToHash("0x1").Hex() || topics[1] != common.HexToHash("0x2").Hex() || topics[2] != "" || topics[3] != "" {
+		t.Fatal("wrong topics", topics)
+	}
+}
@@ -0,0 +1,130 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cvm
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// the call types of the callTracer
+const (
+	callTypeCall         = "CALL"
+	callTypeCreate       = "CREATE"
+	callTypeCreate2      = "CREATE2"
+	callTypeSelfDestruct = "SELFDESTRUCT"
+)
+
+// maxTraceErrorLen is the length of the error column of the internal transfers.
+const maxTraceErrorLen = 256
+
+// traceRows are the contract deployments and the internal transfers of a
+// transaction trace.
+type traceRows struct {
+	chainID   string
+	trace     *modelsc.TransactionTrace
+	createdAt time.Time
+
+	deployments []*db.CvmContractDeployments
+	transfers   []*db.CvmInternalTransfers
+}
+
+// newTraceRows walks the calls of the trace, the creation of a contract
+// creation transaction is a deployment but not an internal transfer. The calls
+// of a failed transaction are reverted with it.
+func newTraceRows(chainID string, trace *modelsc.TransactionTrace, createdAt time.Time) (*traceRows, error) {
+	rows := &traceRows{chainID: chainID, trace: trace, createdAt: createdAt}
+	if trace.Create != nil {
+		rows.addDeployment(trace.Create, trace.Error)
+	}
+	if len(trace.Trace) == 0 {
+		return rows, nil
+	}
+
+	call := &modelsc.Call{}
+	if err := json.Unmarshal(trace.Trace, call); err != nil {
+		return nil, err
+	}
+	if err := rows.walk(call, []int{int(trace.Idx)}, trace.Error); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *traceRows) walk(call *modelsc.Call, traceAddress []int, revertErr string) error {
+	if revertErr == "" {
+		revertErr = call.Error
+	}
+
+	r.addDeployment(call, revertErr)
+
+	switch strings.ToUpper(call.Type) {
+	case callTypeCall, callTypeCreate, callTypeCreate2, callTypeSelfDestruct:
+		if call.Value != nil && call.Value.ToInt().Sign() > 0 {
+			if err := r.addTransfer(call, traceAddress, revertErr); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, child := range call.Calls {
+		childAddress := append(append(make([]int, 0, len(traceAddress)+1), traceAddress...), i)
+		if err := r.walk(child, childAddress, revertErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *traceRows) addDeployment(call *modelsc.Call, revertErr string) {
+	typ := strings.ToUpper(call.Type)
+	if revertErr != "" || (typ != callTypeCreate && typ != callTypeCreate2) {
+		return
+	}
+	r.deployments = append(r.deployments, &db.CvmContractDeployments{
+		ChainID:   r.chainID,
+		Address:   utils.CommonAddressHexRepair(&call.To),
+		Creator:   utils.CommonAddressHexRepair(&call.From),
+		TxHash:    r.trace.Hash,
+		Block:     r.trace.Block,
+		Type:      typ,
+		CodeHash:  crypto.Keccak256Hash(call.Output).Hex(),
+		CreatedAt: r.createdAt,
+	})
+}
+
+func (r *traceRows) addTransfer(call *modelsc.Call, traceAddress []int, revertErr string) error {
+	if len(revertErr) > maxTraceErrorLen {
+		revertErr = revertErr[:maxTraceErrorLen]
+	}
+	traceAddressStrs := make([]string, 0, len(traceAddress))
+	for _, i := range traceAddress {
+		traceAddressStrs = append(traceAddressStrs, strconv.Itoa(i))
+	}
+
+	transfer := &db.CvmInternalTransfers{
+		ChainID:      r.chainID,
+		TxHash:       r.trace.Hash,
+		Block:        r.trace.Block,
+		TraceAddress: strings.Join(traceAddressStrs, ","),
+		Type:         strings.ToUpper(call.Type),
+		FromAddr:     utils.CommonAddressHexRepair(&call.From),
+		ToAddr:       utils.CommonAddressHexRepair(&call.To),
+		Value:        call.Value.ToInt().String(),
+		Error:        revertErr,
+		CreatedAt:    r.createdAt,
+	}
+	if err := transfer.ComputeID(); err != nil {
+		return err
+	}
+	r.transfers = append(r.transfers, transfer)
+	return nil
+}
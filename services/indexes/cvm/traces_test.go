@@ -0,0 +1,104 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cvm
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestNewTraceRows(t *testing.T) {
+	creator := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	factory := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	child := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	payee := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	initCode := hexutil.Bytes{0x60, 0x80}
+	runtimeCode := hexutil.Bytes{0x60, 0x40}
+
+	trace, err := json.Marshal(&modelsc.Call{
+		Type:   "CREATE2",
+		From:   factory,
+		To:     child,
+		Value:  (*hexutil.Big)(big.NewInt(5)),
+		Input:  initCode,
+		Output: runtimeCode,
+		Calls: []*modelsc.Call{
+			{Type: "CALL", From: child, To: payee, Value: (*hexutil.Big)(big.NewInt(2))},
+			{Type: "DELEGATECALL", From: child, To: payee, Value: (*hexutil.Big)(big.NewInt(2))},
+			{Type: "CALL", From: child, To: payee, Value: (*hexutil.Big)(big.NewInt(0))},
+			{Type: "CALL", From: child, To: payee, Value: (*hexutil.Big)(big.NewInt(1)), Error: "execution reverted", Calls: []*modelsc.Call{
+				{Type: "CALL", From: payee, To: creator, Value: (*hexutil.Big)(big.NewInt(1))},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal("marshal failed", err)
+	}
+
+	tm := time.Now().UTC()
+	rows, err := newTraceRows("ch1", &modelsc.TransactionTrace{
+		Hash:   "0x01",
+		Idx:    1,
+		Trace:  trace,
+		Block:  "10",
+		Create: &modelsc.Call{Type: "CREATE", From: creator, To: factory, Input: initCode},
+	}, tm)
+	if err != nil {
+		t.Fatal("trace rows failed", err)
+	}
+
+	if len(rows.deployments) != 2 {
+		t.Fatal("deployments", len(rows.deployments))
+	}
+	if d := rows.deployments[0]; d.Address != "0x2000000000000000000000000000000000000002" || d.Creator != "0x1000000000000000000000000000000000000001" || d.Type != "CREATE" {
+		t.Fatal("transaction deployment", d)
+	}
+	d := rows.deployments[1]
+	if d.Address != "0x3000000000000000000000000000000000000003" || d.Creator != "0x2000000000000000000000000000000000000002" || d.Type != "CREATE2" {
+		t.Fatal("call deployment", d)
+	}
+	if d.TxHash != "0x01" || d.Block != "10" || d.CodeHash != crypto.Keccak256Hash(runtimeCode).Hex() || !d.CreatedAt.Equal(tm) {
+		t.Fatal("call deployment values", d)
+	}
+
+	expected := []struct {
+		traceAddress string
+		value        string
+		err          string
+	}{
+		{"1", "5", ""},
+		{"1,0", "2", ""},
+		{"1,3", "1", "execution reverted"},
+		{"1,3,0", "1", "execution reverted"},
+	}
+	if len(rows.transfers) != len(expected) {
+		t.Fatal("transfers", len(rows.transfers))
+	}
+	for i, e := range expected {
+		transfer := rows.transfers[i]
+		if transfer.TraceAddress != e.traceAddress || transfer.Value != e.value || transfer.Error != e.err || transfer.ID == "" {
+			t.Fatal("transfer", i, transfer)
+		}
+	}
+
+	rows, err = newTraceRows("ch1", &modelsc.TransactionTrace{
+		Hash:   "0x02",
+		Block:  "11",
+		Error:  "out of gas",
+		Create: &modelsc.Call{Type: "CREATE", From: creator, To: factory, Input: initCode},
+	}, tm)
+	if err != nil {
+		t.Fatal("trace rows failed", err)
+	}
+	if len(rows.deployments) != 0 || len(rows.transfers) != 0 {
+		t.Fatal("failed creation", rows.deployments, rows.transfers)
+	}
+}
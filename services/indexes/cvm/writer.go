@@ -35,11 +35,14 @@ var (
 
 type Writer struct {
 	networkID   uint32
+	chainID     string
 	axcAssetID ids.ID
 
 	codec         codec.Manager
 	axc          *axcIndexer.Writer
 	ap5Activation uint64
+
+	contractCaller ContractCaller
 }
 
 func NewWriter(networkID uint32, chainID string) (*Writer, error) {
@@ -52,6 +55,7 @@ func NewWriter(networkID uint32, chainID string) (*Writer, error) {
 
 	return &Writer{
 		networkID:     networkID,
+		chainID:       chainID,
 		axcAssetID:   axcAssetID,
 		codec:         evm.Codec,
 		axc:          axcIndexer.NewWriter(chainID, axcAssetID),
@@ -110,10 +114,31 @@ func (w *Writer) extractAtomicTxsPostApricotPhase5(atomicTxBytes []byte) ([]*evm
 	return atomicTxs, nil
 }
 
+// LogAddressTopics returns the address and the topics of the log as they are
+// stored, the topics the log does not have are empty.
+func LogAddressTopics(txLogs *types.Log) (string, [4]string) {
+	var topics [4]string
+	for i := 0; i < len(topics) && i < len(txLogs.Topics); i++ {
+		topics[i] = txLogs.Topics[i].Hex()
+	}
+	return utils.CommonAddressHexRepair(&txLogs.Address), topics
+}
+
 func (w *Writer) ConsumeLogs(ctx context.Context, conns *utils.Connections, c services.Consumable, txLogs *types.Log, persist db.Persist) error {
 	job := conns.Stream().NewJob("cvm-index")
 	sess := conns.DB().NewSessionForEventReceiver(job)
 
+	// the metadata of a new token is read before the db transaction
+	var err error
+	var token *db.CvmTokens
+	tl, isTokenLog := decodeTokenLog(txLogs)
+	if isTokenLog && !txLogs.Removed {
+		token, err = w.queryNewToken(ctx, sess, persist, txLogs.Address, tl.tokenType, time.Unix(c.Timestamp(), c.Nanosecond()))
+		if err != nil {
+			return err
+		}
+	}
+
 	dbTx, err := sess.Begin()
 	if err != nil {
 		return err
@@ -122,16 +147,18 @@ func (w *Writer) ConsumeLogs(ctx context.Context, conns *utils.Connections, c se
 
 	cCtx := services.NewConsumerContext(ctx, dbTx, c.Timestamp(), c.Nanosecond(), persist)
 
-	firstTopic := ""
-	if len(txLogs.Topics) > 0 {
-		firstTopic = txLogs.Topics[0].Hex()
-	}
+	address, topics := LogAddressTopics(txLogs)
 	cvmLogs := &db.CvmLogs{
+		ChainID:       w.chainID,
 		BlockHash:     txLogs.BlockHash.Hex(),
 		TxHash:        txLogs.TxHash.Hex(),
 		LogIndex:      uint64(txLogs.Index),
+		Address:       address,
 		Block:         fmt.Sprintf("%d", txLogs.BlockNumber),
-		FirstTopic:    firstTopic,
+		FirstTopic:    topics[0],
+		Topic1:        topics[1],
+		Topic2:        topics[2],
+		Topic3:        topics[3],
 		Removed:       txLogs.Removed,
 		CreatedAt:     cCtx.Time(),
 		Serialization: c.Body(),
@@ -145,6 +172,19 @@ func (w *Writer) ConsumeLogs(ctx context.Context, conns *utils.Connections, c se
 		return err
 	}
 
+	if isTokenLog && !txLogs.Removed {
+		if token != nil {
+			err = persist.InsertCvmTokens(ctx, dbTx, token, false)
+			if err != nil {
+				return err
+			}
+		}
+		err = persist.InsertCvmTokenTransfers(ctx, dbTx, w.newTokenTransfer(cvmLogs, txLogs, tl), cfg.PerformUpdates)
+		if err != nil {
+			return err
+		}
+	}
+
 	return dbTx.Commit()
 }
 
@@ -159,27 +199,54 @@ func (w *Writer) ConsumeTrace(ctx context.Context, conns *utils.Connections, c s
 	defer dbTx.RollbackUnlessCommitted()
 
 	txTraceModel := &models.CvmTransactionsTxDataTrace{}
-	err = json.Unmarshal(transactionTrace.Trace, txTraceModel)
-	if err != nil {
-		return err
+	if len(transactionTrace.Trace) != 0 {
+		err = json.Unmarshal(transactionTrace.Trace, txTraceModel)
+		if err != nil {
+			return err
+		}
 	}
 
 	cCtx := services.NewConsumerContext(ctx, dbTx, c.Timestamp(), c.Nanosecond(), persist)
 
-	txTraceService := &db.CvmTransactionsTxdataTrace{
-		Hash:          transactionTrace.Hash,
-		Idx:           transactionTrace.Idx,
-		ToAddr:        txTraceModel.ToAddr,
-		FromAddr:      txTraceModel.FromAddr,
-		CallType:      txTraceModel.CallType,
-		Type:          txTraceModel.Type,
-		Serialization: transactionTrace.Trace,
-		CreatedAt:     cCtx.Time(),
+	// the first trace of a contract creation which made no call only has its
+	// creation
+	if len(transactionTrace.Trace) != 0 {
+		txTraceService := &db.CvmTransactionsTxdataTrace{
+			ChainID:       w.chainID,
+			Hash:          transactionTrace.Hash,
+			Idx:           transactionTrace.Idx,
+			ToAddr:        txTraceModel.ToAddr,
+			FromAddr:      txTraceModel.FromAddr,
+			CallType:      txTraceModel.CallType,
+			Type:          txTraceModel.Type,
+			Serialization: transactionTrace.Trace,
+			CreatedAt:     cCtx.Time(),
+		}
+
+		err = persist.InsertCvmTransactionsTxdataTrace(ctx, dbTx, txTraceService, cfg.PerformUpdates)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = persist.InsertCvmTransactionsTxdataTrace(ctx, dbTx, txTraceService, cfg.PerformUpdates)
-	if err != nil {
-		return err
+	// the traces produced before their block was added are not derived
+	if transactionTrace.Block != "" {
+		rows, err := newTraceRows(w.chainID, transactionTrace, cCtx.Time())
+		if err != nil {
+			return err
+		}
+		for _, deployment := range rows.deployments {
+			err = persist.InsertCvmContractDeployments(ctx, dbTx, deployment, cfg.PerformUpdates)
+			if err != nil {
+				return err
+			}
+		}
+		for _, transfer := range rows.transfers {
+			err = persist.InsertCvmInternalTransfers(ctx, dbTx, transfer, cfg.PerformUpdates)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return dbTx.Commit()
@@ -266,6 +333,7 @@ func (w *Writer) indexBlockInternal(ctx services.ConsumerCtx, atomicTXs []*evm.T
 		rawhash := rawtx.Hash()
 		rcptstr := utils.CommonAddressHexRepair(rawtx.To())
 		cvmTransactionTxdata := &db.CvmTransactionsTxdata{
+			ChainID:       w.chainID,
 			Hash:          rawhash.String(),
 			Block:         block.Header.Number.String(),
 			Idx:           uint64(ipos),
@@ -295,6 +363,7 @@ func (w *Writer) indexBlockInternal(ctx services.ConsumerCtx, atomicTXs []*evm.T
 
 	for _, txIDString := range txIDs {
 		cvmTransaction := &db.CvmTransactions{
+			ChainID:       w.chainID,
 			ID:            id.String(),
 			TransactionID: txIDString,
 			Type:          typ,
@@ -359,6 +428,7 @@ func (w *Writer) insertAddress(
 
 	cvmAddress := &db.CvmAddresses{
 		ID:            idprefix.String(),
+		ChainID:       w.chainID,
 		Type:          typ,
 		Idx:           idx,
 		TransactionID: id.String(),
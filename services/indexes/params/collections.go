@@ -4,6 +4,8 @@
 package params
 
 import (
+	"encoding/hex"
+	"fmt"
 	"math/big"
 	"net/url"
 	"strconv"
@@ -31,8 +33,14 @@ var (
 	_ Param = &AggregateParams{}
 	_ Param = &ListTransactionsParams{}
 	_ Param = &ListAssetsParams{}
+	_ Param = &ListAssetHoldersParams{}
+	_ Param = &ListNFTsParams{}
+	_ Param = &ListAddressNFTsParams{}
+	_ Param = &ListNFTTransfersParams{}
 	_ Param = &ListAddressesParams{}
 	_ Param = &ListOutputsParams{}
+	_ Param = &SubscribeParams{}
+	_ Param = &ListWebhookDeliveriesParams{}
 )
 
 type SearchParams struct {
@@ -209,7 +217,7 @@ func (p *ListTransactionsParams) ForValues(v uint8, q url.Values) error {
 		return err
 	}
 
-	return nil
+	return p.ListParams.ForCursorList("transactions," + p.Sort.String())
 }
 
 func (p *ListTransactionsParams) CacheKey() []string {
@@ -238,6 +246,7 @@ func (p *ListTransactionsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder
 
 type ListCTransactionsParams struct {
 	ListParams     ListParams
+	ChainIDs       []string
 	CAddresses     []string
 	CAddressesTo   []string
 	CAddressesFrom []string
@@ -253,6 +262,8 @@ func (p *ListCTransactionsParams) ForValues(v uint8, q url.Values) error {
 		return err
 	}
 
+	p.ChainIDs = q[KeyChainID]
+
 	p.Sort = TransactionSortDefault
 	sortBys, ok := q[KeySortBy]
 	if ok && len(sortBys) >= 1 {
@@ -305,12 +316,13 @@ func (p *ListCTransactionsParams) ForValues(v uint8, q url.Values) error {
 		p.Hashes = append(p.Hashes, hashStr)
 	}
 
-	return nil
+	return p.ListParams.ForCursorList("ctransactions")
 }
 
 func (p *ListCTransactionsParams) CacheKey() []string {
 	k := p.ListParams.CacheKey()
 	k = append(k, CacheKey(KeySortBy, p.Sort))
+	k = append(k, CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")))
 
 	for _, address := range p.CAddresses {
 		k = append(k, CacheKey(KeyAddress, address))
@@ -321,6 +333,11 @@ func (p *ListCTransactionsParams) CacheKey() []string {
 
 func (p *ListCTransactionsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
 	p.ListParams.ApplyPk(db.TableCvmTransactionsTxdata, b, "hash", false)
+	p.ListParams.ApplyCursor(b, db.TableCvmTransactionsTxdata+".created_at", db.TableCvmTransactionsTxdata+".hash", true)
+
+	if len(p.ChainIDs) != 0 {
+		b.Where(db.TableCvmTransactionsTxdata+".chain_id IN ?", p.ChainIDs)
+	}
 
 	return b
 }
@@ -338,7 +355,7 @@ func (p *ListAssetsParams) ForValues(v uint8, q url.Values) error {
 
 	p.Alias = GetQueryString(q, KeyAlias, "")
 
-	return nil
+	return p.ListParams.ForCursorList("assets")
 }
 
 func (p *ListAssetsParams) CacheKey() []string {
@@ -354,6 +371,7 @@ func (p *ListAssetsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
 	p.ListParams.Query = ""
 	p.ListParams.Apply("avm_assets", b)
 	p.ListParams.Query = querySave
+	p.ListParams.ApplyCursor(b, "avm_assets.created_at", "avm_assets.id", false)
 
 	if p.ListParams.Query != "" {
 		b.Where(dbr.Or(
@@ -370,10 +388,275 @@ func (p *ListAssetsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
 	return b
 }
 
+type AssetHolderSort string
+
+const (
+	AssetHolderSortBalanceDesc AssetHolderSort = "balance-desc"
+	AssetHolderSortBalanceAsc  AssetHolderSort = "balance-asc"
+)
+
+// AssetHolderBalance is the balance of an address in the accumulate balances,
+// maintained by the accumulate balance indexer and indexed by asset.
+const AssetHolderBalance = "accumulate_balances_received.balance"
+
+// ListAssetHoldersParams lists the addresses with a balance of an asset, by
+// balance then id, the largest first by default.
+type ListAssetHoldersParams struct {
+	ListParams ListParams
+	AssetID    string
+	ChainIDs   []string
+	Sort       AssetHolderSort
+
+	// the position of the cursor, the page starts after the holder
+	afterBalance *big.Int
+	afterID      string
+}
+
+func (p *ListAssetHoldersParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	p.Sort = AssetHolderSort(GetQueryString(q, KeySortBy, string(AssetHolderSortBalanceDesc)))
+	switch p.Sort {
+	case AssetHolderSortBalanceDesc, AssetHolderSortBalanceAsc:
+	default:
+		return fmt.Errorf("invalid %s %s", KeySortBy, p.Sort)
+	}
+
+	if cursor := p.ListParams.Cursor; cursor != nil {
+		balance, ok := new(big.Int).SetString(cursor.Key, 10)
+		if !ok {
+			return ErrInvalidCursor
+		}
+		p.afterBalance = balance
+		p.afterID = cursor.ID
+	}
+
+	return p.ListParams.ForCursorList("assetholders," + string(p.Sort))
+}
+
+func (p *ListAssetHoldersParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyAssetID, p.AssetID),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeySortBy, string(p.Sort)))
+}
+
+func (p *ListAssetHoldersParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk("accumulate_balances_received", b, "id", false)
+
+	b.Where("accumulate_balances_received.asset_id = ?", p.AssetID)
+	b.Where(AssetHolderBalance + " > 0")
+	if len(p.ChainIDs) != 0 {
+		b.Where("accumulate_balances_received.chain_id IN ?", p.ChainIDs)
+	}
+
+	desc := p.Sort != AssetHolderSortBalanceAsc
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if p.afterBalance != nil {
+		balance := p.afterBalance.String()
+		b.Where("("+AssetHolderBalance+" "+op+" "+balance+" or ("+
+			AssetHolderBalance+" = "+balance+" and accumulate_balances_received.id "+op+" ?))", p.afterID)
+	}
+
+	b.OrderDir(AssetHolderBalance, !desc)
+	b.OrderDir("accumulate_balances_received.id", !desc)
+
+	return b
+}
+
+// ListNFTsParams lists the NFTs of a collection, the NFT transfer outputs of
+// the asset which are not spent, by group ID then output ID.
+type ListNFTsParams struct {
+	ListParams ListParams
+	AssetID    string
+	ChainIDs   []string
+	GroupIDs   []uint64
+	Address    *ids.ShortID
+
+	// the position of the cursor, the page starts after the output
+	afterGroupID  *uint64
+	afterOutputID string
+}
+
+func (p *ListNFTsParams) ForValues(v uint8, q url.Values) (err error) {
+	if err = p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	if p.GroupIDs, err = getQueryGroupIDs(q); err != nil {
+		return err
+	}
+	if p.Address, err = GetQueryAddress(q, KeyAddress); err != nil {
+		return err
+	}
+
+	if cursor := p.ListParams.Cursor; cursor != nil {
+		groupID, err := strconv.ParseUint(cursor.Key, 10, 64)
+		if err != nil {
+			return ErrInvalidCursor
+		}
+		p.afterGroupID = &groupID
+		p.afterOutputID = cursor.ID
+	}
+
+	return p.ListParams.ForCursorList("nfts")
+}
+
+func (p *ListNFTsParams) CacheKey() []string {
+	k := append(p.ListParams.CacheKey(),
+		CacheKey(KeyAssetID, p.AssetID),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeyGroupID, p.GroupIDs))
+	if p.Address != nil {
+		k = append(k, CacheKey(KeyAddress, p.Address.String()))
+	}
+	return k
+}
+
+func (p *ListNFTsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk("avm_outputs", b, "id", false)
+	applyUnspentNFTs(b, p.ChainIDs, p.Address)
+
+	b.Where("avm_outputs.asset_id = ?", p.AssetID)
+	if len(p.GroupIDs) != 0 {
+		b.Where("avm_outputs.group_id IN ?", p.GroupIDs)
+	}
+
+	if p.afterGroupID != nil {
+		b.Where("(avm_outputs.group_id > ? or (avm_outputs.group_id = ? and avm_outputs.id > ?))",
+			*p.afterGroupID, *p.afterGroupID, p.afterOutputID)
+	}
+
+	return b
+}
+
+// ListAddressNFTsParams lists the NFTs an address holds, the NFT transfer
+// outputs of the address which are not spent, the newest first.
+type ListAddressNFTsParams struct {
+	ListParams ListParams
+	Address    ids.ShortID
+	ChainIDs   []string
+	AssetIDs   []string
+}
+
+func (p *ListAddressNFTsParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+	p.AssetIDs = q[KeyAssetID]
+
+	return p.ListParams.ForCursorList("addressnfts")
+}
+
+func (p *ListAddressNFTsParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyAddress, p.Address.String()),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeyAssetID, strings.Join(p.AssetIDs, "|")))
+}
+
+func (p *ListAddressNFTsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk("avm_outputs", b, "id", false)
+	p.ListParams.ApplyCursor(b, "avm_outputs.created_at", "avm_outputs.id", true)
+	applyUnspentNFTs(b, p.ChainIDs, &p.Address)
+
+	if len(p.AssetIDs) != 0 {
+		b.Where("avm_outputs.asset_id IN ?", p.AssetIDs)
+	}
+
+	return b
+}
+
+// applyUnspentNFTs restricts the query to the NFT transfer outputs which are
+// not spent, of the address when it is set.
+func applyUnspentNFTs(b *dbr.SelectBuilder, chainIDs []string, address *ids.ShortID) {
+	b.Where("avm_outputs.output_type = ?", models.OutputTypesNFTTransfer)
+	b.Where("avm_outputs_redeeming.id is null")
+	if len(chainIDs) != 0 {
+		b.Where("avm_outputs.chain_id IN ?", chainIDs)
+	}
+	if address != nil {
+		b.Where("exists (select 1 from avm_output_addresses where avm_output_addresses.output_id = avm_outputs.id and avm_output_addresses.address = ?)",
+			address.String())
+	}
+}
+
+// ListNFTTransfersParams lists the history of an NFT, the NFT transfer outputs
+// of its group in the order they were created.
+type ListNFTTransfersParams struct {
+	ListParams ListParams
+	AssetID    string
+	GroupID    uint64
+	ChainIDs   []string
+}
+
+func (p *ListNFTTransfersParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	return p.ListParams.ForCursorList("nfttransfers")
+}
+
+func (p *ListNFTTransfersParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyAssetID, p.AssetID),
+		CacheKey(KeyGroupID, p.GroupID),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")))
+}
+
+func (p *ListNFTTransfersParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk("avm_outputs", b, "id", false)
+	p.ListParams.ApplyCursor(b, "avm_outputs.created_at", "avm_outputs.id", false)
+
+	b.Where("avm_outputs.output_type = ?", models.OutputTypesNFTTransfer)
+	b.Where("avm_outputs.asset_id = ?", p.AssetID)
+	b.Where("avm_outputs.group_id = ?", p.GroupID)
+	if len(p.ChainIDs) != 0 {
+		b.Where("avm_outputs.chain_id IN ?", p.ChainIDs)
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where("avm_outputs.created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where("avm_outputs.created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+func getQueryGroupIDs(q url.Values) ([]uint64, error) {
+	var groupIDs []uint64
+	for _, groupIDStr := range q[KeyGroupID] {
+		groupID, err := strconv.ParseUint(groupIDStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s", KeyGroupID, groupIDStr)
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+	return groupIDs, nil
+}
+
 type ListAddressesParams struct {
 	ListParams ListParams
 	ChainIDs   []string
 	Address    *ids.ShortID
+	// Addresses batches lookups, it is not read from the query.
+	Addresses []ids.ShortID
 }
 
 func (p *ListAddressesParams) ForValues(v uint8, q url.Values) error {
@@ -396,7 +679,7 @@ func (p *ListAddressesParams) ForValues(v uint8, q url.Values) error {
 		p.Address = &addr
 	}
 
-	return nil
+	return p.ListParams.ForCursorList("addresses")
 }
 
 func (p *ListAddressesParams) CacheKey() []string {
@@ -405,6 +688,9 @@ func (p *ListAddressesParams) CacheKey() []string {
 	if p.Address != nil {
 		k = append(k, CacheKey(KeyAddress, p.Address.String()))
 	}
+	if len(p.Addresses) != 0 {
+		k = append(k, CacheKey(KeyAddress, strings.Join(shortIDStrings(p.Addresses), "|")))
+	}
 
 	k = append(k, CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")))
 
@@ -421,6 +707,9 @@ func (p *ListAddressesParams) Apply(b *dbr.SelectBuilder, accumulateReader bool)
 		if p.Address != nil {
 			b.Where("avm_output_addresses.address = ?", p.Address.String())
 		}
+		if len(p.Addresses) != 0 {
+			b.Where("avm_output_addresses.address IN ?", shortIDStrings(p.Addresses))
+		}
 	} else {
 		b = p.ListParams.ApplyPk("avm_output_addresses", b, "output_id", true)
 		if len(p.ChainIDs) != 0 {
@@ -430,6 +719,9 @@ func (p *ListAddressesParams) Apply(b *dbr.SelectBuilder, accumulateReader bool)
 		if p.Address != nil {
 			b.Where("accumulate_balances_received.address = ?", p.Address.String())
 		}
+		if len(p.Addresses) != 0 {
+			b.Where("accumulate_balances_received.address IN ?", shortIDStrings(p.Addresses))
+		}
 	}
 
 	return b
@@ -513,7 +805,7 @@ func (p *ListOutputsParams) ForValues(v uint8, q url.Values) error {
 		p.Spent = &b
 	}
 
-	return nil
+	return p.ListParams.ForCursorList("outputs")
 }
 
 func (p *ListOutputsParams) CacheKey() []string {
@@ -533,6 +825,7 @@ func (p *ListOutputsParams) CacheKey() []string {
 
 func (p *ListOutputsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
 	p.ListParams.Apply("avm_outputs", b)
+	p.ListParams.ApplyCursor(b, "avm_outputs.created_at", "avm_outputs.id", false)
 
 	if p.Spent != nil {
 		if *p.Spent {
@@ -572,7 +865,10 @@ type ListBlocksParams struct {
 }
 
 func (p *ListBlocksParams) ForValues(v uint8, q url.Values) error {
-	return p.ListParams.ForValues(v, q)
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+	return p.ListParams.ForCursorList("blocks")
 }
 
 func (p *ListBlocksParams) CacheKey() []string {
@@ -580,6 +876,7 @@ func (p *ListBlocksParams) CacheKey() []string {
 }
 
 func (p *ListBlocksParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyCursor(b, "pvm_blocks.created_at", "pvm_blocks.id", false)
 	return p.ListParams.Apply("pvm_blocks", b)
 }
 
@@ -635,6 +932,7 @@ type BlockSort string
 type TxDataParam struct {
 	ListParams ListParams
 	ID         string
+	ChainID    string
 }
 
 func (p *TxDataParam) ForValues(v uint8, q url.Values) error {
@@ -642,9 +940,867 @@ func (p *TxDataParam) ForValues(v uint8, q url.Values) error {
 		return err
 	}
 
+	p.ChainID = GetQueryString(q, KeyChainID, "")
+
 	return nil
 }
 
 func (p *TxDataParam) CacheKey() []string {
-	return p.ListParams.CacheKey()
+	return append(p.ListParams.CacheKey(), CacheKey(KeyChainID, p.ChainID))
+}
+
+type SubscribeParams struct {
+	ChainIDs  []string
+	Addresses []ids.ShortID
+	AssetID   *ids.ID
+	Types     []string
+}
+
+func (p *SubscribeParams) ForValues(v uint8, q url.Values) (err error) {
+	p.ChainIDs = q[KeyChainID]
+
+	p.AssetID, err = GetQueryID(q, KeyAssetID)
+	if err != nil {
+		return err
+	}
+
+	for _, addressStr := range q[KeyAddress] {
+		addr, err := AddressFromString(addressStr)
+		if err != nil {
+			return err
+		}
+		p.Addresses = append(p.Addresses, addr)
+	}
+
+	p.Types = q[KeyType]
+
+	return nil
+}
+
+func (p *SubscribeParams) CacheKey() []string {
+	return nil
+}
+
+// MatchChainID reports if txs of the chain can match, before they are loaded.
+func (p *SubscribeParams) MatchChainID(chainID string) bool {
+	return len(p.ChainIDs) == 0 || containsString(p.ChainIDs, chainID)
+}
+
+// Match reports if the tx passes every filter.
+// The address and asset filters match any of the tx inputs or outputs.
+func (p *SubscribeParams) Match(tx *models.Transaction) bool {
+	if !p.MatchChainID(string(tx.ChainID)) {
+		return false
+	}
+	if len(p.Types) != 0 && !containsString(p.Types, tx.Type) {
+		return false
+	}
+	if p.AssetID == nil && len(p.Addresses) == 0 {
+		return true
+	}
+
+	outputs := make([]*models.Output, 0, len(tx.Inputs)+len(tx.Outputs))
+	for _, input := range tx.Inputs {
+		if input.Output != nil {
+			outputs = append(outputs, input.Output)
+		}
+	}
+	outputs = append(outputs, tx.Outputs...)
+
+	assetMatched := p.AssetID == nil
+	addressMatched := len(p.Addresses) == 0
+	for _, output := range outputs {
+		if !assetMatched && string(output.AssetID) == p.AssetID.String() {
+			assetMatched = true
+		}
+		for _, addr := range output.Addresses {
+			if addressMatched {
+				break
+			}
+			for _, paddr := range p.Addresses {
+				if addr.Equals(models.ToAddress(paddr)) {
+					addressMatched = true
+					break
+				}
+			}
+		}
+	}
+	return assetMatched && addressMatched
+}
+
+type ListWebhookDeliveriesParams struct {
+	ListParams ListParams
+	Statuses   []string
+}
+
+func (p *ListWebhookDeliveriesParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.Statuses = q[KeyStatus]
+
+	return p.ListParams.ForCursorList("webhookdeliveries")
+}
+
+func (p *ListWebhookDeliveriesParams) CacheKey() []string {
+	k := p.ListParams.CacheKey()
+	for _, status := range p.Statuses {
+		k = append(k, CacheKey(KeyStatus, status))
+	}
+	return k
+}
+
+// AddressBalancesParams selects the balances of an address as of a time,
+// which is now if it is not given.
+type AddressBalancesParams struct {
+	ChainIDs []string
+	AssetID  *ids.ID
+	Address  ids.ShortID
+	AsOf     time.Time
+}
+
+func (p *AddressBalancesParams) ForValues(v uint8, q url.Values) (err error) {
+	p.ChainIDs = q[KeyChainID]
+
+	p.AssetID, err = GetQueryID(q, KeyAssetID)
+	if err != nil {
+		return err
+	}
+
+	var asOfProvided bool
+	asOfProvided, p.AsOf, err = GetQueryTime(q, KeyAsOf)
+	if err != nil {
+		return err
+	}
+	if !asOfProvided {
+		p.AsOf = time.Now().UTC()
+	}
+	p.AsOf = p.AsOf.Round(TransactionRoundDuration)
+
+	return nil
+}
+
+func (p *AddressBalancesParams) CacheKey() []string {
+	k := make([]string, 0, len(p.ChainIDs)+3)
+	k = append(k, CacheKey(KeyAddress, p.Address.String()))
+	for _, chainID := range p.ChainIDs {
+		k = append(k, CacheKey(KeyChainID, chainID))
+	}
+	if p.AssetID != nil {
+		k = append(k, CacheKey(KeyAssetID, p.AssetID.String()))
+	}
+	return append(k, CacheKey(KeyAsOf, p.AsOf.Unix()))
+}
+
+// AddressHistoryParams selects the balance history of an address for an
+// asset, in intervals of IntervalSize between the ListParams times.
+type AddressHistoryParams struct {
+	ListParams ListParams
+
+	ChainIDs     []string
+	AssetID      *ids.ID
+	Address      ids.ShortID
+	IntervalSize time.Duration
+}
+
+func (p *AddressHistoryParams) ForValues(v uint8, q url.Values) (err error) {
+	err = p.ListParams.ForValues(v, q)
+	if err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	p.AssetID, err = GetQueryID(q, KeyAssetID)
+	if err != nil {
+		return err
+	}
+
+	p.IntervalSize = IntervalDay
+	if _, ok := q[KeyInterval]; ok {
+		p.IntervalSize, err = GetQueryInterval(q, KeyInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *AddressHistoryParams) CacheKey() []string {
+	k := make([]string, 0, 4)
+
+	k = append(k, CacheKey(KeyAddress, p.Address.String()))
+
+	if p.AssetID != nil {
+		k = append(k, CacheKey(KeyAssetID, p.AssetID.String()))
+	}
+
+	k = append(k,
+		CacheKey(KeyInterval, int64(p.IntervalSize.Seconds())),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+	)
+
+	return append(p.ListParams.CacheKey(), k...)
+}
+
+func shortIDStrings(addrs []ids.ShortID) []string {
+	addrStrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		addrStrs[i] = addr.String()
+	}
+	return addrStrs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type ValidatorStatus string
+
+const (
+	ValidatorStatusPending ValidatorStatus = "pending"
+	ValidatorStatusActive  ValidatorStatus = "active"
+	ValidatorStatusExpired ValidatorStatus = "expired"
+)
+
+// nodeIDPrefix is the optional prefix of a node ID param.
+const nodeIDPrefix = "NodeID-"
+
+// ListValidatorsParams selects the validators, or the delegators, of the
+// P-chain. The time window selects those which stake during part of it.
+type ListValidatorsParams struct {
+	ListParams ListParams
+	Delegators bool
+	NodeIDs    []string
+	SubnetIDs  []string
+	Statuses   []ValidatorStatus
+
+	// Now is the time the statuses are relative to.
+	Now time.Time
+}
+
+func (p *ListValidatorsParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	for _, nodeIDStr := range q[KeyNodeID] {
+		nodeID, err := ids.ShortFromString(strings.TrimPrefix(nodeIDStr, nodeIDPrefix))
+		if err != nil {
+			return err
+		}
+		p.NodeIDs = append(p.NodeIDs, nodeID.String())
+	}
+
+	for _, subnetIDStr := range q[KeySubnetID] {
+		subnetID, err := ids.FromString(subnetIDStr)
+		if err != nil {
+			return err
+		}
+		p.SubnetIDs = append(p.SubnetIDs, subnetID.String())
+	}
+
+	for _, statusStr := range q[KeyStatus] {
+		status := ValidatorStatus(statusStr)
+		switch status {
+		case ValidatorStatusPending, ValidatorStatusActive, ValidatorStatusExpired:
+		default:
+			return fmt.Errorf("invalid status %s", statusStr)
+		}
+		p.Statuses = append(p.Statuses, status)
+	}
+
+	p.Now = time.Now().UTC().Round(TransactionRoundDuration)
+
+	return p.ListParams.ForCursorList("validators")
+}
+
+func (p *ListValidatorsParams) CacheKey() []string {
+	k := append(p.ListParams.CacheKey(),
+		CacheKey("delegators", p.Delegators),
+		CacheKey(KeyNodeID, strings.Join(p.NodeIDs, "|")),
+		CacheKey(KeySubnetID, strings.Join(p.SubnetIDs, "|")))
+
+	for _, status := range p.Statuses {
+		k = append(k, CacheKey(KeyStatus, string(status)))
+	}
+
+	return append(k, CacheKey("now", p.Now.Unix()))
+}
+
+func (p *ListValidatorsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk("transactions_validator", b, "id", true)
+	p.ListParams.ApplyCursor(b, "transactions_validator.created_at", "transactions_validator.id", true)
+
+	if p.Delegators {
+		b.Where("avm_transactions.type = ?", models.TransactionTypeAddDelegator.String())
+	} else {
+		b.Where("avm_transactions.type IN ?", []string{
+			models.TransactionTypeAddValidator.String(),
+			models.TransactionTypeAddSubnetValidator.String(),
+		})
+	}
+
+	if len(p.NodeIDs) != 0 {
+		b.Where("transactions_validator.node_id IN ?", p.NodeIDs)
+	}
+	if len(p.SubnetIDs) != 0 {
+		b.Where("transactions_validator.subnet_id IN ?", p.SubnetIDs)
+	}
+
+	// the conditions quote the columns, end is a reserved word
+	if len(p.Statuses) != 0 {
+		now := p.Now.Unix()
+		var conds []dbr.Builder
+		for _, status := range p.Statuses {
+			switch status {
+			case ValidatorStatusPending:
+				conds = append(conds, dbr.Gt("transactions_validator.start", now))
+			case ValidatorStatusActive:
+				conds = append(conds, dbr.And(
+					dbr.Lte("transactions_validator.start", now),
+					dbr.Gt("transactions_validator.end", now),
+				))
+			case ValidatorStatusExpired:
+				conds = append(conds, dbr.Lte("transactions_validator.end", now))
+			}
+		}
+		b.Where(dbr.Or(conds...))
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where(dbr.Gt("transactions_validator.end", p.ListParams.StartTime.Unix()))
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where(dbr.Lt("transactions_validator.start", p.ListParams.EndTime.Unix()))
+	}
+
+	return b
+}
+
+type ListSubnetsParams struct {
+	ListParams ListParams
+}
+
+func (p *ListSubnetsParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+	return p.ListParams.ForCursorList("subnets")
+}
+
+func (p *ListSubnetsParams) CacheKey() []string {
+	return p.ListParams.CacheKey()
+}
+
+func (p *ListSubnetsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.Apply("subnets", b)
+	p.ListParams.ApplyCursor(b, "subnets.created_at", "subnets.id", false)
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where("subnets.created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where("subnets.created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+type ListBlockchainsParams struct {
+	ListParams ListParams
+	SubnetIDs  []string
+	VMIDs      []string
+}
+
+func (p *ListBlockchainsParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	for _, subnetIDStr := range q[KeySubnetID] {
+		subnetID, err := ids.FromString(subnetIDStr)
+		if err != nil {
+			return err
+		}
+		p.SubnetIDs = append(p.SubnetIDs, subnetID.String())
+	}
+
+	for _, vmIDStr := range q[KeyVMID] {
+		vmID, err := ids.FromString(vmIDStr)
+		if err != nil {
+			return err
+		}
+		p.VMIDs = append(p.VMIDs, vmID.String())
+	}
+
+	return p.ListParams.ForCursorList("blockchains")
+}
+
+func (p *ListBlockchainsParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeySubnetID, strings.Join(p.SubnetIDs, "|")),
+		CacheKey(KeyVMID, strings.Join(p.VMIDs, "|")))
+}
+
+func (p *ListBlockchainsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.Apply("blockchains", b)
+	p.ListParams.ApplyCursor(b, "blockchains.created_at", "blockchains.id", false)
+
+	if len(p.SubnetIDs) != 0 {
+		b.Where("blockchains.subnet_id IN ?", p.SubnetIDs)
+	}
+	if len(p.VMIDs) != 0 {
+		b.Where("blockchains.vm_id IN ?", p.VMIDs)
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where("blockchains.created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where("blockchains.created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+// CAddress is the address of an EVM chain as it is indexed, lower case with
+// the 0x prefix.
+func CAddress(address string) string {
+	if !strings.HasPrefix(address, "0x") {
+		address = "0x" + address
+	}
+	return strings.ToLower(address)
+}
+
+type ListCTokensParams struct {
+	ListParams ListParams
+	ChainIDs   []string
+	Types      []string
+}
+
+func (p *ListCTokensParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	for _, typ := range q[KeyType] {
+		switch typ {
+		case models.CTokenTypeERC20, models.CTokenTypeERC721:
+		default:
+			return fmt.Errorf("invalid type %s", typ)
+		}
+		p.Types = append(p.Types, typ)
+	}
+
+	return p.ListParams.ForCursorList("ctokens")
+}
+
+func (p *ListCTokensParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeyType, strings.Join(p.Types, "|")))
+}
+
+func (p *ListCTokensParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk(db.TableCvmTokens, b, "address", false)
+	p.ListParams.ApplyCursor(b, db.TableCvmTokens+".created_at", db.TableCvmTokens+".address", false)
+
+	if len(p.ChainIDs) != 0 {
+		b.Where(db.TableCvmTokens+".chain_id IN ?", p.ChainIDs)
+	}
+	if len(p.Types) != 0 {
+		b.Where(db.TableCvmTokens+".type IN ?", p.Types)
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where(db.TableCvmTokens+".created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where(db.TableCvmTokens+".created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+type ListCTokenTransfersParams struct {
+	ListParams ListParams
+	Token      string
+	ChainIDs   []string
+	CAddresses []string
+	Events     []string
+}
+
+func (p *ListCTokenTransfersParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	for _, address := range q[KeyAddress] {
+		p.CAddresses = append(p.CAddresses, CAddress(address))
+	}
+
+	for _, event := range q[KeyEvent] {
+		switch event {
+		case models.CTokenEventTransfer, models.CTokenEventApproval:
+		default:
+			return fmt.Errorf("invalid event %s", event)
+		}
+		p.Events = append(p.Events, event)
+	}
+
+	return p.ListParams.ForCursorList("ctokentransfers")
+}
+
+func (p *ListCTokenTransfersParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyID, p.Token),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+		CacheKey(KeyAddress, strings.Join(p.CAddresses, "|")),
+		CacheKey(KeyEvent, strings.Join(p.Events, "|")))
+}
+
+func (p *ListCTokenTransfersParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk(db.TableCvmTokenTransfers, b, "id", false)
+	p.ListParams.ApplyCursor(b, db.TableCvmTokenTransfers+".created_at", db.TableCvmTokenTransfers+".id", true)
+
+	if p.Token != "" {
+		b.Where(db.TableCvmTokenTransfers+".address = ?", p.Token)
+	}
+	if len(p.ChainIDs) != 0 {
+		b.Where(db.TableCvmTokenTransfers+".chain_id IN ?", p.ChainIDs)
+	}
+	if len(p.CAddresses) != 0 {
+		b.Where(dbr.Or(
+			dbr.Expr(db.TableCvmTokenTransfers+".from_addr IN ?", p.CAddresses),
+			dbr.Expr(db.TableCvmTokenTransfers+".to_addr IN ?", p.CAddresses),
+		))
+	}
+	if len(p.Events) != 0 {
+		b.Where(db.TableCvmTokenTransfers+".event IN ?", p.Events)
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where(db.TableCvmTokenTransfers+".created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where(db.TableCvmTokenTransfers+".created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+type CTokenBalancesParams struct {
+	Address  string
+	ChainIDs []string
+}
+
+func (p *CTokenBalancesParams) ForValues(v uint8, q url.Values) error {
+	p.ChainIDs = q[KeyChainID]
+	return nil
+}
+
+func (p *CTokenBalancesParams) CacheKey() []string {
+	return []string{
+		CacheKey(KeyAddress, p.Address),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")),
+	}
+}
+
+// ListCInternalTransfersParams lists the internal transfers from or to an
+// address, the newest first.
+type ListCInternalTransfersParams struct {
+	ListParams ListParams
+	Address    string
+	ChainIDs   []string
+}
+
+func (p *ListCInternalTransfersParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainIDs = q[KeyChainID]
+
+	return p.ListParams.ForCursorList("cinternaltransfers")
+}
+
+func (p *ListCInternalTransfersParams) CacheKey() []string {
+	return append(p.ListParams.CacheKey(),
+		CacheKey(KeyAddress, p.Address),
+		CacheKey(KeyChainID, strings.Join(p.ChainIDs, "|")))
+}
+
+func (p *ListCInternalTransfersParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk(db.TableCvmInternalTransfers, b, "id", false)
+	p.ListParams.ApplyCursor(b, db.TableCvmInternalTransfers+".created_at", db.TableCvmInternalTransfers+".id", true)
+
+	if p.Address != "" {
+		b.Where(dbr.Or(
+			dbr.Expr(db.TableCvmInternalTransfers+".from_addr = ?", p.Address),
+			dbr.Expr(db.TableCvmInternalTransfers+".to_addr = ?", p.Address),
+		))
+	}
+	if len(p.ChainIDs) != 0 {
+		b.Where(db.TableCvmInternalTransfers+".chain_id IN ?", p.ChainIDs)
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where(db.TableCvmInternalTransfers+".created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where(db.TableCvmInternalTransfers+".created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+type AtomicTransferStatus string
+
+const (
+	AtomicTransferStatusPending  AtomicTransferStatus = "pending"
+	AtomicTransferStatusImported AtomicTransferStatus = "imported"
+)
+
+// ListAtomicTransfersParams lists the atomic transfers, the newest first. A
+// transfer is pending when it is exported but not yet imported.
+type ListAtomicTransfersParams struct {
+	ListParams        ListParams
+	TxIDs             []string
+	SourceChains      []string
+	DestinationChains []string
+	AssetIDs          []string
+	Statuses          []AtomicTransferStatus
+}
+
+func (p *ListAtomicTransfersParams) ForValues(v uint8, q url.Values) error {
+	if err := p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.TxIDs = q[KeyTxID]
+	p.SourceChains = q[KeySourceChain]
+	p.DestinationChains = q[KeyDestinationChain]
+	p.AssetIDs = q[KeyAssetID]
+
+	for _, statusStr := range q[KeyStatus] {
+		status := AtomicTransferStatus(statusStr)
+		switch status {
+		case AtomicTransferStatusPending, AtomicTransferStatusImported:
+		default:
+			return fmt.Errorf("invalid status %s", statusStr)
+		}
+		p.Statuses = append(p.Statuses, status)
+	}
+
+	return p.ListParams.ForCursorList("atomictransfers")
+}
+
+func (p *ListAtomicTransfersParams) CacheKey() []string {
+	k := append(p.ListParams.CacheKey(),
+		CacheKey(KeyTxID, strings.Join(p.TxIDs, "|")),
+		CacheKey(KeySourceChain, strings.Join(p.SourceChains, "|")),
+		CacheKey(KeyDestinationChain, strings.Join(p.DestinationChains, "|")),
+		CacheKey(KeyAssetID, strings.Join(p.AssetIDs, "|")))
+
+	for _, status := range p.Statuses {
+		k = append(k, CacheKey(KeyStatus, string(status)))
+	}
+
+	return k
+}
+
+func (p *ListAtomicTransfersParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk(db.TableAtomicTransfers, b, "id", true)
+	p.ListParams.ApplyCursor(b, db.TableAtomicTransfers+".created_at", db.TableAtomicTransfers+".id", true)
+
+	if len(p.TxIDs) != 0 {
+		b.Where(dbr.Or(
+			dbr.Expr(db.TableAtomicTransfers+".export_tx_id IN ?", p.TxIDs),
+			dbr.Expr(db.TableAtomicTransfers+".import_tx_id IN ?", p.TxIDs),
+		))
+	}
+	if len(p.SourceChains) != 0 {
+		b.Where(db.TableAtomicTransfers+".source_chain IN ?", p.SourceChains)
+	}
+	if len(p.DestinationChains) != 0 {
+		b.Where(db.TableAtomicTransfers+".destination_chain IN ?", p.DestinationChains)
+	}
+	if len(p.AssetIDs) != 0 {
+		b.Where(db.TableAtomicTransfers+".asset_id IN ?", p.AssetIDs)
+	}
+
+	if len(p.Statuses) == 1 {
+		switch p.Statuses[0] {
+		case AtomicTransferStatusPending:
+			b.Where(db.TableAtomicTransfers + ".import_tx_id = ''")
+		case AtomicTransferStatusImported:
+			b.Where(db.TableAtomicTransfers + ".import_tx_id <> ''")
+		}
+	}
+
+	if p.ListParams.StartTimeProvided && !p.ListParams.StartTime.IsZero() {
+		b.Where(db.TableAtomicTransfers+".created_at >= ?", p.ListParams.StartTime)
+	}
+	if p.ListParams.EndTimeProvided && !p.ListParams.EndTime.IsZero() {
+		b.Where(db.TableAtomicTransfers+".created_at < ?", p.ListParams.EndTime)
+	}
+
+	return b
+}
+
+// ListCLogsParams filters the logs of an EVM chain like eth_getLogs. A topic
+// position matches any of its topics, or any topic when it has none. ChainID is
+// the AX chain when empty.
+type ListCLogsParams struct {
+	ListParams ListParams
+	ChainID    string
+	FromBlock  *big.Int
+	ToBlock    *big.Int
+	BlockHash  string
+	CAddresses []string
+	Topics     [4][]string
+
+	// the position of the cursor, the page starts after the log
+	afterBlock *big.Int
+	afterIndex uint64
+}
+
+func (p *ListCLogsParams) ForValues(v uint8, q url.Values) (err error) {
+	if err = p.ListParams.ForValues(v, q); err != nil {
+		return err
+	}
+
+	p.ChainID = GetQueryString(q, KeyChainID, "")
+
+	if p.FromBlock, err = getQueryCBlock(q, KeyFromBlock); err != nil {
+		return err
+	}
+	if p.ToBlock, err = getQueryCBlock(q, KeyToBlock); err != nil {
+		return err
+	}
+	if blockHash := GetQueryString(q, KeyBlockHash, ""); blockHash != "" {
+		if p.FromBlock != nil || p.ToBlock != nil {
+			return fmt.Errorf("%s can not be used with %s and %s", KeyBlockHash, KeyFromBlock, KeyToBlock)
+		}
+		if p.BlockHash, err = CHash(blockHash); err != nil {
+			return err
+		}
+	}
+
+	for _, address := range q[KeyAddress] {
+		p.CAddresses = append(p.CAddresses, CAddress(address))
+	}
+
+	for i := range p.Topics {
+		for _, topicStr := range q[KeyTopic+strconv.Itoa(i)] {
+			topic, err := CHash(topicStr)
+			if err != nil {
+				return err
+			}
+			p.Topics[i] = append(p.Topics[i], topic)
+		}
+	}
+
+	if cursor := p.ListParams.Cursor; cursor != nil {
+		block, ok := new(big.Int).SetString(cursor.Key, 10)
+		index, err := strconv.ParseUint(cursor.ID, 10, 64)
+		if !ok || err != nil {
+			return ErrInvalidCursor
+		}
+		p.afterBlock = block
+		p.afterIndex = index
+	}
+
+	return p.ListParams.ForCursorList("clogs")
+}
+
+func (p *ListCLogsParams) CacheKey() []string {
+	k := append(p.ListParams.CacheKey(),
+		CacheKey(KeyChainID, p.ChainID),
+		CacheKey(KeyFromBlock, p.FromBlock),
+		CacheKey(KeyToBlock, p.ToBlock),
+		CacheKey(KeyBlockHash, p.BlockHash),
+		CacheKey(KeyAddress, strings.Join(p.CAddresses, "|")))
+	for i, topics := range p.Topics {
+		k = append(k, CacheKey(KeyTopic+strconv.Itoa(i), strings.Join(topics, "|")))
+	}
+	return k
+}
+
+func (p *ListCLogsParams) Apply(b *dbr.SelectBuilder) *dbr.SelectBuilder {
+	p.ListParams.ApplyPk(db.TableCvmLogs, b, "id", false)
+
+	b.Where(db.TableCvmLogs+".chain_id = ?", p.ChainID)
+	b.Where(db.TableCvmLogs+".removed = ?", false)
+	if p.FromBlock != nil {
+		b.Where(db.TableCvmLogs + ".block >= " + p.FromBlock.String())
+	}
+	if p.ToBlock != nil {
+		b.Where(db.TableCvmLogs + ".block <= " + p.ToBlock.String())
+	}
+	if p.BlockHash != "" {
+		b.Where(db.TableCvmLogs+".block_hash = ?", p.BlockHash)
+	}
+	if len(p.CAddresses) != 0 {
+		b.Where(db.TableCvmLogs+".address IN ?", p.CAddresses)
+	}
+	for i, col := range []string{"first_topic", "topic1", "topic2", "topic3"} {
+		if len(p.Topics[i]) != 0 {
+			b.Where(db.TableCvmLogs+"."+col+" IN ?", p.Topics[i])
+		}
+	}
+
+	if p.afterBlock != nil {
+		block := p.afterBlock.String()
+		b.Where("("+db.TableCvmLogs+".block > "+block+" or ("+
+			db.TableCvmLogs+".block = "+block+" and "+db.TableCvmLogs+".log_index > ?))", p.afterIndex)
+	}
+
+	return b
+}
+
+// getQueryCBlock parses a block number of an EVM chain like eth_getLogs, in
+// decimal or 0x hex. earliest is the first block, and latest no block.
+func getQueryCBlock(q url.Values, key string) (*big.Int, error) {
+	blockStr := GetQueryString(q, key, "")
+	switch blockStr {
+	case "", "latest", "pending":
+		return nil, nil
+	case "earliest":
+		return big.NewInt(0), nil
+	}
+
+	var block *big.Int
+	var ok bool
+	if strings.HasPrefix(blockStr, "0x") {
+		block, ok = new(big.Int).SetString(blockStr[2:], 16)
+	} else {
+		block, ok = new(big.Int).SetString(blockStr, 10)
+	}
+	if !ok || block.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %s", key, blockStr)
+	}
+	return block, nil
+}
+
+// CHash is a hash or topic of an EVM chain as it is indexed, lower case with
+// the 0x prefix.
+func CHash(hash string) (string, error) {
+	hashHex := strings.TrimPrefix(strings.ToLower(hash), "0x")
+	if b, err := hex.DecodeString(hashHex); err != nil || len(b) != 32 {
+		return "", fmt.Errorf("invalid hash %s", hash)
+	}
+	return "0x" + hashHex, nil
 }
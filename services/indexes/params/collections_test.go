@@ -1,6 +1,7 @@
 package params
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/axiacoin/axia-network-v2/ids"
@@ -34,3 +35,124 @@ func TestForValueChainID(t *testing.T) {
 		t.Error("ForValueChainID failed")
 	}
 }
+
+func listCursor(list string, key string, id string) string {
+	return (&ListParams{cursorList: list}).NewCursor(key, id).Encode()
+}
+
+func TestListCLogsParams(t *testing.T) {
+	topic := "0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"
+	p := &ListCLogsParams{}
+	err := p.ForValues(2, url.Values{
+		KeyFromBlock: {"0x10"},
+		KeyToBlock:   {"latest"},
+		KeyAddress:   {"0xAB", "cd"},
+		"topic0":     {topic},
+		"topic2":     {topic, topic},
+		KeyCursor:    {listCursor("clogs", "20", "3")},
+	})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if p.FromBlock.Int64() != 16 || p.ToBlock != nil {
+		t.Fatal("blocks", p.FromBlock, p.ToBlock)
+	}
+	if len(p.CAddresses) != 2 || p.CAddresses[0] != "0xab" || p.CAddresses[1] != "0xcd" {
+		t.Fatal("addresses", p.CAddresses)
+	}
+	if len(p.Topics[0]) != 1 || p.Topics[0][0] != "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" ||
+		len(p.Topics[1]) != 0 || len(p.Topics[2]) != 2 {
+		t.Fatal("topics", p.Topics)
+	}
+	if p.afterBlock.Int64() != 20 || p.afterIndex != 3 {
+		t.Fatal("cursor", p.afterBlock, p.afterIndex)
+	}
+
+	for _, q := range []url.Values{
+		{KeyFromBlock: {"0xzz"}},
+		{"topic1": {"0x01"}},
+		{KeyBlockHash: {topic}, KeyToBlock: {"5"}},
+		{KeyCursor: {listCursor("clogs", "", "x")}},
+		{KeyCursor: {NewCursor("20", "3").Encode()}},
+		{KeyCursor: {listCursor("ctokentransfers", "20", "3")}},
+	} {
+		if err := (&ListCLogsParams{}).ForValues(2, q); err == nil {
+			t.Fatal("ForValues accepted", q)
+		}
+	}
+}
+
+func TestListAtomicTransfersParams(t *testing.T) {
+	p := &ListAtomicTransfersParams{}
+	err := p.ForValues(2, url.Values{
+		KeyStatus:      {"pending"},
+		KeySourceChain: {"ch1", "ch2"},
+	})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if len(p.Statuses) != 1 || p.Statuses[0] != AtomicTransferStatusPending || len(p.SourceChains) != 2 {
+		t.Fatal("params", p.Statuses, p.SourceChains)
+	}
+
+	if err := (&ListAtomicTransfersParams{}).ForValues(2, url.Values{KeyStatus: {"exported"}}); err == nil {
+		t.Fatal("ForValues accepted an invalid status")
+	}
+}
+
+func TestListAssetHoldersParams(t *testing.T) {
+	p := &ListAssetHoldersParams{}
+	if err := p.ForValues(2, url.Values{}); err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if p.Sort != AssetHolderSortBalanceDesc {
+		t.Fatal("default sort", p.Sort)
+	}
+
+	p = &ListAssetHoldersParams{}
+	err := p.ForValues(2, url.Values{
+		KeySortBy: {"balance-asc"},
+		KeyCursor: {listCursor("assetholders,balance-asc", "1000000000000000000000", "h1")},
+	})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if p.Sort != AssetHolderSortBalanceAsc || p.afterBalance.String() != "1000000000000000000000" || p.afterID != "h1" {
+		t.Fatal("params", p.Sort, p.afterBalance, p.afterID)
+	}
+
+	for _, q := range []url.Values{
+		{KeySortBy: {"timestamp-desc"}},
+		{KeyCursor: {listCursor("assetholders,balance-desc", "", "h1")}},
+		// a cursor of the other sort order
+		{KeyCursor: {listCursor("assetholders,balance-asc", "1000000000000000000000", "h1")}},
+	} {
+		if err := (&ListAssetHoldersParams{}).ForValues(2, q); err == nil {
+			t.Fatal("ForValues accepted", q)
+		}
+	}
+}
+
+func TestListNFTsParams(t *testing.T) {
+	p := &ListNFTsParams{}
+	err := p.ForValues(2, url.Values{
+		KeyGroupID: {"1", "7"},
+		KeyCursor:  {listCursor("nfts", "7", "out1")},
+	})
+	if err != nil {
+		t.Fatal("ForValues failed", err)
+	}
+	if len(p.GroupIDs) != 2 || p.GroupIDs[1] != 7 || p.afterGroupID == nil || *p.afterGroupID != 7 || p.afterOutputID != "out1" {
+		t.Fatal("params", p.GroupIDs, p.afterGroupID, p.afterOutputID)
+	}
+
+	for _, q := range []url.Values{
+		{KeyGroupID: {"-1"}},
+		{KeyGroupID: {"4294967296"}},
+		{KeyCursor: {listCursor("nfts", "", "out1")}},
+	} {
+		if err := (&ListNFTsParams{}).ForValues(2, q); err == nil {
+			t.Fatal("ForValues accepted", q)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	cursorSecretLock sync.RWMutex
+	cursorSecret     = newCursorSecret()
+)
+
+func newCursorSecret() []byte {
+	secret := make([]byte, sha256.Size)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// SetCursorSecret sets the key cursors are signed with.
+// Every api instance behind the same endpoint must share the secret, otherwise
+// a random secret is used and cursors are only valid on the issuing process.
+func SetCursorSecret(secret []byte) {
+	if len(secret) == 0 {
+		return
+	}
+	cursorSecretLock.Lock()
+	defer cursorSecretLock.Unlock()
+	cursorSecret = secret
+}
+
+func signCursor(payload []byte) []byte {
+	cursorSecretLock.RLock()
+	defer cursorSecretLock.RUnlock()
+	mac := hmac.New(sha256.New, cursorSecret)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Cursor is the position of the last item of a page.
+// The next page starts after (Key or Time, ID) in the list's sort order.
+// List is the list with its sort order the cursor was issued for, a cursor is
+// only valid on that list.
+type Cursor struct {
+	List string     `json:"l,omitempty"`
+	Key  string     `json:"k,omitempty"`
+	Time *time.Time `json:"t,omitempty"`
+	ID   string     `json:"id"`
+}
+
+func NewCursor(key string, id string) *Cursor {
+	return &Cursor{Key: key, ID: id}
+}
+
+func NewTimeCursor(t time.Time, id string) *Cursor {
+	t = t.UTC()
+	return &Cursor{Time: &t, ID: id}
+}
+
+// Encode returns the cursor as an opaque, signed string.
+func (c *Cursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(payload))
+}
+
+// Next returns the encoded cursor for a page of n items, or nil when the page
+// was not full and there is nothing more to list.
+func (c *Cursor) Next(n int, limit int) *string {
+	if limit <= 0 || n < limit {
+		return nil
+	}
+	next := c.Encode()
+	return &next
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if !hmac.Equal(sig, signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ForCursorList sets the list with its sort order, e.g. "transactions,timestamp-desc",
+// of the request. The cursor of the request must have been issued for it.
+func (p *ListParams) ForCursorList(list string) error {
+	p.cursorList = list
+	if p.Cursor != nil && p.Cursor.List != list {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// NewCursor returns the cursor of the list of the request.
+func (p *ListParams) NewCursor(key string, id string) *Cursor {
+	c := NewCursor(key, id)
+	c.List = p.cursorList
+	return c
+}
+
+// NewTimeCursor returns the cursor of the list of the request.
+func (p *ListParams) NewTimeCursor(t time.Time, id string) *Cursor {
+	c := NewTimeCursor(t, id)
+	c.List = p.cursorList
+	return c
+}
+
+func (c *Cursor) sortValue() interface{} {
+	if c.Time != nil {
+		return *c.Time
+	}
+	return c.Key
+}
+
+// ApplyCursor restricts the query to the rows after the cursor, for a list
+// ordered by sortCol then pkCol.
+func (p ListParams) ApplyCursor(b *dbr.SelectBuilder, sortCol string, pkCol string, desc bool) *dbr.SelectBuilder {
+	if p.Cursor == nil {
+		return b
+	}
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	v := p.Cursor.sortValue()
+	return b.Where("("+sortCol+" "+op+" ? or ("+sortCol+" = ? and "+pkCol+" "+op+" ?))", v, v, p.Cursor.ID)
+}
@@ -0,0 +1,82 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	tm := time.Unix(1600000000, 123456000).UTC()
+	c := NewTimeCursor(tm, "id1")
+
+	if c.Next(1, 2) != nil {
+		t.Fatal("expected no next for a partial page")
+	}
+	next := c.Next(2, 2)
+	if next == nil {
+		t.Fatal("expected next for a full page")
+	}
+
+	p := &ListParams{}
+	if err := p.ForValues(2, url.Values{KeyCursor: []string{*next}}); err != nil {
+		t.Fatal(err)
+	}
+	if p.Cursor == nil || p.Cursor.ID != "id1" || p.Cursor.Time == nil || !p.Cursor.Time.Equal(tm) {
+		t.Fatal("cursor decode failed")
+	}
+
+	c2, err := DecodeCursor(NewCursor("chain", "addr").Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.Key != "chain" || c2.ID != "addr" || c2.Time != nil {
+		t.Fatal("cursor decode failed")
+	}
+
+	// tampering with the payload invalidates the signature
+	tampered := NewCursor("chain", "addr2").Encode()
+	tampered = (*next)[:len(*next)/2] + tampered[len(tampered)/2:]
+	if _, err := DecodeCursor(tampered); err != ErrInvalidCursor {
+		t.Fatal("expected invalid cursor")
+	}
+	if _, err := DecodeCursor("garbage"); err != ErrInvalidCursor {
+		t.Fatal("expected invalid cursor")
+	}
+}
+
+func TestCursorList(t *testing.T) {
+	tm := time.Unix(1600000000, 0).UTC()
+	desc := &ListParams{}
+	if err := desc.ForCursorList("transactions,timestamp-desc"); err != nil {
+		t.Fatal(err)
+	}
+	next := desc.NewTimeCursor(tm, "id1").Encode()
+
+	p := &ListParams{}
+	if err := p.ForValues(2, url.Values{KeyCursor: {next}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ForCursorList("transactions,timestamp-desc"); err != nil {
+		t.Fatal("cursor of the list rejected", err)
+	}
+	// the cursor is only valid on the list and sort order it was issued for
+	if err := p.ForCursorList("transactions,timestamp-asc"); err != ErrInvalidCursor {
+		t.Fatal("cursor of another sort order accepted", err)
+	}
+	if err := p.ForCursorList("blocks"); err != ErrInvalidCursor {
+		t.Fatal("cursor of another list accepted", err)
+	}
+	if err := (&ListTransactionsParams{}).ForValues(2, url.Values{KeyCursor: {next}, KeySortBy: {TransactionSortTimestampAscStr}}); err != ErrInvalidCursor {
+		t.Fatal("cursor of another sort order accepted", err)
+	}
+	if err := (&ListBlocksParams{}).ForValues(2, url.Values{KeyCursor: {next}}); err != ErrInvalidCursor {
+		t.Fatal("cursor of another list accepted", err)
+	}
+	if err := (&ListTransactionsParams{}).ForValues(2, url.Values{KeyCursor: {next}, KeySortBy: {TransactionSortTimestampDescStr}}); err != nil {
+		t.Fatal("cursor of the list rejected", err)
+	}
+}
@@ -28,6 +28,7 @@ const (
 	KeySortBy           = "sort"
 	KeyLimit            = "limit"
 	KeyOffset           = "offset"
+	KeyCursor           = "cursor"
 	KeySpent            = "spent"
 	KeyStartTime        = "startTime"
 	KeyEndTime          = "endTime"
@@ -36,6 +37,23 @@ const (
 	KeyDisableGenesis   = "disableGenesis"
 	KeyOutputOutputType = "outputOutputType"
 	KeyOutputGroupID    = "outputGroupId"
+	KeyType             = "type"
+	KeyStatus           = "status"
+	KeyAsOf             = "asOf"
+	KeyInterval         = "interval"
+	KeyFormat           = "format"
+	KeyNodeID           = "nodeID"
+	KeySubnetID         = "subnetID"
+	KeyVMID             = "vmID"
+	KeyEvent            = "event"
+	KeyFromBlock        = "fromBlock"
+	KeyToBlock          = "toBlock"
+	KeyBlockHash        = "blockHash"
+	KeyTopic            = "topic"
+	KeyTxID             = "txID"
+	KeySourceChain      = "sourceChain"
+	KeyDestinationChain = "destinationChain"
+	KeyGroupID          = "groupID"
 
 	PaginationMaxLimit      = 5000
 	PaginationDefaultOffset = 0
@@ -88,8 +106,11 @@ type ListParams struct {
 
 	Limit           int
 	Offset          int
+	Cursor          *Cursor
 	DisableCounting bool
 
+	cursorList string
+
 	StartTimeProvided bool
 	EndTimeProvided   bool
 
@@ -124,7 +145,12 @@ func (p *ListParams) forValues(version uint8, q url.Values, allowOffset bool) (e
 		p.Offset = 0
 	}
 	if !allowOffset && p.Offset > 0 {
-		return errors.New("offset deprecated")
+		return errors.New("offset deprecated, use cursor")
+	}
+
+	p.Cursor, err = GetQueryCursor(q, KeyCursor)
+	if err != nil {
+		return err
 	}
 
 	p.ID, err = GetQueryID(q, KeyID)
@@ -166,6 +192,9 @@ func (p *ListParams) CacheKey() []string {
 	if p.ID != nil {
 		keys = append(keys, CacheKey(KeyID, p.ID.String()))
 	}
+	if p.Cursor != nil {
+		keys = append(keys, CacheKey(KeyCursor, p.Cursor.Encode()))
+	}
 
 	return append(keys,
 		CacheKey(KeyLimit, p.Limit),
@@ -68,6 +68,15 @@ func GetQueryID(q url.Values, key string) (*ids.ID, error) {
 	return &id, nil
 }
 
+func GetQueryCursor(q url.Values, key string) (*Cursor, error) {
+	cursorStr := GetQueryString(q, key, "")
+	if cursorStr == "" {
+		return nil, nil
+	}
+
+	return DecodeCursor(cursorStr)
+}
+
 func GetQueryInterval(q url.Values, key string) (time.Duration, error) {
 	intervalStrs, ok := q[key]
 	if !ok || len(intervalStrs) < 1 {
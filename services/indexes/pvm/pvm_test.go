@@ -8,6 +8,7 @@ import (
 	"github.com/axiacoin/axia-network-v2/ids"
 	"github.com/axiacoin/axia-network-v2/utils/logging"
 	"github.com/axiacoin/axia-network-v2/vms/platformvm"
+	"github.com/axiacoin/axia-network-v2/vms/secp256k1fx"
 	"github.com/axiacoin/axia-network-v2-magellan/cfg"
 	"github.com/axiacoin/axia-network-v2-magellan/db"
 	"github.com/axiacoin/axia-network-v2-magellan/models"
@@ -153,3 +154,52 @@ func TestCommonBlock(t *testing.T) {
 		t.Fatal("insert failed")
 	}
 }
+
+func TestIndexSubnets(t *testing.T) {
+	writer, err := NewWriter(5, testSwapChainID.String())
+	if err != nil {
+		t.Fatal("Failed to create writer:", err.Error())
+	}
+
+	owner := ids.GenerateTestShortID()
+	subnetTx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedCreateSubnetTx{
+		Owner: &secp256k1fx.OutputOwners{Threshold: 1, Addrs: []ids.ShortID{owner}},
+	}}
+	chainTx := &platformvm.Tx{UnsignedTx: &platformvm.UnsignedCreateChainTx{
+		SubnetID:    ids.GenerateTestID(),
+		ChainName:   "chain",
+		VMID:        ids.GenerateTestID(),
+		GenesisData: []byte("genesis"),
+		SubnetAuth:  &secp256k1fx.Input{},
+	}}
+	var blk platformvm.Block = &platformvm.StandardBlock{Txs: []*platformvm.Tx{subnetTx, chainTx}}
+	blockBytes, err := platformvm.Codec.Marshal(platformvm.CodecVersion, &blk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	persist := db.NewPersistMock()
+	// the mock does not use the session
+	cCtx := services.NewConsumerContext(context.Background(), nil, time.Now().Unix(), 0, persist)
+	if err := writer.IndexSubnets(cCtx, blockBytes); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(persist.Subnets) != 1 || len(persist.SubnetControlKeys) != 1 || len(persist.Blockchains) != 1 {
+		t.Fatal("subnets not indexed", len(persist.Subnets), len(persist.SubnetControlKeys), len(persist.Blockchains))
+	}
+	for _, subnet := range persist.Subnets {
+		if subnet.Threshold != 1 {
+			t.Fatal("subnet", subnet)
+		}
+	}
+	for _, blockchain := range persist.Blockchains {
+		if blockchain.Name != "chain" || blockchain.SubnetID != chainTx.UnsignedTx.(*platformvm.UnsignedCreateChainTx).SubnetID.String() {
+			t.Fatal("blockchain", blockchain)
+		}
+	}
+	// and nothing else
+	if len(persist.Transactions) != 0 || len(persist.PvmBlocks) != 0 {
+		t.Fatal("block indexed")
+	}
+}
@@ -5,6 +5,7 @@ package pvm
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +17,7 @@ import (
 	"github.com/axiacoin/axia-network-v2/genesis"
 	"github.com/axiacoin/axia-network-v2/ids"
 	"github.com/axiacoin/axia-network-v2/snow"
+	"github.com/axiacoin/axia-network-v2/utils/constants"
 	"github.com/axiacoin/axia-network-v2/utils/hashing"
 	"github.com/axiacoin/axia-network-v2/utils/logging"
 	"github.com/axiacoin/axia-network-v2/utils/wrappers"
@@ -338,25 +340,32 @@ func initializeTx(version uint16, c codec.Manager, tx platformvm.Tx) error {
 	return nil
 }
 
-func (w *Writer) indexBlock(ctx services.ConsumerCtx, proposerblockBytes []byte) error {
+// parseBlock parses the platform block of the container, which is wrapped in
+// a proposer block once the proposer vm is active. It returns the proposer
+// block, or nil, and the bytes of the platform block.
+func (w *Writer) parseBlock(proposerblockBytes []byte) (platformvm.Block, uint16, block.Block, []byte, error) {
 	var pblock platformvm.Block
-	var ver uint16
-	var err error
 
 	proposerBlock, err := block.Parse(proposerblockBytes)
-	blockBytes := proposerblockBytes
 	if err == nil {
-		ver, err = w.codec.Unmarshal(proposerBlock.Block(), &pblock)
+		ver, err := w.codec.Unmarshal(proposerBlock.Block(), &pblock)
 		if err != nil {
-			return stacktrace.Propagate(err, "proposer bytes")
-		}
-		blockBytes = append([]byte{}, proposerBlock.Block()...)
-	} else {
-		proposerBlock = nil
-		ver, err = w.codec.Unmarshal(blockBytes, &pblock)
-		if err != nil {
-			return stacktrace.Propagate(err, "block bytes")
+			return nil, 0, nil, nil, stacktrace.Propagate(err, "proposer bytes")
 		}
+		return pblock, ver, proposerBlock, append([]byte{}, proposerBlock.Block()...), nil
+	}
+
+	ver, err := w.codec.Unmarshal(proposerblockBytes, &pblock)
+	if err != nil {
+		return nil, 0, nil, nil, stacktrace.Propagate(err, "block bytes")
+	}
+	return pblock, ver, nil, proposerblockBytes, nil
+}
+
+func (w *Writer) indexBlock(ctx services.ConsumerCtx, proposerblockBytes []byte) error {
+	pblock, ver, proposerBlock, blockBytes, err := w.parseBlock(proposerblockBytes)
+	if err != nil {
+		return err
 	}
 
 	blkID := ids.ID(hashing.ComputeHash256Array(blockBytes))
@@ -428,6 +437,64 @@ func (w *Writer) indexBlock(ctx services.ConsumerCtx, proposerblockBytes []byte)
 	return errs.Err
 }
 
+// IndexSubnets indexes the subnets and blockchains created in the block, and
+// nothing else, for the blocks indexed before the subnets were.
+func (w *Writer) IndexSubnets(ctx services.ConsumerCtx, proposerblockBytes []byte) error {
+	pblock, ver, _, _, err := w.parseBlock(proposerblockBytes)
+	if err != nil {
+		return err
+	}
+	// the txs which create subnets and chains are decisions, of standard blocks
+	blk, ok := pblock.(*platformvm.StandardBlock)
+	if !ok {
+		return nil
+	}
+	for _, tx := range blk.Txs {
+		if err := initializeTx(ver, w.codec, *tx); err != nil {
+			return err
+		}
+		if err := w.indexSubnetTx(ctx, *tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IndexGenesisSubnets indexes the blockchains created in the genesis, and
+// nothing else, see IndexSubnets.
+func (w *Writer) IndexGenesisSubnets(ctx context.Context, conns *utils.Connections, persist db.Persist) error {
+	genesisBytes, _, err := genesis.FromConfig(genesis.GetConfig(w.networkID))
+	if err != nil {
+		return err
+	}
+	platformGenesis := &platformvm.Genesis{}
+	if _, err = platformvm.GenesisCodec.Unmarshal(genesisBytes, platformGenesis); err != nil {
+		return err
+	}
+	if err = platformGenesis.Initialize(); err != nil {
+		return err
+	}
+
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("index-genesis-subnets"))
+	cCtx := services.NewConsumerContext(ctx, sess, int64(platformGenesis.Timestamp), 0, persist)
+	for _, tx := range platformGenesis.Chains {
+		if err := w.indexSubnetTx(cCtx, *tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) indexSubnetTx(ctx services.ConsumerCtx, tx platformvm.Tx) error {
+	switch castTx := tx.UnsignedTx.(type) {
+	case *platformvm.UnsignedCreateSubnetTx:
+		return w.insertSubnet(ctx, castTx.ID(), castTx.Owner)
+	case *platformvm.UnsignedCreateChainTx:
+		return w.insertBlockchain(ctx, castTx.ID(), castTx)
+	}
+	return nil
+}
+
 func (w *Writer) indexCommonBlock(
 	ctx services.ConsumerCtx,
 	blkID ids.ID,
@@ -470,7 +537,7 @@ func (w *Writer) indexTransaction(ctx services.ConsumerCtx, blkID ids.ID, tx pla
 			ChainID: w.chainID,
 		}
 		typ = models.TransactionTypeAddValidator
-		err = w.InsertTransactionValidator(ctx, baseTx.ID(), castTx.Validator)
+		err = w.InsertTransactionValidator(ctx, baseTx.ID(), castTx.Validator, constants.PrimaryNetworkID)
 		if err != nil {
 			return err
 		}
@@ -487,6 +554,10 @@ func (w *Writer) indexTransaction(ctx services.ConsumerCtx, blkID ids.ID, tx pla
 	case *platformvm.UnsignedAddSubnetValidatorTx:
 		baseTx = castTx.BaseTx.BaseTx
 		typ = models.TransactionTypeAddSubnetValidator
+		err = w.InsertTransactionValidator(ctx, baseTx.ID(), castTx.Validator.Validator, castTx.Validator.Subnet)
+		if err != nil {
+			return err
+		}
 		err = w.InsertTransactionBlock(ctx, baseTx.ID(), blkID)
 		if err != nil {
 			return err
@@ -499,7 +570,7 @@ func (w *Writer) indexTransaction(ctx services.ConsumerCtx, blkID ids.ID, tx pla
 			ChainID: w.chainID,
 		}
 		typ = models.TransactionTypeAddDelegator
-		err = w.InsertTransactionValidator(ctx, baseTx.ID(), castTx.Validator)
+		err = w.InsertTransactionValidator(ctx, baseTx.ID(), castTx.Validator, constants.PrimaryNetworkID)
 		if err != nil {
 			return err
 		}
@@ -520,6 +591,10 @@ func (w *Writer) indexTransaction(ctx services.ConsumerCtx, blkID ids.ID, tx pla
 		if err != nil {
 			return err
 		}
+		err = w.insertSubnet(ctx, baseTx.ID(), castTx.Owner)
+		if err != nil {
+			return err
+		}
 	case *platformvm.UnsignedCreateChainTx:
 		baseTx = castTx.BaseTx.BaseTx
 		typ = models.TransactionTypeCreateChain
@@ -527,6 +602,10 @@ func (w *Writer) indexTransaction(ctx services.ConsumerCtx, blkID ids.ID, tx pla
 		if err != nil {
 			return err
 		}
+		err = w.insertBlockchain(ctx, baseTx.ID(), castTx)
+		if err != nil {
+			return err
+		}
 	case *platformvm.UnsignedImportTx:
 		baseTx = castTx.BaseTx.BaseTx
 		ins = &axcIndexer.AddInsContainer{
@@ -632,12 +711,53 @@ func (w *Writer) insertTransactionsRewardsOwners(ctx services.ConsumerCtx, rewar
 	return ctx.Persist().InsertTransactionsRewardsOwners(ctx.Ctx(), ctx.DB(), txRewardsOwner, cfg.PerformUpdates)
 }
 
-func (w *Writer) InsertTransactionValidator(ctx services.ConsumerCtx, txID ids.ID, validator platformvm.Validator) error {
+func (w *Writer) insertSubnet(ctx services.ConsumerCtx, subnetID ids.ID, subnetOwner verify.Verifiable) error {
+	owner, ok := subnetOwner.(*secp256k1fx.OutputOwners)
+	if !ok {
+		return fmt.Errorf("subnet owner %v", reflect.TypeOf(subnetOwner))
+	}
+
+	for _, addr := range owner.Addrs {
+		subnetControlKey := &db.SubnetControlKeys{
+			SubnetID:  subnetID.String(),
+			Address:   addr.String(),
+			CreatedAt: ctx.Time(),
+		}
+		err := ctx.Persist().InsertSubnetControlKeys(ctx.Ctx(), ctx.DB(), subnetControlKey, cfg.PerformUpdates)
+		if err != nil {
+			return err
+		}
+	}
+
+	subnet := &db.Subnets{
+		ID:        subnetID.String(),
+		Threshold: owner.Threshold,
+		Locktime:  owner.Locktime,
+		CreatedAt: ctx.Time(),
+	}
+	return ctx.Persist().InsertSubnets(ctx.Ctx(), ctx.DB(), subnet, cfg.PerformUpdates)
+}
+
+func (w *Writer) insertBlockchain(ctx services.ConsumerCtx, blockchainID ids.ID, tx *platformvm.UnsignedCreateChainTx) error {
+	blockchain := &db.Blockchains{
+		ID:          blockchainID.String(),
+		SubnetID:    tx.SubnetID.String(),
+		Name:        tx.ChainName,
+		VmID:        tx.VMID.String(),
+		GenesisHash: hex.EncodeToString(hashing.ComputeHash256(tx.GenesisData)),
+		CreatedAt:   ctx.Time(),
+	}
+	return ctx.Persist().InsertBlockchains(ctx.Ctx(), ctx.DB(), blockchain, cfg.PerformUpdates)
+}
+
+func (w *Writer) InsertTransactionValidator(ctx services.ConsumerCtx, txID ids.ID, validator platformvm.Validator, subnetID ids.ID) error {
 	transactionsValidator := &db.TransactionsValidator{
 		ID:        txID.String(),
 		NodeID:    validator.NodeID.String(),
+		SubnetID:  subnetID.String(),
 		Start:     validator.Start,
 		End:       validator.End,
+		Weight:    validator.Wght,
 		CreatedAt: ctx.Time(),
 	}
 	return ctx.Persist().InsertTransactionsValidator(ctx.Ctx(), ctx.DB(), transactionsValidator, cfg.PerformUpdates)
@@ -0,0 +1,120 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package webhooks
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/params"
+)
+
+// Matcher is a parsed WebhookFilter.
+type Matcher struct {
+	addresses map[models.Address]struct{}
+	assetIDs  map[models.StringID]struct{}
+	chainIDs  map[models.StringID]struct{}
+	types     map[string]struct{}
+	minAmount *big.Int
+}
+
+func NewMatcher(f models.WebhookFilter) (*Matcher, error) {
+	m := &Matcher{
+		addresses: make(map[models.Address]struct{}),
+		assetIDs:  make(map[models.StringID]struct{}),
+		chainIDs:  make(map[models.StringID]struct{}),
+		types:     make(map[string]struct{}),
+	}
+	for _, addressStr := range f.Addresses {
+		addr, err := params.AddressFromString(addressStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s", addressStr)
+		}
+		m.addresses[models.ToAddress(addr)] = struct{}{}
+	}
+	for _, assetIDStr := range f.AssetIDs {
+		assetID, err := ids.FromString(assetIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assetID %s", assetIDStr)
+		}
+		m.assetIDs[models.StringID(assetID.String())] = struct{}{}
+	}
+	for _, chainIDStr := range f.ChainIDs {
+		chainID, err := ids.FromString(chainIDStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chainID %s", chainIDStr)
+		}
+		m.chainIDs[models.StringID(chainID.String())] = struct{}{}
+	}
+	for _, txType := range f.Types {
+		m.types[txType] = struct{}{}
+	}
+	if f.MinAmount != "" {
+		minAmount, ok := new(big.Int).SetString(string(f.MinAmount), 10)
+		if !ok || minAmount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid minAmount %s", f.MinAmount)
+		}
+		m.minAmount = minAmount
+	}
+	return m, nil
+}
+
+// Match reports if the tx passes the chain and type filters, and has an input
+// or output passing the address, asset and amount filters.
+func (m *Matcher) Match(tx *models.Transaction) bool {
+	if len(m.chainIDs) != 0 {
+		if _, ok := m.chainIDs[tx.ChainID]; !ok {
+			return false
+		}
+	}
+	if len(m.types) != 0 {
+		if _, ok := m.types[tx.Type]; !ok {
+			return false
+		}
+	}
+	if len(m.addresses) == 0 && len(m.assetIDs) == 0 && m.minAmount == nil {
+		return true
+	}
+
+	for _, input := range tx.Inputs {
+		if input.Output != nil && m.matchOutput(input.Output) {
+			return true
+		}
+	}
+	for _, output := range tx.Outputs {
+		if m.matchOutput(output) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matcher) matchOutput(output *models.Output) bool {
+	if len(m.assetIDs) != 0 {
+		if _, ok := m.assetIDs[output.AssetID]; !ok {
+			return false
+		}
+	}
+	if len(m.addresses) != 0 {
+		found := false
+		for _, addr := range output.Addresses {
+			if _, ok := m.addresses[addr]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if m.minAmount != nil {
+		amount, ok := new(big.Int).SetString(string(output.Amount), 10)
+		if !ok || amount.Cmp(m.minAmount) < 0 {
+			return false
+		}
+	}
+	return true
+}
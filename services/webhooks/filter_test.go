@@ -0,0 +1,53 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+)
+
+func TestMatcher(t *testing.T) {
+	chainID := ids.GenerateTestID()
+	assetID := ids.GenerateTestID()
+	addr := ids.GenerateTestShortID()
+
+	tx := &models.Transaction{
+		ChainID: models.StringID(chainID.String()),
+		Type:    "base",
+		Outputs: []*models.Output{{
+			AssetID:   models.StringID(assetID.String()),
+			Amount:    "100",
+			Addresses: []models.Address{models.ToAddress(addr)},
+		}},
+	}
+
+	tests := []struct {
+		filter  models.WebhookFilter
+		matches bool
+	}{
+		{models.WebhookFilter{}, true},
+		{models.WebhookFilter{ChainIDs: []string{chainID.String()}, Types: []string{"base"}}, true},
+		{models.WebhookFilter{Types: []string{"import"}}, false},
+		{models.WebhookFilter{Addresses: []string{addr.String()}, AssetIDs: []string{assetID.String()}}, true},
+		{models.WebhookFilter{Addresses: []string{ids.GenerateTestShortID().String()}}, false},
+		{models.WebhookFilter{AssetIDs: []string{assetID.String()}, MinAmount: "100"}, true},
+		{models.WebhookFilter{AssetIDs: []string{assetID.String()}, MinAmount: "101"}, false},
+	}
+	for i, test := range tests {
+		m, err := NewMatcher(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Match(tx) != test.matches {
+			t.Fatalf("filter %d expected match %v", i, test.matches)
+		}
+	}
+
+	if _, err := NewMatcher(models.WebhookFilter{MinAmount: "-1"}); err == nil {
+		t.Fatal("expected invalid minAmount")
+	}
+}
@@ -0,0 +1,308 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/axc"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/dbr/v2"
+)
+
+const (
+	// MaxAttempts is the number of failed attempts after which a delivery is dead.
+	MaxAttempts = 12
+
+	matchersRefresh = 10 * time.Second
+
+	deliveryInterval = time.Second
+	deliveryBatch    = 100
+	deliveryThreads  = 8
+	deliveryTimeout  = 10 * time.Second
+	// a claimed delivery is retried after the lease if the process dies while posting it
+	deliveryLease = time.Minute
+
+	lastErrorMaxSize = 1024
+)
+
+// retries back off from 10 seconds, doubling up to an hour
+var deliveryBackoff = utils.NewRetrySleeper(0, 10*time.Second, time.Hour)
+
+// Handler queues a delivery for every webhook matching an indexed transaction,
+// and posts the queued deliveries. The indexer calls Enqueue before it marks a
+// message processed, so no transaction is missed across restarts.
+type Handler struct {
+	sc      *servicesctrl.Control
+	conns   *utils.Connections
+	persist db.Persist
+	reader  *axc.Reader
+	client  *http.Client
+	worker  utils.Worker
+	doneCh  chan struct{}
+
+	matchersLock sync.Mutex
+	matchers     map[string]*Matcher
+	matchersTime time.Time
+}
+
+func (h *Handler) Start(sc *servicesctrl.Control) error {
+	conns, err := sc.Database()
+	if err != nil {
+		return err
+	}
+
+	// the reader only dresses the indexed transactions, skip its aggregate processing
+	readerCtrl := *sc
+	readerCtrl.IsAggregateCache = false
+	reader, err := axc.NewReader(sc.ServicesCfg.NetworkID, conns, nil, nil, &readerCtrl)
+	if err != nil {
+		_ = conns.Close()
+		return err
+	}
+
+	h.sc = sc
+	h.conns = conns
+	h.persist = db.NewPersist()
+	h.reader = reader
+	h.client = newClient()
+	h.worker = utils.NewWorker(deliveryBatch, deliveryThreads, h.processDelivery)
+	h.doneCh = make(chan struct{})
+
+	go h.runDeliver()
+	return nil
+}
+
+func (h *Handler) Close() {
+	close(h.doneCh)
+}
+
+func (h *Handler) newSession(name string) *dbr.Session {
+	return h.conns.DB().NewSessionForEventReceiver(h.conns.Stream().NewJob(name))
+}
+
+func (h *Handler) loadMatchers(ctx context.Context) (map[string]*Matcher, error) {
+	h.matchersLock.Lock()
+	defer h.matchersLock.Unlock()
+	if h.matchers != nil && time.Since(h.matchersTime) < matchersRefresh {
+		return h.matchers, nil
+	}
+
+	webhooks, err := h.persist.QueryAllWebhooks(ctx, h.newSession("webhooks-load"))
+	if err != nil {
+		return nil, err
+	}
+	matchers := make(map[string]*Matcher, len(webhooks))
+	for _, webhook := range webhooks {
+		filter, err := ParseFilter(webhook)
+		if err != nil {
+			h.sc.Log.Warn("webhook %s filter %v", webhook.ID, err)
+			continue
+		}
+		matcher, err := NewMatcher(filter)
+		if err != nil {
+			h.sc.Log.Warn("webhook %s filter %v", webhook.ID, err)
+			continue
+		}
+		matchers[webhook.ID] = matcher
+	}
+	h.matchers = matchers
+	h.matchersTime = time.Now()
+	return matchers, nil
+}
+
+// Enqueue queues a delivery of the indexed transaction to every matching webhook.
+// Deliveries have a deterministic id, enqueueing a transaction again is a no-op.
+func (h *Handler) Enqueue(indexedTx *utils.IndexedTx) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+
+	matchers, err := h.loadMatchers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(matchers) == 0 {
+		return nil
+	}
+
+	id, err := ids.FromString(indexedTx.TxID)
+	if err != nil {
+		return err
+	}
+	txs, err := h.reader.GetIndexedTransactions(ctx, id, h.sc.GenesisContainer.AxcAssetID)
+	if err != nil {
+		return err
+	}
+
+	sess := h.newSession("webhooks-enqueue")
+	for _, tx := range txs {
+		for webhookID, matcher := range matchers {
+			if !matcher.Match(tx) {
+				continue
+			}
+			tm := time.Now().UTC()
+			delivery := &db.WebhookDeliveries{
+				WebhookID:     webhookID,
+				TxID:          string(tx.ID),
+				Status:        db.WebhookDeliveryPending,
+				NextAttemptAt: tm,
+				CreatedAt:     tm,
+				UpdatedAt:     tm,
+			}
+			if err := delivery.ComputeID(); err != nil {
+				return err
+			}
+			delivery.Payload, err = json.Marshal(&models.WebhookPayload{
+				WebhookID:   models.StringID(webhookID),
+				DeliveryID:  models.StringID(delivery.ID),
+				Transaction: tx,
+			})
+			if err != nil {
+				return err
+			}
+			if err := h.persist.InsertWebhookDeliveries(ctx, sess, delivery); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h *Handler) runDeliver() {
+	ticker := time.NewTicker(deliveryInterval)
+	defer func() {
+		ticker.Stop()
+		h.worker.Finish(10 * time.Millisecond)
+		_ = h.conns.Close()
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.claimDeliveries(); err != nil {
+				h.sc.Log.Error("webhooks deliver %v", err)
+			}
+		case <-h.doneCh:
+			return
+		}
+	}
+}
+
+// claimDeliveries hands the due deliveries this process claimed to the workers.
+func (h *Handler) claimDeliveries() error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+
+	// only claim what the workers can take, a queued delivery holds its lease
+	limit := deliveryBatch - int(h.worker.JobCnt())
+	if limit <= 0 {
+		return nil
+	}
+
+	sess := h.newSession("webhooks-claim")
+	tm := time.Now().UTC()
+	deliveries, err := h.persist.QueryWebhookDeliveriesList(ctx, sess, &db.WebhookDeliveriesFilter{
+		Statuses:  []db.WebhookDeliveryStatus{db.WebhookDeliveryPending},
+		DueBefore: &tm,
+		Limit:     limit,
+	})
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		claimed, err := h.persist.ClaimWebhookDeliveries(ctx, sess, delivery, tm.Add(deliveryLease))
+		if err != nil {
+			return err
+		}
+		if claimed {
+			h.worker.Enque(delivery)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) processDelivery(_ int, job interface{}) {
+	delivery, ok := job.(*db.WebhookDeliveries)
+	if !ok {
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+
+	sess := h.newSession("webhooks-delivery")
+	webhook, err := h.persist.QueryWebhooks(ctx, sess, &db.Webhooks{ID: delivery.WebhookID})
+	switch err {
+	case nil:
+		err = h.post(webhook, delivery)
+	case dbr.ErrNotFound:
+		err = fmt.Errorf("webhook deleted")
+		delivery.Attempts = MaxAttempts
+	default:
+		h.sc.Log.Error("webhooks delivery %s %v", delivery.ID, err)
+		return
+	}
+
+	tm := time.Now().UTC()
+	delivery.UpdatedAt = tm
+	switch {
+	case err == nil:
+		delivery.Status = db.WebhookDeliveryDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= MaxAttempts:
+		delivery.Status = db.WebhookDeliveryDead
+		delivery.LastError = lastError(err)
+	default:
+		delivery.NextAttemptAt = tm.Add(deliveryBackoff.Backoff(uint64(delivery.Attempts)))
+		delivery.LastError = lastError(err)
+	}
+
+	if err := h.persist.UpdateWebhookDeliveriesStatus(ctx, sess, delivery); err != nil {
+		h.sc.Log.Error("webhooks delivery %s %v", delivery.ID, err)
+	}
+}
+
+func (h *Handler) post(webhook *db.Webhooks, delivery *db.WebhookDeliveries) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, delivery.Payload))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, lastErrorMaxSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func lastError(err error) string {
+	msg := err.Error()
+	if len(msg) > lastErrorMaxSize {
+		msg = msg[:lastErrorMaxSize]
+	}
+	return msg
+}
@@ -0,0 +1,115 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const resolveTimeout = 5 * time.Second
+
+var ErrPrivateTarget = errors.New("webhook url targets a private address")
+
+// blocked ranges which are not covered by the net.IP predicates
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",      // this network
+	"100.64.0.0/10",  // carrier grade nat
+	"192.0.0.0/24",   // protocol assignments
+	"198.18.0.0/15",  // benchmarking
+	"240.0.0.0/4",    // reserved
+	"64:ff9b:1::/48", // local nat64
+	"100::/64",       // discard
+	"2001::/32",      // teredo, embeds an ipv4
+	"2002::/16",      // 6to4, embeds an ipv4
+	"fec0::/10",      // site local
+)
+
+// publicIP reports whether ip is a public unicast address a webhook may be posted to.
+func publicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkHost fails unless every address of the host is public.
+func checkHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicIP(ip) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+
+	ctx, cancelFn := context.WithTimeout(ctx, resolveTimeout)
+	defer cancelFn()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrInvalidURL
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+// newClient returns a client which refuses to connect to a private address.
+// The url is checked when the webhook is saved, but the host may resolve to
+// another address by the time a delivery is posted, or redirect to one, so the
+// address actually dialed is checked again.
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w %s", ErrPrivateTarget, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			// a proxy would dial the target itself, past the check
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+			MaxIdleConnsPerHost: deliveryThreads,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
@@ -0,0 +1,159 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/models"
+)
+
+const (
+	// HeaderSignature carries the hex HMAC-SHA256 of the body, keyed by the webhook secret.
+	HeaderSignature = "X-Magellan-Signature"
+	HeaderDelivery  = "X-Magellan-Delivery"
+
+	secretSize = 32
+)
+
+var ErrInvalidURL = errors.New("invalid webhook url")
+
+// NewWebhook validates the url and filter of a new webhook, and generates its id and secret.
+func NewWebhook(webhookURL string, filter models.WebhookFilter) (*db.Webhooks, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	tm := time.Now().UTC()
+	v := &db.Webhooks{
+		ID:        id.String(),
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: tm,
+	}
+	if err := UpdateWebhook(v, webhookURL, filter); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateWebhook validates and sets the url and filter of the webhook.
+// The url must be http(s) and its host must only resolve to public addresses.
+func UpdateWebhook(v *db.Webhooks, webhookURL string, filter models.WebhookFilter) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	if err := checkHost(context.Background(), u.Hostname()); err != nil {
+		return err
+	}
+	if _, err := NewMatcher(filter); err != nil {
+		return err
+	}
+	filterBytes, err := json.Marshal(filter)
+	if err != nil {
+		return err
+	}
+	v.URL = webhookURL
+	v.Filter = string(filterBytes)
+	v.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func ParseFilter(v *db.Webhooks) (models.WebhookFilter, error) {
+	var filter models.WebhookFilter
+	err := json.Unmarshal([]byte(v.Filter), &filter)
+	return filter, err
+}
+
+// ToWebhook converts the webhook to its api model, without the secret.
+func ToWebhook(v *db.Webhooks) (*models.Webhook, error) {
+	filter, err := ParseFilter(v)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Webhook{
+		ID:        models.StringID(v.ID),
+		URL:       v.URL,
+		Filter:    filter,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}, nil
+}
+
+func ToDelivery(v *db.WebhookDeliveries) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:            models.StringID(v.ID),
+		WebhookID:     models.StringID(v.WebhookID),
+		TransactionID: models.StringID(v.TxID),
+		Status:        ToDeliveryStatus(v.Status),
+		Attempts:      v.Attempts,
+		NextAttemptAt: v.NextAttemptAt,
+		LastError:     v.LastError,
+		CreatedAt:     v.CreatedAt,
+		UpdatedAt:     v.UpdatedAt,
+	}
+}
+
+func ToDeliveryStatus(status db.WebhookDeliveryStatus) models.WebhookDeliveryStatus {
+	switch status {
+	case db.WebhookDeliveryDelivered:
+		return models.WebhookDeliveryStatusDelivered
+	case db.WebhookDeliveryDead:
+		return models.WebhookDeliveryStatusDead
+	default:
+		return models.WebhookDeliveryStatusPending
+	}
+}
+
+func ParseDeliveryStatus(status string) (db.WebhookDeliveryStatus, error) {
+	switch models.WebhookDeliveryStatus(status) {
+	case models.WebhookDeliveryStatusPending:
+		return db.WebhookDeliveryPending, nil
+	case models.WebhookDeliveryStatusDelivered:
+		return db.WebhookDeliveryDelivered, nil
+	case models.WebhookDeliveryStatusDead:
+		return db.WebhookDeliveryDead, nil
+	}
+	return 0, fmt.Errorf("invalid status %s", status)
+}
+
+// Redeliver queues a dead delivery again, with a fresh set of attempts.
+func Redeliver(v *db.WebhookDeliveries) {
+	tm := time.Now().UTC()
+	v.Status = db.WebhookDeliveryPending
+	v.Attempts = 0
+	v.NextAttemptAt = tm
+	v.UpdatedAt = tm
+}
+
+// Sign returns the signature of the body sent in HeaderSignature.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomID() (ids.ID, error) {
+	b := make([]byte, len(ids.Empty))
+	if _, err := rand.Read(b); err != nil {
+		return ids.Empty, err
+	}
+	return ids.ToID(b)
+}
@@ -0,0 +1,136 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package servicesctrl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+)
+
+// ChainsPollInterval is how often the registered chains are reloaded.
+var ChainsPollInterval = 10 * time.Second
+
+var chainsPollTimeout = 10 * time.Second
+
+// ChainListener attaches and detaches the chains registered at runtime.
+type ChainListener interface {
+	AddChain(cfg.Chain) error
+	RemoveChain(cfg.Chain) error
+}
+
+// ChainsWatcher polls the chains table, and passes the chains registered and
+// removed since the last poll on to its listener. The chains of the config are
+// never passed on, they are attached at startup.
+type ChainsWatcher struct {
+	sc       *Control
+	conns    *utils.Connections
+	listener ChainListener
+	doneCh   chan struct{}
+
+	lock   sync.Mutex
+	chains map[string]cfg.Chain
+}
+
+// WatchChains passes the registered chains on to the listener, then starts
+// watching for changes.
+func (s *Control) WatchChains(listener ChainListener) (*ChainsWatcher, error) {
+	conns, err := s.Database()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ChainsWatcher{
+		sc:       s,
+		conns:    conns,
+		listener: listener,
+		doneCh:   make(chan struct{}),
+		chains:   make(map[string]cfg.Chain),
+	}
+	if err := w.refresh(); err != nil {
+		_ = conns.Close()
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(ChainsPollInterval)
+		defer func() {
+			ticker.Stop()
+			_ = conns.Close()
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.refresh(); err != nil {
+					s.Log.Warn("chains refresh %v", err)
+				}
+			case <-w.doneCh:
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+func (w *ChainsWatcher) Close() {
+	close(w.doneCh)
+}
+
+// refresh diffs the registered chains against the attached ones. A chain which
+// fails to attach is retried on the next poll.
+func (w *ChainsWatcher) refresh() error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), chainsPollTimeout)
+	defer cancelFn()
+
+	sess := w.conns.DB().NewSessionForEventReceiver(w.conns.Stream().NewJob("query-chains"))
+	rows, err := w.sc.Persist.QueryAllChains(ctx, sess)
+	if err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	registered := make(map[string]cfg.Chain, len(rows))
+	for _, row := range rows {
+		if w.sc.IsConfiguredChain(row.ID) {
+			continue
+		}
+		registered[row.ID] = cfg.Chain{ID: row.ID, VMType: row.VMType}
+	}
+
+	for id, chain := range w.chains {
+		if _, ok := registered[id]; ok {
+			continue
+		}
+		if err := w.listener.RemoveChain(chain); err != nil {
+			w.sc.Log.Warn("remove chain %s %v", id, err)
+			continue
+		}
+		w.sc.Log.Info("removed chain %s", id)
+		delete(w.chains, id)
+	}
+	for id, chain := range registered {
+		if _, ok := w.chains[id]; ok {
+			continue
+		}
+		if err := w.listener.AddChain(chain); err != nil {
+			w.sc.Log.Warn("add chain %s vm %s %v", id, chain.VMType, err)
+			continue
+		}
+		w.sc.Log.Info("added chain %s vm %s", id, chain.VMType)
+		w.chains[id] = chain
+	}
+	return nil
+}
+
+// IsConfiguredChain is whether the chain is one of the config, which can not be
+// registered or removed at runtime.
+func (s *Control) IsConfiguredChain(chainID string) bool {
+	_, ok := s.Chains[chainID]
+	return ok
+}
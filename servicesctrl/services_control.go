@@ -22,6 +22,10 @@ const (
 	MetricConsumeProcessMillisCounterKey = "consume_records_process_millis"
 	MetricConsumeSuccessCountKey         = "consume_records_success"
 	MetricConsumeFailureCountKey         = "consume_records_failure"
+
+	// MetricTxPoolEnqueueDroppedKey counts the messages not enqueued as the
+	// local tx pool was full, the indexer reads them from the tx pool instead.
+	MetricTxPoolEnqueueDroppedKey = "tx_pool_enqueue_dropped"
 )
 
 type LocalTxPoolJob struct {
@@ -54,6 +58,7 @@ func (s *Control) Logger() logging.Logger {
 func (s *Control) Init(networkID uint32) error {
 	s.IndexedList = utils.NewIndexedList(cfg.MaxSizedList)
 	s.LocalTxPool = make(chan *LocalTxPoolJob, cfg.MaxTxPoolSize)
+	utils.Prometheus.CounterInit(MetricTxPoolEnqueueDroppedKey, "tx pool messages not enqueued")
 
 	if _, ok := s.Features["accumulate_balance_indexer"]; ok {
 		s.Log.Info("enable feature accumulate_balance_indexer")
@@ -122,5 +127,6 @@ func (s *Control) Enqueue(pool *db.TxPool) {
 	select {
 	case s.LocalTxPool <- &LocalTxPoolJob{TxPool: pool}:
 	default:
+		_ = utils.Prometheus.CounterInc(MetricTxPoolEnqueueDroppedKey)
 	}
 }
@@ -20,22 +20,33 @@ import (
 	"github.com/axiacoin/axia-network-v2-magellan/utils"
 )
 
+// consumerAXChainDB consumes the blocks, traces and logs of the EVM chains, the
+// AX chain and the EVM chains of the config.
 type consumerAXChainDB struct {
 	id string
 	sc *servicesctrl.Control
 
+	conf cfg.Config
+
+	// Concurrency control
+	quitCh chan struct{}
+
+	chains map[string]*consumerEVMChain
+	topics []string
+}
+
+// consumerEVMChain is the writer, topics and metrics of an EVM chain.
+type consumerEVMChain struct {
+	chainID  string
+	consumer *cvm.Writer
+	client   *modelsc.Client
+
 	// metrics
 	metricProcessedCountKey       string
 	metricProcessMillisCounterKey string
 	metricSuccessCountKey         string
 	metricFailureCountKey         string
 
-	conf cfg.Config
-
-	// Concurrency control
-	quitCh   chan struct{}
-	consumer *cvm.Writer
-
 	topicName     string
 	topicTrcName  string
 	topicLogsName string
@@ -44,39 +55,68 @@ type consumerAXChainDB struct {
 func NewConsumerAXChainDB() ProcessorFactoryInstDB {
 	return func(sc *servicesctrl.Control, conf cfg.Config) (ProcessorDB, error) {
 		c := &consumerAXChainDB{
-			conf:                          conf,
-			sc:                            sc,
-			metricProcessedCountKey:       fmt.Sprintf("consume_records_processed_%s_axchain", conf.AXchainID),
-			metricProcessMillisCounterKey: fmt.Sprintf("consume_records_process_millis_%s_axchain", conf.AXchainID),
-			metricSuccessCountKey:         fmt.Sprintf("consume_records_success_%s_axchain", conf.AXchainID),
-			metricFailureCountKey:         fmt.Sprintf("consume_records_failure_%s_axchain", conf.AXchainID),
-			id:                            fmt.Sprintf("consumer %d %s axchain", conf.NetworkID, conf.AXchainID),
-
+			conf:   conf,
+			sc:     sc,
+			id:     fmt.Sprintf("consumer %d axchain", conf.NetworkID),
+			chains: make(map[string]*consumerEVMChain),
 			quitCh: make(chan struct{}),
 		}
-		utils.Prometheus.CounterInit(c.metricProcessedCountKey, "records processed")
-		utils.Prometheus.CounterInit(c.metricProcessMillisCounterKey, "records processed millis")
-		utils.Prometheus.CounterInit(c.metricSuccessCountKey, "records success")
-		utils.Prometheus.CounterInit(c.metricFailureCountKey, "records failure")
 		sc.InitConsumeMetrics()
 
-		var err error
-		c.consumer, err = cvm.NewWriter(c.conf.NetworkID, c.conf.AXchainID)
-		if err != nil {
-			_ = c.Close()
-			return nil, err
+		for _, evmChain := range conf.AllEVMChains() {
+			chain, err := newConsumerEVMChain(conf.NetworkID, evmChain)
+			if err != nil {
+				_ = c.Close()
+				return nil, err
+			}
+			for _, topic := range []string{chain.topicName, chain.topicTrcName, chain.topicLogsName} {
+				c.chains[topic] = chain
+				c.topics = append(c.topics, topic)
+			}
 		}
 
-		c.topicName = fmt.Sprintf("%d-%s-axchain", c.conf.NetworkID, c.conf.AXchainID)
-		c.topicTrcName = fmt.Sprintf("%d-%s-axchain-trc", c.conf.NetworkID, c.conf.AXchainID)
-		c.topicLogsName = fmt.Sprintf("%d-%s-axchain-logs", conf.NetworkID, conf.AXchainID)
-
 		return c, nil
 	}
 }
 
+func newConsumerEVMChain(networkID uint32, evmChain cfg.EVMChain) (*consumerEVMChain, error) {
+	chainID := evmChain.ID
+	consumer, err := cvm.NewWriter(networkID, chainID)
+	if err != nil {
+		return nil, err
+	}
+	// the client reads the metadata of the tokens
+	client, err := modelsc.NewClient(evmChain.RPC)
+	if err != nil {
+		return nil, err
+	}
+	consumer.SetContractCaller(client)
+	chain := &consumerEVMChain{
+		chainID:                       chainID,
+		consumer:                      consumer,
+		client:                        client,
+		metricProcessedCountKey:       fmt.Sprintf("consume_records_processed_%s_axchain", chainID),
+		metricProcessMillisCounterKey: fmt.Sprintf("consume_records_process_millis_%s_axchain", chainID),
+		metricSuccessCountKey:         fmt.Sprintf("consume_records_success_%s_axchain", chainID),
+		metricFailureCountKey:         fmt.Sprintf("consume_records_failure_%s_axchain", chainID),
+		topicName:                     fmt.Sprintf("%d-%s-axchain", networkID, chainID),
+		topicTrcName:                  fmt.Sprintf("%d-%s-axchain-trc", networkID, chainID),
+		topicLogsName:                 fmt.Sprintf("%d-%s-axchain-logs", networkID, chainID),
+	}
+	utils.Prometheus.CounterInit(chain.metricProcessedCountKey, "records processed")
+	utils.Prometheus.CounterInit(chain.metricProcessMillisCounterKey, "records processed millis")
+	utils.Prometheus.CounterInit(chain.metricSuccessCountKey, "records success")
+	utils.Prometheus.CounterInit(chain.metricFailureCountKey, "records failure")
+	return chain, nil
+}
+
 // Close shuts down the producer
 func (c *consumerAXChainDB) Close() error {
+	for topic, chain := range c.chains {
+		if topic == chain.topicName {
+			chain.client.Close()
+		}
+	}
 	return nil
 }
 
@@ -85,52 +125,42 @@ func (c *consumerAXChainDB) ID() string {
 }
 
 func (c *consumerAXChainDB) Topic() []string {
-	return []string{c.topicName, c.topicTrcName, c.topicLogsName}
+	return c.topics
 }
 
 func (c *consumerAXChainDB) Process(conns *utils.Connections, row *db.TxPool) error {
+	chain, ok := c.chains[row.Topic]
+	if !ok {
+		return nil
+	}
+	msg := &Message{
+		id:         row.MsgKey,
+		chainID:    chain.chainID,
+		body:       row.Serialization,
+		timestamp:  row.CreatedAt.UTC().Unix(),
+		nanosecond: int64(row.CreatedAt.UTC().Nanosecond()),
+	}
 	switch row.Topic {
-	case c.topicName:
-		msg := &Message{
-			id:         row.MsgKey,
-			chainID:    c.conf.AXchainID,
-			body:       row.Serialization,
-			timestamp:  row.CreatedAt.UTC().Unix(),
-			nanosecond: int64(row.CreatedAt.UTC().Nanosecond()),
-		}
-		return c.Consume(conns, msg)
-	case c.topicTrcName:
-		msg := &Message{
-			id:         row.MsgKey,
-			chainID:    c.conf.AXchainID,
-			body:       row.Serialization,
-			timestamp:  row.CreatedAt.UTC().Unix(),
-			nanosecond: int64(row.CreatedAt.UTC().Nanosecond()),
-		}
-		return c.ConsumeTrace(conns, msg)
-	case c.topicLogsName:
-		msg := &Message{
-			id:         row.MsgKey,
-			chainID:    c.conf.AXchainID,
-			body:       row.Serialization,
-			timestamp:  row.CreatedAt.UTC().Unix(),
-			nanosecond: int64(row.CreatedAt.UTC().Nanosecond()),
-		}
-		return c.ConsumeLogs(conns, msg)
+	case chain.topicName:
+		return c.Consume(conns, chain, msg)
+	case chain.topicTrcName:
+		return c.ConsumeTrace(conns, chain, msg)
+	case chain.topicLogsName:
+		return c.ConsumeLogs(conns, chain, msg)
 	}
 
 	return nil
 }
 
-func (c *consumerAXChainDB) ConsumeLogs(conns *utils.Connections, msg services.Consumable) error {
+func (c *consumerAXChainDB) ConsumeLogs(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable) error {
 	txLogs := &types.Log{}
 	err := json.Unmarshal(msg.Body(), txLogs)
 	if err != nil {
 		return err
 	}
 	collectors := utils.NewCollectors(
-		utils.NewCounterIncCollect(c.metricProcessedCountKey),
-		utils.NewCounterObserveMillisCollect(c.metricProcessMillisCounterKey),
+		utils.NewCounterIncCollect(chain.metricProcessedCountKey),
+		utils.NewCounterObserveMillisCollect(chain.metricProcessMillisCounterKey),
 		utils.NewCounterIncCollect(servicesctrl.MetricConsumeProcessedCountKey),
 		utils.NewCounterObserveMillisCollect(servicesctrl.MetricConsumeProcessMillisCounterKey),
 	)
@@ -147,7 +177,7 @@ func (c *consumerAXChainDB) ConsumeLogs(conns *utils.Connections, msg services.C
 
 	rsleep := utils.NewRetrySleeper(1, 100*time.Millisecond, time.Second)
 	for {
-		err = c.persistConsumeLogs(conns, nmsg, txLogs)
+		err = c.persistConsumeLogs(conns, chain, nmsg, txLogs)
 		if !utils.ErrIsLockError(err) {
 			break
 		}
@@ -155,25 +185,25 @@ func (c *consumerAXChainDB) ConsumeLogs(conns *utils.Connections, msg services.C
 	}
 
 	if err != nil {
-		c.Failure()
+		c.Failure(chain)
 		collectors.Error()
 		c.sc.Log.Error("consumer.Consume: %s", err)
 		return err
 	}
-	c.Success()
+	c.Success(chain)
 
 	return nil
 }
 
-func (c *consumerAXChainDB) ConsumeTrace(conns *utils.Connections, msg services.Consumable) error {
+func (c *consumerAXChainDB) ConsumeTrace(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable) error {
 	transactionTrace := &modelsc.TransactionTrace{}
 	err := json.Unmarshal(msg.Body(), transactionTrace)
 	if err != nil {
 		return err
 	}
 	collectors := utils.NewCollectors(
-		utils.NewCounterIncCollect(c.metricProcessedCountKey),
-		utils.NewCounterObserveMillisCollect(c.metricProcessMillisCounterKey),
+		utils.NewCounterIncCollect(chain.metricProcessedCountKey),
+		utils.NewCounterObserveMillisCollect(chain.metricProcessMillisCounterKey),
 		utils.NewCounterIncCollect(servicesctrl.MetricConsumeProcessedCountKey),
 		utils.NewCounterObserveMillisCollect(servicesctrl.MetricConsumeProcessMillisCounterKey),
 	)
@@ -190,7 +220,7 @@ func (c *consumerAXChainDB) ConsumeTrace(conns *utils.Connections, msg services.
 
 	rsleep := utils.NewRetrySleeper(1, 100*time.Millisecond, time.Second)
 	for {
-		err = c.persistConsumeTrace(conns, nmsg, transactionTrace)
+		err = c.persistConsumeTrace(conns, chain, nmsg, transactionTrace)
 		if !utils.ErrIsLockError(err) {
 			break
 		}
@@ -198,25 +228,25 @@ func (c *consumerAXChainDB) ConsumeTrace(conns *utils.Connections, msg services.
 	}
 
 	if err != nil {
-		c.Failure()
+		c.Failure(chain)
 		collectors.Error()
 		c.sc.Log.Error("consumer.Consume: %s", err)
 		return err
 	}
-	c.Success()
+	c.Success(chain)
 
 	return nil
 }
 
-func (c *consumerAXChainDB) Consume(conns *utils.Connections, msg services.Consumable) error {
+func (c *consumerAXChainDB) Consume(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable) error {
 	block, err := modelsc.Unmarshal(msg.Body())
 	if err != nil {
 		return err
 	}
 
 	collectors := utils.NewCollectors(
-		utils.NewCounterIncCollect(c.metricProcessedCountKey),
-		utils.NewCounterObserveMillisCollect(c.metricProcessMillisCounterKey),
+		utils.NewCounterIncCollect(chain.metricProcessedCountKey),
+		utils.NewCounterObserveMillisCollect(chain.metricProcessMillisCounterKey),
 		utils.NewCounterIncCollect(servicesctrl.MetricConsumeProcessedCountKey),
 		utils.NewCounterObserveMillisCollect(servicesctrl.MetricConsumeProcessMillisCounterKey),
 	)
@@ -236,7 +266,7 @@ func (c *consumerAXChainDB) Consume(conns *utils.Connections, msg services.Consu
 
 	rsleep := utils.NewRetrySleeper(1, 100*time.Millisecond, time.Second)
 	for {
-		err = c.persistConsume(conns, nmsg, block)
+		err = c.persistConsume(conns, chain, nmsg, block)
 		if !utils.ErrIsLockError(err) {
 			break
 		}
@@ -244,42 +274,42 @@ func (c *consumerAXChainDB) Consume(conns *utils.Connections, msg services.Consu
 	}
 
 	if err != nil {
-		c.Failure()
+		c.Failure(chain)
 		collectors.Error()
 		c.sc.Log.Error("consumer.Consume: %s", err)
 		return err
 	}
-	c.Success()
+	c.Success(chain)
 
 	c.sc.BalanceManager.Exec()
 
 	return nil
 }
 
-func (c *consumerAXChainDB) persistConsumeLogs(conns *utils.Connections, msg services.Consumable, txLogs *types.Log) error {
+func (c *consumerAXChainDB) persistConsumeLogs(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable, txLogs *types.Log) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
 	defer cancelFn()
-	return c.consumer.ConsumeLogs(ctx, conns, msg, txLogs, c.sc.Persist)
+	return chain.consumer.ConsumeLogs(ctx, conns, msg, txLogs, c.sc.Persist)
 }
 
-func (c *consumerAXChainDB) persistConsumeTrace(conns *utils.Connections, msg services.Consumable, transactionTrace *modelsc.TransactionTrace) error {
+func (c *consumerAXChainDB) persistConsumeTrace(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable, transactionTrace *modelsc.TransactionTrace) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
 	defer cancelFn()
-	return c.consumer.ConsumeTrace(ctx, conns, msg, transactionTrace, c.sc.Persist)
+	return chain.consumer.ConsumeTrace(ctx, conns, msg, transactionTrace, c.sc.Persist)
 }
 
-func (c *consumerAXChainDB) persistConsume(conns *utils.Connections, msg services.Consumable, block *modelsc.Block) error {
+func (c *consumerAXChainDB) persistConsume(conns *utils.Connections, chain *consumerEVMChain, msg services.Consumable, block *modelsc.Block) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
 	defer cancelFn()
-	return c.consumer.Consume(ctx, conns, msg, block, c.sc.Persist)
+	return chain.consumer.Consume(ctx, conns, msg, block, c.sc.Persist)
 }
 
-func (c *consumerAXChainDB) Failure() {
-	_ = utils.Prometheus.CounterInc(c.metricFailureCountKey)
+func (c *consumerAXChainDB) Failure(chain *consumerEVMChain) {
+	_ = utils.Prometheus.CounterInc(chain.metricFailureCountKey)
 	_ = utils.Prometheus.CounterInc(servicesctrl.MetricConsumeFailureCountKey)
 }
 
-func (c *consumerAXChainDB) Success() {
-	_ = utils.Prometheus.CounterInc(c.metricSuccessCountKey)
+func (c *consumerAXChainDB) Success(chain *consumerEVMChain) {
+	_ = utils.Prometheus.CounterInc(chain.metricSuccessCountKey)
 	_ = utils.Prometheus.CounterInc(servicesctrl.MetricConsumeSuccessCountKey)
 }
@@ -0,0 +1,219 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/services"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/cvm"
+	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/pvm"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/stream"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/dbr/v2"
+)
+
+const backfillBatch = 1000
+
+var errBackfillStopped = errors.New("backfill stopped")
+
+// backfilled returns whether the one time backfill of the key is done.
+func backfilled(sc *servicesctrl.Control, conns *utils.Connections, key string) (bool, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("backfill-key-value"))
+	v, err := sc.Persist.QueryKeyValueStore(ctx, sess, &db.KeyValueStore{K: key})
+	switch err {
+	case nil:
+		return v.V == "true", nil
+	case dbr.ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func setBackfilled(sc *servicesctrl.Control, conns *utils.Connections, key string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("backfill-key-value"))
+	return sc.Persist.InsertKeyValueStore(ctx, sess, &db.KeyValueStore{K: key, V: "true"})
+}
+
+// backfillSubnets indexes the subnets and blockchains of the platform chain
+// blocks indexed before they were, once. The blocks are read back from the tx
+// pool a page at a time, and only the subnet and blockchain rows are written.
+// A backfill which fails, or is stopped, runs again from the start with the
+// next indexer, the rows are inserted once.
+func backfillSubnets(sc *servicesctrl.Control, config *cfg.Config, doneCh chan struct{}) error {
+	conns, err := sc.Database()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conns.Close()
+	}()
+
+	done, err := backfilled(sc, conns, utils.KeyValueSubnetsBackfill)
+	if err != nil || done {
+		return err
+	}
+
+	sc.Log.Info("subnets backfill start")
+	for _, chain := range config.Chains {
+		if chain.VMType != IndexerPVMName {
+			continue
+		}
+		writer, err := pvm.NewWriter(config.NetworkID, chain.ID)
+		if err != nil {
+			return err
+		}
+		if err := writer.IndexGenesisSubnets(context.Background(), conns, sc.Persist); err != nil {
+			return err
+		}
+		topic := stream.GetTopicName(config.NetworkID, chain.ID, stream.EventTypeDecisions)
+		if err := backfillSubnetsTopic(sc, conns, writer, topic, doneCh); err != nil {
+			return err
+		}
+	}
+	sc.Log.Info("subnets backfill complete")
+
+	return setBackfilled(sc, conns, utils.KeyValueSubnetsBackfill)
+}
+
+func backfillSubnetsTopic(sc *servicesctrl.Control, conns *utils.Connections, writer *pvm.Writer, topic string, doneCh chan struct{}) error {
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("backfill-subnets"))
+
+	var after string
+	for {
+		select {
+		case <-doneCh:
+			return errBackfillStopped
+		default:
+		}
+
+		ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
+		var txPools []*db.TxPool
+		_, err := sess.Select(
+			"id",
+			"serialization",
+			"created_at",
+		).From(db.TableTxPool).
+			Where("topic=? and id > ?", topic, after).
+			OrderAsc("id").
+			Limit(backfillBatch).
+			LoadContext(ctx, &txPools)
+		if err != nil {
+			cancelFn()
+			return err
+		}
+
+		for _, txPool := range txPools {
+			cCtx := services.NewConsumerContext(ctx, sess, txPool.CreatedAt.Unix(), int64(txPool.CreatedAt.Nanosecond()), sc.Persist)
+			if err = writer.IndexSubnets(cCtx, txPool.Serialization); err != nil {
+				break
+			}
+		}
+		cancelFn()
+		if err != nil {
+			return err
+		}
+
+		if len(txPools) < backfillBatch {
+			return nil
+		}
+		after = txPools[len(txPools)-1].ID
+	}
+}
+
+// backfillCvmLogs sets the address and the topics of the logs indexed before
+// they were stored, once. The logs are read back a page at a time, and the
+// columns are set from their serialization. A backfill which fails, or is
+// stopped, runs again from the start with the next indexer.
+func backfillCvmLogs(sc *servicesctrl.Control, doneCh chan struct{}) error {
+	conns, err := sc.Database()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conns.Close()
+	}()
+
+	done, err := backfilled(sc, conns, utils.KeyValueCvmLogsBackfill)
+	if err != nil || done {
+		return err
+	}
+
+	sc.Log.Info("cvm logs backfill start")
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("backfill-cvm-logs"))
+
+	var after string
+	for {
+		select {
+		case <-doneCh:
+			return errBackfillStopped
+		default:
+		}
+
+		cvmLogs, err := backfillCvmLogsPage(sess, after)
+		if err != nil {
+			return err
+		}
+		if len(cvmLogs) < backfillBatch {
+			break
+		}
+		after = cvmLogs[len(cvmLogs)-1].ID
+	}
+	sc.Log.Info("cvm logs backfill complete")
+
+	return setBackfilled(sc, conns, utils.KeyValueCvmLogsBackfill)
+}
+
+func backfillCvmLogsPage(sess *dbr.Session, after string) ([]*db.CvmLogs, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
+	defer cancelFn()
+
+	var cvmLogs []*db.CvmLogs
+	_, err := sess.Select(
+		"id",
+		"address",
+		"serialization",
+	).From(db.TableCvmLogs).
+		Where("id > ?", after).
+		OrderAsc("id").
+		Limit(backfillBatch).
+		LoadContext(ctx, &cvmLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cvmLog := range cvmLogs {
+		// a log stored with its address has its topics too
+		if cvmLog.Address != "" || len(cvmLog.Serialization) == 0 {
+			continue
+		}
+		txLogs := &types.Log{}
+		if err := json.Unmarshal(cvmLog.Serialization, txLogs); err != nil {
+			return nil, err
+		}
+		address, topics := cvm.LogAddressTopics(txLogs)
+		_, err = sess.Update(db.TableCvmLogs).
+			Set("address", address).
+			Set("topic1", topics[1]).
+			Set("topic2", topics[2]).
+			Set("topic3", topics[3]).
+			Where("id=?", cvmLog.ID).
+			ExecContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cvmLogs, nil
+}
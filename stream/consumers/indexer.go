@@ -11,15 +11,18 @@ import (
 	"time"
 
 	avlancheGoUtils "github.com/axiacoin/axia-network-v2/utils"
+	"github.com/axiacoin/axia-network-v2-magellan/balance"
 	"github.com/axiacoin/axia-network-v2-magellan/cfg"
 	"github.com/axiacoin/axia-network-v2-magellan/db"
 	"github.com/axiacoin/axia-network-v2-magellan/services"
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/avm"
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/cvm"
 	"github.com/axiacoin/axia-network-v2-magellan/services/indexes/pvm"
+	"github.com/axiacoin/axia-network-v2-magellan/services/webhooks"
 	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
 	"github.com/axiacoin/axia-network-v2-magellan/stream"
 	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/gocraft/dbr/v2"
 )
 
 const (
@@ -30,6 +33,11 @@ const (
 	MaxTheads          = 10
 
 	IteratorTimeout = 3 * time.Minute
+
+	// MetricTxPoolQuarantinedKey is the gauge of the quarantined messages of
+	// each topic, refreshed every quarantinedRefreshInterval.
+	MetricTxPoolQuarantinedKey = "tx_pool_quarantined"
+	quarantinedRefreshInterval = 30 * time.Second
 )
 
 type ConsumerFactory func(uint32, string, string) (services.Consumer, error)
@@ -123,9 +131,105 @@ func Bootstrap(sc *servicesctrl.Control, networkID uint32, chains cfg.Chains, fa
 }
 
 type IndexerFactoryControl struct {
-	sc     *servicesctrl.Control
-	fsm    map[string]stream.ProcessorDB
-	doneCh chan struct{}
+	sc               *servicesctrl.Control
+	config           *cfg.Config
+	factoriesChainDB []stream.ProcessorFactoryChainDB
+	webhooks         *webhooks.Handler
+	balanceManager   *balance.Manager
+	doneCh           chan struct{}
+
+	// the processors of the chains registered at runtime are attached while
+	// the tx pool is processed
+	lock        sync.RWMutex
+	fsm         map[string]stream.ProcessorDB
+	topicNames  []string
+	chainTopics map[string][]string
+}
+
+// addProcessor attaches the processor to its topics, it expects the lock held.
+func (c *IndexerFactoryControl) addProcessor(f stream.ProcessorDB) error {
+	for _, topic := range f.Topic() {
+		if _, ok := c.fsm[topic]; ok {
+			return fmt.Errorf("duplicate topic %v", topic)
+		}
+	}
+	for _, topic := range f.Topic() {
+		c.fsm[topic] = f
+		c.topicNames = append(c.topicNames, topic)
+	}
+	return nil
+}
+
+// AddChain attaches the processors of a chain registered at runtime.
+func (c *IndexerFactoryControl) AddChain(chain cfg.Chain) error {
+	processors := make([]stream.ProcessorDB, 0, len(c.factoriesChainDB))
+	for _, factory := range c.factoriesChainDB {
+		f, err := factory(c.sc, *c.config, chain.VMType, chain.ID)
+		if err != nil {
+			return err
+		}
+		processors = append(processors, f)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.chainTopics[chain.ID]; ok {
+		return fmt.Errorf("duplicate chain %v", chain.ID)
+	}
+	var topics []string
+	for _, f := range processors {
+		if err := c.addProcessor(f); err != nil {
+			c.removeTopics(topics)
+			return err
+		}
+		topics = append(topics, f.Topic()...)
+	}
+	c.chainTopics[chain.ID] = topics
+	return nil
+}
+
+// RemoveChain detaches the processors of a chain registered at runtime, the
+// messages of its topics are no longer processed.
+func (c *IndexerFactoryControl) RemoveChain(chain cfg.Chain) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	topics, ok := c.chainTopics[chain.ID]
+	if !ok {
+		return nil
+	}
+	c.removeTopics(topics)
+	delete(c.chainTopics, chain.ID)
+	return nil
+}
+
+func (c *IndexerFactoryControl) removeTopics(topics []string) {
+	removed := make(map[string]struct{}, len(topics))
+	for _, topic := range topics {
+		delete(c.fsm, topic)
+		removed[topic] = struct{}{}
+	}
+	topicNames := make([]string, 0, len(c.topicNames))
+	for _, topic := range c.topicNames {
+		if _, ok := removed[topic]; !ok {
+			topicNames = append(topicNames, topic)
+		}
+	}
+	c.topicNames = topicNames
+}
+
+func (c *IndexerFactoryControl) processor(topic string) (stream.ProcessorDB, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	p, ok := c.fsm[topic]
+	return p, ok
+}
+
+func (c *IndexerFactoryControl) topics() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.topicNames...)
 }
 
 func (c *IndexerFactoryControl) updateTxPollStatus(conns *utils.Connections, txPoll *db.TxPool) error {
@@ -135,6 +239,55 @@ func (c *IndexerFactoryControl) updateTxPollStatus(conns *utils.Connections, txP
 	return c.sc.Persist.UpdateTxPoolStatus(ctx, sess, txPoll)
 }
 
+func (c *IndexerFactoryControl) updateTxPoolFailure(conns *utils.Connections, txPool *db.TxPool, err error) {
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("update-txpool-failure"))
+	c.countTxPoolFailure(sess, txPool, err)
+}
+
+// countTxPoolFailure counts the failure of the message, which is quarantined
+// after cfg.TxPoolMaxAttempts failures. A failure of the database or the
+// network is not of the message, it is not counted and the message is
+// processed again.
+func (c *IndexerFactoryControl) countTxPoolFailure(sess dbr.SessionRunner, txPool *db.TxPool, err error) {
+	if utils.ErrIsUnavailableError(err) {
+		c.sc.Log.Warn("process %s topic %s %v", txPool.ID, txPool.Topic, err)
+		return
+	}
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
+	defer cancelFn()
+
+	failure := &db.TxPool{ID: txPool.ID, LastError: err.Error()}
+	if err := c.sc.Persist.UpdateTxPoolFailure(ctx, sess, failure, cfg.TxPoolMaxAttempts); err != nil {
+		c.sc.Log.Warn("update tx pool failure %s %v", txPool.ID, err)
+		return
+	}
+	if txPool.Attempts+1 >= cfg.TxPoolMaxAttempts {
+		c.sc.Log.Warn("quarantined %s topic %s after %d attempts: %v", txPool.ID, txPool.Topic, txPool.Attempts+1, err)
+	}
+}
+
+// updateQuarantined refreshes the gauge of the quarantined messages of each
+// topic.
+func (c *IndexerFactoryControl) updateQuarantined(conns *utils.Connections) {
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("quarantined-counts"))
+	ctx, cancelFn := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelFn()
+
+	counts, err := c.sc.Persist.QueryTxPoolQuarantinedCounts(ctx, sess)
+	if err != nil {
+		c.sc.Log.Warn("quarantined counts %v", err)
+		return
+	}
+	quarantined := make(map[string]uint64, len(counts))
+	for _, count := range counts {
+		quarantined[count.Topic] = count.Count
+	}
+	for _, topic := range c.topics() {
+		_ = utils.Prometheus.GaugeVecSet(MetricTxPoolQuarantinedKey, float64(quarantined[topic]), topic)
+	}
+}
+
 func (c *IndexerFactoryControl) handleTxPool(_ int, conns *utils.Connections) {
 	defer func() {
 		_ = conns.Close()
@@ -149,14 +302,35 @@ func (c *IndexerFactoryControl) handleTxPool(_ int, conns *utils.Connections) {
 			if txd.Errs != nil && txd.Errs.GetValue() != nil {
 				continue
 			}
-			if p, ok := c.fsm[txd.TxPool.Topic]; ok {
+			if p, ok := c.processor(txd.TxPool.Topic); ok {
 				err := p.Process(conns, txd.TxPool)
 				if err != nil {
+					c.updateTxPoolFailure(conns, txd.TxPool, err)
 					if txd.Errs != nil {
 						txd.Errs.SetValue(err)
 					}
 					continue
 				}
+				// the snapshots are invalidated, and the deliveries queued,
+				// before the message is marked processed, a failure leaves it
+				// to be processed again
+				sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("balance-snapshot-invalidate"))
+				if err := c.balanceManager.InvalidateSnapshots(sess, txd.TxPool.CreatedAt); err != nil {
+					c.sc.Log.Warn("balance snapshots invalidate %s %v", txd.TxPool.ID, err)
+					if txd.Errs != nil {
+						txd.Errs.SetValue(err)
+					}
+					continue
+				}
+				if indexedTx := indexedTxOf(txd.TxPool); indexedTx != nil {
+					if err := c.webhooks.Enqueue(indexedTx); err != nil {
+						c.sc.Log.Warn("webhooks enqueue %s %v", indexedTx.TxID, err)
+						if txd.Errs != nil {
+							txd.Errs.SetValue(err)
+						}
+						continue
+					}
+				}
 				txd.TxPool.Processed = 1
 				err = c.updateTxPollStatus(conns, txd.TxPool)
 				if err != nil {
@@ -173,6 +347,15 @@ func (c *IndexerFactoryControl) handleTxPool(_ int, conns *utils.Connections) {
 	}
 }
 
+// indexedTxOf returns the tx of a decision, which is keyed by the tx id, or nil
+// for the other messages.
+func indexedTxOf(txPool *db.TxPool) *utils.IndexedTx {
+	if txPool.Topic != stream.GetTopicName(txPool.NetworkID, txPool.ChainID, stream.EventTypeDecisions) {
+		return nil
+	}
+	return &utils.IndexedTx{ChainID: txPool.ChainID, TxID: txPool.MsgKey}
+}
+
 func IndexerFactories(
 	sc *servicesctrl.Control,
 	config *cfg.Config,
@@ -182,25 +365,22 @@ func IndexerFactories(
 	runningControl utils.Running,
 ) error {
 	ctrl := &IndexerFactoryControl{
-		sc:     sc,
-		fsm:    make(map[string]stream.ProcessorDB),
-		doneCh: make(chan struct{}),
+		sc:               sc,
+		config:           config,
+		factoriesChainDB: factoriesChainDB,
+		fsm:              make(map[string]stream.ProcessorDB),
+		chainTopics:      make(map[string][]string),
+		doneCh:           make(chan struct{}),
 	}
 
-	var topicNames []string
-
 	for _, factory := range factoriesChainDB {
 		for _, chainConfig := range config.Chains {
 			f, err := factory(sc, *config, chainConfig.VMType, chainConfig.ID)
 			if err != nil {
 				return err
 			}
-			for _, topic := range f.Topic() {
-				if _, ok := ctrl.fsm[topic]; ok {
-					return fmt.Errorf("duplicate topic %v", topic)
-				}
-				ctrl.fsm[topic] = f
-				topicNames = append(topicNames, topic)
+			if err := ctrl.addProcessor(f); err != nil {
+				return err
 			}
 		}
 	}
@@ -209,12 +389,8 @@ func IndexerFactories(
 		if err != nil {
 			return err
 		}
-		for _, topic := range f.Topic() {
-			if _, ok := ctrl.fsm[topic]; ok {
-				return fmt.Errorf("duplicate topic %v", topic)
-			}
-			ctrl.fsm[topic] = f
-			topicNames = append(topicNames, topic)
+		if err := ctrl.addProcessor(f); err != nil {
+			return err
 		}
 	}
 
@@ -223,9 +399,28 @@ func IndexerFactories(
 		return err
 	}
 
+	webhooksHandler := &webhooks.Handler{}
+	if err := webhooksHandler.Start(sc); err != nil {
+		_ = conns.Close()
+		close(ctrl.doneCh)
+		return err
+	}
+	ctrl.webhooks = webhooksHandler
+
+	balanceManager := balance.NewManager(db.NewPersist(), sc)
+	if err := balanceManager.StartSnapshots(); err != nil {
+		webhooksHandler.Close()
+		_ = conns.Close()
+		close(ctrl.doneCh)
+		return err
+	}
+	ctrl.balanceManager = balanceManager
+
 	for ipos := 0; ipos < MaxTheads; ipos++ {
 		conns1, err := sc.Database()
 		if err != nil {
+			balanceManager.Close()
+			webhooksHandler.Close()
 			_ = conns.Close()
 			close(ctrl.doneCh)
 			return err
@@ -233,11 +428,50 @@ func IndexerFactories(
 		go ctrl.handleTxPool(ipos, conns1)
 	}
 
+	chainsWatcher, err := sc.WatchChains(ctrl)
+	if err != nil {
+		balanceManager.Close()
+		webhooksHandler.Close()
+		_ = conns.Close()
+		close(ctrl.doneCh)
+		return err
+	}
+
+	go func() {
+		err := backfillSubnets(sc, config, ctrl.doneCh)
+		if err != nil && err != errBackfillStopped {
+			sc.Log.Error("subnets backfill %v", err)
+		}
+	}()
+	go func() {
+		err := backfillCvmLogs(sc, ctrl.doneCh)
+		if err != nil && err != errBackfillStopped {
+			sc.Log.Error("cvm logs backfill %v", err)
+		}
+	}()
+
+	utils.Prometheus.GaugeVecInit(MetricTxPoolQuarantinedKey, "quarantined tx pool messages", "topic")
+	go func() {
+		ticker := time.NewTicker(quarantinedRefreshInterval)
+		defer ticker.Stop()
+		for {
+			ctrl.updateQuarantined(conns)
+			select {
+			case <-ticker.C:
+			case <-ctrl.doneCh:
+				return
+			}
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer func() {
 			wg.Done()
 			close(ctrl.doneCh)
+			chainsWatcher.Close()
+			webhooksHandler.Close()
+			balanceManager.Close()
 			_ = conns.Close()
 		}()
 		for !runningControl.IsStopped() {
@@ -251,6 +485,7 @@ func IndexerFactories(
 					time.Sleep(250 * time.Millisecond)
 					return
 				}
+				topicNames := ctrl.topics()
 				iterator, err := sess.Select(
 					"id",
 					"network_id",
@@ -260,6 +495,7 @@ func IndexerFactories(
 					"processed",
 					"topic",
 					"created_at",
+					"attempts",
 				).From(db.TableTxPool).
 					Where("processed=? and topic in ?", 0, topicNames).
 					OrderAsc("processed").OrderAsc("created_at").
@@ -0,0 +1,69 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2/utils/logging"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestCountTxPoolFailure(t *testing.T) {
+	persist := db.NewPersistMock()
+	ctrl := &IndexerFactoryControl{sc: &servicesctrl.Control{Log: logging.NoLog{}, Persist: persist}}
+
+	ctx := context.Background()
+	txPool := &db.TxPool{ID: "tx1", Topic: "topic1"}
+	if err := persist.InsertTxPool(ctx, nil, txPool); err != nil {
+		t.Fatal(err)
+	}
+	stored := func() *db.TxPool {
+		v, err := persist.QueryTxPool(ctx, nil, txPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	// the failures of the database are not the message's
+	for _, err := range []error{
+		fmt.Errorf("insert: %w", context.DeadlineExceeded),
+		&mysql.MySQLError{Number: 1040, Message: "Too many connections"},
+		mysql.ErrInvalidConn,
+	} {
+		ctrl.countTxPoolFailure(nil, txPool, err)
+	}
+	if v := stored(); v.Attempts != 0 || v.Processed != db.TxPoolUnprocessed {
+		t.Fatal("database failures counted", v.Attempts)
+	}
+
+	// the message is quarantined after its failures
+	parseErr := errors.New("couldn't unmarshal an interface")
+	for i := 0; i < cfg.TxPoolMaxAttempts; i++ {
+		if v := stored(); v.Processed != db.TxPoolUnprocessed {
+			t.Fatal("quarantined after", i, "failures")
+		}
+		ctrl.countTxPoolFailure(nil, txPool, parseErr)
+	}
+	v := stored()
+	if v.Processed != db.TxPoolQuarantined || v.Attempts != cfg.TxPoolMaxAttempts || v.LastError != parseErr.Error() {
+		t.Fatal("not quarantined", v.Processed, v.Attempts, v.LastError)
+	}
+
+	// a retried message counts its failures again
+	if err := persist.ResetTxPool(ctx, nil, txPool); err != nil {
+		t.Fatal(err)
+	}
+	ctrl.countTxPoolFailure(nil, txPool, parseErr)
+	if v := stored(); v.Processed != db.TxPoolUnprocessed || v.Attempts != 1 {
+		t.Fatal("retried message", v.Processed, v.Attempts)
+	}
+}
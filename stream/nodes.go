@@ -0,0 +1,105 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// nodeProbeInterval is how often a producer probes the nodes of its chain.
+	nodeProbeInterval = 30 * time.Second
+	nodeProbeTimeout  = 10 * time.Second
+
+	// nodeMaxLag is how many containers or blocks the node a producer reads
+	// from may be behind the highest healthy node before it fails over.
+	nodeMaxLag = 100
+)
+
+// errNodeFailover stops the processor of a producer which failed over to
+// another node, so it restarts reading from the node.
+var errNodeFailover = errors.New("failed over to another node")
+
+// nodeProbe returns the height of a node, which compares across the nodes, the
+// last accepted block, or the containers accepted after the last one read.
+type nodeProbe func(ctx context.Context, node int) (uint64, error)
+
+// nodeSet is the nodes a producer reads its chain from. The producer reads
+// from one node at a time, and fails over to the healthiest node when the node
+// is down or falls behind.
+type nodeSet struct {
+	endpoints []string
+	probe     nodeProbe
+	current   int
+	lastProbe time.Time
+}
+
+func newNodeSet(endpoints []string, probe nodeProbe) *nodeSet {
+	return &nodeSet{endpoints: endpoints, probe: probe}
+}
+
+func (n *nodeSet) Current() int {
+	return n.current
+}
+
+func (n *nodeSet) Endpoint() string {
+	return n.endpoints[n.current]
+}
+
+// Pick probes the nodes when a probe is due, or at once when the current node
+// failed, and returns the node to read from and whether it is another node.
+func (n *nodeSet) Pick(failed bool) (int, bool) {
+	if len(n.endpoints) < 2 || (!failed && time.Since(n.lastProbe) < nodeProbeInterval) {
+		return n.current, false
+	}
+	n.lastProbe = time.Now()
+
+	heights := make([]uint64, len(n.endpoints))
+	healthy := make([]bool, len(n.endpoints))
+	wg := &sync.WaitGroup{}
+	for i := range n.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancelCtx := context.WithTimeout(context.Background(), nodeProbeTimeout)
+			defer cancelCtx()
+			height, err := n.probe(ctx, i)
+			heights[i] = height
+			healthy[i] = err == nil
+		}(i)
+	}
+	wg.Wait()
+
+	if failed {
+		healthy[n.current] = false
+	}
+	next := pickNode(n.current, heights, healthy)
+	return next, next != n.current
+}
+
+// Set makes node the node to read from.
+func (n *nodeSet) Set(node int) {
+	n.current = node
+}
+
+// pickNode returns the highest healthy node, or the current node while it is
+// healthy and at most nodeMaxLag behind it.
+func pickNode(current int, heights []uint64, healthy []bool) int {
+	best := -1
+	for i := range heights {
+		if healthy[i] && (best == -1 || heights[i] > heights[best]) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return current
+	}
+	if healthy[current] && heights[current]+nodeMaxLag >= heights[best] {
+		return current
+	}
+	return best
+}
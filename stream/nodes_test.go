@@ -0,0 +1,235 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2/ids"
+	"github.com/axiacoin/axia-network-v2/indexer"
+	"github.com/axiacoin/axia-network-v2/utils/json"
+	"github.com/axiacoin/axia-network-v2/utils/rpc"
+)
+
+var (
+	errTestNodeDown     = errors.New("node down")
+	errTestNoneAccepted = errors.New("no containers have been accepted")
+)
+
+// testNodeIndexer is the index of a node, the containers it accepted in the
+// order it indexed them.
+type testNodeIndexer struct {
+	containers []indexer.Container
+	err        error
+
+	// rangeDelay delays the range from the start index
+	rangeDelay func(start uint64) time.Duration
+}
+
+func newTestNodeIndexer(containerIDs ...ids.ID) *testNodeIndexer {
+	n := &testNodeIndexer{}
+	for _, containerID := range containerIDs {
+		n.containers = append(n.containers, indexer.Container{ID: containerID, Bytes: containerID[:]})
+	}
+	return n
+}
+
+func (n *testNodeIndexer) GetContainerRange(_ context.Context, args *indexer.GetContainerRangeArgs, _ ...rpc.Option) ([]indexer.Container, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	start := uint64(args.StartIndex)
+	if n.rangeDelay != nil {
+		time.Sleep(n.rangeDelay(start))
+	}
+	// the errors of a node past its last accepted index
+	if len(n.containers) == 0 {
+		return nil, errTestNoneAccepted
+	}
+	if start >= uint64(len(n.containers)) {
+		return nil, fmt.Errorf("start index (%d) > last accepted index (%d)", start, len(n.containers)-1)
+	}
+	end := start + uint64(args.NumToFetch)
+	if end > uint64(len(n.containers)) {
+		end = uint64(len(n.containers))
+	}
+	return n.containers[start:end], nil
+}
+
+func (n *testNodeIndexer) GetContainerByIndex(_ context.Context, args *indexer.GetContainerByIndexArgs, _ ...rpc.Option) (indexer.Container, error) {
+	if n.err != nil {
+		return indexer.Container{}, n.err
+	}
+	if uint64(args.Index) >= uint64(len(n.containers)) {
+		return indexer.Container{}, errors.New("no container at index")
+	}
+	return n.containers[args.Index], nil
+}
+
+func (n *testNodeIndexer) GetLastAccepted(_ context.Context, _ *indexer.GetLastAcceptedArgs, _ ...rpc.Option) (indexer.Container, error) {
+	if n.err != nil {
+		return indexer.Container{}, n.err
+	}
+	if len(n.containers) == 0 {
+		return indexer.Container{}, errTestNoneAccepted
+	}
+	return n.containers[len(n.containers)-1], nil
+}
+
+func (n *testNodeIndexer) GetIndex(_ context.Context, args *indexer.GetIndexArgs, _ ...rpc.Option) (indexer.GetIndexResponse, error) {
+	if n.err != nil {
+		return indexer.GetIndexResponse{}, n.err
+	}
+	for i, container := range n.containers {
+		if container.ID == args.ContainerID {
+			return indexer.GetIndexResponse{Index: json.Uint64(i)}, nil
+		}
+	}
+	return indexer.GetIndexResponse{}, errors.New("container not found")
+}
+
+func (n *testNodeIndexer) IsAccepted(ctx context.Context, args *indexer.GetIndexArgs, _ ...rpc.Option) (bool, error) {
+	if n.err != nil {
+		return false, n.err
+	}
+	_, err := n.GetIndex(ctx, args)
+	return err == nil, nil
+}
+
+func (n *testNodeIndexer) GetContainerByID(ctx context.Context, args *indexer.GetIndexArgs, _ ...rpc.Option) (indexer.Container, error) {
+	index, err := n.GetIndex(ctx, args)
+	if err != nil {
+		return indexer.Container{}, err
+	}
+	return n.containers[index.Index], nil
+}
+
+func testContainerIDs(n int) []ids.ID {
+	containerIDs := make([]ids.ID, n)
+	for i := range containerIDs {
+		containerIDs[i] = ids.GenerateTestID()
+	}
+	return containerIDs
+}
+
+func TestPickNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		heights  []uint64
+		healthy  []bool
+		expected int
+	}{
+		{"highest", 0, []uint64{10, 10 + nodeMaxLag}, []bool{true, true}, 0},
+		{"behind", 0, []uint64{10, 11 + nodeMaxLag}, []bool{true, true}, 1},
+		{"current failed", 0, []uint64{10, 5}, []bool{false, true}, 1},
+		{"highest failed", 0, []uint64{10, 11 + nodeMaxLag, 12}, []bool{true, false, true}, 0},
+		{"all failed", 1, []uint64{10, 11}, []bool{false, false}, 1},
+		{"highest of the others", 0, []uint64{0, 5, 9, 7}, []bool{false, true, true, true}, 2},
+	}
+	for _, test := range tests {
+		if node := pickNode(test.current, test.heights, test.healthy); node != test.expected {
+			t.Fatal(test.name, "picked", node)
+		}
+	}
+}
+
+func TestNodeSetPick(t *testing.T) {
+	heights := []uint64{10, 10 + nodeMaxLag + 1}
+	var down []bool
+	var probes int32
+	nodes := newNodeSet([]string{"node0", "node1"}, func(_ context.Context, node int) (uint64, error) {
+		atomic.AddInt32(&probes, 1)
+		if down != nil && down[node] {
+			return 0, errTestNodeDown
+		}
+		return heights[node], nil
+	})
+
+	// the current node is behind
+	node, ok := nodes.Pick(false)
+	if !ok || node != 1 {
+		t.Fatal("no failover from the node behind", node, ok)
+	}
+	nodes.Set(node)
+
+	// the nodes are probed at the interval
+	atomic.StoreInt32(&probes, 0)
+	if _, ok := nodes.Pick(false); ok || atomic.LoadInt32(&probes) != 0 {
+		t.Fatal("probed before the interval", atomic.LoadInt32(&probes))
+	}
+
+	// and at once when the node fails
+	heights = []uint64{10, 10}
+	node, ok = nodes.Pick(true)
+	if !ok || node != 0 || atomic.LoadInt32(&probes) != 2 {
+		t.Fatal("no failover from the failed node", node, ok, atomic.LoadInt32(&probes))
+	}
+	nodes.Set(node)
+
+	// the node stays when the other nodes are down
+	down = []bool{false, true}
+	if node, ok := nodes.Pick(true); ok || node != 0 {
+		t.Fatal("failover to a node down", node)
+	}
+
+	// a single node is never probed
+	nodes = newNodeSet([]string{"node0"}, func(context.Context, int) (uint64, error) {
+		t.Fatal("single node probed")
+		return 0, nil
+	})
+	if _, ok := nodes.Pick(true); ok {
+		t.Fatal("failover from a single node")
+	}
+}
+
+func TestProbeNode(t *testing.T) {
+	containerIDs := testContainerIDs(300)
+
+	// node1 indexed from genesis, node0 from container 100, and node2 is
+	// missing the last containers
+	node0 := newTestNodeIndexer(containerIDs[100:]...)
+	node1 := newTestNodeIndexer(containerIDs...)
+	node2 := newTestNodeIndexer(containerIDs[:250]...)
+	p := &ProducerChain{nodeIndexers: []indexer.Client{node0, node1, node2}}
+
+	heights := func() []uint64 {
+		heights := make([]uint64, len(p.nodeIndexers))
+		for i := range p.nodeIndexers {
+			height, err := p.probeNode(context.Background(), i)
+			if err != nil {
+				t.Fatal("probe", i, err)
+			}
+			heights[i] = height
+		}
+		return heights
+	}
+
+	// the heights compare from the last container read, not the indexes of
+	// the last containers of the nodes
+	p.probeContainerID = containerIDs[200]
+	h := heights()
+	if h[0] != 99 || h[1] != 99 || h[2] != 49 {
+		t.Fatal("heights", h)
+	}
+	if node := pickNode(0, h, []bool{true, true, true}); node != 0 {
+		t.Fatal("failover from the highest node to", node)
+	}
+
+	// a node which does not know the container fails the probe
+	p.probeContainerID = containerIDs[280]
+	if _, err := p.probeNode(context.Background(), 2); err == nil {
+		t.Fatal("node without the container probed")
+	}
+
+	node0.err = errTestNodeDown
+	if _, err := p.probeNode(context.Background(), 0); !errors.Is(err, errTestNodeDown) {
+		t.Fatal("node down probed", err)
+	}
+}
@@ -6,6 +6,7 @@ package stream
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -17,11 +18,14 @@ import (
 	axiaUtils "github.com/axiacoin/axia-network-v2/utils"
 	"github.com/axiacoin/axia-network-v2/utils/hashing"
 	"github.com/axiacoin/axia-network-v2/utils/wrappers"
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
 	"github.com/axiacoin/axia-network-v2-magellan/cfg"
 	"github.com/axiacoin/axia-network-v2-magellan/db"
 	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
 	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
 	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gocraft/dbr/v2"
 )
 
 const (
@@ -33,10 +37,19 @@ const (
 
 	maxWorkerQueue = 4000
 	maxWorkers     = 8
+
+	// the blocks are verified against their parents after each batch of
+	// maxBlockBatch blocks, and a reorg is rolled back at most maxReorgDepth
+	// blocks, a deeper fork needs to be resolved by hand
+	maxBlockBatch = maxWorkerQueue
+	maxReorgDepth = 128
 )
 
+var ErrReorgTooDeep = errors.New("reorg deeper than the maximum depth")
+
 type producerAXChainContainer struct {
-	sc *servicesctrl.Control
+	sc      *servicesctrl.Control
+	chainID string
 
 	conns      *utils.Connections
 	block      *big.Int
@@ -50,11 +63,14 @@ type producerAXChainContainer struct {
 	runningControl utils.Running
 
 	catchupErrs axiaUtils.AtomicInterface
+
+	nodeFailed bool
 }
 
 func newContainerC(
 	sc *servicesctrl.Control,
-	conf cfg.Config,
+	evmChain cfg.EVMChain,
+	rpc string,
 ) (*producerAXChainContainer, error) {
 	conns, err := sc.Database()
 	if err != nil {
@@ -67,6 +83,7 @@ func newContainerC(
 		runningControl: utils.NewRunning(),
 		conns:          conns,
 		sc:             sc,
+		chainID:        evmChain.ID,
 	}
 
 	err = pc.getBlock()
@@ -75,7 +92,7 @@ func newContainerC(
 		return nil, err
 	}
 
-	cl, err := modelsc.NewClient(conf.Axia + "/ext/bc/C/rpc")
+	cl, err := modelsc.NewClient(rpc)
 	if err != nil {
 		_ = conns.Close()
 		return nil, err
@@ -109,10 +126,11 @@ func (p *producerAXChainContainer) getBlock() error {
 	}
 	maxBlock := MaxBlock{}
 	_, err = sess.Select(
-		"cast(case when max(block) is null then -1 else max(block) end as char) as block",
-		"cast(count(*) as char) as block_count",
+		db.CastStringAs(sess, "case when max(block) is null then -1 else max(block) end", "block"),
+		db.CastStringAs(sess, "count(*)", "block_count"),
 	).
 		From(db.TableCvmBlocks).
+		Where("chain_id=?", p.chainID).
 		LoadContext(ctx, &maxBlock)
 	if err != nil {
 		return err
@@ -128,7 +146,7 @@ func (p *producerAXChainContainer) getBlock() error {
 	}
 	p.block = mblock
 	p.blockCount = cblockCount
-	p.sc.Log.Info("starting processing block %s cnt %s", p.block.String(), p.blockCount.String())
+	p.sc.Log.Info("starting processing chain %s block %s cnt %s", p.chainID, p.block.String(), p.blockCount.String())
 	return nil
 }
 
@@ -161,7 +179,7 @@ func (p *producerAXChainContainer) catchupBlock(conns *utils.Connections, catchu
 		_, err = sess.Select(
 			"block",
 		).From(db.TableCvmBlocks).
-			Where("block >= "+startBlock.String()+" and block < "+endBlock.String()).
+			Where("chain_id=? and block >= "+startBlock.String()+" and block < "+endBlock.String(), p.chainID).
 			LoadContext(ctx, &cvmBlocks)
 		if err != nil {
 			p.catchupErrs.SetValue(err)
@@ -171,20 +189,38 @@ func (p *producerAXChainContainer) catchupBlock(conns *utils.Connections, catchu
 		for _, bl := range cvmBlocks {
 			blockMap[bl.Block] = struct{}{}
 		}
+		windowStart := startBlock
+		windowWg := &sync.WaitGroup{}
+		refills := 0
 		for startBlock.Cmp(endBlock) < 0 {
 			if p.runningControl.IsStopped() {
 				break
 			}
 
 			if p.catchupErrs.GetValue() != nil {
-				return
+				break
 			}
 			if _, ok := blockMap[startBlock.String()]; !ok {
 				p.sc.Log.Info("refill %v", startBlock.String())
-				p.msgChan <- &blockWorkContainer{errs: &p.catchupErrs, blockNumber: startBlock}
+				windowWg.Add(1)
+				p.msgChan <- &blockWorkContainer{errs: &p.catchupErrs, blockNumber: startBlock, wg: windowWg}
+				refills++
 			}
 			startBlock = big.NewInt(0).Add(startBlock, big.NewInt(1))
 		}
+
+		// the refilled blocks are verified against their neighbours once they are all stored
+		windowWg.Wait()
+		if p.catchupErrs.GetValue() != nil || p.runningControl.IsStopped() {
+			return
+		}
+		if refills > 0 {
+			err = p.verifyBlocks(sess, windowStart, endBlock)
+			if err != nil {
+				p.catchupErrs.SetValue(err)
+				return
+			}
+		}
 	}
 
 	p.sc.Log.Info("catchup complete")
@@ -193,6 +229,7 @@ func (p *producerAXChainContainer) catchupBlock(conns *utils.Connections, catchu
 func (p *producerAXChainContainer) ProcessNextMessage() error {
 	lblocknext, err := p.client.Latest(rpcTimeout)
 	if err != nil {
+		p.nodeFailed = true
 		time.Sleep(readRPCTimeout)
 		return err
 	}
@@ -202,29 +239,84 @@ func (p *producerAXChainContainer) ProcessNextMessage() error {
 	}
 
 	errs := &axiaUtils.AtomicInterface{}
-	for lblocknext.Cmp(p.block) > 0 {
+	wg := &sync.WaitGroup{}
+	batchStart := big.NewInt(0).Add(p.block, big.NewInt(1))
+	for batch := 0; lblocknext.Cmp(p.block) > 0 && batch < maxBlockBatch; batch++ {
 		if p.runningControl.IsStopped() {
 			break
 		}
 		if errs.GetValue() != nil {
-			return errs.GetValue().(error)
+			break
 		}
 		if p.catchupErrs.GetValue() != nil {
 			return p.catchupErrs.GetValue().(error)
 		}
 		ncurrent := big.NewInt(0).Add(p.block, big.NewInt(1))
-		p.msgChan <- &blockWorkContainer{errs: errs, blockNumber: ncurrent}
+		wg.Add(1)
+		p.msgChan <- &blockWorkContainer{errs: errs, blockNumber: ncurrent, wg: wg}
 		p.block = big.NewInt(0).Add(p.block, big.NewInt(1))
 	}
 
-	for len(p.msgChan) > 0 {
-		time.Sleep(1 * time.Millisecond)
-	}
+	// the blocks are processed concurrently, wait for the whole batch before
+	// verifying each block against its parent in order.
+	wg.Wait()
 
 	if errs.GetValue() != nil {
 		return errs.GetValue().(error)
 	}
 
+	sess := p.conns.DB().NewSessionForEventReceiver(p.conns.Stream().NewJob("verify-blocks"))
+	return p.verifyBlocks(sess, batchStart, p.block)
+}
+
+// verifyBlocks compares the parent hash of each stored block from from to to
+// with the hash stored for the block before it, starting with the parent of from.
+// A block which has not been stored, or was stored before hashes were recorded,
+// is not checked.
+func (p *producerAXChainContainer) verifyBlocks(sess *dbr.Session, from *big.Int, to *big.Int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), dbReadTimeout)
+	defer cancelCtx()
+
+	parent := big.NewInt(0).Sub(from, big.NewInt(1))
+	var cvmBlocks []*db.CvmBlocks
+	_, err := sess.Select(
+		db.CastString(sess, "block"),
+		"hash",
+		"parent_hash",
+	).From(db.TableCvmBlocks).
+		Where("chain_id=? and block >= "+parent.String()+" and block <= "+to.String(), p.chainID).
+		OrderAsc("block").
+		LoadContext(ctx, &cvmBlocks)
+	if err != nil {
+		return err
+	}
+
+	if block := brokenLink(cvmBlocks); block != nil {
+		return &reorgError{block: block}
+	}
+	return nil
+}
+
+// brokenLink returns the first block, of the blocks ordered by number, whose
+// hash is not the parent hash of the next block, or nil if the blocks are linked.
+func brokenLink(cvmBlocks []*db.CvmBlocks) *big.Int {
+	for i := 1; i < len(cvmBlocks); i++ {
+		parent, child := cvmBlocks[i-1], cvmBlocks[i]
+		if parent.Hash == "" || child.ParentHash == "" {
+			continue
+		}
+		parentBlock, ok := big.NewInt(0).SetString(parent.Block, 10)
+		if !ok {
+			continue
+		}
+		childBlock, ok := big.NewInt(0).SetString(child.Block, 10)
+		if !ok || big.NewInt(0).Sub(childBlock, parentBlock).Cmp(big.NewInt(1)) != 0 {
+			continue
+		}
+		if child.ParentHash != parent.Hash {
+			return parentBlock
+		}
+	}
 	return nil
 }
 
@@ -236,8 +328,14 @@ type ProducerAXChain struct {
 	metricProcessedCountKey string
 	metricSuccessCountKey   string
 	metricFailureCountKey   string
+	metricReorgCountKey     string
+	metricFailoverCountKey  string
 
-	conf cfg.Config
+	conf     cfg.Config
+	evmChain cfg.EVMChain
+
+	// the RPC endpoints of the chain, nodes picks the one to read from
+	nodes *nodeSet
 
 	runningControl utils.Running
 
@@ -246,26 +344,52 @@ type ProducerAXChain struct {
 	topicLogs string
 }
 
+// NewProducerAXChain creates the producer of the AX chain.
 func NewProducerAXChain(sc *servicesctrl.Control, conf cfg.Config) utils.ListenCloser {
-	topicName := fmt.Sprintf("%d-%s-axchain", conf.NetworkID, conf.AXchainID)
-	topicTrcName := fmt.Sprintf("%d-%s-axchain-trc", conf.NetworkID, conf.AXchainID)
-	topicLogsName := fmt.Sprintf("%d-%s-axchain-logs", conf.NetworkID, conf.AXchainID)
+	return NewProducerEVMChain(sc, conf, conf.AllEVMChains()[conf.AXchainID])
+}
+
+// NewProducerEVMChains creates a producer for each EVM chain to index.
+func NewProducerEVMChains(sc *servicesctrl.Control, conf cfg.Config) []utils.ListenCloser {
+	var producers []utils.ListenCloser
+	for _, evmChain := range conf.AllEVMChains() {
+		producers = append(producers, NewProducerEVMChain(sc, conf, evmChain))
+	}
+	return producers
+}
+
+// NewProducerEVMChain creates the producer of an EVM chain, which reads the
+// blocks from the RPC endpoint of the chain.
+func NewProducerEVMChain(sc *servicesctrl.Control, conf cfg.Config, evmChain cfg.EVMChain) utils.ListenCloser {
+	topicName := fmt.Sprintf("%d-%s-axchain", conf.NetworkID, evmChain.ID)
+	topicTrcName := fmt.Sprintf("%d-%s-axchain-trc", conf.NetworkID, evmChain.ID)
+	topicLogsName := fmt.Sprintf("%d-%s-axchain-logs", conf.NetworkID, evmChain.ID)
 
 	p := &ProducerAXChain{
 		topic:                   topicName,
 		topicTrc:                topicTrcName,
 		topicLogs:               topicLogsName,
 		conf:                    conf,
+		evmChain:                evmChain,
 		sc:                      sc,
-		metricProcessedCountKey: fmt.Sprintf("produce_records_processed_%s_axchain", conf.AXchainID),
-		metricSuccessCountKey:   fmt.Sprintf("produce_records_success_%s_axchain", conf.AXchainID),
-		metricFailureCountKey:   fmt.Sprintf("produce_records_failure_%s_axchain", conf.AXchainID),
-		id:                      fmt.Sprintf("producer %d %s axchain", conf.NetworkID, conf.AXchainID),
+		metricProcessedCountKey: fmt.Sprintf("produce_records_processed_%s_axchain", evmChain.ID),
+		metricSuccessCountKey:   fmt.Sprintf("produce_records_success_%s_axchain", evmChain.ID),
+		metricFailureCountKey:   fmt.Sprintf("produce_records_failure_%s_axchain", evmChain.ID),
+		metricReorgCountKey:     fmt.Sprintf("produce_reorgs_%s_axchain", evmChain.ID),
+		metricFailoverCountKey:  fmt.Sprintf("produce_node_failovers_%s_axchain", evmChain.ID),
+		id:                      fmt.Sprintf("producer %d %s axchain", conf.NetworkID, evmChain.ID),
 		runningControl:          utils.NewRunning(),
 	}
+	rpcs := evmChain.RPCs
+	if len(rpcs) == 0 {
+		rpcs = []string{evmChain.RPC}
+	}
+	p.nodes = newNodeSet(rpcs, p.probeNode)
 	utils.Prometheus.CounterInit(p.metricProcessedCountKey, "records processed")
 	utils.Prometheus.CounterInit(p.metricSuccessCountKey, "records success")
 	utils.Prometheus.CounterInit(p.metricFailureCountKey, "records failure")
+	utils.Prometheus.CounterInit(p.metricReorgCountKey, "reorgs detected")
+	utils.Prometheus.CounterInit(p.metricFailoverCountKey, "node failovers")
 	sc.InitProduceMetrics()
 
 	return p
@@ -280,19 +404,363 @@ func (p *ProducerAXChain) ID() string {
 	return p.id
 }
 
-func (p *ProducerAXChain) updateBlock(conns *utils.Connections, blockNumber *big.Int, updateTime time.Time) error {
+func (p *ProducerAXChain) updateBlock(conns *utils.Connections, blockNumber *big.Int, hash string, parentHash string, updateTime time.Time) error {
 	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("update-block"))
 
 	ctx, cancelCtx := context.WithTimeout(context.Background(), dbWriteTimeout)
 	defer cancelCtx()
 
 	cvmBlocks := &db.CvmBlocks{
-		Block:     blockNumber.String(),
-		CreatedAt: updateTime,
+		ChainID:    p.evmChain.ID,
+		Block:      blockNumber.String(),
+		Hash:       hash,
+		ParentHash: parentHash,
+		CreatedAt:  updateTime,
 	}
 	return p.sc.Persist.InsertCvmBlocks(ctx, sess, cvmBlocks)
 }
 
+// findAncestor walks back from block, at most maxDepth blocks and not below
+// the first block, to the common ancestor: the highest stored block whose hash
+// is the hash of the block on the node. A block stored before hashes were
+// recorded is taken as the ancestor.
+func findAncestor(
+	block *big.Int,
+	maxDepth int64,
+	storedHash func(*big.Int) (string, bool, error),
+	nodeHash func(*big.Int) (string, error),
+) (*big.Int, error) {
+	lowest := big.NewInt(0).Sub(block, big.NewInt(maxDepth))
+	if lowest.Sign() < 0 {
+		lowest.SetInt64(0)
+	}
+	for ancestor := big.NewInt(0).Set(block); ancestor.Cmp(lowest) >= 0; ancestor = big.NewInt(0).Sub(ancestor, big.NewInt(1)) {
+		hash, ok, err := storedHash(ancestor)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if hash == "" {
+			return ancestor, nil
+		}
+		nhash, err := nodeHash(ancestor)
+		if err != nil {
+			return nil, err
+		}
+		if nhash == hash {
+			return ancestor, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: block %s depth %d", ErrReorgTooDeep, block.String(), maxDepth)
+}
+
+// rollback walks back from block until the stored hash matches the node again,
+// and removes everything indexed above that common ancestor.
+// The producer then continues from the ancestor, which re-indexes the canonical branch.
+func (p *ProducerAXChain) rollback(pc *producerAXChainContainer, block *big.Int) error {
+	sess := pc.conns.DB().NewSessionForEventReceiver(pc.conns.Stream().NewJob("rollback"))
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), dbWriteTimeout)
+	defer cancelCtx()
+
+	ancestor, err := findAncestor(block, maxReorgDepth,
+		func(block *big.Int) (string, bool, error) {
+			cvmBlock, err := p.sc.Persist.QueryCvmBlocks(ctx, sess, &db.CvmBlocks{ChainID: p.evmChain.ID, Block: block.String()})
+			if err == dbr.ErrNotFound {
+				return "", false, nil
+			}
+			if err != nil {
+				return "", false, err
+			}
+			return cvmBlock.Hash, true, nil
+		},
+		func(block *big.Int) (string, error) {
+			header, err := pc.client.ReadHeader(block, rpcTimeout)
+			if err != nil {
+				return "", err
+			}
+			return header.Hash().String(), nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	var cvmBlocks []*db.CvmBlocks
+	_, err = sess.Select(
+		db.CastString(sess, "block"),
+	).From(db.TableCvmBlocks).
+		Where("chain_id=? and block > "+ancestor.String(), p.evmChain.ID).
+		LoadContext(ctx, &cvmBlocks)
+	if err != nil {
+		return err
+	}
+
+	// the atomic transactions of the orphaned blocks, their outputs and addresses are indexed by transaction.
+	var atomicTxIDs []string
+	_, err = sess.Select("transaction_id").
+		From(db.TableCvmTransactions).
+		Where("chain_id=? and block > "+ancestor.String(), p.evmChain.ID).
+		LoadContext(ctx, &atomicTxIDs)
+	if err != nil {
+		return err
+	}
+
+	dbTx, err := sess.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.RollbackUnlessCommitted()
+
+	// the block messages are keyed by block number, drop them so the canonical block is picked up again.
+	var txPools []*db.TxPool
+	for _, cvmBlock := range cvmBlocks {
+		id, err := ids.ToID(hashing.ComputeHash256([]byte(cvmBlock.Block)))
+		if err != nil {
+			return err
+		}
+		txPool := &db.TxPool{MsgKey: id.String(), Topic: p.topic}
+		err = txPool.ComputeID()
+		if err != nil {
+			return err
+		}
+		txPools = append(txPools, txPool)
+	}
+
+	// the trace messages are keyed by transaction, drop them so the traces of a
+	// transaction included again in a canonical block are indexed again, and
+	// the log messages so those still to be indexed are not.
+	orphans, err := p.orphanedMessages(ctx, dbTx, ancestor)
+	if err != nil {
+		return err
+	}
+	txPools = append(txPools, orphans...)
+
+	txPoolIDs, err := p.deleteTxPools(ctx, dbTx, txPools)
+	if err != nil {
+		return err
+	}
+
+	err = p.sc.Persist.DeleteCvmTransactionsTxdataTraceAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmTransactionsTxdataAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmAddressesOfTransactions(ctx, dbTx, p.evmChain.ID, atomicTxIDs)
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteAtomicTransactions(ctx, dbTx, atomicTxIDs)
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmTransactionsAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmLogsAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmTokenTransfersAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmContractDeploymentsAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmInternalTransfersAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = p.sc.Persist.DeleteCvmBlocksAfter(ctx, dbTx, p.evmChain.ID, ancestor.String())
+	if err != nil {
+		return err
+	}
+	err = dbTx.Commit()
+	if err != nil {
+		return err
+	}
+
+	p.unindexTxPools(txPoolIDs)
+
+	depth := big.NewInt(0).Sub(block, ancestor)
+	p.sc.Log.Warn("reorg detected on chain %s at block %s depth %s, rolled back to block %s", p.evmChain.ID, block.String(), depth.String(), ancestor.String())
+	_ = utils.Prometheus.CounterInc(p.metricReorgCountKey)
+
+	pc.block = ancestor
+	return nil
+}
+
+// orphanedMessages returns the trace and log messages of the blocks above the
+// ancestor, those indexed and those still to be.
+func (p *ProducerAXChain) orphanedMessages(ctx context.Context, sess dbr.SessionRunner, ancestor *big.Int) ([]*db.TxPool, error) {
+	var txHashes []string
+	_, err := sess.Select("hash").
+		From(db.TableCvmTransactionsTxdata).
+		Where("chain_id=? and block > "+ancestor.String(), p.evmChain.ID).
+		LoadContext(ctx, &txHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	var traces []*db.CvmTransactionsTxdataTrace
+	_, err = sess.Select("hash", "idx").
+		From(db.TableCvmTransactionsTxdataTrace).
+		Where("chain_id=? and hash in (select hash from "+db.TableCvmTransactionsTxdata+" where chain_id=? and block > "+ancestor.String()+")", p.evmChain.ID, p.evmChain.ID).
+		LoadContext(ctx, &traces)
+	if err != nil {
+		return nil, err
+	}
+	// the first trace of a contract creation which made no call is not stored
+	for _, txHash := range txHashes {
+		traces = append(traces, &db.CvmTransactionsTxdataTrace{Hash: txHash})
+	}
+
+	var logs []*db.CvmLogs
+	_, err = sess.Select("block_hash", "tx_hash", "log_index").
+		From(db.TableCvmLogs).
+		Where("chain_id=? and block > "+ancestor.String(), p.evmChain.ID).
+		LoadContext(ctx, &logs)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*db.TxPool
+	_, err = sess.Select("id", "msg_key", "topic", "serialization").
+		From(db.TableTxPool).
+		Where("topic in ? and processed in ?",
+			[]string{p.topicTrc, p.topicLogs},
+			[]int{db.TxPoolUnprocessed, db.TxPoolQuarantined, db.TxPoolSkipped}).
+		LoadContext(ctx, &pending)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.orphanedTxPools(ancestor, traces, logs, pending)
+}
+
+// orphanedTxPools returns the messages of the traces and logs indexed from the
+// blocks above the ancestor, and the pending messages of those blocks.
+func (p *ProducerAXChain) orphanedTxPools(ancestor *big.Int, traces []*db.CvmTransactionsTxdataTrace, logs []*db.CvmLogs, pending []*db.TxPool) ([]*db.TxPool, error) {
+	var txPools []*db.TxPool
+	seen := make(map[string]struct{})
+	add := func(txPool *db.TxPool) error {
+		if txPool.ID == "" {
+			if err := txPool.ComputeID(); err != nil {
+				return err
+			}
+		}
+		if _, ok := seen[txPool.ID]; ok {
+			return nil
+		}
+		seen[txPool.ID] = struct{}{}
+		txPools = append(txPools, &db.TxPool{ID: txPool.ID, MsgKey: txPool.MsgKey, Topic: txPool.Topic})
+		return nil
+	}
+
+	for _, trace := range traces {
+		msgKey, err := axChainTraceMsgKey(trace.Hash, trace.Idx)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(&db.TxPool{MsgKey: msgKey, Topic: p.topicTrc}); err != nil {
+			return nil, err
+		}
+	}
+	for _, log := range logs {
+		msgKey, err := axChainLogMsgKey(common.HexToHash(log.BlockHash), common.HexToHash(log.TxHash), uint(log.LogIndex))
+		if err != nil {
+			return nil, err
+		}
+		if err := add(&db.TxPool{MsgKey: msgKey, Topic: p.topicLogs}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, txPool := range pending {
+		var block *big.Int
+		switch txPool.Topic {
+		case p.topicTrc:
+			trace := &modelsc.TransactionTrace{}
+			if err := json.Unmarshal(txPool.Serialization, trace); err != nil {
+				return nil, err
+			}
+			// the traces produced before their block was added have no block
+			if trace.Block == "" {
+				continue
+			}
+			var ok bool
+			block, ok = new(big.Int).SetString(trace.Block, 10)
+			if !ok {
+				return nil, fmt.Errorf("trace %s block %s", txPool.ID, trace.Block)
+			}
+		case p.topicLogs:
+			log := &types.Log{}
+			if err := json.Unmarshal(txPool.Serialization, log); err != nil {
+				return nil, err
+			}
+			block = new(big.Int).SetUint64(log.BlockNumber)
+		default:
+			continue
+		}
+		if block.Cmp(ancestor) <= 0 {
+			continue
+		}
+		if err := add(txPool); err != nil {
+			return nil, err
+		}
+	}
+	return txPools, nil
+}
+
+// deleteTxPools deletes the messages, and returns their ids.
+func (p *ProducerAXChain) deleteTxPools(ctx context.Context, sess dbr.SessionRunner, txPools []*db.TxPool) ([]string, error) {
+	txPoolIDs := make([]string, 0, len(txPools))
+	for _, txPool := range txPools {
+		err := p.sc.Persist.DeleteTxPool(ctx, sess, txPool)
+		if err != nil {
+			return nil, err
+		}
+		txPoolIDs = append(txPoolIDs, txPool.ID)
+	}
+	return txPoolIDs, nil
+}
+
+// unindexTxPools forgets the messages were indexed, so they are not skipped
+// when they are written again.
+func (p *ProducerAXChain) unindexTxPools(txPoolIDs []string) {
+	for _, txPoolID := range txPoolIDs {
+		p.sc.IndexedList.Remove(txPoolID)
+	}
+}
+
+// axChainTraceMsgKey is the message key of the trace of a transaction.
+func axChainTraceMsgKey(txHash string, idx uint32) (string, error) {
+	idsv := fmt.Sprintf("%s:%d", txHash, idx)
+	id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// axChainLogMsgKey is the message key of a log.
+func axChainLogMsgKey(blockHash common.Hash, txHash common.Hash, index uint) (string, error) {
+	idsv := fmt.Sprintf("%s:%s:%d", blockHash, txHash, index)
+	id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
 func (p *ProducerAXChain) Failure() {
 	_ = utils.Prometheus.CounterInc(p.metricFailureCountKey)
 	_ = utils.Prometheus.CounterInc(servicesctrl.MetricProduceFailureCountKey)
@@ -309,6 +777,9 @@ func (p *ProducerAXChain) Listen() error {
 
 	for !p.runningControl.IsStopped() {
 		err := p.runProcessor()
+		if err == errNodeFailover {
+			continue
+		}
 
 		// If there was an error we want to log it, and iff we are not stopping
 		// we want to add a retry delay.
@@ -326,6 +797,25 @@ func (p *ProducerAXChain) Listen() error {
 	return nil
 }
 
+// probeNode returns the latest block of the node.
+func (p *ProducerAXChain) probeNode(ctx context.Context, node int) (uint64, error) {
+	cl, err := modelsc.NewClient(p.nodes.endpoints[node])
+	if err != nil {
+		return 0, err
+	}
+	defer cl.Close()
+
+	timeout := rpcTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	latest, err := cl.Latest(timeout)
+	if err != nil {
+		return 0, err
+	}
+	return latest.Uint64(), nil
+}
+
 func AXChainNotReady(err error) bool {
 	if strings.HasPrefix(err.Error(), "404 Not Found") {
 		return true
@@ -350,13 +840,19 @@ func (p *ProducerAXChain) runProcessor() error {
 		return nil
 	}
 
-	p.sc.Log.Info("Starting worker for axchain")
-	defer p.sc.Log.Info("Exiting worker for axchain")
+	p.sc.Log.Info("Starting worker for axchain %s", p.evmChain.ID)
+	defer p.sc.Log.Info("Exiting worker for axchain %s", p.evmChain.ID)
 
 	wgpc := &sync.WaitGroup{}
 	wgpcmsgchan := &sync.WaitGroup{}
 
-	pc, err := newContainerC(p.sc, p.conf)
+	if p.evmChain.ID == p.conf.AXchainID {
+		if err := AdoptAXChainRows(p.sc, p.evmChain.ID); err != nil {
+			return err
+		}
+	}
+
+	pc, err := newContainerC(p.sc, p.evmChain, p.nodes.Endpoint())
 	if err != nil {
 		return err
 	}
@@ -385,7 +881,7 @@ func (p *ProducerAXChain) runProcessor() error {
 	}
 
 	for icnt := 0; icnt < maxWorkers; icnt++ {
-		cl, err := modelsc.NewClient(p.conf.Axia + "/ext/bc/C/rpc")
+		cl, err := modelsc.NewClient(p.nodes.Endpoint())
 		if err != nil {
 			return err
 		}
@@ -401,8 +897,17 @@ func (p *ProducerAXChain) runProcessor() error {
 	// Create a closure that processes the next message from the backend
 	processNextMessage := func() error {
 		err := pc.ProcessNextMessage()
+		if reorgErr, ok := err.(*reorgError); ok {
+			err = p.rollback(pc, reorgErr.block)
+		}
 		if pc.catchupErrs.GetValue() != nil {
 			err = pc.catchupErrs.GetValue().(error)
+			// the catch up stops at a reorg, the worker restarts and catches up from the ancestor
+			if reorgErr, ok := err.(*reorgError); ok {
+				if rerr := p.rollback(pc, reorgErr.block); rerr != nil {
+					err = rerr
+				}
+			}
 			if !AXChainNotReady(err) {
 				p.Failure()
 				p.sc.Log.Error("Catchup error: %v", err)
@@ -446,7 +951,18 @@ func (p *ProducerAXChain) runProcessor() error {
 		if p.runningControl.IsStopped() || pc.runningControl.IsStopped() {
 			break
 		}
+		pc.nodeFailed = false
 		err := processNextMessage()
+
+		// block numbers are the same on every node, the producer continues
+		// from its block on the new node
+		if node, ok := p.nodes.Pick(pc.nodeFailed); ok {
+			p.sc.Log.Warn("%s failed over from %s to %s at block %s", p.ID(), p.nodes.Endpoint(), p.nodes.endpoints[node], pc.block.String())
+			p.nodes.Set(node)
+			_ = utils.Prometheus.CounterInc(p.metricFailoverCountKey)
+			return errNodeFailover
+		}
+
 		if err != nil {
 			return err
 		}
@@ -472,16 +988,15 @@ func (p *ProducerAXChain) processWork(conns *utils.Connections, localBlock *loca
 			return err
 		}
 
-		idsv := fmt.Sprintf("%s:%d", txTranactionTraces.Hash, txTranactionTraces.Idx)
-		id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+		msgKey, err := axChainTraceMsgKey(txTranactionTraces.Hash, txTranactionTraces.Idx)
 		if err != nil {
 			return err
 		}
 
 		txPool := &db.TxPool{
 			NetworkID:     p.conf.NetworkID,
-			ChainID:       p.conf.AXchainID,
-			MsgKey:        id.String(),
+			ChainID:       p.evmChain.ID,
+			MsgKey:        msgKey,
 			Serialization: txTransactionTracesBits,
 			Processed:     0,
 			Topic:         p.topicTrc,
@@ -503,16 +1018,15 @@ func (p *ProducerAXChain) processWork(conns *utils.Connections, localBlock *loca
 			return err
 		}
 
-		idsv := fmt.Sprintf("%s:%s:%d", log.BlockHash, log.TxHash, log.Index)
-		id, err := ids.ToID(hashing.ComputeHash256([]byte(idsv)))
+		msgKey, err := axChainLogMsgKey(log.BlockHash, log.TxHash, log.Index)
 		if err != nil {
 			return err
 		}
 
 		txPool := &db.TxPool{
 			NetworkID:     p.conf.NetworkID,
-			ChainID:       p.conf.AXchainID,
-			MsgKey:        id.String(),
+			ChainID:       p.evmChain.ID,
+			MsgKey:        msgKey,
 			Serialization: logBits,
 			Processed:     0,
 			Topic:         p.topicLogs,
@@ -540,7 +1054,7 @@ func (p *ProducerAXChain) processWork(conns *utils.Connections, localBlock *loca
 
 	txPool := &db.TxPool{
 		NetworkID:     p.conf.NetworkID,
-		ChainID:       p.conf.AXchainID,
+		ChainID:       p.evmChain.ID,
 		MsgKey:        id.String(),
 		Serialization: block,
 		Processed:     0,
@@ -562,6 +1076,16 @@ func (p *ProducerAXChain) processWork(conns *utils.Connections, localBlock *loca
 type blockWorkContainer struct {
 	errs        *axiaUtils.AtomicInterface
 	blockNumber *big.Int
+	wg          *sync.WaitGroup
+}
+
+// reorgError reports a stored block whose hash is not the parent hash of its successor on the node.
+type reorgError struct {
+	block *big.Int
+}
+
+func (e *reorgError) Error() string {
+	return fmt.Sprintf("reorg detected at block %s", e.block.String())
 }
 
 func (p *ProducerAXChain) blockProcessor(pc *producerAXChainContainer, client *modelsc.Client, conns *utils.Connections, wg *sync.WaitGroup) {
@@ -576,31 +1100,129 @@ func (p *ProducerAXChain) blockProcessor(pc *producerAXChainContainer, client *m
 		case <-pc.msgChanDone:
 			return
 		case blockWork := <-pc.msgChan:
-			if blockWork.errs.GetValue() != nil {
-				continue
-			}
-
-			blContainer, err := client.ReadBlock(blockWork.blockNumber, rpcTimeout)
+			err := p.processBlockWork(client, conns, blockWork)
 			if err != nil {
 				blockWork.errs.SetValue(err)
-				continue
 			}
-
-			localBlockObject := &localBlockObject{blockContainer: blContainer, time: time.Now()}
-			err = p.processWork(conns, localBlockObject)
-			if err != nil {
-				blockWork.errs.SetValue(err)
-				continue
+			if blockWork.wg != nil {
+				blockWork.wg.Done()
 			}
+		}
+	}
+}
 
-			err = p.updateBlock(conns, blockWork.blockNumber, localBlockObject.time)
+func (p *ProducerAXChain) processBlockWork(client *modelsc.Client, conns *utils.Connections, blockWork *blockWorkContainer) error {
+	if blockWork.errs.GetValue() != nil {
+		return nil
+	}
+
+	blContainer, err := client.ReadBlock(blockWork.blockNumber, rpcTimeout)
+	if err != nil {
+		return err
+	}
+
+	localBlockObject := &localBlockObject{blockContainer: blContainer, time: time.Now()}
+	err = p.processWork(conns, localBlockObject)
+	if err != nil {
+		return err
+	}
+
+	err = p.updateBlock(conns, blockWork.blockNumber, blContainer.Block.Hash().String(), blContainer.Block.ParentHash().String(), localBlockObject.time)
+	if err != nil {
+		return err
+	}
+
+	_ = utils.Prometheus.CounterInc(p.metricProcessedCountKey)
+	_ = utils.Prometheus.CounterInc(servicesctrl.MetricProduceProcessedCountKey)
+	return nil
+}
+
+// adoptBatch is the number of keys of a table adopted per update.
+const adoptBatch = 1000
+
+// adoptTables are the cvm tables keyed by chain, with the column their rows
+// are adopted by.
+var adoptTables = []struct {
+	table string
+	key   string
+}{
+	{db.TableCvmBlocks, "block"},
+	{db.TableCvmTransactions, "id"},
+	{db.TableCvmAddresses, "id"},
+	{db.TableCvmTransactionsTxdata, "hash"},
+	{db.TableCvmTransactionsTxdataTrace, "hash"},
+	{db.TableCvmLogs, "id"},
+}
+
+// AdoptAXChainRows keys the cvm rows indexed before the rows were keyed by chain
+// to the AX chain, which was the only EVM chain indexed. The rows are adopted
+// once, a batch at a time, before the producer of the AX chain starts.
+func AdoptAXChainRows(sc *servicesctrl.Control, chainID string) error {
+	if chainID == "" {
+		return nil
+	}
+	conns, err := sc.Database()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conns.Close()
+	}()
+
+	sess := conns.DB().NewSessionForEventReceiver(conns.Stream().NewJob("adopt-axchain-rows"))
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	_, err = sc.Persist.QueryKeyValueStore(ctx, sess, &db.KeyValueStore{K: utils.KeyValueAXChainRowsAdopted})
+	cancelCtx()
+	switch err {
+	case nil:
+		return nil
+	case dbr.ErrNotFound:
+	default:
+		return err
+	}
+
+	sc.Log.Info("adopting cvm rows of axchain %s", chainID)
+	for _, t := range adoptTables {
+		for {
+			n, err := adoptAXChainRowsBatch(sess, t.table, t.key, chainID)
 			if err != nil {
-				blockWork.errs.SetValue(err)
-				continue
+				return err
+			}
+			if n < adoptBatch {
+				break
 			}
-
-			_ = utils.Prometheus.CounterInc(p.metricProcessedCountKey)
-			_ = utils.Prometheus.CounterInc(servicesctrl.MetricProduceProcessedCountKey)
 		}
 	}
+
+	ctx, cancelCtx = context.WithTimeout(context.Background(), cfg.RequestTimeout)
+	defer cancelCtx()
+	return sc.Persist.InsertKeyValueStore(ctx, sess, &db.KeyValueStore{K: utils.KeyValueAXChainRowsAdopted, V: "true"})
+}
+
+// adoptAXChainRowsBatch keys the rows of a batch of keys to the chain, and
+// returns the number of keys.
+func adoptAXChainRowsBatch(sess *dbr.Session, table string, key string, chainID string) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), cfg.DefaultConsumeProcessWriteTimeout)
+	defer cancelCtx()
+
+	var keys []string
+	_, err := sess.Select(db.CastStringAs(sess, key, key)).
+		Distinct().
+		From(table).
+		Where("chain_id=?", "").
+		Limit(adoptBatch).
+		LoadContext(ctx, &keys)
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+
+	_, err = sess.Update(table).
+		Set("chain_id", chainID).
+		Where("chain_id=? and "+key+" in ?", "", keys).
+		ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
 }
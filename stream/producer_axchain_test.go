@@ -0,0 +1,225 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/axiacoin/axia-network-v2-coreth/core/types"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/modelsc"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBrokenLink(t *testing.T) {
+	blocks := []*db.CvmBlocks{
+		{Block: "9", Hash: "h9"},
+		{Block: "10", Hash: "h10", ParentHash: "h9"},
+		{Block: "11", Hash: "h11", ParentHash: "h10"},
+	}
+	if block := brokenLink(blocks); block != nil {
+		t.Fatal("linked blocks reported broken at", block)
+	}
+
+	// a block stored before hashes were recorded, and a hole, are not checked
+	blocks = []*db.CvmBlocks{
+		{Block: "9"},
+		{Block: "10", Hash: "h10", ParentHash: "x9"},
+		{Block: "12", Hash: "h12", ParentHash: "x11"},
+	}
+	if block := brokenLink(blocks); block != nil {
+		t.Fatal("unchecked blocks reported broken at", block)
+	}
+
+	blocks = []*db.CvmBlocks{
+		{Block: "9", Hash: "h9"},
+		{Block: "10", Hash: "h10", ParentHash: "h9"},
+		{Block: "11", Hash: "h11", ParentHash: "x10"},
+		{Block: "12", Hash: "h12", ParentHash: "x11"},
+	}
+	block := brokenLink(blocks)
+	if block == nil || block.String() != "10" {
+		t.Fatal("broken link not at block 10", block)
+	}
+}
+
+func TestFindAncestor(t *testing.T) {
+	stored := map[int64]string{}
+	node := map[int64]string{}
+	for i := int64(0); i <= 20; i++ {
+		stored[i] = "h"
+		node[i] = "h"
+	}
+	// the blocks above 15 were orphaned, 17 was never stored
+	for i := int64(16); i <= 20; i++ {
+		node[i] = "n"
+	}
+	delete(stored, 17)
+
+	var nodeReads int
+	storedHash := func(b *big.Int) (string, bool, error) {
+		hash, ok := stored[b.Int64()]
+		return hash, ok, nil
+	}
+	nodeHash := func(b *big.Int) (string, error) {
+		nodeReads++
+		return node[b.Int64()], nil
+	}
+
+	ancestor, err := findAncestor(big.NewInt(20), maxReorgDepth, storedHash, nodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestor.Int64() != 15 {
+		t.Fatal("ancestor", ancestor)
+	}
+	if nodeReads != 5 {
+		t.Fatal("node reads", nodeReads)
+	}
+
+	// the walk stops at the maximum depth
+	_, err = findAncestor(big.NewInt(20), 3, storedHash, nodeHash)
+	if !errors.Is(err, ErrReorgTooDeep) {
+		t.Fatal("expected too deep", err)
+	}
+
+	// and does not go below the first block
+	for i := int64(0); i <= 20; i++ {
+		node[i] = "n"
+	}
+	_, err = findAncestor(big.NewInt(20), maxReorgDepth, storedHash, nodeHash)
+	if !errors.Is(err, ErrReorgTooDeep) {
+		t.Fatal("expected too deep", err)
+	}
+
+	// a block stored before hashes were recorded is the ancestor
+	stored[18] = ""
+	ancestor, err = findAncestor(big.NewInt(20), maxReorgDepth, storedHash, nodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ancestor.Int64() != 18 {
+		t.Fatal("ancestor", ancestor)
+	}
+}
+
+func TestRollbackReincludedTransaction(t *testing.T) {
+	persist := db.NewPersistMock()
+	p := &ProducerAXChain{
+		sc:        &servicesctrl.Control{Persist: persist, IndexedList: utils.NewIndexedList(100)},
+		topicTrc:  "trc",
+		topicLogs: "logs",
+	}
+	ctx := context.Background()
+
+	txHash := common.HexToHash("0x01")
+	orphanHash := common.HexToHash("0x11")
+
+	traceTxPool := func(idx uint32, block string, trace string) *db.TxPool {
+		msgKey, err := axChainTraceMsgKey(txHash.Hex(), idx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(&modelsc.TransactionTrace{Hash: txHash.Hex(), Idx: idx, Trace: []byte(trace), Block: block})
+		if err != nil {
+			t.Fatal(err)
+		}
+		txPool := &db.TxPool{MsgKey: msgKey, Topic: p.topicTrc, Serialization: b}
+		if err := txPool.ComputeID(); err != nil {
+			t.Fatal(err)
+		}
+		return txPool
+	}
+	logTxPool := func(blockHash common.Hash, block uint64, index uint) *db.TxPool {
+		msgKey, err := axChainLogMsgKey(blockHash, txHash, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(&types.Log{BlockHash: blockHash, TxHash: txHash, BlockNumber: block, Index: index, Topics: []common.Hash{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		txPool := &db.TxPool{MsgKey: msgKey, Topic: p.topicLogs, Serialization: b}
+		if err := txPool.ComputeID(); err != nil {
+			t.Fatal(err)
+		}
+		return txPool
+	}
+	insert := func(txPool *db.TxPool, processed int) {
+		txPool.Processed = processed
+		if err := persist.InsertTxPools(ctx, nil, []*db.TxPool{txPool}); err != nil {
+			t.Fatal(err)
+		}
+		if processed == db.TxPoolProcessed {
+			p.sc.IndexedList.PushFront(txPool.ID, txPool.ID)
+		}
+	}
+
+	// the transaction was indexed in the orphaned block 11, with its traces and
+	// a log, and a second log of the block is still to be indexed
+	orphanTraces := []*db.TxPool{traceTxPool(0, "11", "{}"), traceTxPool(1, "11", "{}")}
+	for _, txPool := range orphanTraces {
+		insert(txPool, db.TxPoolProcessed)
+	}
+	orphanLog := logTxPool(orphanHash, 11, 0)
+	insert(orphanLog, db.TxPoolProcessed)
+	pendingLog := logTxPool(orphanHash, 11, 1)
+	insert(pendingLog, db.TxPoolUnprocessed)
+
+	// the messages of the ancestor and those with no block are kept
+	keptLog := logTxPool(common.HexToHash("0x10"), 10, 0)
+	insert(keptLog, db.TxPoolUnprocessed)
+	keptTrace := traceTxPool(5, "", "{}")
+	insert(keptTrace, db.TxPoolUnprocessed)
+
+	orphans, err := p.orphanedTxPools(big.NewInt(10),
+		[]*db.CvmTransactionsTxdataTrace{{Hash: txHash.Hex(), Idx: 1}, {Hash: txHash.Hex()}},
+		[]*db.CvmLogs{{BlockHash: orphanHash.Hex(), TxHash: txHash.Hex(), LogIndex: 0}},
+		[]*db.TxPool{pendingLog, keptLog, keptTrace},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 4 {
+		t.Fatal("orphaned messages", len(orphans))
+	}
+	txPoolIDs, err := p.deleteTxPools(ctx, nil, orphans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.unindexTxPools(txPoolIDs)
+
+	for _, txPool := range []*db.TxPool{orphanTraces[0], orphanTraces[1], orphanLog, pendingLog} {
+		if stored, _ := persist.QueryTxPool(ctx, nil, txPool); stored != nil {
+			t.Fatal("orphaned message kept", txPool.ID)
+		}
+	}
+	for _, txPool := range []*db.TxPool{keptLog, keptTrace} {
+		if stored, _ := persist.QueryTxPool(ctx, nil, txPool); stored == nil {
+			t.Fatal("message dropped", txPool.ID)
+		}
+	}
+
+	// the transaction is included again in the canonical block 11, its traces
+	// are written and indexed again
+	for idx, canonical := range []*db.TxPool{traceTxPool(0, "11", `{"type":"CALL"}`), traceTxPool(1, "11", `{"type":"CALL"}`)} {
+		insert(canonical, db.TxPoolUnprocessed)
+		stored, err := persist.QueryTxPool(ctx, nil, canonical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.ID != orphanTraces[idx].ID || stored.Processed != db.TxPoolUnprocessed || string(stored.Serialization) != string(canonical.Serialization) {
+			t.Fatal("trace not written again", idx)
+		}
+		if p.sc.IndexedList.Exists(canonical.ID) {
+			t.Fatal("trace skipped as indexed", idx)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package stream
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -78,6 +79,9 @@ type producerChainContainer struct {
 	conf                    cfg.Config
 	nodeIndex               *db.NodeIndex
 	nodeinstance            string
+	lastContainerID         ids.ID
+	nodeFailed              bool
+	catchup                 bool
 	topic                   string
 	chainID                 string
 	indexerType             IndexType
@@ -112,6 +116,7 @@ func newContainer(
 		topic:                   topic,
 		nodeinstance:            conf.NodeInstance,
 		metricProcessedCountKey: metricProcessedCountKey,
+		catchup:                 true,
 	}
 
 	// init the node index table
@@ -122,9 +127,22 @@ func newContainer(
 
 	err = pc.getIndex()
 	if err != nil {
+		_ = pc.Close()
 		return nil, err
 	}
 
+	err = pc.resumeIndex()
+	if err != nil {
+		_ = pc.Close()
+		return nil, err
+	}
+
+	// the position on another node is resolved by the last container, read it
+	// while the node is up
+	if _, err := pc.getLastContainerID(); err != nil {
+		pc.sc.Log.Warn("last container %d not read %v", pc.nodeIndex.Idx, err)
+	}
+
 	return pc, nil
 }
 
@@ -155,7 +173,84 @@ func (p *producerChainContainer) getIndex() error {
 	return nil
 }
 
+// resumeIndex resumes after the last container read, at its index on the node.
+// The index is local to a node, a node synced from scratch or another node of
+// the instance may have the container at another index. A position stored
+// without its container is resumed at its index. A node which fails is told
+// apart from a node which does not know the container.
+func (p *producerChainContainer) resumeIndex() error {
+	if p.nodeIndex.ContainerID == "" {
+		return nil
+	}
+	containerID, err := ids.FromString(p.nodeIndex.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), IndexerTimeout)
+	defer cancelCtx()
+
+	accepted, err := p.nodeIndexer.IsAccepted(ctx, &indexer.GetIndexArgs{ContainerID: containerID})
+	if err != nil {
+		p.nodeFailed = true
+		return fmt.Errorf("%w: %s %s index %d: %v", ErrResumeNodeFailed, p.topic, containerID, p.nodeIndex.Idx, err)
+	}
+	if !accepted {
+		return fmt.Errorf("%w: %s %s index %d", ErrResumeContainerUnknown, p.topic, containerID, p.nodeIndex.Idx)
+	}
+	index, err := p.nodeIndexer.GetIndex(ctx, &indexer.GetIndexArgs{ContainerID: containerID})
+	if err != nil {
+		p.nodeFailed = true
+		return fmt.Errorf("%w: %s %s index %d: %v", ErrResumeNodeFailed, p.topic, containerID, p.nodeIndex.Idx, err)
+	}
+	p.lastContainerID = containerID
+
+	idx := uint64(index.Index) + 1
+	if idx == p.nodeIndex.Idx {
+		return nil
+	}
+
+	nodeIdx := &db.NodeIndex{
+		Instance:    p.nodeinstance,
+		Topic:       p.topic,
+		Idx:         idx,
+		ContainerID: p.nodeIndex.ContainerID,
+	}
+	err = p.updateNodeIndex(p.conns, nodeIdx)
+	if err != nil {
+		return err
+	}
+	p.sc.Log.Info("resuming processing %d after container %s, stored at %d", idx, containerID, p.nodeIndex.Idx)
+	p.nodeIndex.Idx = idx
+	return nil
+}
+
+// getLastContainerID returns the ID of the last container read, the one before
+// the index, which is read from the node if it was not read in a page yet.
+func (p *producerChainContainer) getLastContainerID() (ids.ID, error) {
+	if p.lastContainerID != ids.Empty || p.nodeIndex.Idx == 0 {
+		return p.lastContainerID, nil
+	}
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), IndexerTimeout)
+	defer cancelCtx()
+
+	container, err := p.nodeIndexer.GetContainerByIndex(ctx, &indexer.GetContainerByIndexArgs{
+		Index:    json.Uint64(p.nodeIndex.Idx - 1),
+		Encoding: formatting.Hex,
+	})
+	if err != nil {
+		return ids.Empty, err
+	}
+	p.lastContainerID = container.ID
+	return p.lastContainerID, nil
+}
+
 func (p *producerChainContainer) ProcessNextMessage() error {
+	if p.catchup {
+		return p.processCatchup()
+	}
+
 	containerRangeArgs := &indexer.GetContainerRangeArgs{
 		StartIndex: json.Uint64(p.nodeIndex.Idx),
 		NumToFetch: json.Uint64(MaxTxRead),
@@ -167,9 +262,11 @@ func (p *producerChainContainer) ProcessNextMessage() error {
 	containers, err := p.nodeIndexer.GetContainerRange(ctx, containerRangeArgs)
 	if err != nil {
 		time.Sleep(readRPCTimeout)
-		if IndexNotReady(err) {
+		// a node answers past its last accepted index when the producer is at the tip
+		if IndexNotReady(err) || ChainNotReady(err) {
 			return nil
 		}
+		p.nodeFailed = true
 		return err
 	}
 	if len(containers) == 0 {
@@ -177,33 +274,7 @@ func (p *producerChainContainer) ProcessNextMessage() error {
 		return ErrNoMessage
 	}
 	for _, container := range containers {
-		if err != nil {
-			return err
-		}
-
-		var id ids.ID
-		switch p.indexerChain {
-		case IndexAXChain:
-			id = container.ID
-		default:
-			// x and p we compute the hash
-			nid, err := ids.ToID(hashing.ComputeHash256(container.Bytes))
-			if err != nil {
-				return err
-			}
-			id = nid
-		}
-
-		txPool := &db.TxPool{
-			NetworkID:     p.conf.NetworkID,
-			ChainID:       p.chainID,
-			MsgKey:        id.String(),
-			Serialization: container.Bytes,
-			Processed:     0,
-			Topic:         p.topic,
-			CreatedAt:     time.Unix(container.Timestamp, 0),
-		}
-		err = txPool.ComputeID()
+		txPool, err := p.newTxPool(container)
 		if err != nil {
 			return err
 		}
@@ -216,23 +287,70 @@ func (p *producerChainContainer) ProcessNextMessage() error {
 		_ = utils.Prometheus.CounterInc(servicesctrl.MetricProduceProcessedCountKey)
 	}
 
-	nodeIdx := &db.NodeIndex{
-		Instance: p.nodeinstance,
-		Topic:    p.topic,
-		Idx:      p.nodeIndex.Idx + uint64(len(containers)),
-	}
-
-	err = p.updateNodeIndex(p.conns, nodeIdx)
+	err = p.advance(containers)
 	if err != nil {
 		return err
 	}
 
-	p.nodeIndex.Idx = nodeIdx.Idx
-
+	// a full page may be far behind the node, check whether to catch up
 	if len(containers) < MaxTxRead {
 		time.Sleep(readRPCTimeout)
+	} else {
+		p.catchup = true
+	}
+
+	return nil
+}
+
+// newTxPool returns the tx pool row of a container.
+func (p *producerChainContainer) newTxPool(container indexer.Container) (*db.TxPool, error) {
+	var id ids.ID
+	switch p.indexerChain {
+	case IndexAXChain:
+		id = container.ID
+	default:
+		// x and p we compute the hash
+		nid, err := ids.ToID(hashing.ComputeHash256(container.Bytes))
+		if err != nil {
+			return nil, err
+		}
+		id = nid
 	}
 
+	txPool := &db.TxPool{
+		NetworkID:     p.conf.NetworkID,
+		ChainID:       p.chainID,
+		MsgKey:        id.String(),
+		Serialization: container.Bytes,
+		Processed:     0,
+		Topic:         p.topic,
+		CreatedAt:     time.Unix(container.Timestamp, 0),
+	}
+	err := txPool.ComputeID()
+	if err != nil {
+		return nil, err
+	}
+	return txPool, nil
+}
+
+// advance moves the index past the containers, which were written.
+func (p *producerChainContainer) advance(containers []indexer.Container) error {
+	lastContainerID := containers[len(containers)-1].ID
+	nodeIdx := &db.NodeIndex{
+		Instance:    p.nodeinstance,
+		Topic:       p.topic,
+		Idx:         p.nodeIndex.Idx + uint64(len(containers)),
+		ContainerID: lastContainerID.String(),
+	}
+
+	err := p.updateNodeIndex(p.conns, nodeIdx)
+	if err != nil {
+		return err
+	}
+
+	p.nodeIndex.Idx = nodeIdx.Idx
+	p.nodeIndex.ContainerID = nodeIdx.ContainerID
+	p.lastContainerID = lastContainerID
 	return nil
 }
 
@@ -262,6 +380,7 @@ type ProducerChain struct {
 	metricProcessedCountKey string
 	metricSuccessCountKey   string
 	metricFailureCountKey   string
+	metricFailoverCountKey  string
 
 	conf cfg.Config
 
@@ -269,18 +388,33 @@ type ProducerChain struct {
 
 	topic string
 
-	nodeIndexer  indexer.Client
+	// the index API of each node, nodes picks the one to read from
+	nodeIndexers []indexer.Client
+	nodes        *nodeSet
 	chainID      string
 	indexerType  IndexType
 	indexerChain IndexedChain
+
+	// the last container read, the nodes are probed from
+	probeContainerID ids.ID
 }
 
 func NewProducerChain(sc *servicesctrl.Control, conf cfg.Config, chainID string, eventType EventType, indexerType IndexType, indexerChain IndexedChain) (*ProducerChain, error) {
+	return NewProducerChainAlias(sc, conf, chainID, indexerChain.String(), eventType, indexerType, indexerChain)
+}
+
+// NewProducerChainAlias creates a producer which reads the index of the node
+// under the chain alias, a chain registered at runtime is indexed under its id.
+func NewProducerChainAlias(sc *servicesctrl.Control, conf cfg.Config, chainID string, chainAlias string, eventType EventType, indexerType IndexType, indexerChain IndexedChain) (*ProducerChain, error) {
 	topicName := GetTopicName(conf.NetworkID, chainID, eventType)
 
-	endpoint := fmt.Sprintf("/ext/index/%s/%s", indexerChain, indexerType)
+	endpoint := fmt.Sprintf("/ext/index/%s/%s", chainAlias, indexerType)
 
-	nodeIndexer := indexer.NewClient(conf.Axia, endpoint)
+	endpoints := conf.AxiaEndpoints()
+	nodeIndexers := make([]indexer.Client, 0, len(endpoints))
+	for _, nodeEndpoint := range endpoints {
+		nodeIndexers = append(nodeIndexers, indexer.NewClient(nodeEndpoint, endpoint))
+	}
 
 	p := &ProducerChain{
 		indexerType:             indexerType,
@@ -292,13 +426,16 @@ func NewProducerChain(sc *servicesctrl.Control, conf cfg.Config, chainID string,
 		metricProcessedCountKey: fmt.Sprintf("produce_records_processed_%s_%s", chainID, eventType),
 		metricSuccessCountKey:   fmt.Sprintf("produce_records_success_%s_%s", chainID, eventType),
 		metricFailureCountKey:   fmt.Sprintf("produce_records_failure_%s_%s", chainID, eventType),
+		metricFailoverCountKey:  fmt.Sprintf("produce_node_failovers_%s_%s", chainID, eventType),
 		id:                      fmt.Sprintf("producer %d %s %s", conf.NetworkID, chainID, eventType),
 		runningControl:          utils.NewRunning(),
-		nodeIndexer:             nodeIndexer,
+		nodeIndexers:            nodeIndexers,
 	}
+	p.nodes = newNodeSet(endpoints, p.probeNode)
 	utils.Prometheus.CounterInit(p.metricProcessedCountKey, "records processed")
 	utils.Prometheus.CounterInit(p.metricSuccessCountKey, "records success")
 	utils.Prometheus.CounterInit(p.metricFailureCountKey, "records failure")
+	utils.Prometheus.CounterInit(p.metricFailoverCountKey, "node failovers")
 	sc.InitProduceMetrics()
 
 	return p, nil
@@ -329,6 +466,15 @@ func (p *ProducerChain) Listen() error {
 
 	for !p.runningControl.IsStopped() {
 		err := p.runProcessor()
+		if err == errNodeFailover {
+			continue
+		}
+		// the container to resume after is unknown on every node, which a
+		// retry does not change
+		if errors.Is(err, ErrResumeContainerUnknown) {
+			p.sc.Log.Error("%s refused to start: %v", p.ID(), err)
+			return err
+		}
 
 		// If there was an error we want to log it, and iff we are not stopping
 		// we want to add a retry delay.
@@ -346,6 +492,109 @@ func (p *ProducerChain) Listen() error {
 	return nil
 }
 
+// probeNode returns the number of containers the node accepted after the last
+// container read. The index of a container is local to a node, but the
+// containers after it are the same on every node, so the heights of the nodes
+// compare. A node which does not know the container is behind, and fails the
+// probe. Before the first container is read it is the number of containers the
+// node accepted.
+func (p *ProducerChain) probeNode(ctx context.Context, node int) (uint64, error) {
+	nodeIndexer := p.nodeIndexers[node]
+	lastIdx, err := lastAcceptedIndex(ctx, nodeIndexer)
+	if err != nil {
+		return 0, err
+	}
+	if p.probeContainerID == ids.Empty {
+		return lastIdx + 1, nil
+	}
+	index, err := nodeIndexer.GetIndex(ctx, &indexer.GetIndexArgs{ContainerID: p.probeContainerID})
+	if err != nil {
+		return 0, err
+	}
+	if lastIdx < uint64(index.Index) {
+		return 0, nil
+	}
+	return lastIdx - uint64(index.Index), nil
+}
+
+// failover moves the producer to the node. The index of a container is local
+// to a node, so the position on the node is the index of the last container
+// read on it. The producer stays on its node when the container is unknown.
+func (p *ProducerChain) failover(pc *producerChainContainer, node int) error {
+	lastContainerID, err := pc.getLastContainerID()
+	if err != nil {
+		return err
+	}
+
+	idx := uint64(0)
+	containerID := ""
+	if lastContainerID != ids.Empty {
+		containerID = lastContainerID.String()
+		ctx, cancelCtx := context.WithTimeout(context.Background(), IndexerTimeout)
+		defer cancelCtx()
+
+		index, err := p.nodeIndexers[node].GetIndex(ctx, &indexer.GetIndexArgs{ContainerID: lastContainerID})
+		if err != nil {
+			return err
+		}
+		idx = uint64(index.Index) + 1
+	}
+
+	err = pc.updateNodeIndex(pc.conns, &db.NodeIndex{Instance: pc.nodeinstance, Topic: pc.topic, Idx: idx, ContainerID: containerID})
+	if err != nil {
+		return err
+	}
+
+	p.sc.Log.Warn("%s failed over from %s index %d to %s index %d", p.ID(), p.nodes.Endpoint(), pc.nodeIndex.Idx, p.nodes.endpoints[node], idx)
+	p.nodes.Set(node)
+	_ = utils.Prometheus.CounterInc(p.metricFailoverCountKey)
+	return nil
+}
+
+// newNodeContainer creates the container on the current node, or else on the
+// next node which knows the container to resume after. The error of the current
+// node is returned when no node does.
+func (p *ProducerChain) newNodeContainer() (*producerChainContainer, error) {
+	current := p.nodes.Current()
+	var nodeErrs []error
+	for i := range p.nodeIndexers {
+		node := (current + i) % len(p.nodeIndexers)
+		pc, err := newContainer(p.sc, p.conf, p.nodeIndexers[node], p.topic, p.chainID, p.indexerType, p.indexerChain, p.metricProcessedCountKey)
+		if err == nil {
+			if node != current {
+				p.sc.Log.Warn("%s resumed on %s, %s %v", p.ID(), p.nodes.endpoints[node], p.nodes.Endpoint(), nodeErrs[0])
+				p.nodes.Set(node)
+				_ = utils.Prometheus.CounterInc(p.metricFailoverCountKey)
+			}
+			return pc, nil
+		}
+		if !errors.Is(err, ErrResumeContainerUnknown) && !errors.Is(err, ErrResumeNodeFailed) {
+			return nil, err
+		}
+		if len(nodeErrs) != 0 {
+			p.sc.Log.Warn("%s %s %v", p.ID(), p.nodes.endpoints[node], err)
+		}
+		nodeErrs = append(nodeErrs, err)
+	}
+	return nil, resumeError(nodeErrs)
+}
+
+// resumeError is the error of the producer resuming on none of the nodes, from
+// the errors of the nodes starting with the current node. The container is
+// unknown only when it is unknown on every node, a node which failed may still
+// know it.
+func resumeError(nodeErrs []error) error {
+	for _, err := range nodeErrs {
+		if !errors.Is(err, ErrResumeContainerUnknown) {
+			if errors.Is(nodeErrs[0], ErrResumeContainerUnknown) {
+				return err
+			}
+			break
+		}
+	}
+	return nodeErrs[0]
+}
+
 // runProcessor starts the processing loop for the backend and closes it when
 // finished
 func (p *ProducerChain) runProcessor() error {
@@ -357,7 +606,7 @@ func (p *ProducerChain) runProcessor() error {
 	p.sc.Log.Info("Starting worker for %s", p.ID())
 	defer p.sc.Log.Info("Exiting worker for %s", p.ID())
 
-	pc, err := newContainer(p.sc, p.conf, p.nodeIndexer, p.topic, p.chainID, p.indexerType, p.indexerChain, p.metricProcessedCountKey)
+	pc, err := p.newNodeContainer()
 	if err != nil {
 		return err
 	}
@@ -407,7 +656,20 @@ func (p *ProducerChain) runProcessor() error {
 		if p.runningControl.IsStopped() || pc.runningControl.IsStopped() {
 			break
 		}
+		pc.nodeFailed = false
 		err := processNextMessage()
+
+		if pc.lastContainerID != ids.Empty {
+			p.probeContainerID = pc.lastContainerID
+		}
+		if node, ok := p.nodes.Pick(pc.nodeFailed); ok {
+			if failoverErr := p.failover(pc, node); failoverErr != nil {
+				p.sc.Log.Warn("%s failover to %s failed %v", p.ID(), p.nodes.endpoints[node], failoverErr)
+			} else {
+				return errNodeFailover
+			}
+		}
+
 		if err != nil {
 			return err
 		}
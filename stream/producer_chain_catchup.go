@@ -0,0 +1,190 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"context"
+
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/axiacoin/axia-network-v2/indexer"
+	"github.com/axiacoin/axia-network-v2/utils/formatting"
+	"github.com/axiacoin/axia-network-v2/utils/json"
+)
+
+const (
+	// defaultCatchupDistance is the catch-up distance when the config has none.
+	defaultCatchupDistance = 10 * MaxTxRead
+
+	// catchupPages is how many pages are fetched ahead of the writer.
+	catchupPages = 4
+
+	// catchupRoundPages is how many pages are caught up in a round, between
+	// the rounds the producer checks its node.
+	catchupRoundPages = 100
+)
+
+// containerPage is a page of containers fetched ahead of the writer.
+type containerPage struct {
+	containers []indexer.Container
+	err        error
+}
+
+// lastAcceptedIndex returns the index of the last container accepted by the
+// node.
+func lastAcceptedIndex(ctx context.Context, nodeIndexer indexer.Client) (uint64, error) {
+	container, err := nodeIndexer.GetLastAccepted(ctx, &indexer.GetLastAcceptedArgs{Encoding: formatting.Hex})
+	if err != nil {
+		return 0, err
+	}
+	index, err := nodeIndexer.GetIndex(ctx, &indexer.GetIndexArgs{ContainerID: container.ID})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(index.Index), nil
+}
+
+// processCatchup catches up a round of pages while the producer is more than
+// the catch-up distance behind the node. The pages are fetched concurrently
+// ahead of the writer, which writes the containers of a page in as few
+// inserts as fit. Within the distance the producer follows the node page by page.
+func (p *producerChainContainer) processCatchup() error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), IndexerTimeout)
+	lastIdx, err := lastAcceptedIndex(ctx, p.nodeIndexer)
+	cancelCtx()
+	if err != nil {
+		if ChainNotReady(err) {
+			p.catchup = false
+			return nil
+		}
+		p.nodeFailed = true
+		return err
+	}
+
+	end, ok := p.catchupEnd(lastIdx)
+	if !ok {
+		p.catchup = false
+		return nil
+	}
+
+	p.sc.Log.Info("catching up %s from %d to %d, last accepted %d", p.topic, p.nodeIndex.Idx, end, lastIdx)
+
+	return p.catchupPages(end, p.writeContainers)
+}
+
+// catchupEnd returns the end of the round of pages to catch up, and false when
+// the producer is within the catch-up distance of the last accepted index.
+func (p *producerChainContainer) catchupEnd(lastIdx uint64) (uint64, bool) {
+	distance := p.conf.CatchupDistance
+	if distance == 0 {
+		distance = defaultCatchupDistance
+	}
+	if lastIdx+1 <= p.nodeIndex.Idx+distance {
+		return 0, false
+	}
+
+	end := lastIdx + 1 - distance
+	if roundEnd := p.nodeIndex.Idx + catchupRoundPages*MaxTxRead; roundEnd < end {
+		end = roundEnd
+	}
+	return end, true
+}
+
+// catchupPages fetches the pages from the index up to end ahead of the writer,
+// and writes them in order. It stops after a short page, the pages after it
+// start past the last container.
+func (p *producerChainContainer) catchupPages(end uint64, write func([]indexer.Container) error) error {
+	fetchCtx, cancelFetch := context.WithCancel(context.Background())
+	defer cancelFetch()
+
+	// the pages in the order of their index, at most catchupPages are fetched
+	// ahead of the writer
+	pages := make(chan chan *containerPage, catchupPages)
+	go func(start uint64) {
+		defer close(pages)
+		for ; start < end && fetchCtx.Err() == nil; start += MaxTxRead {
+			page := make(chan *containerPage, 1)
+			select {
+			case pages <- page:
+			case <-fetchCtx.Done():
+				return
+			}
+			go func(start uint64) {
+				page <- p.fetchPage(fetchCtx, start)
+			}(start)
+		}
+	}(p.nodeIndex.Idx)
+
+	for page := range pages {
+		if p.runningControl.IsStopped() {
+			return nil
+		}
+
+		result := <-page
+		if result.err != nil {
+			if IndexNotReady(result.err) || ChainNotReady(result.err) {
+				return nil
+			}
+			p.nodeFailed = true
+			return result.err
+		}
+		if len(result.containers) == 0 {
+			return nil
+		}
+
+		if err := write(result.containers); err != nil {
+			return err
+		}
+
+		if len(result.containers) < MaxTxRead {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (p *producerChainContainer) fetchPage(ctx context.Context, start uint64) *containerPage {
+	ctx, cancelCtx := context.WithTimeout(ctx, IndexerTimeout)
+	defer cancelCtx()
+
+	containers, err := p.nodeIndexer.GetContainerRange(ctx, &indexer.GetContainerRangeArgs{
+		StartIndex: json.Uint64(start),
+		NumToFetch: json.Uint64(MaxTxRead),
+		Encoding:   formatting.Hex,
+	})
+	return &containerPage{containers: containers, err: err}
+}
+
+// writeContainers writes the containers of a page to the tx pool in as few
+// inserts as fit, and moves the index past them.
+func (p *producerChainContainer) writeContainers(containers []indexer.Container) error {
+	txPools := make([]*db.TxPool, 0, len(containers))
+	for _, container := range containers {
+		txPool, err := p.newTxPool(container)
+		if err != nil {
+			return err
+		}
+		txPools = append(txPools, txPool)
+	}
+
+	sess := p.conns.DB().NewSessionForEventReceiver(p.conns.Stream().NewJob("insert-tx-pools"))
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), dbWriteTimeout)
+	defer cancelCtx()
+
+	err := p.sc.Persist.InsertTxPools(ctx, sess, txPools)
+	if err != nil {
+		return err
+	}
+	for _, txPool := range txPools {
+		p.sc.Enqueue(txPool)
+	}
+
+	_ = utils.Prometheus.CounterAdd(p.metricProcessedCountKey, float64(len(containers)))
+	_ = utils.Prometheus.CounterAdd(servicesctrl.MetricProduceProcessedCountKey, float64(len(containers)))
+
+	return p.advance(containers)
+}
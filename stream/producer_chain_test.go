@@ -0,0 +1,205 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/db"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+	"github.com/axiacoin/axia-network-v2/indexer"
+)
+
+func TestResumeIndex(t *testing.T) {
+	containerIDs := testContainerIDs(10)
+	node := newTestNodeIndexer(containerIDs...)
+	pc := &producerChainContainer{
+		nodeIndexer: node,
+		nodeIndex:   &db.NodeIndex{Idx: 5, ContainerID: containerIDs[4].String()},
+	}
+	if err := pc.resumeIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if pc.lastContainerID != containerIDs[4] || pc.nodeIndex.Idx != 5 {
+		t.Fatal("resumed at", pc.nodeIndex.Idx, pc.lastContainerID)
+	}
+
+	// a node which does not know the container is not a failed node
+	pc.nodeIndexer = newTestNodeIndexer(containerIDs[:3]...)
+	err := pc.resumeIndex()
+	if !errors.Is(err, ErrResumeContainerUnknown) || errors.Is(err, ErrResumeNodeFailed) || pc.nodeFailed {
+		t.Fatal("expected container unknown", err)
+	}
+
+	node.err = errTestNodeDown
+	pc.nodeIndexer = node
+	err = pc.resumeIndex()
+	if !errors.Is(err, ErrResumeNodeFailed) || errors.Is(err, ErrResumeContainerUnknown) || !pc.nodeFailed {
+		t.Fatal("expected node failed", err)
+	}
+}
+
+func TestResumeError(t *testing.T) {
+	unknown := fmt.Errorf("%w: node", ErrResumeContainerUnknown)
+	failed := fmt.Errorf("%w: node", ErrResumeNodeFailed)
+
+	// the container is unknown when no node knows it
+	if err := resumeError([]error{unknown, unknown}); err != unknown {
+		t.Fatal("unknown on every node", err)
+	}
+	// a node which failed may know the container
+	if err := resumeError([]error{unknown, failed, unknown}); err != failed || errors.Is(err, ErrResumeContainerUnknown) {
+		t.Fatal("unknown on a node, failed on another", err)
+	}
+	if err := resumeError([]error{failed, unknown}); err != failed {
+		t.Fatal("failed on the current node", err)
+	}
+}
+
+func TestCatchupEnd(t *testing.T) {
+	pc := &producerChainContainer{
+		conf:      cfg.Config{CatchupDistance: 1000},
+		nodeIndex: &db.NodeIndex{Idx: 200},
+	}
+
+	// within the distance the producer follows the node
+	if _, ok := pc.catchupEnd(1199); ok {
+		t.Fatal("caught up within the distance")
+	}
+	end, ok := pc.catchupEnd(1200)
+	if !ok || end != 201 {
+		t.Fatal("catch up end", end, ok)
+	}
+
+	// a round ends after its pages
+	end, ok = pc.catchupEnd(1000000)
+	if !ok || end != 200+catchupRoundPages*MaxTxRead {
+		t.Fatal("round end", end, ok)
+	}
+
+	pc.conf.CatchupDistance = 0
+	if _, ok := pc.catchupEnd(200 + defaultCatchupDistance - 1); ok {
+		t.Fatal("caught up within the default distance")
+	}
+}
+
+func TestProcessCatchupWithinDistance(t *testing.T) {
+	pc := &producerChainContainer{
+		conf:        cfg.Config{CatchupDistance: 1000},
+		nodeIndexer: newTestNodeIndexer(testContainerIDs(100)...),
+		nodeIndex:   &db.NodeIndex{Idx: 50},
+		catchup:     true,
+	}
+	if err := pc.processCatchup(); err != nil {
+		t.Fatal(err)
+	}
+	if pc.catchup || pc.nodeFailed {
+		t.Fatal("still catching up", pc.catchup, pc.nodeFailed)
+	}
+
+	// a node which fails is reported
+	pc.catchup = true
+	pc.nodeIndexer = &testNodeIndexer{err: errTestNodeDown}
+	if err := pc.processCatchup(); !errors.Is(err, errTestNodeDown) || !pc.nodeFailed || !pc.catchup {
+		t.Fatal("node failure not reported", err)
+	}
+}
+
+func TestCatchupPages(t *testing.T) {
+	containerIDs := testContainerIDs(4*MaxTxRead + 10)
+	newCatchupContainer := func(node *testNodeIndexer, idx uint64) *producerChainContainer {
+		return &producerChainContainer{
+			nodeIndexer:    node,
+			nodeIndex:      &db.NodeIndex{Idx: idx},
+			runningControl: utils.NewRunning(),
+		}
+	}
+
+	var written []indexer.Container
+	var pages []int
+	write := func(containers []indexer.Container) error {
+		written = append(written, containers...)
+		pages = append(pages, len(containers))
+		return nil
+	}
+
+	// the pages are written in order up to the end, the later pages are
+	// fetched first
+	node := newTestNodeIndexer(containerIDs...)
+	node.rangeDelay = func(start uint64) time.Duration {
+		return time.Duration(4*MaxTxRead-start) * 10 * time.Microsecond
+	}
+	if err := newCatchupContainer(node, 0).catchupPages(3*MaxTxRead, write); err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || len(written) != 3*MaxTxRead {
+		t.Fatal("pages", pages)
+	}
+	for i, container := range written {
+		if container.ID != containerIDs[i] {
+			t.Fatal("container out of order at", i)
+		}
+	}
+
+	// the pages stop at a short page
+	written, pages = nil, nil
+	if err := newCatchupContainer(newTestNodeIndexer(containerIDs...), 3*MaxTxRead).catchupPages(100*MaxTxRead, write); err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || pages[0] != MaxTxRead || pages[1] != 10 {
+		t.Fatal("pages", pages)
+	}
+	if written[len(written)-1].ID != containerIDs[len(containerIDs)-1] {
+		t.Fatal("last container not written")
+	}
+
+	// the pages after a failed page are not written
+	pages = nil
+	writeErr := errors.New("write failed")
+	err := newCatchupContainer(newTestNodeIndexer(containerIDs...), 0).catchupPages(3*MaxTxRead, func(containers []indexer.Container) error {
+		pages = append(pages, len(containers))
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) || len(pages) != 1 {
+		t.Fatal("written after a failure", err, pages)
+	}
+
+	// the pages past the last container are not a node failure
+	pages = nil
+	pc := newCatchupContainer(newTestNodeIndexer(containerIDs...), uint64(len(containerIDs)))
+	if err := pc.catchupPages(100*MaxTxRead, write); err != nil || pc.nodeFailed || len(pages) != 0 {
+		t.Fatal("node at the tip failed", err, pc.nodeFailed)
+	}
+
+	pc = newCatchupContainer(&testNodeIndexer{err: errTestNodeDown}, 0)
+	if err := pc.catchupPages(3*MaxTxRead, write); !errors.Is(err, errTestNodeDown) || !pc.nodeFailed || len(pages) != 0 {
+		t.Fatal("node failure not reported", err)
+	}
+}
+
+func TestProcessNextMessageAtTip(t *testing.T) {
+	containerIDs := testContainerIDs(10)
+	pc := &producerChainContainer{
+		nodeIndexer: newTestNodeIndexer(containerIDs...),
+		nodeIndex:   &db.NodeIndex{Idx: uint64(len(containerIDs))},
+	}
+
+	// a node answers past its last accepted index, which is not a failure
+	if err := pc.ProcessNextMessage(); err != nil || pc.nodeFailed {
+		t.Fatal("node at the tip failed", err)
+	}
+	pc.nodeIndexer = newTestNodeIndexer()
+	if err := pc.ProcessNextMessage(); err != nil || pc.nodeFailed {
+		t.Fatal("node with no containers failed", err)
+	}
+
+	pc.nodeIndexer = &testNodeIndexer{err: errTestNodeDown}
+	if err := pc.ProcessNextMessage(); !errors.Is(err, errTestNodeDown) || !pc.nodeFailed {
+		t.Fatal("node failure not reported", err)
+	}
+}
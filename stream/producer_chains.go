@@ -0,0 +1,161 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2-magellan/utils"
+)
+
+const (
+	vmTypeAVM = "avm"
+	vmTypePVM = "pvm"
+)
+
+// ProducerChains runs the producers of the chains registered at runtime.
+type ProducerChains struct {
+	sc            *servicesctrl.Control
+	conf          cfg.Config
+	chainsWatcher *servicesctrl.ChainsWatcher
+	doneCh        chan struct{}
+
+	lock      sync.Mutex
+	closed    bool
+	producers map[string][]*ProducerChain
+}
+
+// NewProducerChains creates the producer of the chains registered at runtime,
+// run with the producers of the configured chains.
+func NewProducerChains(sc *servicesctrl.Control, conf cfg.Config) utils.ListenCloser {
+	return &ProducerChains{
+		sc:        sc,
+		conf:      conf,
+		doneCh:    make(chan struct{}),
+		producers: make(map[string][]*ProducerChain),
+	}
+}
+
+func (p *ProducerChains) ID() string {
+	return fmt.Sprintf("producer %d chains", p.conf.NetworkID)
+}
+
+// Listen watches the chains table, and starts or stops the producers of the
+// chains registered or removed, until it is closed.
+func (p *ProducerChains) Listen() error {
+	p.sc.Log.Info("Started worker manager for %s", p.ID())
+	defer p.sc.Log.Info("Exiting worker manager for %s", p.ID())
+
+	for {
+		chainsWatcher, err := p.sc.WatchChains(p)
+		if err == nil {
+			p.lock.Lock()
+			if p.closed {
+				chainsWatcher.Close()
+			} else {
+				p.chainsWatcher = chainsWatcher
+			}
+			p.lock.Unlock()
+			break
+		}
+
+		p.sc.Log.Error("Error watching chains: %s", err.Error())
+		select {
+		case <-time.After(servicesctrl.ChainsPollInterval):
+		case <-p.doneCh:
+			return nil
+		}
+	}
+
+	<-p.doneCh
+	return nil
+}
+
+// AddChain starts the producers of the chain, like the ones of the Swap chain
+// for an avm, and of the Core chain for a pvm.
+func (p *ProducerChains) AddChain(chain cfg.Chain) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("chain %v added after close", chain.ID)
+	}
+	if _, ok := p.producers[chain.ID]; ok {
+		return fmt.Errorf("duplicate chain %v", chain.ID)
+	}
+
+	var producers []*ProducerChain
+	addProducer := func(eventType EventType, indexerType IndexType, indexerChain IndexedChain) error {
+		producer, err := NewProducerChainAlias(p.sc, p.conf, chain.ID, chain.ID, eventType, indexerType, indexerChain)
+		if err != nil {
+			return err
+		}
+		producers = append(producers, producer)
+		return nil
+	}
+
+	var err error
+	switch chain.VMType {
+	case vmTypeAVM:
+		err = addProducer(EventTypeDecisions, IndexTypeTransactions, IndexXChain)
+		if err == nil {
+			err = addProducer(EventTypeConsensus, IndexTypeVertices, IndexXChain)
+		}
+	case vmTypePVM:
+		err = addProducer(EventTypeDecisions, IndexTypeBlocks, IndexCoreChain)
+	default:
+		err = ErrUnknownVM
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, producer := range producers {
+		go func(producer *ProducerChain) {
+			// the error is logged by the producer
+			_ = producer.Listen()
+		}(producer)
+	}
+	p.producers[chain.ID] = producers
+	return nil
+}
+
+// RemoveChain stops the producers of the chain.
+func (p *ProducerChains) RemoveChain(chain cfg.Chain) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, producer := range p.producers[chain.ID] {
+		_ = producer.Close()
+	}
+	delete(p.producers, chain.ID)
+	return nil
+}
+
+// Close stops watching the chains, and stops the producers of every chain.
+func (p *ProducerChains) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.doneCh)
+	if p.chainsWatcher != nil {
+		p.chainsWatcher.Close()
+	}
+
+	for chainID, producers := range p.producers {
+		for _, producer := range producers {
+			_ = producer.Close()
+		}
+		delete(p.producers, chainID)
+	}
+	return nil
+}
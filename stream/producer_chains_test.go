@@ -0,0 +1,44 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/axiacoin/axia-network-v2-magellan/servicesctrl"
+	"github.com/axiacoin/axia-network-v2/utils/logging"
+)
+
+func TestProducerChainsClose(t *testing.T) {
+	conf := cfg.Services{DB: &cfg.DB{Driver: "mysql", DSN: "magellan:password@tcp(127.0.0.1:1)/magellan"}}
+	sc := &servicesctrl.Control{Log: logging.NoLog{}, Services: conf}
+	p := NewProducerChains(sc, cfg.Config{}).(*ProducerChains)
+
+	// the chains table can not be watched while the database is down, the
+	// producer retries until it is closed
+	listenCh := make(chan error, 1)
+	go func() {
+		listenCh <- p.Listen()
+	}()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-listenCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("listen not stopped")
+	}
+
+	if err := p.AddChain(cfg.Chain{ID: "c1", VMType: vmTypeAVM}); err == nil {
+		t.Fatal("chain added after close")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
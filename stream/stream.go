@@ -16,6 +16,9 @@ var (
 	ErrInvalidTopicName    = errors.New("invalid topic name")
 	ErrWrongTopicEventType = errors.New("wrong topic event type")
 	ErrWrongTopicNetworkID = errors.New("wrong topic networkID")
+
+	ErrResumeContainerUnknown = errors.New("resume container unknown to the node")
+	ErrResumeNodeFailed       = errors.New("resume node failed")
 )
 
 const (
@@ -15,14 +15,19 @@ import (
 )
 
 const (
-	DriverMysql = "mysql"
-	DriverNone  = ""
+	DriverMysql    = "mysql"
+	DriverPostgres = "postgres"
+	DriverNone     = ""
+
+	// sqlDriverPostgres is the database/sql driver registered by pgx
+	sqlDriverPostgres = "pgx"
 )
 
 // Conn is a wrapper around a dbr connection and a health stream
 type Conn struct {
 	conn    *dbr.Connection
 	eventer *EventRcvr
+	driver  string
 }
 
 // New creates a new DB for the given config
@@ -34,6 +39,7 @@ func New(eventer *EventRcvr, conf cfg.DB, ro bool) (*Conn, error) {
 	return &Conn{
 		conn:    conn,
 		eventer: eventer,
+		driver:  conf.Driver,
 	}, nil
 }
 
@@ -43,7 +49,11 @@ func (c *Conn) Close(context.Context) error {
 
 func (c *Conn) NewSession(name string, timeout time.Duration) (*dbr.Session, error) {
 	session := c.NewSessionForEventReceiver(c.eventer.NewJob(name))
-	if _, err := session.Exec(fmt.Sprintf("SET SESSION MAX_EXECUTION_TIME=%d", timeout.Milliseconds())); err != nil {
+	stmt := fmt.Sprintf("SET SESSION MAX_EXECUTION_TIME=%d", timeout.Milliseconds())
+	if c.driver == DriverPostgres {
+		stmt = fmt.Sprintf("SET statement_timeout=%d", timeout.Milliseconds())
+	}
+	if _, err := session.Exec(stmt); err != nil {
 		return nil, err
 	}
 	return session, nil
@@ -79,13 +89,17 @@ func newDBRConnection(conf cfg.DB, ro bool) (*dbr.Connection, error) {
 		dsn = conf.RODSN
 	}
 
+	switch conf.Driver {
 	// If we're using MySQL we need to ensure to set the parseTime option
-	if conf.Driver == DriverMysql {
+	case DriverMysql:
 		dbrDialect = dialect.MySQL
 		dsn, err = ForceParseTimeParam(dsn)
 		if err != nil {
 			return nil, err
 		}
+	case DriverPostgres:
+		dbrDialect = dialect.PostgreSQL
+		driver = sqlDriverPostgres
 	}
 
 	// Create the underlying connection and ping it to ensure liveness
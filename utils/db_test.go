@@ -4,10 +4,17 @@
 package utils
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
 	"strings"
 	"testing"
 
 	"github.com/axiacoin/axia-network-v2-magellan/cfg"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
 )
 
 func TestParse(t *testing.T) {
@@ -64,3 +71,53 @@ func TestNewErrors(t *testing.T) {
 		t.Fatal("Expected i/o or context deadline timeout")
 	}
 }
+
+func TestErrClassification(t *testing.T) {
+	if !ErrIsDuplicateEntryError(errors.New("Error 1062: Duplicate entry 'id' for key 'PRIMARY'")) {
+		t.Fatal("Expected mysql duplicate entry")
+	}
+	if !ErrIsLockError(errors.New("Error 1213: " + DeadlockDBErrorMessage)) {
+		t.Fatal("Expected mysql lock error")
+	}
+
+	pgErr := fmt.Errorf("insert: %w", &pgconn.PgError{Code: PostgresUniqueViolation})
+	if !ErrIsDuplicateEntryError(pgErr) || ErrIsLockError(pgErr) {
+		t.Fatal("Expected postgres duplicate entry")
+	}
+	for _, code := range []string{PostgresSerializationFailure, PostgresDeadlockDetected, PostgresLockNotAvailable} {
+		pgErr = &pgconn.PgError{Code: code}
+		if !ErrIsLockError(pgErr) || ErrIsDuplicateEntryError(pgErr) {
+			t.Fatal("Expected postgres lock error", code)
+		}
+	}
+
+	if ErrIsDuplicateEntryError(nil) || ErrIsLockError(nil) || ErrIsUnavailableError(nil) {
+		t.Fatal("Expected nil to not match")
+	}
+}
+
+func TestErrIsUnavailableError(t *testing.T) {
+	for _, err := range []error{
+		fmt.Errorf("insert: %w", context.DeadlineExceeded),
+		fmt.Errorf("insert: %w", driver.ErrBadConn),
+		mysql.ErrInvalidConn,
+		&mysql.MySQLError{Number: 1040, Message: "Too many connections"},
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		&pgconn.PgError{Code: "08006"},
+		&pgconn.PgError{Code: PostgresDeadlockDetected},
+	} {
+		if !ErrIsUnavailableError(err) {
+			t.Fatal("Expected unavailable", err)
+		}
+	}
+	for _, err := range []error{
+		errors.New("couldn't unmarshal an interface"),
+		&mysql.MySQLError{Number: 1406, Message: "Data too long for column"},
+		&pgconn.PgError{Code: "22001"},
+		&pgconn.PgError{Code: PostgresUniqueViolation},
+	} {
+		if ErrIsUnavailableError(err) {
+			t.Fatal("Expected a message error", err)
+		}
+	}
+}
@@ -4,23 +4,102 @@
 package utils
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
 	"strings"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
 const (
 	DeadlockDBErrorMessage = "Deadlock found when trying to get lock; try restarting transaction"
 	TimeoutDBErrorMessage  = "Lock wait timeout exceeded; try restarting transaction"
+
+	// https://www.postgresql.org/docs/current/errcodes-appendix.html
+	PostgresUniqueViolation      = "23505"
+	PostgresSerializationFailure = "40001"
+	PostgresDeadlockDetected     = "40P01"
+	PostgresLockNotAvailable     = "55P03"
 )
 
 func ErrIsDuplicateEntryError(err error) bool {
-	return err != nil && strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry")
+	if err == nil {
+		return false
+	}
+	if code, ok := postgresErrCode(err); ok {
+		return code == PostgresUniqueViolation
+	}
+	return strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry")
 }
 
 func ErrIsLockError(err error) bool {
-	return err != nil && (strings.Contains(err.Error(), DeadlockDBErrorMessage) ||
-		strings.Contains(err.Error(), TimeoutDBErrorMessage))
+	if err == nil {
+		return false
+	}
+	if code, ok := postgresErrCode(err); ok {
+		return code == PostgresSerializationFailure ||
+			code == PostgresDeadlockDetected ||
+			code == PostgresLockNotAvailable
+	}
+	return strings.Contains(err.Error(), DeadlockDBErrorMessage) ||
+		strings.Contains(err.Error(), TimeoutDBErrorMessage)
+}
+
+// mysqlUnavailableErrors are the server errors of an unavailable database.
+var mysqlUnavailableErrors = map[uint16]bool{
+	1040: true, // too many connections
+	1053: true, // server shutdown
+	1205: true, // lock wait timeout
+	1213: true, // deadlock
+	1317: true, // query interrupted
+	3024: true, // max execution time exceeded
+}
+
+// ErrIsUnavailableError is whether the error is of the database or the network
+// being unavailable, rather than of what was written, so a retry may succeed.
+func ErrIsUnavailableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if ErrIsLockError(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		pgconn.Timeout(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlUnavailableErrors[mysqlErr.Number]
+	}
+	if code, ok := postgresErrCode(err); ok {
+		// connection exception, insufficient resources, operator intervention
+		// and transaction rollback
+		switch code[:2] {
+		case "08", "53", "57", "40":
+			return true
+		}
+	}
+	return false
+}
+
+func postgresErrCode(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, true
+	}
+	return "", false
 }
 
 func ForceParseTimeParam(dsn string) (string, error) {
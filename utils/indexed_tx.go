@@ -0,0 +1,10 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+// IndexedTx identifies a transaction the indexer has committed.
+type IndexedTx struct {
+	ChainID string
+	TxID    string
+}
@@ -11,6 +11,7 @@ import (
 type IndexedList interface {
 	PushFront(key, val interface{})
 	Exists(key interface{}) bool
+	Remove(key interface{})
 }
 
 func NewIndexedList(maxSize int) IndexedList {
@@ -52,6 +53,21 @@ func (c *indexedList) Exists(key interface{}) bool {
 	return c.exists(key)
 }
 
+func (c *indexedList) Remove(key interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.exists(key) {
+		return
+	}
+	for e := c.entryList.Front(); e != nil; e = e.Next() {
+		if e.Value == key {
+			c.entryList.Remove(e)
+			break
+		}
+	}
+	delete(c.entryMap, key)
+}
+
 func (c *indexedList) exists(key interface{}) bool {
 	_, ok := c.entryMap[key]
 	return ok
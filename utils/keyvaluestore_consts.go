@@ -5,4 +5,16 @@ package utils
 
 const (
 	KeyValueBootstrap = "bootstrap"
+
+	// KeyValueSubnetsBackfill marks the subnets of the blocks indexed before
+	// them as indexed.
+	KeyValueSubnetsBackfill = "subnets-backfill"
+
+	// KeyValueAXChainRowsAdopted marks the cvm rows indexed before they were
+	// keyed by chain as keyed to the AX chain.
+	KeyValueAXChainRowsAdopted = "axchain-rows-adopted"
+
+	// KeyValueCvmLogsBackfill marks the address and the topics of the logs
+	// indexed before them as set.
+	KeyValueCvmLogsBackfill = "cvm-logs-backfill"
 )
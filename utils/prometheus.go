@@ -23,6 +23,7 @@ func init() {
 type Metrics struct {
 	counters    map[string]*prometheus.Counter
 	histograms  map[string]*prometheus.Histogram
+	gaugeVecs   map[string]*prometheus.GaugeVec
 	metricsLock sync.RWMutex
 }
 
@@ -35,6 +36,9 @@ func (m *Metrics) Init() {
 	if m.histograms == nil {
 		m.histograms = make(map[string]*prometheus.Histogram)
 	}
+	if m.gaugeVecs == nil {
+		m.gaugeVecs = make(map[string]*prometheus.GaugeVec)
+	}
 }
 
 func (m *Metrics) CounterInit(name string, help string) {
@@ -96,6 +100,34 @@ func (m *Metrics) HistogramObserve(name string, v float64) error {
 	return fmt.Errorf("metric not found: %s", name)
 }
 
+// GaugeVecInit creates a gauge with a value for each value of the labels.
+func (m *Metrics) GaugeVecInit(name string, help string, labels ...string) {
+	m.Init()
+	m.metricsLock.Lock()
+	defer m.metricsLock.Unlock()
+	if _, ok := m.gaugeVecs[name]; ok {
+		return
+	}
+	m.gaugeVecs[name] = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: name,
+		Help: help,
+	}, labels)
+}
+
+func (m *Metrics) GaugeVecSet(name string, v float64, labelValues ...string) error {
+	m.metricsLock.RLock()
+	defer m.metricsLock.RUnlock()
+	if gaugeVec, ok := m.gaugeVecs[name]; ok {
+		gauge, err := gaugeVec.GetMetricWithLabelValues(labelValues...)
+		if err != nil {
+			return err
+		}
+		gauge.Set(v)
+		return nil
+	}
+	return fmt.Errorf("metric not found: %s", name)
+}
+
 type Collector interface {
 	Error()
 	Collect() error
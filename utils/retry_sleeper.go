@@ -31,3 +31,20 @@ func (r *RetrySleeper) Inc() {
 		time.Sleep(r.sleepTime)
 	}
 }
+
+// Backoff returns the delay before the given retry without sleeping, for
+// retries which are scheduled rather than waited for.
+// The delay is zero for the first retries, then doubles from sleepDuration up to sleepDurationMax.
+func (r *RetrySleeper) Backoff(retry uint64) time.Duration {
+	if retry <= r.retries {
+		return 0
+	}
+	delay := r.sleepDuration
+	for i := r.retries + 1; i < retry && delay < r.sleepDurationMax; i++ {
+		delay *= 2
+	}
+	if delay > r.sleepDurationMax {
+		delay = r.sleepDurationMax
+	}
+	return delay
+}
@@ -0,0 +1,19 @@
+// (c) 2021, AXIA Systems, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetrySleeperBackoff(t *testing.T) {
+	r := NewRetrySleeper(1, time.Second, 5*time.Second)
+	expected := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for retry, delay := range expected {
+		if d := r.Backoff(uint64(retry)); d != delay {
+			t.Fatalf("retry %d expected %v got %v", retry, delay, d)
+		}
+	}
+}